@@ -0,0 +1,59 @@
+package assets
+
+//  --------------------------------------------------
+//  Assets.go contains the errors returned when loading
+//  asset files, and the functions every loader in the
+//  engine uses to open them through the Default VFS.
+//  --------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// ErrAssetNotFound is returned, wrapped with the path,
+// when an asset file doesn't exist.
+var ErrAssetNotFound = errors.New("asset not found")
+
+// ErrReadOnly is returned, wrapped with the path, when an
+// asset can't be written where it would be read from.
+var ErrReadOnly = errors.New("asset is read only")
+
+// DecodeError is returned when an asset exists
+// but its contents can't be understood.
+type DecodeError struct {
+	Path   string
+	Format string
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decoding %s %s: %v", e.Format, e.Path, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Open opens an asset file from the Default VFS
+func Open(path string) (fs.File, error) {
+	return Default.Open(path)
+}
+
+// ReadFile reads the whole of an asset file from the Default VFS
+func ReadFile(path string) ([]byte, error) {
+	return Default.ReadFile(path)
+}
+
+// WriteFile writes an asset file through the Default VFS, see FS.WriteFile
+func WriteFile(path string, data []byte) error {
+	return Default.WriteFile(path, data)
+}
+
+func wrapNotFound(path string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrAssetNotFound, path)
+	}
+	return err
+}
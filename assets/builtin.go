@@ -0,0 +1,11 @@
+package assets
+
+import (
+	"embed"
+)
+
+// builtin holds the engine's default assets, so binaries
+// don't depend on the engine's source tree being present
+//
+//go:embed abstract.jpg fonts/avenir-next-regular.ttf skybox/TropicalSunnyDay obj/sphere_uv.obj
+var builtin embed.FS
@@ -0,0 +1,261 @@
+package assets
+
+//  --------------------------------------------------
+//  Vfs.go contains the virtual filesystem all engine
+//  assets are loaded through. Paths are looked up in
+//  each mount in the order they were added, then in the
+//  engine's built in assets.
+//  --------------------------------------------------
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Mount is a filesystem attached to the VFS under a prefix.
+// A file "prefix/name" is looked up as "name" in FS.
+type Mount struct {
+	Prefix string
+	Name   string
+	FS     fs.FS
+
+	// Dir is the directory on disk mounted by MountDir, which
+	// files can be written to. Other mounts are read only.
+	Dir string
+}
+
+// FS is an ordered set of mounted filesystems which itself
+// implements fs.FS. Mounts are searched in the order they were
+// added, followed by the built in fallback mounts.
+type FS struct {
+	mu       sync.RWMutex
+	mounts   []Mount
+	fallback []Mount
+	closers  []func() error
+}
+
+// NewFS creates an empty VFS
+func NewFS() *FS {
+	return &FS{}
+}
+
+// Default is the VFS used by the package level Open and ReadFile. It
+// searches the working directory first, then the engine's built in assets.
+var Default = newDefaultFS()
+
+func newDefaultFS() *FS {
+	v := NewFS()
+	v.MountDir("", ".")
+	v.MountBuiltin("", builtin)
+	return v
+}
+
+// Mount attaches fsys under prefix. It is searched after all
+// mounts added before it, and before the built in assets.
+func (v *FS) Mount(prefix string, name string, fsys fs.FS) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.mounts = append(v.mounts, Mount{Prefix: cleanPrefix(prefix), Name: name, FS: fsys})
+}
+
+// MountDir attaches a directory on disk under prefix
+func (v *FS) MountDir(prefix string, dir string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.mounts = append(v.mounts, Mount{Prefix: cleanPrefix(prefix), Name: dir, FS: os.DirFS(dir), Dir: dir})
+}
+
+// MountZip opens a zip pack and attaches it under prefix.
+// The archive stays open until Close is called.
+func (v *FS) MountZip(prefix string, zipPath string) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return wrapNotFound(zipPath, err)
+		}
+		return &DecodeError{Path: zipPath, Format: "zip", Err: err}
+	}
+
+	v.Mount(prefix, zipPath, r)
+
+	v.mu.Lock()
+	v.closers = append(v.closers, r.Close)
+	v.mu.Unlock()
+
+	return nil
+}
+
+// MountBuiltin attaches fsys as a fallback, searched only
+// after every regular mount. Packages embedding their own
+// assets register them with this.
+func (v *FS) MountBuiltin(prefix string, fsys fs.FS) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.fallback = append(v.fallback, Mount{Prefix: cleanPrefix(prefix), Name: "builtin", FS: fsys})
+}
+
+// Unmount removes every regular mount with the given name
+func (v *FS) Unmount(name string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	mounts := v.mounts[:0]
+	for _, m := range v.mounts {
+		if m.Name != name {
+			mounts = append(mounts, m)
+		}
+	}
+	v.mounts = mounts
+}
+
+// Mounts returns the mounts in the order they are searched
+func (v *FS) Mounts() []Mount {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	all := make([]Mount, 0, len(v.mounts)+len(v.fallback))
+	all = append(all, v.mounts...)
+	return append(all, v.fallback...)
+}
+
+// Close closes any zip packs mounted with MountZip
+func (v *FS) Close() error {
+	v.mu.Lock()
+	closers := v.closers
+	v.closers = nil
+	v.mu.Unlock()
+
+	var first error
+	for _, c := range closers {
+		if err := c(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Open opens name from the first mount which has it. Names that
+// aren't valid io/fs paths, such as absolute paths or paths
+// starting with "..", are opened directly from disk.
+func (v *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		if f, err := os.Open(name); err == nil {
+			return f, nil
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		name = path.Clean(strings.TrimPrefix(name, "./"))
+		if !fs.ValidPath(name) {
+			return nil, wrapNotFound(name, fs.ErrNotExist)
+		}
+	}
+
+	for _, m := range v.Mounts() {
+		rel, ok := m.relative(name)
+		if !ok {
+			continue
+		}
+
+		f, err := m.FS.Open(rel)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return nil, wrapNotFound(name, fs.ErrNotExist)
+}
+
+// ReadFile reads the whole of name from the first mount which has it
+func (v *FS) ReadFile(name string) ([]byte, error) {
+	f, err := v.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+// WriteFile writes data to name, so that it is read back by Open.
+// It is written into the first mounted directory name would be
+// read from, creating its parent directories, and paths Open
+// reads directly from disk are written there. It returns
+// ErrReadOnly if name would be read from a zip pack or the built
+// in assets, or no directory is mounted for it.
+func (v *FS) WriteFile(name string, data []byte) error {
+	if !fs.ValidPath(name) {
+		clean := path.Clean(strings.TrimPrefix(name, "./"))
+		if !fs.ValidPath(clean) {
+			return os.WriteFile(name, data, 0644)
+		}
+		name = clean
+	}
+
+	for _, m := range v.Mounts() {
+		rel, ok := m.relative(name)
+		if !ok {
+			continue
+		}
+
+		if m.Dir != "" {
+			file := filepath.Join(m.Dir, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+				return err
+			}
+			return os.WriteFile(file, data, 0644)
+		}
+
+		// Files in earlier mounts hide those written to later ones
+		if f, err := m.FS.Open(rel); err == nil {
+			f.Close()
+			return fmt.Errorf("%w: %s is in %s", ErrReadOnly, name, m.Name)
+		}
+	}
+
+	return fmt.Errorf("%w: no directory is mounted for %s", ErrReadOnly, name)
+}
+
+// Exists reports whether any mount has name
+func (v *FS) Exists(name string) bool {
+	f, err := v.Open(name)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
+
+// relative returns name relative to the mount's prefix,
+// and whether name is inside the prefix at all
+func (m Mount) relative(name string) (string, bool) {
+	if m.Prefix == "" {
+		return name, true
+	}
+	if name == m.Prefix {
+		return ".", true
+	}
+	if strings.HasPrefix(name, m.Prefix+"/") {
+		return name[len(m.Prefix)+1:], true
+	}
+	return "", false
+}
+
+func cleanPrefix(prefix string) string {
+	prefix = path.Clean("/" + prefix)
+	return strings.TrimPrefix(prefix, "/")
+}
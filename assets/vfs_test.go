@@ -0,0 +1,167 @@
+package assets
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// readString reads name from v, failing the test if it can't
+func readString(t *testing.T, v *FS, name string) string {
+	t.Helper()
+	data, err := v.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestMountOrder(t *testing.T) {
+	v := NewFS()
+	v.MountBuiltin("", fstest.MapFS{
+		"a.txt":     {Data: []byte("builtin")},
+		"extra.txt": {Data: []byte("builtin")},
+	})
+	v.Mount("", "first", fstest.MapFS{"a.txt": {Data: []byte("first")}})
+	v.Mount("", "second", fstest.MapFS{"a.txt": {Data: []byte("second")}})
+
+	// Mounts are searched in order, and the built in
+	// assets after them, however early they were added
+	if got := readString(t, v, "a.txt"); got != "first" {
+		t.Fatalf("read %q, want first", got)
+	}
+	if got := readString(t, v, "extra.txt"); got != "builtin" {
+		t.Fatalf("read %q, want builtin", got)
+	}
+
+	v.Unmount("first")
+	if got := readString(t, v, "a.txt"); got != "second" {
+		t.Fatalf("read %q after unmounting, want second", got)
+	}
+
+	var names []string
+	for _, m := range v.Mounts() {
+		names = append(names, m.Name)
+	}
+	if len(names) != 2 || names[0] != "second" || names[1] != "builtin" {
+		t.Fatalf("mounts %q, want second then builtin", names)
+	}
+}
+
+func TestMountPrefix(t *testing.T) {
+	v := NewFS()
+	v.Mount("/packs/level/", "level", fstest.MapFS{"a.txt": {Data: []byte("level")}})
+
+	if got := readString(t, v, "packs/level/a.txt"); got != "level" {
+		t.Fatalf("read %q, want level", got)
+	}
+	if v.Exists("a.txt") || v.Exists("packs/levels/a.txt") {
+		t.Fatal("a file was found outside of its mount's prefix")
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	v := NewFS()
+	v.Mount("", "empty", fstest.MapFS{})
+
+	for _, name := range []string{"missing.txt", "../missing.txt", filepath.Join(t.TempDir(), "missing.txt")} {
+		if _, err := v.Open(name); !errors.Is(err, ErrAssetNotFound) {
+			t.Errorf("opening %s returned %v, want ErrAssetNotFound", name, err)
+		}
+		if v.Exists(name) {
+			t.Errorf("%s exists", name)
+		}
+	}
+}
+
+func TestMountBuiltinAssets(t *testing.T) {
+	v := NewFS()
+	v.MountBuiltin("", builtin)
+
+	for _, name := range []string{"abstract.jpg", "fonts/avenir-next-regular.ttf", "obj/sphere_uv.obj"} {
+		if !v.Exists(name) {
+			t.Errorf("built in %s doesn't exist", name)
+		}
+	}
+}
+
+func TestMountZip(t *testing.T) {
+	packPath := filepath.Join(t.TempDir(), "pack.zip")
+	f, err := os.Create(packPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := zip.NewWriter(f)
+	w, err := z.Create("textures/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("zipped"))
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	v := NewFS()
+	if err := v.MountZip("pack", packPath); err != nil {
+		t.Fatal(err)
+	}
+	defer v.Close()
+
+	if got := readString(t, v, "pack/textures/a.txt"); got != "zipped" {
+		t.Fatalf("read %q, want zipped", got)
+	}
+
+	// Zip packs are read only
+	if err := v.WriteFile("pack/textures/a.txt", nil); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("writing into a zip pack returned %v, want ErrReadOnly", err)
+	}
+
+	if err := v.MountZip("", filepath.Join(t.TempDir(), "missing.zip")); !errors.Is(err, ErrAssetNotFound) {
+		t.Fatalf("mounting a missing pack returned %v, want ErrAssetNotFound", err)
+	}
+	var decodeErr *DecodeError
+	if err := v.MountZip("", filepath.Join("fonts", "aroma-bold.ttf")); !errors.As(err, &decodeErr) {
+		t.Fatalf("mounting a file which isn't a zip returned %v, want a DecodeError", err)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+
+	v := NewFS()
+	v.Mount("", "pack", fstest.MapFS{"packed.txt": {Data: []byte("packed")}})
+	v.MountDir("saves", dir)
+
+	// Parent directories are created
+	if err := v.WriteFile("saves/slot1/game.txt", []byte("saved")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, v, "saves/slot1/game.txt"); got != "saved" {
+		t.Fatalf("read %q, want saved", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "slot1", "game.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Files which would be read from a pack, or
+	// paths with no directory mounted, can't be written
+	if err := v.WriteFile("packed.txt", nil); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("writing over a packed file returned %v, want ErrReadOnly", err)
+	}
+	if err := v.WriteFile("other.txt", nil); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("writing outside of mounted directories returned %v, want ErrReadOnly", err)
+	}
+
+	// Absolute paths are written directly
+	abs := filepath.Join(t.TempDir(), "abs.txt")
+	if err := v.WriteFile(abs, []byte("abs")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, v, abs); got != "abs" {
+		t.Fatalf("read %q, want abs", got)
+	}
+}
@@ -22,6 +22,7 @@ type Camera interface {
 	ChangeRoll(float32)
 
 	GetFirstViewIndex() *float32
+	GetView() mgl32.Mat4
 	GetFirstModelIndex() *float32
 
 	GetStaticView() mgl32.Mat4
@@ -1,6 +1,7 @@
 package camera
 
 import (
+	"math"
 	"math/rand"
 
 	"github.com/go-gl/mathgl/mgl32"
@@ -48,8 +49,13 @@ func NewCamera2D(position mgl32.Vec3, speed float32, config *configuration.Engin
 }
 
 func (camera2D *Camera2D) Look(delta float64) {
-	// Move toward target position
-	camera2D.Position = LerpPosition(camera2D.Position, camera2D.TargetPosition, camera2D.SmoothSpeed*float32(delta)*60)
+	// Move toward target position. The lerp amount is scaled so the
+	// camera closes the same distance per second at any frame rate.
+	amount := float32(1)
+	if camera2D.SmoothSpeed < 1 {
+		amount = 1 - float32(math.Pow(float64(1-camera2D.SmoothSpeed), delta*60))
+	}
+	camera2D.Position = LerpPosition(camera2D.Position, camera2D.TargetPosition, amount)
 
 	if camera2D.ShakeDuration > 0 {
 		camera2D.Position = camera2D.Position.Add(mgl32.Vec3{
@@ -120,6 +126,11 @@ func (camera2D *Camera2D) DefaultControls(inputs *input.Input) {
 func (camera2D *Camera2D) ChangeYaw(y float32)   {}
 func (camera2D *Camera2D) ChangePitch(p float32) {}
 
+// GetView returns the view matrix
+func (camera2D *Camera2D) GetView() mgl32.Mat4 {
+	return camera2D.View
+}
+
 func (camera2D *Camera2D) GetFirstViewIndex() *float32 {
 	return &camera2D.View[0]
 }
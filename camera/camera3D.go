@@ -176,6 +176,11 @@ func (camera3D *Camera3D) Shake(duration float64, strength float32) {
 //  Getters
 //  --------------------------------------------------
 
+// GetView returns the view matrix
+func (camera3D *Camera3D) GetView() mgl32.Mat4 {
+	return camera3D.View
+}
+
 func (camera3D *Camera3D) GetFirstViewIndex() *float32 {
 	return &camera3D.View[0]
 }
@@ -1,5 +1,7 @@
 package child
 
+import "github.com/go-gl/mathgl/mgl32"
+
 import "rapidengine/camera"
 import "rapidengine/material"
 
@@ -48,7 +50,66 @@ type Child interface {
 
 	Update(camera.Camera, float64, float64)
 
+	// FixedUpdate advances the child's simulation by a single tick,
+	// and Interpolate sets how far rendering lies between the last two ticks
+	FixedUpdate(float64)
+	Interpolate(float32)
+
 	Activate()
 	Deactivate()
 	IsActive() bool
+
+	// The render layer the child is on. Views only
+	// render children on layers in their layer mask.
+	GetLayer() Layer
+	SetLayer(Layer)
+
+	// The state the child binds to be drawn,
+	// which the renderer sorts children by
+	GetDrawKey() DrawKey
+
+	// The child's place in the scene graph. The local matrix
+	// is relative to the parent, and the world matrix isn't.
+	GetNode() *Node
+	GetLocalMatrix() mgl32.Mat4
+	SetLocalMatrix(mgl32.Mat4)
+	GetWorldMatrix() mgl32.Mat4
+	GetWorldPosition() mgl32.Vec3
+}
+
+// DrawKey is the GL state a child binds to be drawn. Transparent
+// children are drawn after opaque ones, from back to front.
+type DrawKey struct {
+	Shader   uint32
+	Material material.Material
+	Texture  uint32
+	VAO      uint32
+
+	Transparent bool
+}
+
+// Layer is one of 32 render layers. Children are
+// on LayerDefault unless moved to another layer.
+type Layer uint8
+
+const (
+	LayerDefault Layer = 0
+	LayerUI      Layer = 1
+)
+
+// AllLayers is a layer mask containing every layer
+const AllLayers uint32 = 0xFFFFFFFF
+
+// LayerMask returns a mask containing the given layers
+func LayerMask(layers ...Layer) uint32 {
+	mask := uint32(0)
+	for _, l := range layers {
+		mask |= 1 << (l % 32)
+	}
+	return mask
+}
+
+// InMask reports whether the layer is in mask
+func (l Layer) InMask(mask uint32) bool {
+	return mask&(1<<(l%32)) != 0
 }
@@ -7,17 +7,23 @@ package child
 // --------------------------------------------------
 
 import (
-	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
 
 	"rapidengine/camera"
 	"rapidengine/configuration"
+	"rapidengine/device"
 	"rapidengine/geometry"
 	"rapidengine/material"
 	"rapidengine/physics"
 )
 
 type Child2D struct {
+	Node
+
+	// The child's position and size in pixels, and its
+	// rotation, relative to its parent. Z is unused.
+	geometry.Transform
+
 	active bool
 
 	Mesh geometry.Mesh
@@ -39,16 +45,21 @@ type Child2D struct {
 
 	specificRenderDistance float32
 
-	X float32
-	Y float32
+	layer Layer
 
 	VX float32
 	VY float32
 
 	Gravity float32
 
-	ScaleX float32
-	ScaleY float32
+	// Position at the previous tick and at the latest one,
+	// and how far between the two the child is rendered
+	lastX  float32
+	lastY  float32
+	tickX  float32
+	tickY  float32
+	alpha  float32
+	ticked bool
 
 	Group          string
 	collider       physics.Collider
@@ -59,42 +70,42 @@ type Child2D struct {
 
 func NewChild2D(config *configuration.EngineConfig) *Child2D {
 	c := &Child2D{
+		Transform:              geometry.NewTransform(0, 0, 0, 1, 1, 1),
 		modelMatrix:            mgl32.Ident4(),
 		projectionMatrix:       mgl32.Ortho2D(-1, 1, -1, 1),
 		config:                 config,
-		X:                      0,
-		Y:                      0,
 		VX:                     0,
 		VY:                     0,
 		Gravity:                0,
-		ScaleX:                 1,
-		ScaleY:                 1,
 		copyingEnabled:         false,
 		specificRenderDistance: 0,
 		Darkness:               1,
 	}
+	c.Node = newNode(c, &c.Transform, false)
 	return c
 }
 
 func (child2D *Child2D) PreRender(mainCamera camera.Camera) {
+	dev := device.Get()
+
 	child2D.BindChild()
 
-	gl.UniformMatrix4fv(
+	dev.UniformMatrix4fv(
 		child2D.material.GetShader().GetUniform("modelMtx"),
 		1, false, &child2D.modelMatrix[0],
 	)
 
-	gl.UniformMatrix4fv(
+	dev.UniformMatrix4fv(
 		child2D.material.GetShader().GetUniform("viewMtx"),
 		1, false, mainCamera.GetFirstViewIndex(),
 	)
 
-	gl.UniformMatrix4fv(
+	dev.UniformMatrix4fv(
 		child2D.material.GetShader().GetUniform("projectionMtx"),
 		1, false, &child2D.projectionMatrix[0],
 	)
 
-	gl.BindVertexArray(0)
+	dev.BindVertexArray(0)
 }
 
 func (child2D *Child2D) BindChild() {
@@ -103,7 +114,6 @@ func (child2D *Child2D) BindChild() {
 
 func (child2D *Child2D) Update(mainCamera camera.Camera, delta float64, totalTime float64) {
 	//cx, cy, _ := mainCamera.GetPosition()
-	child2D.VY -= child2D.Gravity
 
 	/*cols := child2D.collisioncontrol.CheckCollisionWithGroup(child2D, "ground", cx, cy)
 	if (cols[3] && child2D.VY < 0) || (cols[1] && child2D.VY > 0) {
@@ -119,12 +129,38 @@ func (child2D *Child2D) Update(mainCamera camera.Camera, delta float64, totalTim
 	child2D.Render(mainCamera, delta, totalTime)
 }
 
-func (child2D *Child2D) Render(mainCamera camera.Camera, delta float64, totalTime float64) {
-	sX, sY := ScaleTranslation(child2D.X, child2D.Y, float32(child2D.config.ScreenWidth), float32(child2D.config.ScreenHeight))
-	child2D.modelMatrix = mgl32.Translate3D(sX, sY, 0)
+func (child2D *Child2D) FixedUpdate(delta float64) {
+	if !child2D.ticked {
+		child2D.tickX, child2D.tickY = child2D.X, child2D.Y
+		child2D.ticked = true
+	}
 
-	scaleX, scaleY := ScaleTransformation(child2D.ScaleX, child2D.ScaleY, float32(child2D.config.ScreenWidth), float32(child2D.config.ScreenHeight))
-	child2D.modelMatrix = child2D.modelMatrix.Mul4(mgl32.Scale3D(scaleX, scaleY, 0))
+	// A child which moved since either of the last
+	// two ticks is rendered somewhere new
+	if child2D.lastX != child2D.tickX || child2D.lastY != child2D.tickY ||
+		child2D.tickX != child2D.X || child2D.tickY != child2D.Y {
+		child2D.Invalidate()
+	}
+	child2D.lastX, child2D.lastY = child2D.tickX, child2D.tickY
+	child2D.tickX, child2D.tickY = child2D.X, child2D.Y
+
+	child2D.VY -= child2D.Gravity
+}
+
+func (child2D *Child2D) Interpolate(alpha float32) {
+	if alpha == child2D.alpha {
+		return
+	}
+	child2D.alpha = alpha
+
+	if child2D.lastX != child2D.tickX || child2D.lastY != child2D.tickY {
+		child2D.Invalidate()
+	}
+}
+
+func (child2D *Child2D) Render(mainCamera camera.Camera, delta float64, totalTime float64) {
+	child2D.modelMatrix = ScreenMatrix(float32(child2D.config.ScreenWidth), float32(child2D.config.ScreenHeight))
+	child2D.modelMatrix = child2D.modelMatrix.Mul4(child2D.GetWorldMatrix()).Mul4(mgl32.Scale3D(1, 1, 0))
 
 	if !child2D.Static {
 		child2D.Mesh.Render(child2D.material, mainCamera.GetFirstViewIndex(), &child2D.modelMatrix[0], &child2D.projectionMatrix[0], delta, totalTime, 1)
@@ -138,18 +174,22 @@ func (child2D *Child2D) RenderCopy(config ChildCopy, mainCamera camera.Camera) {
 	sX, sY := ScaleTranslation(config.X, config.Y, float32(child2D.config.ScreenWidth), float32(child2D.config.ScreenHeight))
 	child2D.modelMatrix = mgl32.Translate3D(sX, sY, 0)
 
-	scaleX, scaleY := ScaleTransformation(child2D.ScaleX, child2D.ScaleY, float32(child2D.config.ScreenWidth), float32(child2D.config.ScreenHeight))
+	scaleX, scaleY := ScaleTransformation(child2D.SX, child2D.SY, float32(child2D.config.ScreenWidth), float32(child2D.config.ScreenHeight))
 	child2D.modelMatrix = child2D.modelMatrix.Mul4(mgl32.Scale3D(scaleX, scaleY, 0))
 
 	child2D.Mesh.Render(config.Material, mainCamera.GetFirstViewIndex(), &child2D.modelMatrix[0], &child2D.projectionMatrix[0], 0, 0, config.Darkness)
 }
 
+// CheckCollision checks for a collision between the
+// colliders of both children, at their world positions
 func (child2D *Child2D) CheckCollision(other Child) int {
-	return child2D.collider.CheckCollision(child2D.X, child2D.Y, child2D.VX, child2D.VY, other.GetX(), other.GetY(), other.GetCollider())
+	o := other.GetWorldPosition()
+	return child2D.CheckCollisionRaw(o[0], o[1], other.GetCollider())
 }
 
 func (child2D *Child2D) CheckCollisionRaw(otherX, otherY float32, otherCollider *physics.Collider) int {
-	return child2D.collider.CheckCollision(child2D.X, child2D.Y, child2D.VX, child2D.VY, otherX, otherY, otherCollider)
+	p := child2D.GetWorldPosition()
+	return child2D.collider.CheckCollision(p[0], p[1], child2D.VX, child2D.VY, otherX, otherY, otherCollider)
 }
 
 //  --------------------------------------------------
@@ -196,16 +236,41 @@ func (child2D *Child2D) SetVelocity(vx, vy float32) {
 func (child2D *Child2D) SetPosition(x, y float32) {
 	child2D.X = x
 	child2D.Y = y
+
+	child2D.lastX, child2D.tickX = x, x
+	child2D.lastY, child2D.tickY = y, y
+
+	child2D.Invalidate()
+}
+
+// SetLocalMatrix sets the child's position, scale and
+// rotation from a matrix, relative to its parent
+func (child2D *Child2D) SetLocalMatrix(m mgl32.Mat4) {
+	child2D.Transform.SetMatrix(m)
+	child2D.Z, child2D.SZ = 0, 1
+
+	child2D.lastX, child2D.tickX = child2D.X, child2D.X
+	child2D.lastY, child2D.tickY = child2D.Y, child2D.Y
+
+	child2D.Invalidate()
 }
 
 func (child2D *Child2D) SetSpecificRenderDistance(d float32) {
 	child2D.specificRenderDistance = d
 }
 
+func (child2D *Child2D) SetLayer(l Layer) {
+	child2D.layer = l
+}
+
 //  --------------------------------------------------
 //  Getters
 //  --------------------------------------------------
 
+func (child2D *Child2D) GetMaterial() material.Material {
+	return child2D.material
+}
+
 func (child2D *Child2D) GetShaderProgram() *material.ShaderProgram {
 	return child2D.material.GetShader()
 }
@@ -222,10 +287,46 @@ func (child2D *Child2D) GetY() float32 {
 	return child2D.Y
 }
 
+// GetLocalMatrix returns the child's transform in pixels, relative
+// to its parent. A child moved by the simulation is rendered between
+// its positions at the last two ticks, while one moved since the
+// latest tick is rendered where it is.
+func (child2D *Child2D) GetLocalMatrix() mgl32.Mat4 {
+	t := child2D.Transform
+	t.Z, t.SZ = 0, 1
+
+	if t.X == child2D.tickX && t.Y == child2D.tickY {
+		t.X = lerp(child2D.lastX, child2D.tickX, child2D.alpha)
+		t.Y = lerp(child2D.lastY, child2D.tickY, child2D.alpha)
+	}
+
+	return t.Matrix()
+}
+
 func (child2D *Child2D) GetSpecificRenderDistance() float32 {
 	return child2D.specificRenderDistance
 }
 
+func (child2D *Child2D) GetLayer() Layer {
+	return child2D.layer
+}
+
+// GetDrawKey returns the state the child is drawn with.
+// 2D children are drawn in order, so are never transparent.
+func (child2D *Child2D) GetDrawKey() DrawKey {
+	key := DrawKey{Material: child2D.material}
+
+	if child2D.Mesh.VAO != nil {
+		key.VAO = child2D.Mesh.VAO.GetID()
+	}
+	if child2D.material != nil {
+		key.Shader = child2D.material.GetShader().GetID()
+		key.Texture = material.MainTexture(child2D.material)
+	}
+
+	return key
+}
+
 func (child2D *Child2D) GetDimensions() int {
 	return 2
 }
@@ -287,6 +388,12 @@ func (child2D *Child2D) MouseCollisionFunc(c bool) {
 	child2D.mouseCollision(c)
 }
 
+// ScreenMatrix converts pixels on a screen of size sw, sh
+// to the [-1, 1] space 2D children are drawn in
+func ScreenMatrix(sw, sh float32) mgl32.Mat4 {
+	return mgl32.Translate3D(-1, -1, 0).Mul4(mgl32.Scale3D(2/sw, 2/sh, 1))
+}
+
 func ScaleTranslation(x, y, sw, sh float32) (float32, float32) {
 	return 2*(x/float32(sw)) - 1, 2*(y/float32(sh)) - 1
 }
@@ -1,17 +1,27 @@
 package child
 
 import (
-	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
 
 	"rapidengine/camera"
 	"rapidengine/configuration"
+	"rapidengine/device"
 	"rapidengine/geometry"
 	"rapidengine/material"
 	"rapidengine/physics"
 )
 
+// DefaultFarPlane is the far clipping plane of 3D children
+// which haven't been given an instance render distance
+const DefaultFarPlane = 100000
+
 type Child3D struct {
+	Node
+
+	// The child's position, rotation and scale,
+	// relative to its parent
+	geometry.Transform
+
 	active bool
 
 	numVertices int32
@@ -20,29 +30,23 @@ type Child3D struct {
 
 	Material material.Material
 
-	modelMatrix      mgl32.Mat4
-	projectionMatrix mgl32.Mat4
+	modelMatrix mgl32.Mat4
 
 	copies         []ChildCopy
 	currentCopies  []ChildCopy
 	copyingEnabled bool
 
-	X float32
-	Y float32
-	Z float32
+	// Position at the previous tick, and how far
+	// between the two ticks the child is rendered
+	lastX float32
+	lastY float32
+	lastZ float32
+	alpha float32
 
 	VX float32
 	VY float32
 	VZ float32
 
-	RX float32
-	RY float32
-	RZ float32
-
-	ScaleX float32
-	ScaleY float32
-	ScaleZ float32
-
 	Gravity float32
 
 	Group    string
@@ -50,67 +54,138 @@ type Child3D struct {
 
 	specificRenderDistance float32
 
+	layer Layer
+
+	// Transparent children are drawn after
+	// opaque ones, from back to front
+	Transparent bool
+
+	// Far clipping plane of the projection
+	farPlane float32
+
 	config *configuration.EngineConfig
 }
 
 func NewChild3D(config *configuration.EngineConfig) *Child3D {
-	return &Child3D{
-		modelMatrix: mgl32.Ident4(),
-		projectionMatrix: mgl32.Perspective(
-			mgl32.DegToRad(45),
-			float32(config.ScreenWidth)/float32(config.ScreenHeight),
-			0.1, 100000,
-		),
+	c := &Child3D{
+		Transform:              geometry.NewTransform(0, 0, 0, 1, 1, 1),
+		modelMatrix:            mgl32.Ident4(),
+		farPlane:               DefaultFarPlane,
 		config:                 config,
 		Gravity:                0,
 		copyingEnabled:         false,
 		specificRenderDistance: 0,
-		ScaleX:                 1,
-		ScaleY:                 1,
-		ScaleZ:                 1,
 	}
+	c.Node = newNode(c, &c.Transform, true)
+	return c
 }
 
 func (child3D *Child3D) PreRender(mainCamera camera.Camera) {
 
 }
 
+// Update renders the child projected to the whole screen
 func (child3D *Child3D) Update(mainCamera camera.Camera, delta float64, totalTime float64) {
+	aspect := float32(child3D.config.ScreenWidth) / float32(child3D.config.ScreenHeight)
+	child3D.Render(mainCamera, child3D.Projection(aspect), totalTime)
+}
+
+func (child3D *Child3D) FixedUpdate(delta float64) {
+	// A child which moved last tick is still rendered moving
+	moved := child3D.lastX != child3D.X || child3D.lastY != child3D.Y || child3D.lastZ != child3D.Z
+	child3D.lastX, child3D.lastY, child3D.lastZ = child3D.X, child3D.Y, child3D.Z
+
 	child3D.VY -= child3D.Gravity
 
 	child3D.X += child3D.VX
 	child3D.Y += child3D.VY
-	child3D.X += child3D.VZ
+	child3D.Z += child3D.VZ
 
-	child3D.Render(mainCamera, totalTime)
+	if moved || child3D.VX != 0 || child3D.VY != 0 || child3D.VZ != 0 {
+		child3D.Invalidate()
+	}
 }
 
-func (child3D *Child3D) Render(mainCamera camera.Camera, totalTime float64) {
-	child3D.modelMatrix = mgl32.Translate3D(child3D.X, child3D.Y, child3D.Z)
-	child3D.modelMatrix = child3D.modelMatrix.Mul4(mgl32.Scale3D(child3D.ScaleX, child3D.ScaleY, child3D.ScaleZ))
+func (child3D *Child3D) Interpolate(alpha float32) {
+	if alpha == child3D.alpha {
+		return
+	}
+	child3D.alpha = alpha
 
-	child3D.modelMatrix = child3D.modelMatrix.Mul4(mgl32.HomogRotate3DX(child3D.RX))
-	child3D.modelMatrix = child3D.modelMatrix.Mul4(mgl32.HomogRotate3DY(child3D.RY))
-	child3D.modelMatrix = child3D.modelMatrix.Mul4(mgl32.HomogRotate3DZ(child3D.RZ))
+	if child3D.lastX != child3D.X || child3D.lastY != child3D.Y || child3D.lastZ != child3D.Z {
+		child3D.Invalidate()
+	}
+}
+
+// Render renders the child with a projection, such as
+// the one Projection returns for the view being drawn
+func (child3D *Child3D) Render(mainCamera camera.Camera, projection mgl32.Mat4, totalTime float64) {
+	child3D.modelMatrix = child3D.GetWorldMatrix()
+
+	child3D.Model.Render(mainCamera.GetFirstViewIndex(), &child3D.modelMatrix[0], &projection[0], totalTime)
+}
+
+// GetLocalMatrix returns the child's transform at its
+// interpolated position, relative to its parent
+func (child3D *Child3D) GetLocalMatrix() mgl32.Mat4 {
+	t := child3D.Transform
+	t.SetPosition(
+		lerp(child3D.lastX, child3D.X, child3D.alpha),
+		lerp(child3D.lastY, child3D.Y, child3D.alpha),
+		lerp(child3D.lastZ, child3D.Z, child3D.alpha),
+	)
+
+	return t.Matrix()
+}
+
+// SetLocalMatrix sets the child's position, scale and
+// rotation from a matrix, relative to its parent
+func (child3D *Child3D) SetLocalMatrix(m mgl32.Mat4) {
+	child3D.Transform.SetMatrix(m)
+	child3D.lastX, child3D.lastY, child3D.lastZ = child3D.X, child3D.Y, child3D.Z
+
+	child3D.Invalidate()
+}
 
-	child3D.Model.Render(mainCamera.GetFirstViewIndex(), &child3D.modelMatrix[0], &child3D.projectionMatrix[0], totalTime)
+// Bounds returns the bounds of the child's model in world space
+func (child3D *Child3D) Bounds() geometry.Bounds {
+	return child3D.Model.Bounds().Transform(child3D.GetWorldMatrix())
+}
+
+// CopyBounds returns the bounds of a copy of the child in world space
+func (child3D *Child3D) CopyBounds(cpy ChildCopy) geometry.Bounds {
+	return child3D.Model.Bounds().Transform(mgl32.Translate3D(cpy.X, cpy.Y, cpy.Z))
+}
+
+// Projection returns the projection the child is
+// rendered with in a view of the given aspect ratio
+func (child3D *Child3D) Projection(aspect float32) mgl32.Mat4 {
+	return Perspective(aspect, child3D.farPlane)
+}
+
+// Perspective returns the projection 3D children are
+// rendered with at an aspect ratio and far clipping plane
+func Perspective(aspect, farPlane float32) mgl32.Mat4 {
+	return mgl32.Perspective(mgl32.DegToRad(45), aspect, 0.1, farPlane)
 }
 
 func (child3D *Child3D) RenderCopy(config ChildCopy, mainCamera camera.Camera) {
+	dev := device.Get()
+
 	child3D.modelMatrix = mgl32.Translate3D(config.X, config.Y, config.Z)
 
-	gl.UniformMatrix4fv(
+	dev.UniformMatrix4fv(
 		child3D.Material.GetShader().GetUniform("viewMtx"),
 		1, false, mainCamera.GetFirstViewIndex(),
 	)
 
-	gl.UniformMatrix4fv(
+	dev.UniformMatrix4fv(
 		child3D.Material.GetShader().GetUniform("modelMtx"),
 		1, false, &child3D.modelMatrix[0],
 	)
 
 	c := []float32{1, 0, 0}
-	gl.Uniform3fv(
+	dev.Uniform3fv(
 		child3D.Material.GetShader().GetUniform("copyingEnabled"),
 		1, &c[0],
 	)
@@ -122,10 +197,18 @@ func (child3D *Child3D) BindChild() {
 
 }
 
+// SetPosition moves the child without interpolating
+// from its position at the previous tick
 func (child3D *Child3D) SetPosition(x, y, z float32) {
 	child3D.X = x
 	child3D.Y = y
 	child3D.Z = z
+
+	child3D.lastX = x
+	child3D.lastY = y
+	child3D.lastZ = z
+
+	child3D.Invalidate()
 }
 
 func (child3D *Child3D) AttachMaterial(m material.Material) {
@@ -219,11 +302,13 @@ func (child3D *Child3D) RemoveCurrentCopies() {
 }
 
 func (child3D *Child3D) CheckCollision(other Child) int {
-	return child3D.collider.CheckCollision(child3D.X, child3D.Y, child3D.VX, child3D.VY, other.GetX(), other.GetY(), other.GetCollider())
+	o := other.GetWorldPosition()
+	return child3D.CheckCollisionRaw(o[0], o[1], other.GetCollider())
 }
 
 func (child3D *Child3D) CheckCollisionRaw(otherX, otherY float32, otherCollider *physics.Collider) int {
-	return child3D.collider.CheckCollision(child3D.X, child3D.Y, child3D.VX, child3D.VY, otherX, otherY, otherCollider)
+	p := child3D.GetWorldPosition()
+	return child3D.collider.CheckCollision(p[0], p[1], child3D.VX, child3D.VY, otherX, otherY, otherCollider)
 }
 
 func (child3D *Child3D) SetSpecificRenderDistance(d float32) {
@@ -234,6 +319,39 @@ func (child3D *Child3D) GetSpecificRenderDistance() float32 {
 	return child3D.specificRenderDistance
 }
 
+func (child3D *Child3D) SetLayer(l Layer) {
+	child3D.layer = l
+}
+
+func (child3D *Child3D) GetLayer() Layer {
+	return child3D.layer
+}
+
+// GetDrawKey returns the state of the child's first mesh
+func (child3D *Child3D) GetDrawKey() DrawKey {
+	key := DrawKey{
+		Material:    child3D.Material,
+		Transparent: child3D.Transparent,
+	}
+
+	if len(child3D.Model.Meshes) > 0 {
+		mesh := child3D.Model.Meshes[0]
+		if m, ok := child3D.Model.Materials[mesh.ModelMaterial]; ok {
+			key.Material = m
+		}
+		if mesh.VAO != nil {
+			key.VAO = mesh.VAO.GetID()
+		}
+	}
+
+	if key.Material != nil {
+		key.Shader = key.Material.GetShader().GetID()
+		key.Texture = material.MainTexture(key.Material)
+	}
+
+	return key
+}
+
 func (child3D *Child3D) MouseCollisionFunc(collision bool) {
 
 }
@@ -243,9 +361,9 @@ func (child3D *Child3D) MouseCollisionFunc(collision bool) {
 //  --------------------------------------------------
 
 func (child3D *Child3D) SetInstanceRenderDistance(dist float32) {
-	child3D.projectionMatrix = mgl32.Perspective(
-		mgl32.DegToRad(45),
-		float32(child3D.config.ScreenWidth)/float32(child3D.config.ScreenHeight),
-		0.1, dist,
-	)
+	child3D.farPlane = dist
+}
+
+func lerp(a, b, alpha float32) float32 {
+	return a + (b-a)*alpha
 }
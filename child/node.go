@@ -0,0 +1,333 @@
+package child
+
+//  --------------------------------------------------
+//  Node.go contains Node, which places a child in the
+//  scene graph. A child with a parent is positioned
+//  relative to it, so it moves and turns with it.
+//  Nodes also carry the child's name and tags.
+//  --------------------------------------------------
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/geometry"
+)
+
+// Node links a child to its parent and children, and is embedded
+// in every kind of child. The world matrix is cached until the child
+// or one of its ancestors is moved, turned or scaled by its setters,
+// or attached to another child, which invalidates it.
+//
+// Transform fields changed directly are noticed the next time the
+// world matrix is read. Watchers aren't told until then, so call
+// Invalidate to tell them straight away.
+type Node struct {
+	owner    Child
+	parent   Child
+	children []Child
+
+	name string
+	tags map[string]bool
+
+	// Told when the child changes, such as by a scene's index
+	watchers []Watcher
+
+	// Whether children are scaled by this child's scale.
+	// 2D children are scaled to their size in pixels,
+	// which their children shouldn't be.
+	scaleChildren bool
+
+	// The world matrix, and the matrix the
+	// child's children are positioned by
+	world mgl32.Mat4
+	space mgl32.Mat4
+	valid bool
+
+	// The child's transform, and its value when the
+	// world matrix was computed, to notice direct changes
+	transform *geometry.Transform
+	seen      geometry.Transform
+}
+
+// Watcher is told when the children it watches change,
+// so that it can keep an index of them up to date
+type Watcher interface {
+	// ChildChanged is called when a child is named, tagged,
+	// attached or detached, or a child is attached to it
+	ChildChanged(Child)
+
+	// ChildMoved is called when a child's world matrix is invalidated
+	ChildMoved(Child)
+}
+
+func newNode(owner Child, transform *geometry.Transform, scaleChildren bool) Node {
+	return Node{owner: owner, transform: transform, scaleChildren: scaleChildren}
+}
+
+// GetNode returns the child's place in the scene graph
+func (n *Node) GetNode() *Node {
+	return n
+}
+
+// GetParent returns the child's parent, or nil
+func (n *Node) GetParent() Child {
+	return n.parent
+}
+
+// GetChildren returns the children attached to the child
+func (n *Node) GetChildren() []Child {
+	return n.children
+}
+
+// SetParent attaches the child to parent, or detaches it when
+// parent is nil. The child keeps its world transform, so its
+// local transform becomes relative to the new parent.
+func (n *Node) SetParent(parent Child) error {
+	if parent == n.parent {
+		return nil
+	}
+	for p := parent; p != nil; p = p.GetNode().parent {
+		if p.GetNode() == n {
+			return errors.New("a child can't be attached to itself or its descendants")
+		}
+	}
+
+	world := n.GetWorldMatrix()
+
+	if n.parent != nil {
+		siblings := n.parent.GetNode().children
+		for i, c := range siblings {
+			if c.GetNode() == n {
+				n.parent.GetNode().children = append(siblings[:i], siblings[i+1:]...)
+				break
+			}
+		}
+		n.parent.GetNode().changed()
+	}
+
+	n.parent = parent
+	n.Invalidate()
+	n.changed()
+
+	if parent != nil {
+		p := parent.GetNode()
+		p.children = append(p.children, n.owner)
+		p.changed()
+		world = p.childSpace().Inv().Mul4(world)
+	}
+	n.owner.SetLocalMatrix(world)
+
+	return nil
+}
+
+// AddChild attaches c to the child, see SetParent
+func (n *Node) AddChild(c Child) error {
+	return c.GetNode().SetParent(n.owner)
+}
+
+// Detach removes the child from its parent, keeping its world transform
+func (n *Node) Detach() {
+	n.SetParent(nil)
+}
+
+// FindChild returns the descendant at a path of names separated by
+// slashes, such as "arm/hand", starting from the child's children
+func (n *Node) FindChild(path string) Child {
+	c := n.owner
+	for _, name := range strings.Split(path, "/") {
+		var next Child
+		for _, d := range c.GetNode().children {
+			if d.GetNode().name == name {
+				next = d
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		c = next
+	}
+	return c
+}
+
+// Invalidate marks the world matrices of the child and
+// its descendants out of date, so that they're recomputed
+// the next time they're needed
+func (n *Node) Invalidate() {
+	// Children are only valid while their parent is,
+	// so those of an invalid child already are invalid
+	if !n.valid {
+		return
+	}
+
+	n.valid = false
+	for _, w := range n.watchers {
+		w.ChildMoved(n.owner)
+	}
+	for _, c := range n.children {
+		c.GetNode().Invalidate()
+	}
+}
+
+// sync invalidates the world matrix if the transform of
+// the child or one of its ancestors was changed directly
+func (n *Node) sync() {
+	if n.parent != nil {
+		n.parent.GetNode().sync()
+	}
+	if n.valid && n.transform != nil && *n.transform != n.seen {
+		n.Invalidate()
+	}
+}
+
+// GetWorldMatrix returns the child's local matrix,
+// transformed by the world matrices of its parents
+func (n *Node) GetWorldMatrix() mgl32.Mat4 {
+	n.sync()
+	if n.valid {
+		return n.world
+	}
+
+	if n.transform != nil {
+		n.seen = *n.transform
+	}
+	n.world = n.owner.GetLocalMatrix()
+	if n.parent != nil {
+		n.world = n.parent.GetNode().childSpace().Mul4(n.world)
+	}
+
+	n.space = n.world
+	if !n.scaleChildren {
+		_, scale, _ := geometry.Decompose(n.world)
+		for col := 0; col < 3; col++ {
+			if scale[col] == 0 {
+				continue
+			}
+			for row := 0; row < 3; row++ {
+				n.space.Set(row, col, n.space.At(row, col)/scale[col])
+			}
+		}
+	}
+
+	n.valid = true
+	return n.world
+}
+
+// childSpace returns the matrix the child's children are
+// positioned by, which is its world matrix, without the
+// scale unless its children are scaled with it
+func (n *Node) childSpace() mgl32.Mat4 {
+	n.GetWorldMatrix()
+	return n.space
+}
+
+// GetWorldPosition returns the position of the child in the world
+func (n *Node) GetWorldPosition() mgl32.Vec3 {
+	return n.GetWorldMatrix().Col(3).Vec3()
+}
+
+// GetWorldScale returns the scale of the child in the world
+func (n *Node) GetWorldScale() mgl32.Vec3 {
+	_, scale, _ := geometry.Decompose(n.GetWorldMatrix())
+	return scale
+}
+
+// GetWorldRotation returns the rotation of the child in the world
+func (n *Node) GetWorldRotation() mgl32.Quat {
+	_, _, rotation := geometry.Decompose(n.GetWorldMatrix())
+	return rotation
+}
+
+//  --------------------------------------------------
+//  Names & Tags
+//  --------------------------------------------------
+
+// GetName returns the child's name, which is empty unless set
+func (n *Node) GetName() string {
+	return n.name
+}
+
+// SetName names the child. Names needn't be unique, but
+// shouldn't contain slashes, which separate them in paths.
+func (n *Node) SetName(name string) {
+	n.name = name
+	n.changed()
+}
+
+// GetPath returns the names of the child's ancestors
+// and the child, from the root, separated by slashes
+func (n *Node) GetPath() string {
+	if n.parent == nil {
+		return n.name
+	}
+	return n.parent.GetNode().GetPath() + "/" + n.name
+}
+
+// GetTags returns the child's tags in order
+func (n *Node) GetTags() []string {
+	tags := make([]string, 0, len(n.tags))
+	for tag := range n.tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// HasTag returns whether the child has a tag
+func (n *Node) HasTag(tag string) bool {
+	return n.tags[tag]
+}
+
+// AddTag adds tags to the child
+func (n *Node) AddTag(tags ...string) {
+	if n.tags == nil {
+		n.tags = make(map[string]bool)
+	}
+	for _, tag := range tags {
+		n.tags[tag] = true
+	}
+	n.changed()
+}
+
+// RemoveTag removes tags from the child
+func (n *Node) RemoveTag(tags ...string) {
+	for _, tag := range tags {
+		delete(n.tags, tag)
+	}
+	n.changed()
+}
+
+// SetTags replaces the child's tags
+func (n *Node) SetTags(tags ...string) {
+	n.tags = nil
+	n.AddTag(tags...)
+}
+
+//  --------------------------------------------------
+//  Watchers
+//  --------------------------------------------------
+
+// Watch tells w whenever the child changes or moves
+func (n *Node) Watch(w Watcher) {
+	n.watchers = append(n.watchers, w)
+}
+
+// Unwatch stops telling w about the child
+func (n *Node) Unwatch(w Watcher) {
+	for i, other := range n.watchers {
+		if other == w {
+			n.watchers = append(n.watchers[:i], n.watchers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (n *Node) changed() {
+	for _, w := range append([]Watcher{}, n.watchers...) {
+		w.ChildChanged(n.owner)
+	}
+}
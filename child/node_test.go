@@ -0,0 +1,62 @@
+package child
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type moveWatcher struct {
+	moved []Child
+}
+
+func (w *moveWatcher) ChildChanged(c Child) {}
+func (w *moveWatcher) ChildMoved(c Child)   { w.moved = append(w.moved, c) }
+
+func TestDirectTransformChanges(t *testing.T) {
+	parent := NewChild3D(nil)
+	c := NewChild3D(nil)
+	if err := parent.AddChild(c); err != nil {
+		t.Fatal(err)
+	}
+	c.Translate(1, 0, 0)
+
+	// Rendered where they are, rather than between ticks
+	parent.Interpolate(1)
+	c.Interpolate(1)
+
+	w := &moveWatcher{}
+	c.Watch(w)
+
+	if got := c.GetWorldPosition(); got != (mgl32.Vec3{1, 0, 0}) {
+		t.Fatalf("world position %v, want {1 0 0}", got)
+	}
+
+	// Fields written without the setters are noticed
+	// when the world matrix is next read
+	c.X = 2
+	if got := c.GetWorldPosition(); got != (mgl32.Vec3{2, 0, 0}) {
+		t.Fatalf("world position %v after setting X, want {2 0 0}", got)
+	}
+	if len(w.moved) != 1 {
+		t.Fatalf("watcher told of %d moves, want 1", len(w.moved))
+	}
+
+	// So are those of an ancestor
+	parent.Y = 3
+	if got := c.GetWorldPosition(); got != (mgl32.Vec3{2, 3, 0}) {
+		t.Fatalf("world position %v after setting the parent's Y, want {2 3 0}", got)
+	}
+
+	parent.SX = 2
+	if got := c.GetWorldScale(); !got.ApproxEqual(mgl32.Vec3{2, 1, 1}) {
+		t.Fatalf("world scale %v after setting the parent's SX, want {2 1 1}", got)
+	}
+
+	// Reading it again without changes keeps the cache
+	w.moved = nil
+	c.GetWorldMatrix()
+	if len(w.moved) != 0 {
+		t.Fatalf("watcher told of %d moves without any changes", len(w.moved))
+	}
+}
@@ -0,0 +1,109 @@
+package child
+
+//  --------------------------------------------------
+//  Transform.go wraps the setters of the transforms of
+//  2D and 3D children, so that changing a child's
+//  transform invalidates its cached world matrix.
+//  --------------------------------------------------
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+//  --------------------------------------------------
+//  Child2D
+//  --------------------------------------------------
+
+func (child2D *Child2D) Translate(dx, dy, dz float32) {
+	child2D.Transform.Translate(dx, dy, dz)
+	child2D.Invalidate()
+}
+
+func (child2D *Child2D) SetScale(sx, sy, sz float32) {
+	child2D.Transform.SetScale(sx, sy, sz)
+	child2D.Invalidate()
+}
+
+func (child2D *Child2D) SetOrientation(q mgl32.Quat) {
+	child2D.Transform.SetOrientation(q)
+	child2D.Invalidate()
+}
+
+func (child2D *Child2D) SetEuler(x, y, z float32) {
+	child2D.Transform.SetEuler(x, y, z)
+	child2D.Invalidate()
+}
+
+func (child2D *Child2D) Rotate(angle float32, axis mgl32.Vec3) {
+	child2D.Transform.Rotate(angle, axis)
+	child2D.Invalidate()
+}
+
+func (child2D *Child2D) RotateLocal(angle float32, axis mgl32.Vec3) {
+	child2D.Transform.RotateLocal(angle, axis)
+	child2D.Invalidate()
+}
+
+func (child2D *Child2D) RotateAround(point mgl32.Vec3, axis mgl32.Vec3, angle float32) {
+	child2D.Transform.RotateAround(point, axis, angle)
+	child2D.Invalidate()
+}
+
+func (child2D *Child2D) LookAt(target mgl32.Vec3, up mgl32.Vec3) {
+	child2D.Transform.LookAt(target, up)
+	child2D.Invalidate()
+}
+
+func (child2D *Child2D) SetMatrix(m mgl32.Mat4) {
+	child2D.Transform.SetMatrix(m)
+	child2D.Invalidate()
+}
+
+//  --------------------------------------------------
+//  Child3D
+//  --------------------------------------------------
+
+func (child3D *Child3D) Translate(dx, dy, dz float32) {
+	child3D.Transform.Translate(dx, dy, dz)
+	child3D.Invalidate()
+}
+
+func (child3D *Child3D) SetScale(sx, sy, sz float32) {
+	child3D.Transform.SetScale(sx, sy, sz)
+	child3D.Invalidate()
+}
+
+func (child3D *Child3D) SetOrientation(q mgl32.Quat) {
+	child3D.Transform.SetOrientation(q)
+	child3D.Invalidate()
+}
+
+func (child3D *Child3D) SetEuler(x, y, z float32) {
+	child3D.Transform.SetEuler(x, y, z)
+	child3D.Invalidate()
+}
+
+func (child3D *Child3D) Rotate(angle float32, axis mgl32.Vec3) {
+	child3D.Transform.Rotate(angle, axis)
+	child3D.Invalidate()
+}
+
+func (child3D *Child3D) RotateLocal(angle float32, axis mgl32.Vec3) {
+	child3D.Transform.RotateLocal(angle, axis)
+	child3D.Invalidate()
+}
+
+func (child3D *Child3D) RotateAround(point mgl32.Vec3, axis mgl32.Vec3, angle float32) {
+	child3D.Transform.RotateAround(point, axis, angle)
+	child3D.Invalidate()
+}
+
+func (child3D *Child3D) LookAt(target mgl32.Vec3, up mgl32.Vec3) {
+	child3D.Transform.LookAt(target, up)
+	child3D.Invalidate()
+}
+
+func (child3D *Child3D) SetMatrix(m mgl32.Mat4) {
+	child3D.Transform.SetMatrix(m)
+	child3D.Invalidate()
+}
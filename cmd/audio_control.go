@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"os"
+	"rapidengine/assets"
 	"time"
 
 	"github.com/faiface/beep"
@@ -25,24 +25,37 @@ func (ac *AudioControl) Initialize(e *Engine) {
 	ac.engine = e
 }
 
-func (ac *AudioControl) Load(path string, name string) {
-	f, err := os.Open(path)
+// Load decodes a wav file and registers it under name
+func (ac *AudioControl) Load(path string, name string) error {
+	audio, err := decodeAudio(path)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	s, format, err := wav.Decode(f)
+	ac.Sounds[name] = audio
+	return nil
+}
+
+// decodeAudio opens a wav file and decodes its header. It doesn't
+// touch the AudioControl, so sounds can be decoded on any goroutine.
+func decodeAudio(path string) (Audio, error) {
+	f, err := assets.Open(path)
 	if err != nil {
-		panic(err)
+		return Audio{}, err
 	}
 
-	done := make(chan struct{})
+	s, format, err := wav.Decode(f)
+	if err != nil {
+		f.Close()
+		return Audio{}, &assets.DecodeError{Path: path, Format: "wav", Err: err}
+	}
 
-	ac.Sounds[name] = Audio{
+	return Audio{
+		Path:   path,
 		S:      &s,
 		Format: &format,
-		Done:   done,
-	}
+		Done:   make(chan struct{}),
+	}, nil
 }
 
 func (ac *AudioControl) Play(name string) {
@@ -54,6 +67,9 @@ func (ac *AudioControl) Play(name string) {
 }
 
 type Audio struct {
+	// Path is the file the sound was loaded from
+	Path string
+
 	S      *beep.StreamSeekCloser
 	Format *beep.Format
 	Done   chan struct{}
@@ -0,0 +1,337 @@
+package cmd
+
+//   --------------------------------------------------
+//   Behavior_control.go runs the behaviors attached to
+//   children, so that each object's game logic lives
+//   with the object rather than in the RenderFunc.
+//   --------------------------------------------------
+
+import (
+	"fmt"
+
+	"rapidengine/child"
+)
+
+// Behavior is game logic attached to a child. Embed BaseBehavior
+// to implement only the methods a behavior needs.
+//
+// A behavior runs while its child is active and in the current scene
+// or one of its active subscenes. OnEnable is called when it starts
+// running, and OnDisable when it stops, such as when its child is
+// deactivated or the scene changes. Start is called once, before
+// the behavior is first updated.
+type Behavior interface {
+	Start(e *Engine, c child.Child)
+
+	// Update is called once a frame, and
+	// FixedUpdate once a simulation tick
+	Update(delta float64)
+	FixedUpdate(delta float64)
+
+	// OnCollision is called each frame the child's collision
+	// link finds it colliding with a child in the link's group
+	OnCollision(group string, sides []bool)
+
+	OnEnable()
+	OnDisable()
+
+	// OnDestroy is called once, when the behavior
+	// is detached or its child is destroyed
+	OnDestroy()
+}
+
+// BaseBehavior is a Behavior which does nothing
+type BaseBehavior struct{}
+
+func (BaseBehavior) Start(e *Engine, c child.Child)         {}
+func (BaseBehavior) Update(delta float64)                   {}
+func (BaseBehavior) FixedUpdate(delta float64)              {}
+func (BaseBehavior) OnCollision(group string, sides []bool) {}
+func (BaseBehavior) OnEnable()                              {}
+func (BaseBehavior) OnDisable()                             {}
+func (BaseBehavior) OnDestroy()                             {}
+
+// BehaviorControl runs the behaviors attached to children. Children
+// are run in the order the current scene lists them, parents before
+// their children, and the behaviors of a child in the order they
+// were attached, so that every frame runs in the same order.
+type BehaviorControl struct {
+	// Children with behaviors, in the order they were first given one
+	children []child.Child
+	attached map[child.Child][]*attachedBehavior
+
+	// Collisions found this frame, waiting to be passed on
+	collisions map[child.Child]behaviorCollision
+
+	// Behaviors which can be attached by name, such as by scene files
+	factories map[string]func() Behavior
+
+	engine *Engine
+}
+
+type attachedBehavior struct {
+	Behavior
+
+	// The name the behavior was attached by, if any
+	name string
+
+	enabled   bool
+	started   bool
+	destroyed bool
+}
+
+type behaviorCollision struct {
+	group string
+	sides []bool
+}
+
+func NewBehaviorControl() BehaviorControl {
+	return BehaviorControl{
+		attached:   make(map[child.Child][]*attachedBehavior),
+		collisions: make(map[child.Child]behaviorCollision),
+		factories:  make(map[string]func() Behavior),
+	}
+}
+
+func (bc *BehaviorControl) Initialize(engine *Engine) {
+	bc.engine = engine
+}
+
+//   --------------------------------------------------
+//   Attaching
+//   --------------------------------------------------
+
+// Attach attaches a behavior to a child. It starts
+// running at the next update, if the child is running.
+func (bc *BehaviorControl) Attach(c child.Child, b Behavior) {
+	bc.attach(c, b, "")
+}
+
+// RegisterBehavior names a kind of behavior, so that children
+// with it can be saved to and loaded from scene files
+func (bc *BehaviorControl) RegisterBehavior(name string, factory func() Behavior) {
+	bc.factories[name] = factory
+}
+
+// AttachNamed attaches a new behavior registered by RegisterBehavior
+func (bc *BehaviorControl) AttachNamed(c child.Child, name string) (Behavior, error) {
+	factory, err := bc.factory(name)
+	if err != nil {
+		return nil, err
+	}
+
+	b := factory()
+	bc.attach(c, b, name)
+	return b, nil
+}
+
+// factory returns the factory registered by RegisterBehavior under name
+func (bc *BehaviorControl) factory(name string) (func() Behavior, error) {
+	factory, ok := bc.factories[name]
+	if !ok {
+		return nil, fmt.Errorf("no behavior named %q", name)
+	}
+	return factory, nil
+}
+
+func (bc *BehaviorControl) attach(c child.Child, b Behavior, name string) {
+	if _, ok := bc.attached[c]; !ok {
+		bc.children = append(bc.children, c)
+	}
+	bc.attached[c] = append(bc.attached[c], &attachedBehavior{Behavior: b, name: name})
+}
+
+// Detach disables a behavior, destroys it and removes it from a child
+func (bc *BehaviorControl) Detach(c child.Child, b Behavior) {
+	for _, ab := range bc.attached[c] {
+		if ab.Behavior == b {
+			bc.detach(c, ab)
+			return
+		}
+	}
+}
+
+// Destroy detaches every behavior of a child and of its
+// descendants, calling OnDisable and OnDestroy on each
+func (bc *BehaviorControl) Destroy(c child.Child) {
+	for _, d := range appendTree(nil, c) {
+		for _, ab := range bc.attached[d] {
+			bc.detach(d, ab)
+		}
+	}
+}
+
+func (bc *BehaviorControl) detach(c child.Child, ab *attachedBehavior) {
+	if ab.destroyed {
+		return
+	}
+	ab.destroyed = true
+
+	if ab.enabled {
+		ab.enabled = false
+		ab.OnDisable()
+	}
+	ab.OnDestroy()
+
+	behaviors := bc.attached[c]
+	for i, other := range behaviors {
+		if other == ab {
+			behaviors = append(behaviors[:i:i], behaviors[i+1:]...)
+			break
+		}
+	}
+	if len(behaviors) > 0 {
+		bc.attached[c] = behaviors
+		return
+	}
+
+	delete(bc.attached, c)
+	delete(bc.collisions, c)
+	for i, other := range bc.children {
+		if other == c {
+			bc.children = append(bc.children[:i], bc.children[i+1:]...)
+			break
+		}
+	}
+}
+
+// GetBehaviors returns the behaviors attached to a child, in order
+func (bc *BehaviorControl) GetBehaviors(c child.Child) []Behavior {
+	behaviors := []Behavior{}
+	for _, ab := range bc.attached[c] {
+		behaviors = append(behaviors, ab.Behavior)
+	}
+	return behaviors
+}
+
+// names returns the names of the behaviors attached to a child by
+// name, which are the behaviors saved with it, or nil if it has none
+func (bc *BehaviorControl) names(c child.Child) []string {
+	var names []string
+	for _, ab := range bc.attached[c] {
+		if ab.name != "" {
+			names = append(names, ab.name)
+		}
+	}
+	return names
+}
+
+// detachNamed detaches the behaviors attached to a child by name
+func (bc *BehaviorControl) detachNamed(c child.Child) {
+	for _, ab := range bc.attached[c] {
+		if ab.name != "" {
+			bc.detach(c, ab)
+		}
+	}
+}
+
+//   --------------------------------------------------
+//   Running
+//   --------------------------------------------------
+
+// Update updates the behaviors of the running children
+func (bc *BehaviorControl) Update(delta float64) {
+	bc.run(func(b Behavior) { b.Update(delta) })
+}
+
+// FixedUpdate runs a simulation tick of the behaviors of the running children
+func (bc *BehaviorControl) FixedUpdate(delta float64) {
+	bc.run(func(b Behavior) { b.FixedUpdate(delta) })
+}
+
+// collide queues a collision, which is passed to
+// the child's behaviors by dispatchCollisions
+func (bc *BehaviorControl) collide(c child.Child, group string, sides []bool) {
+	if _, ok := bc.attached[c]; ok {
+		bc.collisions[c] = behaviorCollision{group: group, sides: sides}
+	}
+}
+
+// dispatchCollisions passes the collisions found this frame to the
+// behaviors of the children, in the order the children are run in
+func (bc *BehaviorControl) dispatchCollisions() {
+	if len(bc.collisions) == 0 {
+		return
+	}
+
+	collisions := bc.collisions
+	bc.collisions = make(map[child.Child]behaviorCollision)
+
+	for _, c := range bc.running() {
+		if col, ok := collisions[c]; ok {
+			bc.runChild(c, func(b Behavior) { b.OnCollision(col.group, col.sides) })
+		}
+	}
+}
+
+// run enables and disables behaviors whose children have started
+// or stopped running, then calls f on the running behaviors
+func (bc *BehaviorControl) run(f func(Behavior)) {
+	if len(bc.children) == 0 {
+		return
+	}
+
+	running := bc.running()
+
+	isRunning := make(map[child.Child]bool, len(running))
+	for _, c := range running {
+		isRunning[c] = true
+	}
+	for _, c := range append([]child.Child{}, bc.children...) {
+		if isRunning[c] {
+			continue
+		}
+		for _, ab := range bc.attached[c] {
+			if ab.enabled {
+				ab.enabled = false
+				ab.OnDisable()
+			}
+		}
+	}
+
+	for _, c := range running {
+		bc.runChild(c, f)
+	}
+}
+
+// runChild calls f on each behavior of a child, enabling and
+// starting any which haven't been. Behaviors attached or detached
+// along the way are run from the next update.
+func (bc *BehaviorControl) runChild(c child.Child, f func(Behavior)) {
+	for _, ab := range append([]*attachedBehavior{}, bc.attached[c]...) {
+		if !c.IsActive() {
+			return
+		}
+		if ab.destroyed {
+			continue
+		}
+
+		if !ab.enabled {
+			ab.enabled = true
+			ab.OnEnable()
+		}
+		if !ab.started {
+			ab.started = true
+			ab.Start(bc.engine, c)
+		}
+		if !ab.destroyed && ab.enabled {
+			f(ab.Behavior)
+		}
+	}
+}
+
+// running returns the children with behaviors which are
+// active and in the current scene, in the order they run
+func (bc *BehaviorControl) running() []child.Child {
+	running := []child.Child{}
+	if bc.engine.SceneControl.GetCurrentScene() == nil {
+		return running
+	}
+
+	for _, c := range bc.engine.SceneControl.GetCurrentChildren() {
+		if _, ok := bc.attached[c]; ok && c.IsActive() {
+			running = append(running, c)
+		}
+	}
+	return running
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"rapidengine/child"
+)
+
+// recordBehavior records the calls made to it
+type recordBehavior struct {
+	BaseBehavior
+	calls *[]string
+}
+
+func (b recordBehavior) Start(e *Engine, c child.Child) { *b.calls = append(*b.calls, "start") }
+func (b recordBehavior) OnEnable()                      { *b.calls = append(*b.calls, "enable") }
+func (b recordBehavior) OnDisable()                     { *b.calls = append(*b.calls, "disable") }
+func (b recordBehavior) OnDestroy()                     { *b.calls = append(*b.calls, "destroy") }
+
+// newBehaviorChild creates a child in scn with a recordBehavior,
+// and runs the behavior once
+func newBehaviorChild(e *Engine, scn *Scene) (child.Child, *[]string) {
+	calls := &[]string{}
+
+	c := e.ChildControl.NewChild2D()
+	scn.InstanceChild(c)
+	c.Activate()
+	e.BehaviorControl.Attach(c, recordBehavior{calls: calls})
+	e.BehaviorControl.Update(0)
+
+	return c, calls
+}
+
+func TestRemoveChildDestroysBehaviors(t *testing.T) {
+	e, _ := newTestEngine(t, 2)
+	scn := newTestScene(e)
+
+	c, calls := newBehaviorChild(e, scn)
+	scn.RemoveChild(c)
+
+	if want := []string{"enable", "start", "disable", "destroy"}; !reflect.DeepEqual(*calls, want) {
+		t.Fatalf("calls %v, want %v", *calls, want)
+	}
+	if len(e.BehaviorControl.GetBehaviors(c)) != 0 {
+		t.Fatal("the behavior is still attached to the removed child")
+	}
+
+	// A child still instanced in another scene keeps its behaviors
+	other := e.SceneControl.NewScene("other")
+	e.SceneControl.InstanceScene(other)
+
+	c, calls = newBehaviorChild(e, scn)
+	other.InstanceChild(c)
+	scn.RemoveChild(c)
+
+	if len(e.BehaviorControl.GetBehaviors(c)) != 1 {
+		t.Fatalf("calls %v, the behavior of a child in another scene was destroyed", *calls)
+	}
+
+	other.RemoveChild(c)
+	if len(e.BehaviorControl.GetBehaviors(c)) != 0 {
+		t.Fatal("the behavior is still attached after the child left every scene")
+	}
+}
+
+func TestRemoveSubsceneChildDestroysBehaviors(t *testing.T) {
+	e, _ := newTestEngine(t, 2)
+	scn := newTestScene(e)
+
+	sub := newScene("sub")
+	scn.InstanceSubscene(sub)
+
+	c, calls := newBehaviorChild(e, sub)
+	sub.RemoveChild(c)
+
+	if want := []string{"enable", "start", "disable", "destroy"}; !reflect.DeepEqual(*calls, want) {
+		t.Fatalf("calls %v, want %v", *calls, want)
+	}
+}
+
+func TestDestroyEntityDestroysBehaviors(t *testing.T) {
+	e, _ := newTestEngine(t, 2)
+	scn := newTestScene(e)
+	e.Initialize()
+
+	calls := &[]string{}
+	c := e.ChildControl.NewChild2D()
+	entity := e.NewRenderEntity(c, scn)
+	e.BehaviorControl.Attach(c, recordBehavior{calls: calls})
+	e.BehaviorControl.Update(0)
+
+	e.ECS.Destroy(entity)
+
+	if want := []string{"enable", "start", "disable", "destroy"}; !reflect.DeepEqual(*calls, want) {
+		t.Fatalf("calls %v, want %v", *calls, want)
+	}
+	if scn.contains(c) {
+		t.Fatal("the child is still in the scene after its entity was destroyed")
+	}
+}
@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// registerBuiltinCommands adds the console's own commands,
+// and cvars for the settings most often changed while testing
+func (engine *Engine) registerBuiltinCommands() {
+	e := engine
+	cc := &e.ConsoleControl
+	r := &e.Renderer
+	pc := &e.PostControl
+	lc := &e.LightControl
+
+	names := func(prefix string) []string {
+		return append(cc.CommandNames(), cc.CVarNames()...)
+	}
+	cvarNames := func(prefix string) []string {
+		return cc.CVarNames()
+	}
+
+	commands := []*ConsoleCommand{
+		{
+			Name:     "help",
+			Help:     "help [name] lists the commands, or describes one",
+			Complete: names,
+			Run: func(args []string) error {
+				if len(args) == 0 {
+					for _, name := range cc.CommandNames() {
+						cc.Print("%s - %s", name, cc.GetCommand(name).Help)
+					}
+					cc.Print("cvars lists the console variables")
+					return nil
+				}
+				if c := cc.GetCommand(args[0]); c != nil {
+					cc.Print("%s - %s", c.Name, c.Help)
+					return nil
+				}
+				if cv := cc.GetCVar(args[0]); cv != nil {
+					cc.Print("%s %s = %s (default %s) - %s", cv.Type(), cv.Name, cv.String(), cv.Default(), cv.Help)
+					return nil
+				}
+				return fmt.Errorf("unknown command %q", args[0])
+			},
+		},
+		{
+			Name: "cvars",
+			Help: "cvars [prefix] lists the cvars and their values",
+			Run: func(args []string) error {
+				prefix := ""
+				if len(args) > 0 {
+					prefix = args[0]
+				}
+				for _, name := range cc.CVarNames() {
+					if strings.HasPrefix(name, prefix) {
+						cc.Print("%s = %s", name, cc.GetCVar(name).String())
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "set",
+			Help:     "set <cvar> <value> sets a cvar",
+			Complete: cvarNames,
+			Run: func(args []string) error {
+				if len(args) < 2 {
+					return fmt.Errorf("usage: set <cvar> <value>")
+				}
+				cv := cc.GetCVar(args[0])
+				if cv == nil {
+					return fmt.Errorf("unknown cvar %q", args[0])
+				}
+				return cv.Set(strings.Join(args[1:], " "))
+			},
+		},
+		{
+			Name:     "toggle",
+			Help:     "toggle <cvar> flips a bool cvar",
+			Complete: cvarNames,
+			Run: func(args []string) error {
+				if len(args) != 1 {
+					return fmt.Errorf("usage: toggle <cvar>")
+				}
+				cv := cc.GetCVar(args[0])
+				if cv == nil || cv.Type() != "bool" {
+					return fmt.Errorf("%q isn't a bool cvar", args[0])
+				}
+				return cv.Set(fmt.Sprint(cv.String() != "true"))
+			},
+		},
+		{
+			Name:     "reset",
+			Help:     "reset <cvar> sets a cvar back to its default",
+			Complete: cvarNames,
+			Run: func(args []string) error {
+				if len(args) != 1 {
+					return fmt.Errorf("usage: reset <cvar>")
+				}
+				cv := cc.GetCVar(args[0])
+				if cv == nil {
+					return fmt.Errorf("unknown cvar %q", args[0])
+				}
+				return cv.Reset()
+			},
+		},
+		{
+			Name: "exec",
+			Help: "exec <file> runs each line of a file of commands",
+			Run: func(args []string) error {
+				if len(args) != 1 {
+					return fmt.Errorf("usage: exec <file>")
+				}
+				data, err := e.Assets.ReadFile(args[0])
+				if err != nil {
+					return err
+				}
+				for n, line := range strings.Split(string(data), "\n") {
+					if err := cc.Execute(line); err != nil {
+						return fmt.Errorf("%s:%d: %w", args[0], n+1, err)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name: "save_scene",
+			Help: "save_scene <file> saves the current scene, as JSON if the file ends in .json",
+			Run: func(args []string) error {
+				if len(args) != 1 {
+					return fmt.Errorf("usage: save_scene <file>")
+				}
+				scn := e.SceneControl.GetCurrentScene()
+				if scn == nil {
+					return fmt.Errorf("there is no current scene")
+				}
+				return e.SceneControl.SaveScene(scn, args[0])
+			},
+		},
+		{
+			Name: "load_scene",
+			Help: "load_scene <file> loads a scene file and makes it the current scene",
+			Run: func(args []string) error {
+				if len(args) != 1 {
+					return fmt.Errorf("usage: load_scene <file>")
+				}
+				scn, err := e.SceneControl.LoadScene(args[0])
+				if err != nil {
+					return err
+				}
+				e.SceneControl.SetCurrentScene(scn)
+				return nil
+			},
+		},
+		{
+			Name: "echo",
+			Help: "echo <text> prints text",
+			Run: func(args []string) error {
+				cc.Print("%s", strings.Join(args, " "))
+				return nil
+			},
+		},
+		{
+			Name: "clear",
+			Help: "clear removes the console's output",
+			Run: func(args []string) error {
+				cc.ClearLines()
+				return nil
+			},
+		},
+	}
+
+	for _, c := range commands {
+		if err := cc.AddCommand(c); err != nil {
+			e.Logger.Error(err)
+		}
+	}
+
+	type binding struct {
+		name string
+		help string
+		ptr  interface{}
+	}
+
+	bindings := []binding{
+		// Renderer
+		{"render_distance", "distance beyond which copies aren't drawn", &r.RenderDistance},
+		{"frustum_culling", "skip 3D children outside of the view", &r.FrustumCulling},
+		{"collision_lines", "outline colliders", &e.Config.CollisionLines},
+		{"debug_draw", "draw DebugDraw shapes", &e.DebugDraw.Enabled},
+
+		// Post processing
+		{"hdr", "tone map the scene", &pc.hdrEnabled},
+		{"bloom_threshold", "brightness above which bloom starts", &pc.BloomThreshold},
+		{"bloom_intensity", "strength of bloom", &pc.BloomIntensity},
+		{"scattering_decay", "light scattering decay", &pc.ScatteringDecay},
+		{"scattering_density", "light scattering density", &pc.ScatteringDensity},
+		{"scattering_weight", "light scattering weight", &pc.ScatteringWeight},
+		{"scattering_exposure", "light scattering exposure", &pc.ScatteringExposure},
+	}
+
+	for _, b := range bindings {
+		if _, err := cc.AddCVar(b.name, b.help, b.ptr); err != nil {
+			e.Logger.Error(err)
+		}
+	}
+
+	type funcBinding struct {
+		name string
+		help string
+		get  func() interface{}
+		set  func(interface{})
+	}
+
+	funcBindings := []funcBinding{
+		{
+			"polygon_lines", "draw wireframes",
+			func() interface{} { return r.CheckPolygonLines() },
+			func(v interface{}) {
+				if v.(bool) {
+					r.EnablePolygonLines()
+				} else {
+					r.DisablePolygonLines()
+				}
+			},
+		},
+		{
+			"max_fps", "frames drawn per second at most",
+			func() interface{} { return e.Config.MaxFPS },
+			func(v interface{}) {
+				if fps := v.(int); fps > 0 {
+					e.Config.MaxFPS = fps
+					r.MinFrameTime = 1 / float64(fps)
+				}
+			},
+		},
+		{
+			"show_fps", "show the frame rate",
+			func() interface{} { return e.Config.ShowFPS },
+			func(v interface{}) {
+				e.Config.ShowFPS = v.(bool)
+				if e.Config.ShowFPS && e.FPSBox == nil {
+					e.FPSBox = e.TextControl.NewTextBox("Rapid Engine", "avenir", float32(e.Config.ScreenWidth-100), float32(e.Config.ScreenHeight-50), 1, [3]float32{50, 50, 50})
+					if scn := e.SceneControl.GetCurrentScene(); scn != nil {
+						scn.InstanceText(e.FPSBox)
+					}
+				}
+				if !e.Config.ShowFPS && e.FPSBox != nil {
+					e.FPSBox.Text = ""
+				}
+			},
+		},
+		{
+			"post_processing", "run the post processing passes",
+			func() interface{} { return pc.IsPostProcessingEnabled() },
+			func(v interface{}) {
+				if v.(bool) {
+					pc.EnablePostProcessing()
+				} else {
+					pc.DisablePostProcessing()
+				}
+			},
+		},
+		{
+			"lighting", "light the scene",
+			func() interface{} { return lc.IsLightingEnabled() },
+			func(v interface{}) {
+				if v.(bool) {
+					lc.EnableLighting()
+				} else {
+					lc.DisableLighting()
+				}
+			},
+		},
+		{
+			"directional_lighting", "light the scene with the directional light",
+			func() interface{} { return lc.IsDirectionalLightingEnabled() },
+			func(v interface{}) {
+				if v.(bool) {
+					lc.EnableDirectionalLighting()
+				} else {
+					lc.DisableDirectionalLighting()
+				}
+			},
+		},
+	}
+
+	for _, b := range funcBindings {
+		if _, err := cc.AddCVarFunc(b.name, b.help, b.get, b.set); err != nil {
+			e.Logger.Error(err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package cmd
+
+// registerBuiltinPasses adds the passes every frame is rendered
+// through. The scene is rendered into "color", each post processing
+// effect replaces "color" with its result, and "present" draws it
+// to the backbuffer. Custom effects can be inserted anywhere
+// between "scene" and "present" by reading and writing "color".
+// Without post processing, the scene renders straight to the
+// backbuffer and the effect passes are culled.
+func (engine *Engine) registerBuiltinPasses() {
+	e := engine
+	pc := &e.PostControl
+	fg := e.Renderer.FrameGraph
+
+	post := func() bool {
+		return pc.PostProcessingEnabled && pc.ScreenMaterial != nil
+	}
+
+	builtins := []*RenderPass{
+		{
+			Name: "scene",
+			Setup: func(b *PassBuilder) {
+				if !post() {
+					b.Write(Backbuffer, ColorAttachment)
+					return
+				}
+				b.Write("color", ColorAttachment)
+				if pc.scatteringEnabled {
+					b.Write("scattering", ColorAttachment)
+				}
+				b.Write("depth", DepthAttachment)
+			},
+			Execute: func(ctx *PassContext) {
+				e.Renderer.renderScene(ctx.FrameBuffer)
+			},
+		},
+		{
+			Name: "hdr",
+			Setup: func(b *PassBuilder) {
+				if post() && pc.hdrEnabled {
+					b.Read("color")
+					b.Write("color", ColorAttachment)
+				}
+			},
+			Execute: func(ctx *PassContext) {
+				pc.ApplyHDR(ctx.Input("color"), ctx.Output("color"))
+			},
+		},
+		{
+			Name: "bloom_prefilter",
+			Setup: func(b *PassBuilder) {
+				if post() && pc.bloomEnabled {
+					b.Read("color")
+					b.Write("bloom_bright", ColorAttachment)
+				}
+			},
+			Execute: func(ctx *PassContext) {
+				pc.ApplyPreBloom(ctx.Input("color"), ctx.Output("bloom_bright"))
+			},
+		},
+		{
+			Name: "bloom_blur",
+			Setup: func(b *PassBuilder) {
+				if !post() || !pc.bloomEnabled {
+					return
+				}
+
+				scaled := ColorAttachment
+				scaled.Width = int32(e.Config.ScreenWidth / pc.gaussianScale)
+				scaled.Height = int32(e.Config.ScreenHeight / pc.gaussianScale)
+
+				b.Read("bloom_bright")
+				b.Write("blur_h", scaled)
+				b.Write("blur_v", scaled)
+				b.Write("blur_full", ColorAttachment)
+				b.Write("bloom_blurred", ColorAttachment)
+			},
+			Execute: func(ctx *PassContext) {
+				pc.GaussianBuffer1 = *ctx.Output("blur_h")
+				pc.GaussianBuffer2 = *ctx.Output("blur_v")
+				pc.GaussianBuffer3 = *ctx.Output("blur_full")
+
+				pc.ApplyGaussianBlur(ctx.Input("bloom_bright"), ctx.Output("bloom_blurred"))
+			},
+		},
+		{
+			Name: "bloom",
+			Setup: func(b *PassBuilder) {
+				if post() && pc.bloomEnabled {
+					b.Read("color")
+					b.Read("bloom_blurred")
+					b.Write("color", ColorAttachment)
+				}
+			},
+			Execute: func(ctx *PassContext) {
+				pc.ApplyPostBloom(ctx.Input("color"), ctx.Input("bloom_blurred"), ctx.Output("color"))
+			},
+		},
+		{
+			Name: "scattering",
+			Setup: func(b *PassBuilder) {
+				if post() && pc.scatteringEnabled {
+					b.Read("color")
+					b.Read("scattering")
+					b.Write("scattering_rays", ColorAttachment)
+					b.Write("color", ColorAttachment)
+				}
+			},
+			Execute: func(ctx *PassContext) {
+				rays := ctx.Output("scattering_rays")
+
+				pc.ApplyPreScattering(ctx.Input("scattering"), rays)
+				pc.ApplyPostScattering(ctx.Input("color"), rays, ctx.Output("color"))
+			},
+		},
+		{
+			Name: "post_user",
+			Setup: func(b *PassBuilder) {
+				if post() && pc.UserFunc != nil {
+					b.Read("color")
+					b.Write("color", ColorAttachment)
+				}
+			},
+			Execute: func(ctx *PassContext) {
+				pc.ApplyUserFunc(ctx.Input("color"), ctx.Output("color"))
+			},
+		},
+		{
+			Name: "transition",
+			Setup: func(b *PassBuilder) {
+				if post() && pc.transitionEnabled {
+					b.Read("color")
+					b.Write("color", ColorAttachment)
+				}
+			},
+			Execute: func(ctx *PassContext) {
+				if err := pc.ApplyTransition(ctx.Input("color"), ctx.Output("color")); err != nil {
+					ctx.Fail(err)
+				}
+			},
+		},
+		{
+			Name: "present",
+			Setup: func(b *PassBuilder) {
+				if post() {
+					b.Read("color")
+					b.Write(Backbuffer, ColorAttachment)
+				}
+			},
+			Execute: func(ctx *PassContext) {
+				pc.ApplyCopy(ctx.Input("color"), ctx.Output(Backbuffer))
+			},
+		},
+		{
+			Name: "debug",
+			Setup: func(b *PassBuilder) {
+				b.Modify(Backbuffer)
+			},
+			Execute: func(ctx *PassContext) {
+				e.DebugDraw.render()
+			},
+		},
+		{
+			Name: "console",
+			Setup: func(b *PassBuilder) {
+				b.Modify(Backbuffer)
+			},
+			Execute: func(ctx *PassContext) {
+				e.ConsoleControl.render()
+			},
+		},
+	}
+
+	for _, p := range builtins {
+		if err := fg.AddPass(p); err != nil {
+			e.Logger.Error(err)
+		}
+	}
+}
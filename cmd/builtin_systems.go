@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"rapidengine/event"
+	"rapidengine/material"
+)
+
+// Orders of the post update systems, which are updated in the order
+// the engine has always updated them, so lights are uploaded before
+// terrain is drawn, the UI reacts to this frame's collisions, and text
+// is drawn over everything else. They're negative so that systems
+// registered by users are updated after them by default.
+const (
+	orderTerrain = iota - 5
+	orderLight
+	orderCollision
+	orderUI
+	orderText
+)
+
+// registerBuiltinSystems registers the engine's controls as systems,
+// in the order they have always been initialized
+func (engine *Engine) registerBuiltinSystems() error {
+	e := engine
+
+	builtins := []*FuncSystem{
+		{
+			SystemName: "child",
+			InitFunc:   initWith(e.ChildControl.Initialize),
+		},
+		{
+			SystemName: "geometry",
+			InitFunc:   initWith(e.GeometryControl.Initialize),
+		},
+		{
+			SystemName:  "scene",
+			SystemPhase: PhasePreUpdate,
+			InitFunc:    initWith(e.SceneControl.Initialize),
+			UpdateFunc:  e.SceneControl.Update,
+		},
+		{
+			SystemName: "prefab",
+			InitFunc:   initWith(e.PrefabControl.Initialize),
+		},
+		{
+			SystemName: "shader",
+			InitFunc: func(*Engine) error {
+				return e.ShaderControl.Initialize()
+			},
+		},
+		{
+			SystemName: "material",
+			Requires:   []string{"shader"},
+			InitFunc:   initWith(e.MaterialControl.Initialize),
+		},
+		{
+			SystemName:  "input",
+			SystemPhase: PhasePreUpdate,
+			UpdateFunc: func(float64) {
+				last := e.Inputs()
+				e.inputs = e.InputControl.Update(e.Renderer.Window)
+
+				// Keys typed into the console don't reach the game
+				if e.ConsoleControl.Open {
+					for key := range e.inputs.Keys {
+						e.inputs.Keys[key] = false
+					}
+				}
+
+				for key, pressed := range e.inputs.Keys {
+					if pressed && !last.Keys[key] {
+						e.Events.Publish(event.KeyPressed{Key: key})
+					} else if !pressed && last.Keys[key] {
+						e.Events.Publish(event.KeyReleased{Key: key})
+					}
+				}
+			},
+		},
+		{
+			SystemName:  "ecs",
+			SystemPhase: PhaseUpdate,
+			Requires:    []string{"input"},
+			InitFunc: func(*Engine) error {
+				e.initializeEntities()
+				return nil
+			},
+			UpdateFunc: e.updateEntities,
+		},
+		{
+			SystemName:  "behavior",
+			SystemPhase: PhaseUpdate,
+			Requires:    []string{"scene"},
+			InitFunc:    initWith(e.BehaviorControl.Initialize),
+			UpdateFunc:  e.BehaviorControl.Update,
+		},
+		{
+			SystemName:  "ui",
+			SystemPhase: PhasePostUpdate,
+			SystemOrder: orderUI,
+			Requires:    []string{"input"},
+			InitFunc:    initWith(e.UIControl.Initialize),
+			UpdateFunc: func(float64) {
+				e.UIControl.Update(e.inputs)
+			},
+		},
+		{
+			SystemName:  "text",
+			SystemPhase: PhasePostUpdate,
+			SystemOrder: orderText,
+			InitFunc:    initWith(e.TextControl.Initialize),
+			UpdateFunc: func(float64) {
+				e.TextControl.Update()
+			},
+		},
+		{
+			SystemName:  "terrain",
+			SystemPhase: PhasePostUpdate,
+			SystemOrder: orderTerrain,
+			Requires:    []string{"material"},
+			InitFunc:    initWith(e.TerrainControl.Initialize),
+			UpdateFunc: func(float64) {
+				e.TerrainControl.Update()
+			},
+		},
+		{
+			SystemName:  "collision",
+			SystemPhase: PhasePostUpdate,
+			SystemOrder: orderCollision,
+			Requires:    []string{"input"},
+			InitFunc:    initWith(e.CollisionControl.Initialize),
+			UpdateFunc: func(float64) {
+				x, y, _ := e.Renderer.MainCamera.GetPosition()
+				e.CollisionControl.Update(x, y, e.inputs)
+			},
+		},
+		{
+			SystemName: "audio",
+			InitFunc:   initWith(e.AudioControl.Initialize),
+		},
+		{
+			SystemName:  "post",
+			SystemPhase: PhasePostRender,
+			Requires:    []string{"shader"},
+			InitFunc:    initWith(e.PostControl.Initialize),
+		},
+		{
+			SystemName:  "light",
+			SystemPhase: PhasePostUpdate,
+			SystemOrder: orderLight,
+			Requires:    []string{"shader"},
+			InitFunc: func(*Engine) error {
+				e.LightControl.Initialize(e)
+				e.LightControl.Shaders = []*material.ShaderProgram{
+					e.ShaderControl.GetShader("standard"),
+					e.ShaderControl.GetShader("terrain"),
+					e.ShaderControl.GetShader("foliage"),
+					e.ShaderControl.GetShader("water"),
+					e.ShaderControl.GetShader("pbr"),
+				}
+				return nil
+			},
+			UpdateFunc: func(float64) {
+				x, y, z := e.Renderer.MainCamera.GetPosition()
+				e.LightControl.Update(x, y, z)
+			},
+		},
+		{
+			SystemName: "console",
+			InitFunc:   initWith(e.ConsoleControl.Initialize),
+		},
+		{
+			SystemName: "debug_draw",
+			Requires:   []string{"shader"},
+			InitFunc:   initWith(e.DebugDraw.Initialize),
+		},
+		{
+			SystemName:   "debug",
+			SystemPhase:  PhasePostRender,
+			Requires:     []string{"post"},
+			InitFunc:     e.DebugControl.Initialize,
+			ShutdownFunc: e.DebugControl.Stop,
+			UpdateFunc: func(float64) {
+				e.DebugControl.EndFrame()
+			},
+		},
+	}
+
+	for _, s := range builtins {
+		if err := e.SystemControl.Register(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initWith adapts a control's Initialize method to System.Init
+func initWith(initialize func(*Engine)) func(*Engine) error {
+	return func(e *Engine) error {
+		initialize(e)
+		return nil
+	}
+}
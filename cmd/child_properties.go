@@ -0,0 +1,387 @@
+package cmd
+
+//   --------------------------------------------------
+//   Child_properties.go lists the properties of a
+//   ChildData, and how each one is set on a child. Scene
+//   files set all of them, while prefabs set only those
+//   which change.
+//   --------------------------------------------------
+
+import (
+	"reflect"
+
+	"rapidengine/child"
+	"rapidengine/geometry"
+	"rapidengine/physics"
+)
+
+type childProperty struct {
+	name string
+
+	// field returns a pointer to the property in d
+	field func(d *ChildData) interface{}
+
+	// apply sets the property of c from d
+	apply func(r *sceneReader, c child.Child, d *ChildData) error
+}
+
+var childProperties = []childProperty{
+	{
+		name:  "name",
+		field: func(d *ChildData) interface{} { return &d.Name },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			c.GetNode().SetName(d.Name)
+			return nil
+		},
+	},
+	{
+		name:  "tags",
+		field: func(d *ChildData) interface{} { return &d.Tags },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			c.GetNode().SetTags(d.Tags...)
+			return nil
+		},
+	},
+	{
+		name:  "position",
+		field: func(d *ChildData) interface{} { return &d.Position },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			switch c := c.(type) {
+			case *child.Child2D:
+				c.SetPosition(d.Position[0], d.Position[1])
+			case *child.Child3D:
+				c.SetPosition(d.Position[0], d.Position[1], d.Position[2])
+			}
+			return nil
+		},
+	},
+	{
+		name:  "orientation",
+		field: func(d *ChildData) interface{} { return &d.Orientation },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			switch c := c.(type) {
+			case *child.Child2D:
+				c.SetOrientation(dataQuat(d.Orientation))
+			case *child.Child3D:
+				c.SetOrientation(dataQuat(d.Orientation))
+			}
+			return nil
+		},
+	},
+	{
+		name:  "scale",
+		field: func(d *ChildData) interface{} { return &d.Scale },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			switch c := c.(type) {
+			case *child.Child2D:
+				c.SetScale(d.Scale[0], d.Scale[1], c.SZ)
+			case *child.Child3D:
+				c.SetScale(d.Scale[0], d.Scale[1], d.Scale[2])
+			}
+			return nil
+		},
+	},
+	{
+		name:  "velocity",
+		field: func(d *ChildData) interface{} { return &d.Velocity },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			switch c := c.(type) {
+			case *child.Child2D:
+				c.SetVelocity(d.Velocity[0], d.Velocity[1])
+			case *child.Child3D:
+				c.VX, c.VY, c.VZ = d.Velocity[0], d.Velocity[1], d.Velocity[2]
+			}
+			return nil
+		},
+	},
+	{
+		name:  "gravity",
+		field: func(d *ChildData) interface{} { return &d.Gravity },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			switch c := c.(type) {
+			case *child.Child2D:
+				c.Gravity = d.Gravity
+			case *child.Child3D:
+				c.Gravity = d.Gravity
+			}
+			return nil
+		},
+	},
+	{
+		name:  "layer",
+		field: func(d *ChildData) interface{} { return &d.Layer },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			c.SetLayer(d.Layer)
+			return nil
+		},
+	},
+	{
+		name:  "render_distance",
+		field: func(d *ChildData) interface{} { return &d.RenderDistance },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			c.SetSpecificRenderDistance(d.RenderDistance)
+			return nil
+		},
+	},
+	{
+		name:  "transparent",
+		field: func(d *ChildData) interface{} { return &d.Transparent },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			if c, ok := c.(*child.Child3D); ok {
+				c.Transparent = d.Transparent
+			}
+			return nil
+		},
+	},
+	{
+		name:  "static",
+		field: func(d *ChildData) interface{} { return &d.Static },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			if c, ok := c.(*child.Child2D); ok {
+				c.Static = d.Static
+			}
+			return nil
+		},
+	},
+	{
+		name:  "darkness",
+		field: func(d *ChildData) interface{} { return &d.Darkness },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			if c, ok := c.(*child.Child2D); ok {
+				c.Darkness = d.Darkness
+			}
+			return nil
+		},
+	},
+	{
+		name:  "material",
+		field: func(d *ChildData) interface{} { return &d.Material },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			c2, ok := c.(*child.Child2D)
+			if !ok {
+				return nil
+			}
+
+			name := d.Material
+			if name == "" {
+				name = "default1"
+			}
+			mat, err := r.material(name)
+			if err != nil {
+				return err
+			}
+			c2.AttachMaterial(mat)
+			return nil
+		},
+	},
+	{
+		name:  "mesh",
+		field: func(d *ChildData) interface{} { return &d.Mesh },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			c2, ok := c.(*child.Child2D)
+			if !ok {
+				return nil
+			}
+
+			id := d.Mesh
+			if id == "" {
+				id = "rectangle"
+			}
+			mesh, err := r.mesh(id)
+			if err != nil {
+				return err
+			}
+			c2.AttachMesh(mesh)
+			return nil
+		},
+	},
+	{
+		name:  "model",
+		field: func(d *ChildData) interface{} { return &d.Model },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			c3, ok := c.(*child.Child3D)
+			if !ok {
+				return nil
+			}
+
+			model := geometry.Model{}
+			if d.Model != nil {
+				var err error
+				if model, err = r.model(d.Model); err != nil {
+					return err
+				}
+			}
+			c3.AttachModel(model)
+			return nil
+		},
+	},
+	{
+		name:  "collider",
+		field: func(d *ChildData) interface{} { return &d.Collider },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			c2, ok := c.(*child.Child2D)
+			if !ok {
+				return nil
+			}
+
+			col := [4]float32{}
+			if d.Collider != nil {
+				col = *d.Collider
+			}
+			c2.AttachCollider(col[0], col[1], col[2], col[3])
+			return nil
+		},
+	},
+	{
+		name:  "group",
+		field: func(d *ChildData) interface{} { return &d.Group },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			switch c := c.(type) {
+			case *child.Child2D:
+				c.AttachGroup(d.Group)
+			case *child.Child3D:
+				c.Group = d.Group
+			}
+			return nil
+		},
+	},
+	{
+		name:  "collision_groups",
+		field: func(d *ChildData) interface{} { return &d.CollisionGroups },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			cc := &r.engine.CollisionControl
+			groups := d.CollisionGroups
+
+			r.later(func() {
+				for group := range cc.GroupMap {
+					cc.RemoveChildFromGroup(c, group)
+				}
+				for _, group := range groups {
+					cc.AddChildToGroup(c, group)
+				}
+			})
+			return nil
+		},
+	},
+	{
+		name:  "link",
+		field: func(d *ChildData) interface{} { return &d.Link },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			cc := &r.engine.CollisionControl
+
+			if d.Link == nil {
+				r.later(func() { cc.RemoveCollision(c) })
+				return nil
+			}
+
+			link := *d.Link
+			var callback func([]bool)
+			if link.Callback != "" {
+				var err error
+				if callback, err = cc.namedCallback(link.Callback); err != nil {
+					return err
+				}
+			}
+
+			r.later(func() {
+				cc.LinkMap[c] = physics.CollisionLink{Group: link.Group, Callback: callback, Name: link.Callback}
+			})
+			return nil
+		},
+	},
+	{
+		name:  "behaviors",
+		field: func(d *ChildData) interface{} { return &d.Behaviors },
+		apply: func(r *sceneReader, c child.Child, d *ChildData) error {
+			bc := &r.engine.BehaviorControl
+
+			// Behaviors keep their state unless they change
+			if reflect.DeepEqual(bc.names(c), d.Behaviors) {
+				return nil
+			}
+
+			names := append([]string{}, d.Behaviors...)
+			factories := make([]func() Behavior, len(names))
+			for i, name := range names {
+				var err error
+				if factories[i], err = bc.factory(name); err != nil {
+					return err
+				}
+			}
+
+			r.later(func() {
+				bc.detachNamed(c)
+				for i, factory := range factories {
+					bc.attach(c, factory(), names[i])
+				}
+			})
+			return nil
+		},
+	},
+}
+
+// changedProperties returns the properties which differ between a and b
+func changedProperties(a, b *ChildData) []childProperty {
+	changed := []childProperty{}
+	for _, p := range childProperties {
+		if !reflect.DeepEqual(p.field(a), p.field(b)) {
+			changed = append(changed, p)
+		}
+	}
+	return changed
+}
+
+// copyProperty sets a property of dst to a copy of src's
+func copyProperty(p childProperty, dst, src *ChildData) {
+	from := cloneChildData(src)
+	reflect.ValueOf(p.field(dst)).Elem().Set(reflect.ValueOf(p.field(&from)).Elem())
+}
+
+// cloneChildData returns a copy of d which shares no memory with it
+func cloneChildData(d *ChildData) ChildData {
+	c := *d
+
+	if d.Model != nil {
+		m := *d.Model
+		if d.Model.Meshes != nil {
+			m.Meshes = append([]MeshData{}, d.Model.Meshes...)
+		}
+		if d.Model.Materials != nil {
+			m.Materials = make(map[int]string, len(d.Model.Materials))
+			for i, name := range d.Model.Materials {
+				m.Materials[i] = name
+			}
+		}
+		c.Model = &m
+	}
+	if d.Collider != nil {
+		col := *d.Collider
+		c.Collider = &col
+	}
+	if d.Link != nil {
+		link := *d.Link
+		c.Link = &link
+	}
+	if d.Rotation != nil {
+		rotation := *d.Rotation
+		c.Rotation = &rotation
+	}
+	if d.Tags != nil {
+		c.Tags = append([]string{}, d.Tags...)
+	}
+	if d.Behaviors != nil {
+		c.Behaviors = append([]string{}, d.Behaviors...)
+	}
+	if d.CollisionGroups != nil {
+		c.CollisionGroups = append([]string{}, d.CollisionGroups...)
+	}
+
+	if d.Children != nil {
+		c.Children = make([]ChildData, len(d.Children))
+		for i := range d.Children {
+			c.Children[i] = cloneChildData(&d.Children[i])
+		}
+	}
+
+	return c
+}
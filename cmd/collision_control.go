@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"fmt"
 	"rapidengine/child"
 	"rapidengine/configuration"
+	"rapidengine/event"
 	"rapidengine/input"
 	"rapidengine/physics"
 )
@@ -24,6 +26,12 @@ type CollisionControl struct {
 	NumMouseChildren int
 	MouseCollider    physics.Collider
 
+	// Children currently colliding with their link's group
+	colliding map[child.Child]bool
+
+	// Callbacks links can be created with by name
+	callbacks map[string]func([]bool)
+
 	config *configuration.EngineConfig
 	engine *Engine
 }
@@ -41,7 +49,9 @@ func NewCollisionControl(config *configuration.EngineConfig) CollisionControl {
 			Width:   5,
 			Height:  5,
 		},
-		config: config,
+		colliding: make(map[child.Child]bool),
+		callbacks: make(map[string]func([]bool)),
+		config:    config,
 	}
 }
 
@@ -59,10 +69,54 @@ func (collisionControl *CollisionControl) AddChildToGroup(c child.Child, group s
 	collisionControl.GroupMap[group] = append(collisionControl.GroupMap[group], c)
 }
 
+// RemoveChildFromGroup removes a child from a collision group
+func (collisionControl *CollisionControl) RemoveChildFromGroup(c child.Child, group string) {
+	members := collisionControl.GroupMap[group]
+	for i, m := range members {
+		if m == c {
+			collisionControl.GroupMap[group] = append(members[:i], members[i+1:]...)
+			return
+		}
+	}
+}
+
 // CreateCollision adds a child/collisionlink pair to the LinkMap, so that
 // collision will be checked for in Update()
 func (collisionControl *CollisionControl) CreateCollision(c child.Child, group string, callback func([]bool)) {
-	collisionControl.LinkMap[c] = physics.CollisionLink{group, callback}
+	collisionControl.LinkMap[c] = physics.CollisionLink{Group: group, Callback: callback}
+}
+
+// RegisterCallback names a collision callback, so that links
+// using it can be saved to and loaded from scene files
+func (collisionControl *CollisionControl) RegisterCallback(name string, callback func([]bool)) {
+	collisionControl.callbacks[name] = callback
+}
+
+// CreateNamedCollision creates a collision like CreateCollision,
+// with a callback registered by RegisterCallback
+func (collisionControl *CollisionControl) CreateNamedCollision(c child.Child, group string, name string) error {
+	callback, err := collisionControl.namedCallback(name)
+	if err != nil {
+		return err
+	}
+
+	collisionControl.LinkMap[c] = physics.CollisionLink{Group: group, Callback: callback, Name: name}
+	return nil
+}
+
+// namedCallback returns the callback registered by RegisterCallback under name
+func (collisionControl *CollisionControl) namedCallback(name string) (func([]bool), error) {
+	callback, ok := collisionControl.callbacks[name]
+	if !ok {
+		return nil, fmt.Errorf("no collision callback named %q", name)
+	}
+	return callback, nil
+}
+
+// RemoveCollision removes a child's collision link
+func (collisionControl *CollisionControl) RemoveCollision(c child.Child) {
+	delete(collisionControl.LinkMap, c)
+	delete(collisionControl.colliding, c)
 }
 
 // CreateMouseCollision adds a child to the MouseChildren list to be checked against mouse coordinates
@@ -100,10 +154,19 @@ func (collisionControl *CollisionControl) Update(camX, camY float32, inputs *inp
 	for c, link := range collisionControl.LinkMap {
 		if c.IsActive() {
 			if col := collisionControl.CheckCollisionWithGroup(c, link.Group, camX, camY); col != nil {
-				link.Callback(col)
+				if link.Callback != nil {
+					link.Callback(col)
+				}
+				if col[0] || col[1] || col[2] || col[3] {
+					collisionControl.engine.BehaviorControl.collide(c, link.Group, col)
+				}
+				collisionControl.publishCollision(c, link.Group, col)
 			}
+		} else {
+			collisionControl.publishCollision(c, link.Group, nil)
 		}
 	}
+	collisionControl.engine.BehaviorControl.dispatchCollisions()
 
 	mx, my := float32(inputs.MouseX), float32(inputs.MouseY)-float32(collisionControl.config.ScreenHeight) //collisionControl.ScaleMouseCoords(inputs.MouseX, inputs.MouseY, camX, camY)
 	for _, c := range collisionControl.MouseChildren {
@@ -115,6 +178,26 @@ func (collisionControl *CollisionControl) Update(camX, camY float32, inputs *inp
 	}
 }
 
+// publishCollision publishes CollisionBegan and CollisionEnded
+// events when a child starts or stops colliding with its group
+func (collisionControl *CollisionControl) publishCollision(c child.Child, group string, col []bool) {
+	colliding := false
+	for _, side := range col {
+		colliding = colliding || side
+	}
+
+	if colliding == collisionControl.colliding[c] {
+		return
+	}
+	collisionControl.colliding[c] = colliding
+
+	if colliding {
+		collisionControl.engine.Events.Publish(event.CollisionBegan{Child: c, Group: group, Sides: col})
+	} else {
+		collisionControl.engine.Events.Publish(event.CollisionEnded{Child: c, Group: group})
+	}
+}
+
 func (collisionControl *CollisionControl) ScaleMouseCoords(x, y float64, camX, camY float32) (float32, float32) {
 	return float32(x) + camX - float32(collisionControl.config.ScreenWidth/2), (float32(y) - camY - float32(collisionControl.config.ScreenHeight/2))
 }
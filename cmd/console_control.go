@@ -0,0 +1,611 @@
+package cmd
+
+//   --------------------------------------------------
+//   Console_control.go contains the developer console,
+//   which drops down over the screen. It runs commands,
+//   and reads and sets console variables (cvars), which
+//   are bound to settings of the engine, so they can be
+//   changed while the game runs.
+//   --------------------------------------------------
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"rapidengine/device"
+	"rapidengine/ui"
+)
+
+const (
+	// Lines of output and history kept
+	consoleMaxLines   = 200
+	consoleMaxHistory = 100
+
+	// Fraction of the screen the console covers
+	consoleHeight     = 0.4
+	consoleLineHeight = 20
+	consoleTextScale  = 0.5
+	consoleMargin     = 10
+)
+
+// ConsoleCommand is run by typing its name into the
+// console, followed by its arguments
+type ConsoleCommand struct {
+	Name string
+	Help string
+
+	Run func(args []string) error
+
+	// Complete returns the completions of an argument
+	// beginning with prefix. It can be nil.
+	Complete func(prefix string) []string
+}
+
+// CVar is a console variable bound to a setting. Typing its
+// name prints its value, and typing its name and a value sets it.
+type CVar struct {
+	Name string
+	Help string
+
+	// OnChange is called after the value is set
+	OnChange func()
+
+	typ      reflect.Type
+	get      func() reflect.Value
+	set      func(reflect.Value)
+	defaults string
+}
+
+type ConsoleControl struct {
+	// Whether the console is dropped down
+	Open bool
+
+	// The key which opens and closes the console
+	ToggleKey device.Key
+
+	commands map[string]*ConsoleCommand
+	cvars    map[string]*CVar
+
+	// Output, oldest first
+	lines []string
+
+	// The line being typed
+	input string
+
+	// Lines entered, oldest first, and the one
+	// being shown while browsing with the arrow keys
+	history      []string
+	historyIndex int
+
+	// Whether the last key pressed opened or closed the
+	// console, so the character it types is ignored
+	toggled bool
+
+	textBoxes []*ui.TextBox
+
+	engine *Engine
+}
+
+func NewConsoleControl() ConsoleControl {
+	return ConsoleControl{
+		ToggleKey: device.KeyGraveAccent,
+		commands:  make(map[string]*ConsoleCommand),
+		cvars:     make(map[string]*CVar),
+	}
+}
+
+func (cc *ConsoleControl) Initialize(engine *Engine) {
+	cc.engine = engine
+
+	engine.Renderer.Window.SetKeyCallback(cc.keyCallback)
+	engine.Renderer.Window.SetCharCallback(cc.charCallback)
+}
+
+// Toggle opens or closes the console
+func (cc *ConsoleControl) Toggle() {
+	cc.Open = !cc.Open
+}
+
+// Print adds a line of output
+func (cc *ConsoleControl) Print(format string, a ...interface{}) {
+	cc.lines = append(cc.lines, strings.Split(fmt.Sprintf(format, a...), "\n")...)
+	if len(cc.lines) > consoleMaxLines {
+		cc.lines = cc.lines[len(cc.lines)-consoleMaxLines:]
+	}
+}
+
+// Lines returns the output, oldest first
+func (cc *ConsoleControl) Lines() []string {
+	return cc.lines
+}
+
+// ClearLines removes all of the output
+func (cc *ConsoleControl) ClearLines() {
+	cc.lines = nil
+}
+
+//  --------------------------------------------------
+//  Commands and CVars
+//  --------------------------------------------------
+
+// AddCommand registers a command, whose name can't
+// be used by another command or cvar
+func (cc *ConsoleControl) AddCommand(c *ConsoleCommand) error {
+	if err := cc.checkName(c.Name); err != nil {
+		return err
+	}
+	cc.commands[c.Name] = c
+	return nil
+}
+
+// AddCVar registers a cvar bound to the value ptr points to,
+// which must be a bool, int, float or string
+func (cc *ConsoleControl) AddCVar(name, help string, ptr interface{}) (*CVar, error) {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil, fmt.Errorf("cvar %s must be bound to a pointer", name)
+	}
+	elem := v.Elem()
+
+	return cc.addCVar(&CVar{
+		Name: name,
+		Help: help,
+		typ:  elem.Type(),
+		get:  func() reflect.Value { return elem },
+		set:  func(value reflect.Value) { elem.Set(value) },
+	})
+}
+
+// AddCVarFunc registers a cvar which is read with get and written
+// with set, for settings behind methods. Its type is that of the
+// value get returns, and set is passed values of the same type.
+func (cc *ConsoleControl) AddCVarFunc(name, help string, get func() interface{}, set func(interface{})) (*CVar, error) {
+	return cc.addCVar(&CVar{
+		Name: name,
+		Help: help,
+		typ:  reflect.TypeOf(get()),
+		get:  func() reflect.Value { return reflect.ValueOf(get()) },
+		set:  func(value reflect.Value) { set(value.Interface()) },
+	})
+}
+
+func (cc *ConsoleControl) addCVar(cv *CVar) (*CVar, error) {
+	if err := cc.checkName(cv.Name); err != nil {
+		return nil, err
+	}
+
+	switch cv.Type() {
+	case "bool", "int", "float", "string":
+	default:
+		return nil, fmt.Errorf("cvar %s can't be a %v", cv.Name, cv.typ)
+	}
+
+	cv.defaults = cv.String()
+	cc.cvars[cv.Name] = cv
+	return cv, nil
+}
+
+func (cc *ConsoleControl) checkName(name string) error {
+	if name == "" || strings.ContainsAny(name, " \t\";") {
+		return fmt.Errorf("invalid console name %q", name)
+	}
+	if _, ok := cc.commands[name]; ok {
+		return fmt.Errorf("console command %s already exists", name)
+	}
+	if _, ok := cc.cvars[name]; ok {
+		return fmt.Errorf("cvar %s already exists", name)
+	}
+	return nil
+}
+
+// GetCommand returns the command with the given name, or nil
+func (cc *ConsoleControl) GetCommand(name string) *ConsoleCommand {
+	return cc.commands[name]
+}
+
+// GetCVar returns the cvar with the given name, or nil
+func (cc *ConsoleControl) GetCVar(name string) *CVar {
+	return cc.cvars[name]
+}
+
+// CommandNames returns the names of every command, sorted
+func (cc *ConsoleControl) CommandNames() []string {
+	names := []string{}
+	for name := range cc.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CVarNames returns the names of every cvar, sorted
+func (cc *ConsoleControl) CVarNames() []string {
+	names := []string{}
+	for name := range cc.cvars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String returns the value of the cvar as text
+func (cv *CVar) String() string {
+	return fmt.Sprint(cv.get().Interface())
+}
+
+// Default returns the value the cvar had when it was registered
+func (cv *CVar) Default() string {
+	return cv.defaults
+}
+
+// Type returns "bool", "int", "float" or "string"
+func (cv *CVar) Type() string {
+	switch cv.typ.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.String:
+		return "string"
+	}
+	return cv.typ.String()
+}
+
+// Set parses s as the cvar's type, and sets it
+func (cv *CVar) Set(s string) error {
+	value := reflect.New(cv.typ).Elem()
+	s = strings.TrimSpace(s)
+
+	switch cv.Type() {
+	case "bool":
+		b, err := parseConsoleBool(s)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false", cv.Name)
+		}
+		value.SetBool(b)
+	case "int":
+		n, err := strconv.ParseInt(s, 10, cv.typ.Bits())
+		if err != nil {
+			return fmt.Errorf("%s must be an integer", cv.Name)
+		}
+		value.SetInt(n)
+	case "float":
+		f, err := strconv.ParseFloat(s, cv.typ.Bits())
+		if err != nil {
+			return fmt.Errorf("%s must be a number", cv.Name)
+		}
+		value.SetFloat(f)
+	default:
+		value.SetString(s)
+	}
+
+	cv.set(value)
+	if cv.OnChange != nil {
+		cv.OnChange()
+	}
+	return nil
+}
+
+// Reset sets the cvar back to its default
+func (cv *CVar) Reset() error {
+	return cv.Set(cv.defaults)
+}
+
+func parseConsoleBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "on", "yes":
+		return true, nil
+	case "off", "no":
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+//  --------------------------------------------------
+//  Execution
+//  --------------------------------------------------
+
+// Execute runs a line of commands separated by semicolons,
+// stopping at the first which fails
+func (cc *ConsoleControl) Execute(line string) error {
+	for _, args := range parseConsoleLine(line) {
+		if err := cc.run(args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExecArgs runs command line arguments, where each "+" starts
+// a command, such as "+bloom_threshold 0.5 +exec autoexec.cfg".
+// Every command is run, and the errors are returned.
+func (cc *ConsoleControl) ExecArgs(args []string) []error {
+	errs := []error{}
+
+	var current []string
+	run := func() {
+		if len(current) == 0 {
+			return
+		}
+		if err := cc.run(current); err != nil {
+			errs = append(errs, err)
+		}
+		current = nil
+	}
+
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "+") {
+			run()
+			arg = arg[1:]
+		} else if len(current) == 0 {
+			errs = append(errs, fmt.Errorf("expected a console command starting with +, got %q", arg))
+			continue
+		}
+		current = append(current, arg)
+	}
+	run()
+
+	return errs
+}
+
+func (cc *ConsoleControl) run(args []string) error {
+	name := args[0]
+
+	if c, ok := cc.commands[name]; ok {
+		return c.Run(args[1:])
+	}
+
+	if cv, ok := cc.cvars[name]; ok {
+		if len(args) == 1 {
+			cc.Print("%s = %s", name, cv.String())
+			return nil
+		}
+		return cv.Set(strings.Join(args[1:], " "))
+	}
+
+	return fmt.Errorf("unknown command %q", name)
+}
+
+// parseConsoleLine splits a line into commands, and each command
+// into its arguments. Arguments are separated by spaces, unless
+// quoted, and anything after // is a comment.
+func parseConsoleLine(line string) [][]string {
+	commands := [][]string{}
+	args := []string{}
+	var arg strings.Builder
+	inArg, inQuotes := false, false
+
+	endArg := func() {
+		if inArg {
+			args = append(args, arg.String())
+			arg.Reset()
+			inArg = false
+		}
+	}
+	endCommand := func() {
+		endArg()
+		if len(args) > 0 {
+			commands = append(commands, args)
+			args = []string{}
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		switch {
+		case inQuotes && c == '"':
+			inQuotes = false
+		case inQuotes:
+			arg.WriteByte(c)
+		case c == '"':
+			inArg, inQuotes = true, true
+		case c == '/' && !inArg && strings.HasPrefix(line[i:], "//"):
+			endCommand()
+			return commands
+		case c == ';':
+			endCommand()
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			endArg()
+		default:
+			inArg = true
+			arg.WriteByte(c)
+		}
+	}
+	endCommand()
+
+	return commands
+}
+
+//  --------------------------------------------------
+//  Completion
+//  --------------------------------------------------
+
+// Complete returns the completions of the last word of a line.
+// The first word of a command completes to the names of commands
+// and cvars, and later words are completed by the command.
+func (cc *ConsoleControl) Complete(line string) []string {
+	statement := line[strings.LastIndex(line, ";")+1:]
+	words := strings.Fields(statement)
+
+	prefix := ""
+	if len(words) > 0 && !strings.HasSuffix(statement, " ") {
+		prefix = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	var candidates []string
+	if len(words) == 0 {
+		candidates = append(cc.CommandNames(), cc.CVarNames()...)
+	} else if c, ok := cc.commands[words[0]]; ok && c.Complete != nil {
+		candidates = c.Complete(prefix)
+	}
+
+	matches := []string{}
+	for _, name := range candidates {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// completeInput completes the word being typed as far as all of
+// its completions agree, and lists them if there are several
+func (cc *ConsoleControl) completeInput() {
+	matches := cc.Complete(cc.input)
+	if len(matches) == 0 {
+		return
+	}
+
+	common := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, common) {
+			common = common[:len(common)-1]
+		}
+	}
+
+	start := strings.LastIndexAny(cc.input, " ;") + 1
+	cc.input = cc.input[:start] + common
+
+	if len(matches) == 1 {
+		cc.input += " "
+		return
+	}
+	cc.Print("%s", strings.Join(matches, "  "))
+}
+
+//  --------------------------------------------------
+//  Input
+//  --------------------------------------------------
+
+func (cc *ConsoleControl) keyCallback(key device.Key, action device.Action) {
+	if action == device.Release {
+		return
+	}
+
+	cc.toggled = key == cc.ToggleKey && action == device.Press
+	if cc.toggled {
+		cc.Toggle()
+		return
+	}
+
+	if !cc.Open {
+		return
+	}
+
+	switch key {
+	case device.KeyEnter:
+		cc.submit()
+	case device.KeyBackspace:
+		if _, size := utf8.DecodeLastRuneInString(cc.input); size > 0 {
+			cc.input = cc.input[:len(cc.input)-size]
+		}
+	case device.KeyTab:
+		cc.completeInput()
+	case device.KeyUp:
+		if cc.historyIndex > 0 {
+			cc.historyIndex--
+			cc.input = cc.history[cc.historyIndex]
+		}
+	case device.KeyDown:
+		if cc.historyIndex < len(cc.history)-1 {
+			cc.historyIndex++
+			cc.input = cc.history[cc.historyIndex]
+		} else {
+			cc.historyIndex = len(cc.history)
+			cc.input = ""
+		}
+	case device.KeyEscape:
+		cc.Open = false
+	}
+}
+
+func (cc *ConsoleControl) charCallback(char rune) {
+	if cc.toggled {
+		cc.toggled = false
+		return
+	}
+	if cc.Open {
+		cc.input += string(char)
+	}
+}
+
+// submit runs the line being typed, and adds it to the history
+func (cc *ConsoleControl) submit() {
+	line := strings.TrimSpace(cc.input)
+	cc.input = ""
+
+	if line != "" && (len(cc.history) == 0 || cc.history[len(cc.history)-1] != line) {
+		cc.history = append(cc.history, line)
+		if len(cc.history) > consoleMaxHistory {
+			cc.history = cc.history[1:]
+		}
+	}
+	cc.historyIndex = len(cc.history)
+
+	cc.Print("> %s", line)
+	if err := cc.Execute(line); err != nil {
+		cc.Print("error: %v", err)
+	}
+}
+
+// History returns the lines entered, oldest first
+func (cc *ConsoleControl) History() []string {
+	return cc.history
+}
+
+//  --------------------------------------------------
+//  Rendering
+//  --------------------------------------------------
+
+// render draws the console over the top of the screen, with
+// the newest output at the bottom, above the line being typed
+func (cc *ConsoleControl) render() {
+	if !cc.Open || cc.engine.Renderer.Device.Headless() {
+		return
+	}
+
+	config := cc.engine.Config
+	top := float32(config.ScreenHeight)
+	bottom := top - consoleHeight*top
+
+	rows := int((top-bottom)/consoleLineHeight) - 1
+	if rows < 0 {
+		rows = 0
+	}
+	first := len(cc.lines) - rows
+	if first < 0 {
+		first = 0
+	}
+
+	cc.drawLine(0, "> "+cc.input+"_", bottom+consoleMargin, [3]float32{255, 255, 255})
+	for i, line := range cc.lines[first:] {
+		y := bottom + consoleMargin + float32(len(cc.lines)-first-i)*consoleLineHeight
+		cc.drawLine(i+1, line, y, [3]float32{190, 190, 190})
+	}
+
+	// gltext binds its own program, VAO and textures
+	cc.engine.Renderer.State.Invalidate()
+}
+
+func (cc *ConsoleControl) drawLine(i int, text string, y float32, color [3]float32) {
+	for len(cc.textBoxes) <= i {
+		t := cc.engine.TextControl.NewTextBox("", "avenir", 0, 0, consoleTextScale, color)
+		t.LeftAligned = true
+		cc.textBoxes = append(cc.textBoxes, t)
+	}
+
+	t := cc.textBoxes[i]
+	t.Text = text
+	t.X, t.Y = consoleMargin, y
+	t.Color = [3]float32{color[0] / 255, color[1] / 255, color[2] / 255}
+	t.Update(cc.engine.Config)
+}
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseConsoleLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want [][]string
+	}{
+		{"", [][]string{}},
+		{"   ", [][]string{}},
+		{"quit", [][]string{{"quit"}}},
+		{"  bloom   0.5\t", [][]string{{"bloom", "0.5"}}},
+		{"a 1; b 2;c", [][]string{{"a", "1"}, {"b", "2"}, {"c"}}},
+		{";; a ;;", [][]string{{"a"}}},
+		{`echo "hello world" x`, [][]string{{"echo", "hello world", "x"}}},
+		{`echo "a;b // c"`, [][]string{{"echo", "a;b // c"}}},
+		{`echo ""`, [][]string{{"echo", ""}}},
+		{`echo pre"quoted"post`, [][]string{{"echo", "prequotedpost"}}},
+		{`echo "unterminated`, [][]string{{"echo", "unterminated"}}},
+		{"a 1 // b 2; c", [][]string{{"a", "1"}}},
+		{"// only a comment", [][]string{}},
+		{"exec cfg/auto.cfg", [][]string{{"exec", "cfg/auto.cfg"}}},
+		{"url http://x", [][]string{{"url", "http://x"}}},
+	}
+
+	for _, test := range tests {
+		if got := parseConsoleLine(test.line); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseConsoleLine(%q) = %q, want %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestConsoleExecute(t *testing.T) {
+	cc := NewConsoleControl()
+
+	var ran [][]string
+	fail := errors.New("fail")
+	cc.AddCommand(&ConsoleCommand{Name: "echo", Run: func(args []string) error {
+		ran = append(ran, args)
+		return nil
+	}})
+	cc.AddCommand(&ConsoleCommand{Name: "fail", Run: func(args []string) error {
+		return fail
+	}})
+
+	if err := cc.Execute(`echo a "b c"; echo`); err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"a", "b c"}, {}}; !reflect.DeepEqual(ran, want) {
+		t.Fatalf("ran %q, want %q", ran, want)
+	}
+
+	// Execution stops at the first command which fails
+	ran = nil
+	if err := cc.Execute("echo 1; fail; echo 2"); err != fail {
+		t.Fatalf("Execute returned %v, want %v", err, fail)
+	}
+	if want := [][]string{{"1"}}; !reflect.DeepEqual(ran, want) {
+		t.Fatalf("ran %q, want %q", ran, want)
+	}
+
+	if err := cc.Execute("nope"); err == nil {
+		t.Fatal("unknown command didn't fail")
+	}
+}
+
+func TestConsoleNames(t *testing.T) {
+	cc := NewConsoleControl()
+
+	var n int
+	if err := cc.AddCommand(&ConsoleCommand{Name: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cc.AddCVar("x", "", &n); err == nil {
+		t.Fatal("a cvar was added with the name of a command")
+	}
+	if err := cc.AddCommand(&ConsoleCommand{Name: "x"}); err == nil {
+		t.Fatal("a command was added twice")
+	}
+
+	for _, name := range []string{"", "a b", "a;b", `a"b`} {
+		if err := cc.AddCommand(&ConsoleCommand{Name: name}); err == nil {
+			t.Errorf("command named %q was added", name)
+		}
+	}
+
+	if _, err := cc.AddCVar("notptr", "", n); err == nil {
+		t.Fatal("a cvar was bound to a value rather than a pointer")
+	}
+	var unsupported []int
+	if _, err := cc.AddCVar("slice", "", &unsupported); err == nil {
+		t.Fatal("a cvar was bound to a slice")
+	}
+}
+
+func TestCVars(t *testing.T) {
+	cc := NewConsoleControl()
+
+	var (
+		enabled   = true
+		samples   = 4
+		threshold = float32(0.8)
+		title     = "game"
+	)
+	cc.AddCVar("enabled", "", &enabled)
+	cc.AddCVar("samples", "", &samples)
+	cc.AddCVar("threshold", "", &threshold)
+	cc.AddCVar("title", "", &title)
+
+	changes := 0
+	cc.GetCVar("samples").OnChange = func() { changes++ }
+
+	lines := []string{
+		"enabled off",
+		"samples 16",
+		"threshold 0.25",
+		`title "my game"`,
+	}
+	for _, line := range lines {
+		if err := cc.Execute(line); err != nil {
+			t.Fatalf("Execute(%q): %v", line, err)
+		}
+	}
+
+	if enabled || samples != 16 || threshold != 0.25 || title != "my game" {
+		t.Fatalf("cvars are %v %v %v %q", enabled, samples, threshold, title)
+	}
+	if changes != 1 {
+		t.Fatalf("OnChange called %d times, want 1", changes)
+	}
+
+	// Values which don't parse leave the cvar unchanged
+	for _, line := range []string{"enabled maybe", "samples 1.5", "threshold x"} {
+		if err := cc.Execute(line); err == nil {
+			t.Errorf("Execute(%q) didn't fail", line)
+		}
+	}
+	if enabled || samples != 16 || threshold != 0.25 {
+		t.Fatalf("cvars changed by invalid values to %v %v %v", enabled, samples, threshold)
+	}
+
+	// Typing only the name prints the value
+	cc.ClearLines()
+	cc.Execute("samples")
+	if want := []string{"samples = 16"}; !reflect.DeepEqual(cc.Lines(), want) {
+		t.Fatalf("printed %q, want %q", cc.Lines(), want)
+	}
+
+	for _, name := range []string{"enabled", "samples", "threshold", "title"} {
+		if err := cc.GetCVar(name).Reset(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !enabled || samples != 4 || threshold != 0.8 || title != "game" {
+		t.Fatalf("cvars reset to %v %v %v %q", enabled, samples, threshold, title)
+	}
+}
+
+func TestCVarFunc(t *testing.T) {
+	cc := NewConsoleControl()
+
+	value := 0.5
+	cv, err := cc.AddCVarFunc("gamma", "",
+		func() interface{} { return value },
+		func(v interface{}) { value = v.(float64) * 2 },
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cv.Type() != "float" || cv.Default() != "0.5" {
+		t.Fatalf("type %s default %s, want float 0.5", cv.Type(), cv.Default())
+	}
+	if err := cc.Execute("gamma 2"); err != nil {
+		t.Fatal(err)
+	}
+	if value != 4 || cv.String() != "4" {
+		t.Fatalf("value %v, %s, want 4", value, cv.String())
+	}
+}
+
+func TestConsoleExecArgs(t *testing.T) {
+	cc := NewConsoleControl()
+
+	samples := 0
+	cc.AddCVar("samples", "", &samples)
+
+	var ran [][]string
+	cc.AddCommand(&ConsoleCommand{Name: "exec", Run: func(args []string) error {
+		ran = append(ran, args)
+		return nil
+	}})
+
+	errs := cc.ExecArgs([]string{"stray", "+samples", "8", "+exec", "a.cfg", "b.cfg", "+unknown"})
+
+	if samples != 8 {
+		t.Fatalf("samples = %d, want 8", samples)
+	}
+	if want := [][]string{{"a.cfg", "b.cfg"}}; !reflect.DeepEqual(ran, want) {
+		t.Fatalf("ran %q, want %q", ran, want)
+	}
+
+	// The stray argument and the unknown command both fail,
+	// without stopping the commands between them
+	if len(errs) != 2 {
+		t.Fatalf("got errors %v, want 2", errs)
+	}
+}
+
+func TestConsoleComplete(t *testing.T) {
+	cc := NewConsoleControl()
+
+	var n int
+	cc.AddCVar("bloom_threshold", "", &n)
+	cc.AddCVar("bloom_enabled", "", &n)
+	cc.AddCommand(&ConsoleCommand{Name: "bind"})
+	cc.AddCommand(&ConsoleCommand{Name: "exec", Complete: func(prefix string) []string {
+		return []string{"autoexec.cfg", "video.cfg"}
+	}})
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"b", []string{"bind", "bloom_enabled", "bloom_threshold"}},
+		{"bloom_t", []string{"bloom_threshold"}},
+		{"exec ", []string{"autoexec.cfg", "video.cfg"}},
+		{"exec v", []string{"video.cfg"}},
+		{"bind x; ex", []string{"exec"}},
+		{"bind ", []string{}},
+		{"zzz", []string{}},
+	}
+
+	for _, test := range tests {
+		if got := cc.Complete(test.line); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Complete(%q) = %q, want %q", test.line, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+//   --------------------------------------------------
+//   Culling.go skips 3D children and copies which are
+//   outside of the view frustum of the current camera,
+//   or further away than their render distance.
+//   --------------------------------------------------
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/child"
+	"rapidengine/geometry"
+)
+
+// CullStats counts the 3D children and copies
+// tested against the view frustum in a frame
+type CullStats struct {
+	Children       int `json:"children"`
+	ChildrenCulled int `json:"children_culled"`
+	Copies         int `json:"copies"`
+	CopiesCulled   int `json:"copies_culled"`
+}
+
+type culler struct {
+	// Frustums of the current camera, by the projection
+	// they were made with, as children's projections differ
+	frustums map[mgl32.Mat4]geometry.Frustum
+	view     mgl32.Mat4
+	position mgl32.Vec3
+
+	stats     CullStats
+	lastStats CullStats
+}
+
+// beginCulling culls against the current camera
+// until the next call
+func (renderer *Renderer) beginCulling() {
+	c := &renderer.culling
+	if c.frustums == nil {
+		c.frustums = make(map[mgl32.Mat4]geometry.Frustum)
+	}
+	for p := range c.frustums {
+		delete(c.frustums, p)
+	}
+
+	cam := renderer.CurrentCamera()
+	c.view = cam.GetView()
+	x, y, z := cam.GetPosition()
+	c.position = mgl32.Vec3{x, y, z}
+}
+
+func (renderer *Renderer) frustum(projection mgl32.Mat4) geometry.Frustum {
+	c := &renderer.culling
+	f, ok := c.frustums[projection]
+	if !ok {
+		f = geometry.NewFrustum(projection.Mul4(c.view))
+		c.frustums[projection] = f
+	}
+	return f
+}
+
+// childVisible reports whether any of a 3D child is in view
+func (renderer *Renderer) childVisible(c *child.Child3D) bool {
+	if !renderer.FrustumCulling {
+		return true
+	}
+	renderer.culling.stats.Children++
+
+	b := c.Bounds()
+	if renderer.inView(b, renderer.projection(c), c.GetSpecificRenderDistance()) {
+		return true
+	}
+
+	renderer.culling.stats.ChildrenCulled++
+	return false
+}
+
+// copyVisible reports whether any of a copy of a 3D child
+// is in view, and within the renderer's render distance
+func (renderer *Renderer) copyVisible(c *child.Child3D, cpy child.ChildCopy) bool {
+	renderer.culling.stats.Copies++
+
+	b := c.CopyBounds(cpy)
+	if !b.Valid {
+		b = geometry.NewBoundsFromBox(mgl32.Vec3{cpy.X, cpy.Y, cpy.Z}, mgl32.Vec3{cpy.X, cpy.Y, cpy.Z})
+	}
+
+	if b.Distance(renderer.culling.position) <= renderer.RenderDistance &&
+		(!renderer.FrustumCulling || renderer.inView(b, renderer.projection(c), c.GetSpecificRenderDistance())) {
+		return true
+	}
+
+	renderer.culling.stats.CopiesCulled++
+	return false
+}
+
+func (renderer *Renderer) inView(b geometry.Bounds, projection mgl32.Mat4, renderDistance float32) bool {
+	if !b.Valid {
+		return true
+	}
+	if renderDistance != 0 && b.Distance(renderer.culling.position) > renderDistance {
+		return false
+	}
+	return renderer.frustum(projection).IntersectsBounds(b)
+}
+
+// CullStats returns the culling stats of the last complete frame
+func (renderer *Renderer) CullStats() CullStats {
+	return renderer.culling.lastStats
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/geometry"
+)
+
+func TestFrustumCulling(t *testing.T) {
+	e, null := newTestEngine(t, 3)
+	scn := newTestScene(e)
+
+	// The camera is at the origin, looking down -Z. Of the children in
+	// front of it, behind it, and in front but further than their
+	// render distance, only the first is drawn.
+	add := func(z, renderDistance float32) {
+		c := e.ChildControl.NewChild3D()
+		c.AttachModel(geometry.NewModel(geometry.NewCube(), e.MaterialControl.NewBasicMaterial()))
+		c.SetLocalMatrix(mgl32.Translate3D(0, 0, z))
+		c.SetSpecificRenderDistance(renderDistance)
+		scn.InstanceChild(c)
+	}
+	add(-10, 0)
+	add(10, 0)
+	add(-50, 20)
+	e.Initialize()
+
+	// Cameras look after each frame, so the
+	// first frame has no view to cull against
+	e.Renderer.renderFrame()
+
+	null.Reset()
+	e.Renderer.renderFrame()
+	culled := len(null.Draws())
+
+	if stats := e.Renderer.CullStats(); stats != (CullStats{Children: 3, ChildrenCulled: 2}) {
+		t.Fatalf("cull stats %+v, want 3 children with 2 culled", stats)
+	}
+
+	// Without culling, every child is drawn
+	e.Renderer.FrustumCulling = false
+	null.Reset()
+	e.Renderer.renderFrame()
+
+	if stats := e.Renderer.CullStats(); stats != (CullStats{}) {
+		t.Fatalf("cull stats %+v without culling, want none", stats)
+	}
+	if all := len(null.Draws()); all != culled+2 {
+		t.Fatalf("%d draws without culling and %d with it, want 2 more", all, culled)
+	}
+}
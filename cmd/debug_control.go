@@ -0,0 +1,432 @@
+package cmd
+
+//   --------------------------------------------------
+//   Debug_control.go contains the optional diagnostics
+//   server. When EngineConfig.DebugAddr is set, it serves
+//   JSON frame, system and render statistics along with
+//   net/http/pprof. Engine state is only read on the render
+//   thread, between frames.
+//   --------------------------------------------------
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"sync"
+	"time"
+
+	"rapidengine/child"
+	"rapidengine/device"
+)
+
+// Upper bounds of the frame time histogram buckets, in milliseconds
+var frameTimeBuckets = []float64{1, 2, 4, 8, 16.7, 33.3, 50, 100, 250}
+
+// Number of recent frames kept for percentiles
+const frameHistory = 600
+
+type DebugControl struct {
+	mu sync.Mutex
+
+	frames     []float64
+	frameIndex int
+	frameCount uint64
+	histogram  []uint64
+
+	systems map[string]*systemTiming
+
+	lastDraws device.DrawStats
+	lastState device.StateStats
+
+	// Window time when the last frame ended, which
+	// frames are timed from once a frame has ended
+	lastFrameEnd float64
+	timing       bool
+
+	requests chan func()
+
+	listener net.Listener
+	server   *http.Server
+
+	engine *Engine
+}
+
+type systemTiming struct {
+	Phase   string  `json:"phase"`
+	Updates uint64  `json:"updates"`
+	LastMS  float64 `json:"last_ms"`
+	AvgMS   float64 `json:"avg_ms"`
+	MaxMS   float64 `json:"max_ms"`
+	totalMS float64
+}
+
+func NewDebugControl() DebugControl {
+	return DebugControl{
+		histogram: make([]uint64, len(frameTimeBuckets)+1),
+		systems:   make(map[string]*systemTiming),
+		requests:  make(chan func()),
+	}
+}
+
+// Initialize starts the debug server if the engine config has a DebugAddr
+func (dc *DebugControl) Initialize(engine *Engine) error {
+	dc.engine = engine
+
+	if engine.Config.DebugAddr == "" {
+		return nil
+	}
+	return dc.Start(engine.Config.DebugAddr)
+}
+
+// Start serves the debug endpoints on addr
+func (dc *DebugControl) Start(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("starting debug server: %w", err)
+	}
+
+	dc.listener = l
+	dc.server = &http.Server{Handler: dc.Handler()}
+	dc.engine.SystemControl.SetUpdateTimer(dc.recordSystem)
+
+	go dc.server.Serve(l)
+
+	dc.engine.Logger.Info("Debug server listening on http://", l.Addr().String())
+	return nil
+}
+
+// Addr returns the address the debug server is listening
+// on, or an empty string if it isn't running
+func (dc *DebugControl) Addr() string {
+	if dc.listener == nil {
+		return ""
+	}
+	return dc.listener.Addr().String()
+}
+
+// Stop shuts down the debug server
+func (dc *DebugControl) Stop() {
+	if dc.server == nil {
+		return
+	}
+
+	dc.engine.SystemControl.SetUpdateTimer(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	dc.server.Shutdown(ctx)
+
+	dc.server = nil
+	dc.listener = nil
+}
+
+// Handler returns the debug endpoints
+func (dc *DebugControl) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/debug/frames", dc.serveFrames)
+	mux.HandleFunc("/debug/systems", dc.serveSystems)
+	mux.HandleFunc("/debug/render", dc.serveRender)
+	mux.HandleFunc("/debug/assets", dc.serveAssets)
+	mux.HandleFunc("/debug/scene", dc.serveScene)
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return mux
+}
+
+// EndFrame records the statistics of the frame which is ending, and
+// answers any requests waiting to read engine state. Frames are timed
+// from the end of one to the end of the next.
+func (dc *DebugControl) EndFrame() {
+	if dc.server == nil {
+		return
+	}
+
+	now := dc.engine.Renderer.Window.GetTime()
+	if dc.timing {
+		dc.recordFrame((now - dc.lastFrameEnd) * 1000)
+	}
+	dc.lastFrameEnd, dc.timing = now, true
+
+	for {
+		select {
+		case req := <-dc.requests:
+			req()
+		default:
+			return
+		}
+	}
+}
+
+//   --------------------------------------------------
+//   Recording
+//   --------------------------------------------------
+
+func (dc *DebugControl) recordFrame(ms float64) {
+	draws := dc.engine.Renderer.DrawCounter.ResetStats()
+	state := dc.engine.Renderer.State.ResetStats()
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	if len(dc.frames) < frameHistory {
+		dc.frames = append(dc.frames, ms)
+	} else {
+		dc.frames[dc.frameIndex] = ms
+	}
+	dc.frameIndex = (dc.frameIndex + 1) % frameHistory
+	dc.frameCount++
+
+	bucket := sort.SearchFloat64s(frameTimeBuckets, ms)
+	dc.histogram[bucket]++
+
+	dc.lastDraws = draws
+	dc.lastState = state
+}
+
+func (dc *DebugControl) recordSystem(s System, took time.Duration) {
+	ms := float64(took) / float64(time.Millisecond)
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	t, ok := dc.systems[s.Name()]
+	if !ok {
+		t = &systemTiming{Phase: s.Phase().String()}
+		dc.systems[s.Name()] = t
+	}
+
+	t.Updates++
+	t.LastMS = ms
+	t.totalMS += ms
+	t.AvgMS = t.totalMS / float64(t.Updates)
+	if ms > t.MaxMS {
+		t.MaxMS = ms
+	}
+}
+
+// onRenderThread runs f between frames and waits for it,
+// so handlers can read engine state safely
+func (dc *DebugControl) onRenderThread(f func()) error {
+	done := make(chan struct{})
+
+	select {
+	case dc.requests <- func() { f(); close(done) }:
+		<-done
+		return nil
+	case <-time.After(2 * time.Second):
+		return fmt.Errorf("render thread is not responding")
+	}
+}
+
+//   --------------------------------------------------
+//   Endpoints
+//   --------------------------------------------------
+
+type histogramBucket struct {
+	Bucket string `json:"bucket"`
+	Count  uint64 `json:"count"`
+}
+
+func (dc *DebugControl) serveFrames(w http.ResponseWriter, r *http.Request) {
+	dc.mu.Lock()
+	frames := append([]float64(nil), dc.frames...)
+	histogram := make([]histogramBucket, len(dc.histogram))
+	for i, count := range dc.histogram {
+		histogram[i].Count = count
+		if i < len(frameTimeBuckets) {
+			histogram[i].Bucket = fmt.Sprintf("<=%gms", frameTimeBuckets[i])
+		} else {
+			histogram[i].Bucket = fmt.Sprintf(">%gms", frameTimeBuckets[i-1])
+		}
+	}
+	count := dc.frameCount
+	dc.mu.Unlock()
+
+	sort.Float64s(frames)
+
+	writeJSON(w, map[string]interface{}{
+		"frames":    count,
+		"histogram": histogram,
+		"recent": map[string]float64{
+			"min_ms": percentile(frames, 0),
+			"p50_ms": percentile(frames, 0.5),
+			"p95_ms": percentile(frames, 0.95),
+			"p99_ms": percentile(frames, 0.99),
+			"max_ms": percentile(frames, 1),
+		},
+	})
+}
+
+func (dc *DebugControl) serveSystems(w http.ResponseWriter, r *http.Request) {
+	dc.mu.Lock()
+	systems := make(map[string]systemTiming, len(dc.systems))
+	for name, t := range dc.systems {
+		systems[name] = *t
+	}
+	dc.mu.Unlock()
+
+	writeJSON(w, systems)
+}
+
+func (dc *DebugControl) serveRender(w http.ResponseWriter, r *http.Request) {
+	dc.mu.Lock()
+	draws := dc.lastDraws
+	state := dc.lastState
+	dc.mu.Unlock()
+
+	var passes, executed []string
+	var queue QueueStats
+	var culling CullStats
+	err := dc.onRenderThread(func() {
+		passes = dc.engine.Renderer.FrameGraph.Passes()
+		executed = dc.engine.Renderer.FrameGraph.Executed()
+		queue = dc.engine.Renderer.Queue.Stats()
+		culling = dc.engine.Renderer.CullStats()
+	})
+
+	out := map[string]interface{}{
+		"last_frame": draws,
+		"state":      state,
+		"headless":   dc.engine.Renderer.Device.Headless(),
+	}
+
+	// These are only listed while the render thread responds
+	if err == nil {
+		out["passes"] = passes
+		out["executed_passes"] = executed
+		out["queue"] = queue
+		out["culling"] = culling
+	}
+
+	writeJSON(w, out)
+}
+
+func (dc *DebugControl) serveAssets(w http.ResponseWriter, r *http.Request) {
+	out := map[string]interface{}{}
+
+	err := dc.onRenderThread(func() {
+		textures := map[string]string{}
+		for name, t := range dc.engine.TextureControl.TexMap {
+			textures[name] = t.Path
+		}
+
+		fonts := []string{}
+		for name := range dc.engine.TextControl.Fonts {
+			fonts = append(fonts, name)
+		}
+		sort.Strings(fonts)
+
+		mounts := []string{}
+		for _, m := range dc.engine.Assets.Mounts() {
+			mounts = append(mounts, m.Name)
+		}
+
+		out["textures"] = textures
+		out["shaders"] = dc.engine.ShaderControl.Names()
+		out["fonts"] = fonts
+		out["mounts"] = mounts
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	writeJSON(w, out)
+}
+
+type debugChild struct {
+	Type   string  `json:"type"`
+	X      float32 `json:"x"`
+	Y      float32 `json:"y"`
+	Active bool    `json:"active"`
+	Copies int     `json:"copies,omitempty"`
+
+	// Children attached to this one, whose x and y are relative to it
+	Children []debugChild `json:"children,omitempty"`
+}
+
+type debugScene struct {
+	ID        string       `json:"id"`
+	Active    bool         `json:"active"`
+	Children  []debugChild `json:"children"`
+	Subscenes []debugScene `json:"subscenes,omitempty"`
+}
+
+func (dc *DebugControl) serveScene(w http.ResponseWriter, r *http.Request) {
+	var out *debugScene
+
+	err := dc.onRenderThread(func() {
+		if scn := dc.engine.SceneControl.GetCurrentScene(); scn != nil {
+			s := describeScene(scn)
+			out = &s
+		}
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	writeJSON(w, out)
+}
+
+func describeScene(scn *Scene) debugScene {
+	s := debugScene{
+		ID:       scn.ID,
+		Active:   scn.IsActive(),
+		Children: []debugChild{},
+	}
+
+	for _, c := range scn.children {
+		if !scn.hasAncestor(c) {
+			s.Children = append(s.Children, describeChild(c))
+		}
+	}
+
+	for _, sub := range scn.subscenes {
+		s.Subscenes = append(s.Subscenes, describeScene(sub))
+	}
+
+	return s
+}
+
+func describeChild(c child.Child) debugChild {
+	d := debugChild{
+		Type:   fmt.Sprintf("%T", c),
+		X:      c.GetX(),
+		Y:      c.GetY(),
+		Active: c.IsActive(),
+		Copies: c.GetNumCopies(),
+	}
+
+	for _, sub := range c.GetNode().GetChildren() {
+		d.Children = append(d.Children, describeChild(sub))
+	}
+
+	return d
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// percentile returns the p'th percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(p*float64(len(sorted)-1))]
+}
@@ -0,0 +1,488 @@
+package cmd
+
+//   --------------------------------------------------
+//   Debug_draw.go contains DebugDraw, an immediate mode
+//   API for drawing lines, shapes and labels over the
+//   scene. Shapes are drawn for a single frame, or for
+//   as long as their duration, and every shape is drawn
+//   from one vertex buffer, refilled each frame.
+//   --------------------------------------------------
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/child"
+	"rapidengine/ui"
+)
+
+// Number of segments in debug circles and spheres
+const debugSegments = 24
+
+// DebugDraw draws shapes and labels for debugging. World shapes
+// are in the scene's coordinates, which are pixels in 2D, and are
+// seen as the main view sees the scene, in its viewport. Rects and circles are in pixels
+// on the screen. Colors are RGBA from 0 to 255, like material hues.
+type DebugDraw struct {
+	// Shapes are only drawn while enabled
+	Enabled bool
+
+	world  []debugShape
+	screen []debugShape
+	labels []debugLabel
+
+	// Vertices of the frame, world shapes first
+	vertices []float32
+
+	vao uint32
+	vbo uint32
+
+	// Text boxes reused for labels
+	textBoxes []*ui.TextBox
+
+	engine *Engine
+}
+
+type debugShape struct {
+	// Pairs of points making up lines
+	points []mgl32.Vec3
+	color  [4]float32
+
+	duration float64
+}
+
+type debugLabel struct {
+	position mgl32.Vec3
+	text     string
+	color    [4]float32
+
+	duration float64
+}
+
+func NewDebugDraw() DebugDraw {
+	return DebugDraw{
+		Enabled: true,
+	}
+}
+
+func (dd *DebugDraw) Initialize(engine *Engine) {
+	dd.engine = engine
+	dev := engine.Renderer.Device
+
+	dev.GenVertexArrays(1, &dd.vao)
+	dev.BindVertexArray(dd.vao)
+
+	// Each vertex is a position and a color
+	dev.GenBuffers(1, &dd.vbo)
+	dev.BindBuffer(gl.ARRAY_BUFFER, dd.vbo)
+	dev.VertexAttribPointer(0, 3, gl.FLOAT, false, 7*4, gl.PtrOffset(0))
+	dev.VertexAttribPointer(1, 4, gl.FLOAT, false, 7*4, gl.PtrOffset(3*4))
+	dev.EnableVertexAttribArray(0)
+	dev.EnableVertexAttribArray(1)
+
+	dev.BindVertexArray(0)
+}
+
+// Clear removes every shape and label
+func (dd *DebugDraw) Clear() {
+	dd.world = dd.world[:0]
+	dd.screen = dd.screen[:0]
+	dd.labels = dd.labels[:0]
+}
+
+//  --------------------------------------------------
+//  World Shapes
+//  --------------------------------------------------
+
+// Line draws a line from a to b
+func (dd *DebugDraw) Line(a, b mgl32.Vec3, color [4]float32, duration float64) {
+	dd.world = append(dd.world, debugShape{points: []mgl32.Vec3{a, b}, color: color, duration: duration})
+}
+
+// Arrow draws a line from a to b, with a head at b
+func (dd *DebugDraw) Arrow(a, b mgl32.Vec3, color [4]float32, duration float64) {
+	dir := b.Sub(a)
+	length := dir.Len()
+	if length == 0 {
+		return
+	}
+	dir = dir.Mul(1 / length)
+
+	// Any axis which isn't parallel to the arrow
+	up := mgl32.Vec3{0, 1, 0}
+	if math.Abs(float64(dir.Dot(up))) > 0.99 {
+		up = mgl32.Vec3{1, 0, 0}
+	}
+	side := dir.Cross(up).Normalize().Mul(length * 0.1)
+	back := b.Sub(dir.Mul(length * 0.2))
+
+	dd.world = append(dd.world, debugShape{
+		points: []mgl32.Vec3{
+			a, b,
+			b, back.Add(side),
+			b, back.Sub(side),
+		},
+		color:    color,
+		duration: duration,
+	})
+}
+
+// AABB draws the edges of the axis aligned box from min to max
+func (dd *DebugDraw) AABB(min, max mgl32.Vec3, color [4]float32, duration float64) {
+	var c [8]mgl32.Vec3
+	for i := range c {
+		c[i] = min
+		if i&1 != 0 {
+			c[i][0] = max[0]
+		}
+		if i&2 != 0 {
+			c[i][1] = max[1]
+		}
+		if i&4 != 0 {
+			c[i][2] = max[2]
+		}
+	}
+
+	dd.world = append(dd.world, debugShape{points: boxEdges(c), color: color, duration: duration})
+}
+
+// Sphere draws three circles around center, one on each axis
+func (dd *DebugDraw) Sphere(center mgl32.Vec3, radius float32, color [4]float32, duration float64) {
+	points := []mgl32.Vec3{}
+	for axis := 0; axis < 3; axis++ {
+		points = append(points, circle(center, radius, axis)...)
+	}
+
+	dd.world = append(dd.world, debugShape{points: points, color: color, duration: duration})
+}
+
+// Grid draws a square grid on the XZ plane, or the XY
+// plane in 2D, with the given number of cells on each side
+func (dd *DebugDraw) Grid(center mgl32.Vec3, size float32, cells int, color [4]float32, duration float64) {
+	if cells < 1 {
+		return
+	}
+
+	// The axis across the plane
+	axis := 2
+	if dd.engine.Config.Dimensions == 2 {
+		axis = 1
+	}
+
+	half := size / 2
+	step := size / float32(cells)
+	points := []mgl32.Vec3{}
+	for i := 0; i <= cells; i++ {
+		offset := -half + float32(i)*step
+
+		a, b := center, center
+		a[0], b[0] = center[0]+offset, center[0]+offset
+		a[axis], b[axis] = center[axis]-half, center[axis]+half
+
+		c, d := center, center
+		c[0], d[0] = center[0]-half, center[0]+half
+		c[axis], d[axis] = center[axis]+offset, center[axis]+offset
+
+		points = append(points, a, b, c, d)
+	}
+
+	dd.world = append(dd.world, debugShape{points: points, color: color, duration: duration})
+}
+
+// Frustum draws the edges of the frustum of a projection * view matrix
+func (dd *DebugDraw) Frustum(viewProjection mgl32.Mat4, color [4]float32, duration float64) {
+	inv := viewProjection.Inv()
+
+	var c [8]mgl32.Vec3
+	for i := range c {
+		ndc := mgl32.Vec3{-1, -1, -1}
+		if i&1 != 0 {
+			ndc[0] = 1
+		}
+		if i&2 != 0 {
+			ndc[1] = 1
+		}
+		if i&4 != 0 {
+			ndc[2] = 1
+		}
+		c[i] = mgl32.TransformCoordinate(ndc, inv)
+	}
+
+	dd.world = append(dd.world, debugShape{points: boxEdges(c), color: color, duration: duration})
+}
+
+// Label draws text at a point in the world
+func (dd *DebugDraw) Label(position mgl32.Vec3, text string, color [4]float32, duration float64) {
+	dd.labels = append(dd.labels, debugLabel{position: position, text: text, color: color, duration: duration})
+}
+
+//  --------------------------------------------------
+//  Screen Shapes
+//  --------------------------------------------------
+
+// Rect draws the outline of a rectangle on the screen,
+// with its bottom left corner at x, y
+func (dd *DebugDraw) Rect(x, y, width, height float32, color [4]float32, duration float64) {
+	dd.screen = append(dd.screen, debugShape{points: rect(x, y, width, height), color: color, duration: duration})
+}
+
+// Circle draws the outline of a circle on the screen
+func (dd *DebugDraw) Circle(x, y, radius float32, color [4]float32, duration float64) {
+	dd.screen = append(dd.screen, debugShape{points: circle(mgl32.Vec3{x, y, 0}, radius, 2), color: color, duration: duration})
+}
+
+func rect(x, y, width, height float32) []mgl32.Vec3 {
+	a, b := mgl32.Vec3{x, y, 0}, mgl32.Vec3{x + width, y, 0}
+	c, d := mgl32.Vec3{x + width, y + height, 0}, mgl32.Vec3{x, y + height, 0}
+
+	return []mgl32.Vec3{a, b, b, c, c, d, d, a}
+}
+
+// circle returns the lines of a circle around the given axis
+func circle(center mgl32.Vec3, radius float32, axis int) []mgl32.Vec3 {
+	u, v := (axis+1)%3, (axis+2)%3
+
+	points := make([]mgl32.Vec3, 0, debugSegments*2)
+	for i := 0; i < debugSegments; i++ {
+		for _, j := range []int{i, i + 1} {
+			angle := 2 * math.Pi * float64(j) / debugSegments
+
+			p := center
+			p[u] += radius * float32(math.Cos(angle))
+			p[v] += radius * float32(math.Sin(angle))
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+// boxEdges returns the 12 edges of a box, whose corners
+// are ordered by x, then y, then z, like the bits of i
+func boxEdges(c [8]mgl32.Vec3) []mgl32.Vec3 {
+	points := make([]mgl32.Vec3, 0, 24)
+	for i := 0; i < 8; i++ {
+		for _, bit := range []int{1, 2, 4} {
+			if i&bit == 0 {
+				points = append(points, c[i], c[i|bit])
+			}
+		}
+	}
+	return points
+}
+
+//  --------------------------------------------------
+//  Rendering
+//  --------------------------------------------------
+
+// render draws every shape and label over the screen,
+// and then drops the ones which have expired
+func (dd *DebugDraw) render() {
+	if !dd.Enabled {
+		dd.expire(0)
+		return
+	}
+
+	world := dd.world
+	if dd.engine.Config.CollisionLines {
+		world = append(world[:len(world):len(world)], dd.colliderShapes()...)
+	}
+
+	dd.vertices = dd.vertices[:0]
+	dd.appendVertices(world)
+	worldCount := int32(len(dd.vertices) / 7)
+	dd.appendVertices(dd.screen)
+	screenCount := int32(len(dd.vertices)/7) - worldCount
+
+	if len(dd.vertices) > 0 {
+		dd.drawLines(worldCount, screenCount)
+	}
+	dd.drawLabels()
+
+	dd.expire(dd.engine.Renderer.DeltaFrameTime)
+}
+
+func (dd *DebugDraw) appendVertices(shapes []debugShape) {
+	for _, s := range shapes {
+		r, g, b, a := s.color[0]/255, s.color[1]/255, s.color[2]/255, s.color[3]/255
+		for _, p := range s.points {
+			dd.vertices = append(dd.vertices, p[0], p[1], p[2], r, g, b, a)
+		}
+	}
+}
+
+func (dd *DebugDraw) drawLines(worldCount, screenCount int32) {
+	r := &dd.engine.Renderer
+	dev := r.Device
+	shader := dd.engine.ShaderControl.GetShader("debug")
+	width, height := float32(r.Config.ScreenWidth), float32(r.Config.ScreenHeight)
+
+	dev.BindVertexArray(dd.vao)
+	dev.BindBuffer(gl.ARRAY_BUFFER, dd.vbo)
+	dev.BufferData(gl.ARRAY_BUFFER, 4*len(dd.vertices), gl.Ptr(dd.vertices), gl.DYNAMIC_DRAW)
+
+	shader.Bind()
+	dev.Disable(gl.DEPTH_TEST)
+
+	if worldCount > 0 {
+		x, y, w, h := dd.worldViewport()
+		dev.Viewport(x, y, w, h)
+
+		model, view, projection := dd.worldMatrices()
+		shader.UniformMatrix4("modelMtx", &model[0])
+		shader.UniformMatrix4("viewMtx", &view[0])
+		shader.UniformMatrix4("projectionMtx", &projection[0])
+		dev.DrawArrays(gl.LINES, 0, worldCount)
+
+		dev.Viewport(0, 0, int32(width), int32(height))
+	}
+
+	if screenCount > 0 {
+		ident := mgl32.Ident4()
+		projection := mgl32.Ortho2D(0, width, 0, height)
+		shader.UniformMatrix4("modelMtx", &ident[0])
+		shader.UniformMatrix4("viewMtx", &ident[0])
+		shader.UniformMatrix4("projectionMtx", &projection[0])
+		dev.DrawArrays(gl.LINES, worldCount, screenCount)
+	}
+
+	if r.Config.Dimensions == 3 {
+		dev.Enable(gl.DEPTH_TEST)
+	}
+	dev.BindVertexArray(0)
+}
+
+// worldView returns the view world shapes are seen through, which
+// is the main view unless it is disabled or renders offscreen
+func (dd *DebugDraw) worldView() *View {
+	v := dd.engine.Renderer.MainView()
+	if v == nil || !v.Enabled || v.Target != nil {
+		return nil
+	}
+	return v
+}
+
+// worldViewport returns the rectangle of the
+// screen world shapes are drawn in, in pixels
+func (dd *DebugDraw) worldViewport() (x, y, w, h int32) {
+	r := &dd.engine.Renderer
+	if v := dd.worldView(); v != nil {
+		return r.viewRect(v)
+	}
+	return 0, 0, int32(r.Config.ScreenWidth), int32(r.Config.ScreenHeight)
+}
+
+// worldMatrices returns the matrices world shapes are drawn
+// with, which match those of the world view's children
+func (dd *DebugDraw) worldMatrices() (model, view, projection mgl32.Mat4) {
+	r := &dd.engine.Renderer
+	width, height := float32(r.Config.ScreenWidth), float32(r.Config.ScreenHeight)
+
+	view = r.MainCamera.GetView()
+	if v := dd.worldView(); v != nil {
+		view = r.viewCamera(v).GetView()
+	}
+
+	if r.Config.Dimensions == 2 {
+		// Pixels to the [-1, 1] space of Child2D
+		model = mgl32.Translate3D(-1, -1, 0).Mul4(mgl32.Scale3D(2/width, 2/height, 1))
+		return model, view, mgl32.Ortho2D(-1, 1, -1, 1)
+	}
+
+	_, _, w, h := dd.worldViewport()
+	return mgl32.Ident4(), view, child.Perspective(float32(w)/float32(h), child.DefaultFarPlane)
+}
+
+// drawLabels draws each label's text where its position is on the
+// screen. Text is drawn by gltext, so only on a real device.
+func (dd *DebugDraw) drawLabels() {
+	if len(dd.labels) == 0 || dd.engine.Renderer.Device.Headless() {
+		return
+	}
+
+	config := dd.engine.Config
+	model, view, projection := dd.worldMatrices()
+	mvp := projection.Mul4(view).Mul4(model)
+	vx, vy, vw, vh := dd.worldViewport()
+
+	used := 0
+	for _, l := range dd.labels {
+		clip := mvp.Mul4x1(l.position.Vec4(1))
+		if clip[3] <= 0 {
+			continue
+		}
+		x := float32(vx) + (clip[0]/clip[3]+1)/2*float32(vw)
+		y := float32(vy) + (clip[1]/clip[3]+1)/2*float32(vh)
+
+		if used == len(dd.textBoxes) {
+			dd.textBoxes = append(dd.textBoxes, dd.engine.TextControl.NewTextBox(l.text, "avenir", x, y, 0.5, [3]float32{}))
+		}
+		t := dd.textBoxes[used]
+		used++
+
+		t.Text = l.text
+		t.X, t.Y = x, y
+		t.Color = [3]float32{l.color[0] / 255, l.color[1] / 255, l.color[2] / 255}
+		t.Update(config)
+	}
+
+	dd.engine.Renderer.State.Invalidate()
+}
+
+// colliderShapes returns the outline of the collider of every
+// child in the current scene, and of their current copies
+func (dd *DebugDraw) colliderShapes() []debugShape {
+	color := [4]float32{0, 255, 0, 255}
+	shapes := []debugShape{}
+
+	for _, c := range dd.engine.SceneControl.GetCurrentChildren() {
+		col := c.GetCollider()
+		if col == nil || col.Width == 0 || col.Height == 0 {
+			continue
+		}
+
+		p := c.GetWorldPosition()
+		positions := [][2]float32{{p[0], p[1]}}
+		if c.CheckCopyingEnabled() {
+			positions = positions[:0]
+			for _, cpy := range c.GetCurrentCopies() {
+				positions = append(positions, [2]float32{cpy.X, cpy.Y})
+			}
+		}
+
+		for _, p := range positions {
+			shapes = append(shapes, debugShape{
+				points: rect(p[0]+col.OffsetX, p[1]+col.OffsetY, col.Width, col.Height),
+				color:  color,
+			})
+		}
+	}
+
+	return shapes
+}
+
+// expire drops shapes and labels whose duration has run out.
+// Shapes without a duration are only drawn once.
+func (dd *DebugDraw) expire(delta float64) {
+	dd.world = expireShapes(dd.world, delta)
+	dd.screen = expireShapes(dd.screen, delta)
+
+	labels := dd.labels[:0]
+	for _, l := range dd.labels {
+		if l.duration -= delta; l.duration > 0 {
+			labels = append(labels, l)
+		}
+	}
+	dd.labels = labels
+}
+
+func expireShapes(shapes []debugShape, delta float64) []debugShape {
+	kept := shapes[:0]
+	for _, s := range shapes {
+		if s.duration -= delta; s.duration > 0 {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
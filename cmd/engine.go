@@ -2,13 +2,17 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
+	"path/filepath"
+	"rapidengine/assets"
 	"rapidengine/camera"
 	"rapidengine/configuration"
+	"rapidengine/device"
+	"rapidengine/ecs"
+	"rapidengine/event"
 	"rapidengine/input"
 	"rapidengine/lighting"
-	"rapidengine/material"
 	"rapidengine/ui"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 
@@ -19,6 +23,13 @@ type Engine struct {
 	Renderer   Renderer
 	RenderFunc func(renderer *Renderer, inputs *input.Input)
 
+	// FixedUpdateFunc is called once per simulation tick with the
+	// fixed tick time, UpdateFunc once per frame with the frame time
+	FixedUpdateFunc func(renderer *Renderer, inputs *input.Input, delta float64)
+	UpdateFunc      func(renderer *Renderer, inputs *input.Input, delta float64)
+
+	inputs *input.Input
+
 	ChildControl     ChildControl
 	GeometryControl  GeometryControl
 	SceneControl     SceneControl
@@ -33,19 +44,82 @@ type Engine struct {
 	TextControl      TextControl
 	AudioControl     AudioControl
 	PostControl      PostControl
+	PrefabControl    PrefabControl
+
+	// Game logic attached to children
+	BehaviorControl BehaviorControl
+
+	// Systems updated every frame, including the controls above
+	SystemControl SystemControl
+	DebugControl  DebugControl
+
+	// Lines, shapes and labels drawn over the frame
+	DebugDraw DebugDraw
+
+	// Developer console, opened with the ToggleKey
+	ConsoleControl ConsoleControl
+
+	// Entities and the systems run over them every frame
+	ECS *ecs.World
+
+	// Events published by the engine and user code. Published
+	// events are dispatched at the end of each frame.
+	Events *event.Bus
 
 	FPSBox     *ui.TextBox
 	FrameCount int
 
 	Config *configuration.EngineConfig
 
+	// Assets is the VFS every loader reads through
+	Assets *assets.FS
+
+	// Window size reported by the window,
+	// applied at the start of the next frame
+	pendingWidth  int
+	pendingHeight int
+
 	Logger *logrus.Logger
 }
 
-func NewEngine(config *configuration.EngineConfig, renderFunc func(*Renderer, *input.Input)) *Engine {
+// NewEngine creates an engine which renders with OpenGL 4.1 into a GLFW window.
+// It returns an error if the config is invalid, the window can't be created
+// or the engine's shaders and assets fail to load.
+func NewEngine(config *configuration.EngineConfig, renderFunc func(*Renderer, *input.Input)) (*Engine, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	renderer, err := NewRenderer(getEngineCamera(config.Dimensions, config), config)
+	if err != nil {
+		return nil, err
+	}
+	return newEngine(config, renderer, renderFunc)
+}
+
+// NewEngineWithDevice creates an engine which renders through the given
+// device and window, such as the headless device.NullDevice and device.NullWindow
+func NewEngineWithDevice(
+	config *configuration.EngineConfig,
+	dev device.RenderDevice,
+	win device.Window,
+	renderFunc func(*Renderer, *input.Input),
+) (*Engine, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	renderer, err := NewRendererWithDevice(getEngineCamera(config.Dimensions, config), config, dev, win)
+	if err != nil {
+		return nil, err
+	}
+	return newEngine(config, renderer, renderFunc)
+}
+
+func newEngine(config *configuration.EngineConfig, renderer Renderer, renderFunc func(*Renderer, *input.Input)) (*Engine, error) {
 	e := Engine{
 		// Main renderer
-		Renderer: NewRenderer(getEngineCamera(config.Dimensions, config), config),
+		Renderer: renderer,
 
 		// Package Controls
 		ChildControl:     NewChildControl(),
@@ -62,10 +136,19 @@ func NewEngine(config *configuration.EngineConfig, renderFunc func(*Renderer, *i
 		TextControl:      NewTextControl(config),
 		AudioControl:     NewAudioControl(),
 		PostControl:      NewPostControl(),
+		PrefabControl:    NewPrefabControl(),
+		BehaviorControl:  NewBehaviorControl(),
+		SystemControl:    NewSystemControl(),
+		DebugControl:     NewDebugControl(),
+		DebugDraw:        NewDebugDraw(),
+		ConsoleControl:   NewConsoleControl(),
+		ECS:              ecs.NewWorld(),
+		Events:           event.NewBus(),
 
 		// Configuration
 		Config:     config,
 		FrameCount: 0,
+		Assets:     assets.Default,
 
 		// User render function
 		RenderFunc: renderFunc,
@@ -73,31 +156,36 @@ func NewEngine(config *configuration.EngineConfig, renderFunc func(*Renderer, *i
 		Logger: config.Logger,
 	}
 
-	e.ChildControl.Initialize(&e)
-	e.GeometryControl.Initialize(&e)
-	e.SceneControl.Initialize(&e)
-	e.ShaderControl.Initialize()
-	e.MaterialControl.Initialize(&e)
-	e.UIControl.Initialize(&e)
-	e.TextControl.Initialize(&e)
-	e.TerrainControl.Initialize(&e)
-	e.CollisionControl.Initialize(&e)
-	e.AudioControl.Initialize(&e)
-	e.PostControl.Initialize(&e)
-	e.LightControl.Initialize(&e)
-
-	e.LightControl.Shaders = []*material.ShaderProgram{
-		e.ShaderControl.GetShader("standard"),
-		e.ShaderControl.GetShader("terrain"),
-		e.ShaderControl.GetShader("foliage"),
-		e.ShaderControl.GetShader("water"),
-		e.ShaderControl.GetShader("pbr"),
-	}
-
-	e.Renderer.Initialize(&e)
+	if err := e.MountAssets(config.AssetPaths...); err != nil {
+		return nil, err
+	}
+
+	// The screen is sized in pixels, which
+	// the window may not have been created with
+	if width, height := e.Renderer.Window.GetFramebufferSize(); width != config.ScreenWidth || height != config.ScreenHeight {
+		e.pendingWidth, e.pendingHeight = width, height
+	}
+	e.Renderer.Window.SetFramebufferSizeCallback(func(width, height int) {
+		e.pendingWidth, e.pendingHeight = width, height
+	})
+
+	if err := e.registerBuiltinSystems(); err != nil {
+		return nil, err
+	}
+	if err := e.SystemControl.Initialize(&e); err != nil {
+		return nil, err
+	}
+
+	if err := e.Renderer.Initialize(&e); err != nil {
+		return nil, err
+	}
+	e.registerBuiltinPasses()
+	e.registerBuiltinCommands()
 	e.Renderer.AttachCallback(e.Update)
 
-	e.TextControl.LoadFont("../rapidengine/assets/fonts/avenir-next-regular.ttf", "avenir", 32, 0)
+	if err := e.TextControl.LoadFont("fonts/avenir-next-regular.ttf", "avenir", 32, 0); err != nil {
+		return nil, err
+	}
 
 	if e.Config.ShowFPS {
 		e.FPSBox = e.TextControl.NewTextBox("Rapid Engine", "avenir", float32(e.Config.ScreenWidth-100), float32(e.Config.ScreenHeight-50), 1, [3]float32{50, 50, 50})
@@ -123,10 +211,86 @@ func NewEngine(config *configuration.EngineConfig, renderFunc func(*Renderer, *i
 		e.LightControl.EnableDirectionalLighting()
 
 		e.Renderer.SkyBoxEnabled = true
-		e.Renderer.SkyBox = e.TerrainControl.NewSkyBox("../rapidengine/assets/skybox", "TropicalSunnyDay", "png", &e.ShaderControl, &e.TextureControl, e.Config)
+		skyBox, err := e.TerrainControl.NewSkyBox("skybox", "TropicalSunnyDay", "png", &e.ShaderControl, &e.TextureControl, e.Config)
+		if err != nil {
+			return nil, err
+		}
+		e.Renderer.SkyBox = skyBox
 	}
 
-	return &e
+	return &e, nil
+}
+
+// MountAssets mounts directories and .zip packs into the asset VFS.
+// They are searched in the order given, after the working directory
+// and any earlier mounts, and before the engine's built in assets.
+func (engine *Engine) MountAssets(paths ...string) error {
+	for _, p := range paths {
+		if strings.EqualFold(filepath.Ext(p), ".zip") {
+			if err := engine.Assets.MountZip("", p); err != nil {
+				return err
+			}
+			continue
+		}
+		engine.Assets.MountDir("", p)
+	}
+	return nil
+}
+
+// Resize changes the screen size, and rebuilds everything sized to
+// the screen: the viewport, the skybox, post processing buffers,
+// fonts and centered UI. It is called automatically when the
+// window is resized, and must only be called on the render thread.
+func (engine *Engine) Resize(width, height int) {
+	// Minimized windows have no size
+	if width <= 0 || height <= 0 {
+		return
+	}
+	if width == engine.Config.ScreenWidth && height == engine.Config.ScreenHeight {
+		return
+	}
+
+	engine.Config.ScreenWidth = width
+	engine.Config.ScreenHeight = height
+
+	engine.Renderer.Device.Viewport(0, 0, int32(width), int32(height))
+
+	if engine.Renderer.SkyBox != nil {
+		engine.Renderer.SkyBox.SetProjection(skyBoxProjection(float32(width) / float32(height)))
+	}
+
+	engine.PostControl.Resize()
+	if err := engine.TextureControl.Resize(); err != nil {
+		engine.Logger.Error(err)
+	}
+	engine.MaterialControl.Resize()
+	engine.TextControl.Resize()
+	engine.UIControl.Resize()
+
+	if engine.FPSBox != nil {
+		engine.FPSBox.X = float32(width - 100)
+		engine.FPSBox.Y = float32(height - 50)
+	}
+
+	engine.Events.Publish(event.WindowResized{Width: width, Height: height})
+}
+
+// SetFullScreen switches the window between fullscreen on
+// the primary monitor and its previous windowed size
+func (engine *Engine) SetFullScreen(fullScreen bool) {
+	engine.Config.FullScreen = fullScreen
+	engine.Renderer.Window.SetFullScreen(fullScreen)
+}
+
+// applyPendingResize resizes the engine to the
+// last size reported by the window, if any
+func (engine *Engine) applyPendingResize() {
+	if engine.pendingWidth == 0 && engine.pendingHeight == 0 {
+		return
+	}
+
+	engine.Resize(engine.pendingWidth, engine.pendingHeight)
+	engine.pendingWidth, engine.pendingHeight = 0, 0
 }
 
 func NewEngineConfig(
@@ -142,14 +306,16 @@ func (engine *Engine) Initialize() {
 }
 
 func (engine *Engine) Update(renderer *Renderer) {
-	// Get camera position
-	x, y, z := renderer.MainCamera.GetPosition()
-
 	// Get user inputs
-	inputs := engine.InputControl.Update(renderer.Window)
+	engine.SystemControl.Run(PhasePreUpdate, renderer.DeltaFrameTime)
+	inputs := engine.Inputs()
 
-	// Call user frame function
+	// Call user frame functions
+	if engine.UpdateFunc != nil {
+		engine.UpdateFunc(renderer, inputs, renderer.DeltaFrameTime)
+	}
 	engine.RenderFunc(renderer, inputs)
+	engine.SystemControl.Run(PhaseUpdate, renderer.DeltaFrameTime)
 
 	// Update FPS
 	if engine.Config.ShowFPS && engine.FrameCount > 10 {
@@ -158,17 +324,51 @@ func (engine *Engine) Update(renderer *Renderer) {
 	}
 
 	// Update controllers
-	engine.TerrainControl.Update()
-	engine.LightControl.Update(x, y, z)
-	engine.CollisionControl.Update(x, y, inputs)
-	engine.UIControl.Update(inputs)
-	engine.TextControl.Update()
+	engine.SystemControl.Run(PhasePostUpdate, renderer.DeltaFrameTime)
 
 	engine.FrameCount++
 }
 
+// FixedUpdate runs a single simulation tick of length delta
+func (engine *Engine) FixedUpdate(delta float64) {
+	if engine.FixedUpdateFunc != nil {
+		engine.FixedUpdateFunc(&engine.Renderer, engine.Inputs(), delta)
+	}
+	engine.BehaviorControl.FixedUpdate(delta)
+
+	for _, c := range engine.SceneControl.GetCurrentChildren() {
+		c.FixedUpdate(delta)
+	}
+
+	engine.MaterialControl.FixedUpdate(delta)
+}
+
+// Inputs returns the inputs polled at the start of the current frame
+func (engine *Engine) Inputs() *input.Input {
+	if engine.inputs == nil {
+		engine.inputs = &input.Input{Keys: make(map[string]bool)}
+	}
+	return engine.inputs
+}
+
+// RegisterSystem adds a user system to the engine, see SystemControl.Register
+func (engine *Engine) RegisterSystem(s System) error {
+	return engine.SystemControl.Register(s)
+}
+
+// SetFixedUpdateFunc sets the function called every simulation tick
+func (engine *Engine) SetFixedUpdateFunc(f func(*Renderer, *input.Input, float64)) {
+	engine.FixedUpdateFunc = f
+}
+
+// SetUpdateFunc sets the function called every frame, before rendering
+func (engine *Engine) SetUpdateFunc(f func(*Renderer, *input.Input, float64)) {
+	engine.UpdateFunc = f
+}
+
 func (engine *Engine) StartRenderer() {
-	if engine.Config.CollisionLines {
+	for _, err := range engine.ConsoleControl.ExecArgs(engine.Config.ConsoleArgs) {
+		engine.Logger.Error(err)
 	}
 	engine.Renderer.StartRenderer()
 }
@@ -202,7 +402,3 @@ func getEngineCamera(dimension int, config *configuration.EngineConfig) camera.C
 	}
 	return nil
 }
-
-func profileEndpoint(w http.ResponseWriter, r *http.Request) {
-	w.Write([]byte("test"))
-}
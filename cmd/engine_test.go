@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"testing"
+
+	"rapidengine/child"
+	"rapidengine/configuration"
+	"rapidengine/device"
+	"rapidengine/geometry"
+	"rapidengine/input"
+)
+
+// newTestEngine creates an engine drawing to a null
+// device, which is restored at the end of the test
+func newTestEngine(t *testing.T, dimensions int) (*Engine, *device.NullDevice) {
+	prev := device.Get()
+	t.Cleanup(func() { device.Set(prev) })
+
+	cfg := configuration.NewEngineConfig(800, 600, dimensions)
+	null := device.NewNullDevice()
+
+	e, err := NewEngineWithDevice(&cfg, null, device.NewNullWindow(1, 1.0/60), func(*Renderer, *input.Input) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, null
+}
+
+// newTestScene makes an empty scene current
+func newTestScene(e *Engine) *Scene {
+	scn := e.SceneControl.NewScene("main")
+	e.SceneControl.InstanceScene(scn)
+	e.SceneControl.SetCurrentScene(scn)
+	return scn
+}
+
+func countCalls(null *device.NullDevice, name string) int {
+	n := 0
+	for _, call := range null.Calls() {
+		if call.Name == name {
+			n++
+		}
+	}
+	return n
+}
+
+func TestHeadlessRender(t *testing.T) {
+	e, null := newTestEngine(t, 2)
+	scn := newTestScene(e)
+
+	children := []child.Child{}
+	for i := 0; i < 3; i++ {
+		c := e.ChildControl.NewChild2D()
+		c.AttachMaterial(e.MaterialControl.NewBasicMaterial())
+		c.AttachMesh(geometry.NewRectangle())
+		scn.InstanceChild(c)
+		children = append(children, c)
+	}
+	e.Initialize()
+
+	null.Reset()
+	e.Renderer.DrawCounter.ResetStats()
+	e.Renderer.renderFrame()
+
+	draws := null.Draws()
+	if len(draws) != 3 {
+		t.Fatalf("%d draws, want one for each child: %+v", len(draws), draws)
+	}
+
+	vaos := map[uint32]bool{}
+	for _, d := range draws {
+		if d.Count != 6 || d.Instances != 1 || d.Framebuffer != 0 {
+			t.Fatalf("draw %+v, want 6 vertices of a rectangle to the screen", d)
+		}
+		vaos[d.VAO] = true
+	}
+	if len(vaos) != 3 {
+		t.Fatalf("draws used %d vertex arrays, want 3", len(vaos))
+	}
+
+	stats := e.Renderer.DrawCounter.ResetStats()
+	if stats != (device.DrawStats{DrawCalls: 3, Vertices: 18, Instances: 3}) {
+		t.Fatalf("counted %+v, want 3 draws of 6 vertices", stats)
+	}
+
+	// The children share a shader, which the state cache binds at most once
+	for _, d := range draws {
+		if d.Program != draws[0].Program {
+			t.Fatalf("draws used programs %d and %d, want one", draws[0].Program, d.Program)
+		}
+	}
+	if n := countCalls(null, "UseProgram"); n > 1 {
+		t.Fatalf("UseProgram called %d times, want at most 1", n)
+	}
+
+	scn.RemoveChild(children[0])
+
+	null.Reset()
+	e.Renderer.renderFrame()
+
+	if stats := e.Renderer.DrawCounter.ResetStats(); stats.DrawCalls != 2 || len(null.Draws()) != 2 {
+		t.Fatalf("counted %+v and %d draws after removing a child, want 2", stats, len(null.Draws()))
+	}
+}
+
+func TestHeadlessRender3D(t *testing.T) {
+	e, null := newTestEngine(t, 3)
+	scn := newTestScene(e)
+
+	c := e.ChildControl.NewChild3D()
+	c.AttachMaterial(e.MaterialControl.NewBasicMaterial())
+	c.AttachMesh(geometry.NewRectangle())
+	scn.InstanceChild(c)
+	e.Initialize()
+
+	e.PostControl.EnablePostProcessing()
+
+	null.Reset()
+	e.Renderer.DrawCounter.ResetStats()
+	e.Renderer.renderFrame()
+
+	// Every draw goes through the counter
+	stats := e.Renderer.DrawCounter.Stats()
+	if stats.DrawCalls == 0 || int(stats.DrawCalls) != len(null.Draws()) {
+		t.Fatalf("counted %+v, but the device drew %d times", stats, len(null.Draws()))
+	}
+
+	// The scene is drawn into an offscreen buffer, which
+	// post processing then draws to the screen
+	offscreen, screen := 0, 0
+	for _, d := range null.Draws() {
+		if d.Framebuffer == 0 {
+			screen++
+		} else {
+			offscreen++
+		}
+	}
+	if offscreen == 0 || screen == 0 {
+		t.Fatalf("%d offscreen and %d screen draws, want both", offscreen, screen)
+	}
+	if len(e.Renderer.FrameGraph.Executed()) < 2 {
+		t.Fatalf("executed passes %v, want the scene and post processing", e.Renderer.FrameGraph.Executed())
+	}
+}
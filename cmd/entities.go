@@ -0,0 +1,59 @@
+package cmd
+
+//   --------------------------------------------------
+//   Entities.go bridges the ECS and the renderer. An
+//   entity with a Renderable component is drawn as its
+//   child, which follows the entity's geometry.Transform.
+//   --------------------------------------------------
+
+import (
+	"rapidengine/child"
+	"rapidengine/ecs"
+	"rapidengine/geometry"
+)
+
+// Renderable is the component which draws an entity as a child.
+// If the entity also has a geometry.Transform, the child's local
+// transform is set to it every frame, after the ECS systems run.
+// Removing the component destroys the behaviors of the child.
+type Renderable struct {
+	Child child.Child
+
+	// The scene the child is instanced in, which
+	// it is removed from when the component is
+	Scene *Scene
+}
+
+// NewRenderEntity creates an entity drawn as c, and instances c in scn
+func (engine *Engine) NewRenderEntity(c child.Child, scn *Scene) ecs.Entity {
+	e := engine.ECS.NewEntity()
+
+	scn.InstanceChild(c)
+	if scn.IsActive() {
+		c.Activate()
+	}
+	ecs.Add(engine.ECS, e, Renderable{Child: c, Scene: scn})
+
+	return e
+}
+
+func (engine *Engine) initializeEntities() {
+	ecs.StorageOf[Renderable](engine.ECS).OnRemove = func(e ecs.Entity, r *Renderable) {
+		if r.Scene != nil {
+			r.Scene.RemoveChild(r.Child)
+		}
+
+		// The child goes with its entity, even if another scene has it
+		engine.BehaviorControl.Destroy(r.Child)
+	}
+}
+
+// updateEntities runs the ECS systems, and then
+// moves each renderable entity's child to its transform
+func (engine *Engine) updateEntities(delta float64) {
+	engine.ECS.Update(delta)
+
+	ecs.Each2(engine.ECS, func(e ecs.Entity, r *Renderable, t *geometry.Transform) {
+		r.Child.SetLocalMatrix(t.Matrix())
+	})
+}
@@ -0,0 +1,714 @@
+package cmd
+
+//   --------------------------------------------------
+//   Frame_graph.go contains the frame graph, which runs
+//   the render passes of every frame. Each pass declares
+//   the attachments it reads and writes, and the graph
+//   culls passes whose output is never used, allocates
+//   and aliases the transient textures behind the
+//   attachments, and runs the passes in dependency order.
+//   --------------------------------------------------
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"rapidengine/device"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// Backbuffer is the attachment for the window's default framebuffer.
+// Passes which write to it are never culled.
+const Backbuffer = "backbuffer"
+
+// AttachmentFormat is the texture format of an attachment
+type AttachmentFormat int
+
+const (
+	FormatRGBA8 AttachmentFormat = iota
+	FormatRGBA16F
+	FormatDepth
+)
+
+// AttachmentDesc describes the texture behind an attachment.
+// Its size is Width by Height, or Scale times the screen
+// size if Width is 0. A Scale of 0 is the screen size.
+type AttachmentDesc struct {
+	Format AttachmentFormat
+	Width  int32
+	Height int32
+	Scale  float32
+}
+
+// ColorAttachment is a screen sized high precision color attachment
+var ColorAttachment = AttachmentDesc{Format: FormatRGBA16F}
+
+// DepthAttachment is a screen sized depth attachment
+var DepthAttachment = AttachmentDesc{Format: FormatDepth}
+
+// RenderPass is a single pass of the frame graph. Setup is called
+// every frame to declare the pass's attachments, and Execute is
+// called with the pass's outputs bound, if the pass isn't culled.
+// Execute reports errors through PassContext.Fail.
+type RenderPass struct {
+	Name string
+
+	Setup   func(b *PassBuilder)
+	Execute func(ctx *PassContext)
+}
+
+// FrameGraph is an ordered list of render passes. An attachment
+// read by a pass is the one written by the closest pass before it,
+// so a pass can be inserted anywhere to change what later passes see.
+type FrameGraph struct {
+	passes []*RenderPass
+
+	// Transient textures and the framebuffers made from them
+	textures     []*graphTexture
+	framebuffers map[string]*graphFramebuffer
+	frame        uint64
+
+	// Passes run in the last frame, in order
+	executed []string
+
+	renderer *Renderer
+}
+
+// NewFrameGraph creates an empty frame graph
+func NewFrameGraph(renderer *Renderer) *FrameGraph {
+	return &FrameGraph{
+		framebuffers: make(map[string]*graphFramebuffer),
+		renderer:     renderer,
+	}
+}
+
+// AddPass adds a pass after every other pass
+func (fg *FrameGraph) AddPass(p *RenderPass) error {
+	return fg.insert(len(fg.passes), p)
+}
+
+// InsertPassBefore adds a pass just before the pass named before
+func (fg *FrameGraph) InsertPassBefore(before string, p *RenderPass) error {
+	i := fg.index(before)
+	if i < 0 {
+		return fmt.Errorf("no render pass named %s", before)
+	}
+	return fg.insert(i, p)
+}
+
+// InsertPassAfter adds a pass just after the pass named after
+func (fg *FrameGraph) InsertPassAfter(after string, p *RenderPass) error {
+	i := fg.index(after)
+	if i < 0 {
+		return fmt.Errorf("no render pass named %s", after)
+	}
+	return fg.insert(i+1, p)
+}
+
+// RemovePass removes the pass with the given name
+func (fg *FrameGraph) RemovePass(name string) {
+	if i := fg.index(name); i >= 0 {
+		fg.passes = append(fg.passes[:i], fg.passes[i+1:]...)
+	}
+}
+
+// Passes returns the names of every pass, in order
+func (fg *FrameGraph) Passes() []string {
+	names := make([]string, len(fg.passes))
+	for i, p := range fg.passes {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// Executed returns the names of the passes run in the last
+// frame, in the order they ran. Culled passes are left out.
+func (fg *FrameGraph) Executed() []string {
+	return append([]string(nil), fg.executed...)
+}
+
+func (fg *FrameGraph) insert(i int, p *RenderPass) error {
+	if fg.index(p.Name) >= 0 {
+		return fmt.Errorf("render pass %s already exists", p.Name)
+	}
+
+	fg.passes = append(fg.passes, nil)
+	copy(fg.passes[i+1:], fg.passes[i:])
+	fg.passes[i] = p
+
+	return nil
+}
+
+func (fg *FrameGraph) index(name string) int {
+	for i, p := range fg.passes {
+		if p.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+//  --------------------------------------------------
+//  Setup
+//  --------------------------------------------------
+
+// PassBuilder declares the attachments of a pass
+type PassBuilder struct {
+	graph *compiledGraph
+	node  *passNode
+}
+
+// Read declares that the pass samples an attachment
+func (b *PassBuilder) Read(name string) {
+	v, ok := b.graph.latest[name]
+	if !ok {
+		b.graph.fail(fmt.Errorf("render pass %s reads %s, which no earlier pass writes", b.node.pass.Name, name))
+		return
+	}
+
+	b.node.reads = append(b.node.reads, v)
+	v.readers = append(v.readers, b.node)
+}
+
+// Write declares that the pass renders into a new attachment. Its
+// previous contents, if any, are left to the passes which read them.
+func (b *PassBuilder) Write(name string, desc AttachmentDesc) {
+	if name == Backbuffer {
+		b.writeBackbuffer(false)
+		return
+	}
+
+	v := &attachmentVersion{
+		name:     name,
+		writer:   b.node,
+		resource: &graphResource{desc: desc},
+	}
+	b.node.writes = append(b.node.writes, v)
+	b.graph.latest[name] = v
+}
+
+// Modify declares that the pass renders on top of an
+// attachment written by an earlier pass, without clearing it
+func (b *PassBuilder) Modify(name string) {
+	if name == Backbuffer {
+		b.writeBackbuffer(true)
+		return
+	}
+
+	prev, ok := b.graph.latest[name]
+	if !ok {
+		b.graph.fail(fmt.Errorf("render pass %s modifies %s, which no earlier pass writes", b.node.pass.Name, name))
+		return
+	}
+
+	prev.readers = append(prev.readers, b.node)
+	b.node.reads = append(b.node.reads, prev)
+	b.node.modifies = true
+
+	v := &attachmentVersion{
+		name:     name,
+		writer:   b.node,
+		resource: prev.resource,
+	}
+	b.node.writes = append(b.node.writes, v)
+	b.graph.latest[name] = v
+}
+
+// SideEffect keeps the pass from being culled, for passes
+// whose results are used outside of the frame graph
+func (b *PassBuilder) SideEffect() {
+	b.node.sideEffect = true
+}
+
+func (b *PassBuilder) writeBackbuffer(modify bool) {
+	if prev, ok := b.graph.latest[Backbuffer]; ok && modify {
+		prev.readers = append(prev.readers, b.node)
+		b.node.reads = append(b.node.reads, prev)
+	}
+
+	v := &attachmentVersion{
+		name:     Backbuffer,
+		writer:   b.node,
+		resource: b.graph.backbuffer,
+	}
+	b.node.writes = append(b.node.writes, v)
+	b.node.modifies = b.node.modifies || modify
+	b.node.sideEffect = true
+	b.graph.latest[Backbuffer] = v
+}
+
+//  --------------------------------------------------
+//  Compilation
+//  --------------------------------------------------
+
+// graphResource is the texture behind one or more versions
+// of an attachment. Modifying an attachment makes a new
+// version which shares the resource of the previous one.
+type graphResource struct {
+	desc          AttachmentDesc
+	width, height int32
+
+	// Passes between which the texture is in use
+	first, last int
+
+	texture  *graphTexture
+	imported bool
+}
+
+type attachmentVersion struct {
+	name     string
+	writer   *passNode
+	readers  []*passNode
+	resource *graphResource
+}
+
+type passNode struct {
+	pass  *RenderPass
+	index int
+
+	reads  []*attachmentVersion
+	writes []*attachmentVersion
+
+	modifies   bool
+	sideEffect bool
+	live       bool
+}
+
+type compiledGraph struct {
+	nodes      []*passNode
+	latest     map[string]*attachmentVersion
+	backbuffer *graphResource
+	err        error
+}
+
+func (cg *compiledGraph) fail(err error) {
+	if cg.err == nil {
+		cg.err = err
+	}
+}
+
+// compile sets up every pass, culls those which don't contribute
+// to a pass with side effects, and works out how long each
+// transient texture is needed for
+func (fg *FrameGraph) compile() (*compiledGraph, error) {
+	width, height := int32(fg.renderer.Config.ScreenWidth), int32(fg.renderer.Config.ScreenHeight)
+
+	cg := &compiledGraph{
+		latest:     make(map[string]*attachmentVersion),
+		backbuffer: &graphResource{width: width, height: height, imported: true},
+	}
+
+	for i, p := range fg.passes {
+		node := &passNode{pass: p, index: i}
+		if p.Setup != nil {
+			p.Setup(&PassBuilder{graph: cg, node: node})
+		}
+		cg.nodes = append(cg.nodes, node)
+	}
+	if cg.err != nil {
+		return nil, cg.err
+	}
+
+	// Cull, working backwards from the passes with side effects
+	for i := len(cg.nodes) - 1; i >= 0; i-- {
+		node := cg.nodes[i]
+		if !node.live && !node.sideEffect {
+			continue
+		}
+		node.live = true
+		for _, v := range node.reads {
+			v.writer.live = true
+		}
+	}
+
+	// Size transient textures, and find when each is first and last used
+	for _, node := range cg.nodes {
+		if !node.live {
+			continue
+		}
+
+		for _, v := range append(append([]*attachmentVersion{}, node.reads...), node.writes...) {
+			r := v.resource
+			if r.imported {
+				continue
+			}
+			if r.width == 0 {
+				r.width, r.height = r.desc.size(width, height)
+				r.first = node.index
+			}
+			r.last = node.index
+		}
+
+		if err := node.checkOutputs(); err != nil {
+			return nil, err
+		}
+	}
+
+	return cg, nil
+}
+
+func (desc AttachmentDesc) size(screenWidth, screenHeight int32) (int32, int32) {
+	if desc.Width > 0 && desc.Height > 0 {
+		return desc.Width, desc.Height
+	}
+
+	scale := desc.Scale
+	if scale == 0 {
+		scale = 1
+	}
+
+	w, h := int32(float32(screenWidth)*scale), int32(float32(screenHeight)*scale)
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// checkOutputs makes sure the pass's outputs can be written together
+func (node *passNode) checkOutputs() error {
+	depths := 0
+	backbuffer := false
+
+	for _, v := range node.writes {
+		if v.resource.imported {
+			backbuffer = true
+			continue
+		}
+		if v.resource.desc.Format == FormatDepth {
+			depths++
+		}
+	}
+
+	if backbuffer && len(node.writes) > 1 {
+		return fmt.Errorf("render pass %s writes the backbuffer and other attachments", node.pass.Name)
+	}
+	if depths > 1 {
+		return fmt.Errorf("render pass %s writes more than one depth attachment", node.pass.Name)
+	}
+	return nil
+}
+
+//  --------------------------------------------------
+//  Execution
+//  --------------------------------------------------
+
+// PassError is an error a pass reported with PassContext.Fail.
+// The rest of the frame is still run when a pass fails.
+type PassError struct {
+	Pass string
+	Err  error
+}
+
+func (e *PassError) Error() string {
+	return fmt.Sprintf("render pass %s: %v", e.Pass, e.Err)
+}
+
+func (e *PassError) Unwrap() error {
+	return e.Err
+}
+
+// Execute compiles and runs the graph for the current frame. If the
+// graph can't be compiled, nothing is run. Otherwise, the first error
+// a pass reported is returned, as a *PassError, once the frame has run.
+func (fg *FrameGraph) Execute() error {
+	cg, err := fg.compile()
+	if err != nil {
+		return err
+	}
+
+	var failed error
+
+	fg.frame++
+	fg.executed = fg.executed[:0]
+
+	for _, node := range cg.nodes {
+		if !node.live {
+			continue
+		}
+
+		for _, v := range node.writes {
+			if r := v.resource; !r.imported && r.texture == nil {
+				r.texture = fg.acquire(r)
+			}
+		}
+
+		ctx := &PassContext{graph: fg, node: node, Renderer: fg.renderer}
+		if ctx.Bind() && !node.modifies {
+			ctx.Clear()
+		}
+
+		if node.pass.Execute != nil {
+			node.pass.Execute(ctx)
+		}
+		if ctx.err != nil && failed == nil {
+			failed = &PassError{Pass: node.pass.Name, Err: ctx.err}
+		}
+		fg.executed = append(fg.executed, node.pass.Name)
+
+		// Release the textures no later pass needs
+		for _, v := range append(append([]*attachmentVersion{}, node.reads...), node.writes...) {
+			if r := v.resource; r.texture != nil && r.last == node.index {
+				r.texture.inUse = false
+				r.texture = nil
+			}
+		}
+	}
+
+	dev := device.Get()
+	dev.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	dev.Viewport(0, 0, int32(fg.renderer.Config.ScreenWidth), int32(fg.renderer.Config.ScreenHeight))
+
+	fg.trim()
+	return failed
+}
+
+// graphTexture is a transient texture, reused by any
+// attachment of the same format and size
+type graphTexture struct {
+	id            uint32
+	format        AttachmentFormat
+	width, height int32
+
+	inUse    bool
+	lastUsed uint64
+}
+
+// acquire returns a free texture for r, creating one if none match
+func (fg *FrameGraph) acquire(r *graphResource) *graphTexture {
+	for _, t := range fg.textures {
+		if !t.inUse && t.format == r.desc.Format && t.width == r.width && t.height == r.height {
+			t.inUse = true
+			t.lastUsed = fg.frame
+			return t
+		}
+	}
+
+	t := &graphTexture{
+		format:   r.desc.Format,
+		width:    r.width,
+		height:   r.height,
+		inUse:    true,
+		lastUsed: fg.frame,
+	}
+	t.create()
+	fg.textures = append(fg.textures, t)
+
+	return t
+}
+
+// trim deletes textures which weren't used this frame,
+// such as after a resize or an effect being disabled
+func (fg *FrameGraph) trim() {
+	textures := fg.textures[:0]
+	for _, t := range fg.textures {
+		if t.lastUsed == fg.frame {
+			textures = append(textures, t)
+			continue
+		}
+
+		for key, fb := range fg.framebuffers {
+			if fb.uses(t.id) {
+				device.Get().DeleteFramebuffers(1, &fb.id)
+				delete(fg.framebuffers, key)
+			}
+		}
+		device.Get().DeleteTextures(1, &t.id)
+	}
+	fg.textures = textures
+}
+
+func (t *graphTexture) create() {
+	dev := device.Get()
+
+	dev.GenTextures(1, &t.id)
+	dev.BindTexture(gl.TEXTURE_2D, t.id)
+
+	switch t.format {
+	case FormatDepth:
+		dev.TexImage2D(gl.TEXTURE_2D, 0, gl.DEPTH_COMPONENT24, t.width, t.height, 0, gl.DEPTH_COMPONENT, gl.FLOAT, gl.PtrOffset(0))
+	case FormatRGBA16F:
+		dev.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F, t.width, t.height, 0, gl.RGBA, gl.FLOAT, gl.PtrOffset(0))
+	default:
+		dev.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, t.width, t.height, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.PtrOffset(0))
+	}
+
+	dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+
+	dev.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+// graphFramebuffer is a framebuffer with a set of transient textures attached
+type graphFramebuffer struct {
+	id       uint32
+	textures []uint32
+}
+
+func (fb *graphFramebuffer) uses(texture uint32) bool {
+	for _, t := range fb.textures {
+		if t == texture {
+			return true
+		}
+	}
+	return false
+}
+
+// framebuffer returns a framebuffer with the given textures attached,
+// in order as color attachments except for a depth texture
+func (fg *FrameGraph) framebuffer(textures []*graphTexture) uint32 {
+	ids := make([]string, len(textures))
+	for i, t := range textures {
+		ids[i] = strconv.Itoa(int(t.id))
+	}
+	key := strings.Join(ids, ",")
+
+	if fb, ok := fg.framebuffers[key]; ok {
+		return fb.id
+	}
+
+	dev := device.Get()
+	fb := &graphFramebuffer{}
+
+	dev.GenFramebuffers(1, &fb.id)
+	dev.BindFramebuffer(gl.FRAMEBUFFER, fb.id)
+
+	drawBuffers := []uint32{}
+	for _, t := range textures {
+		fb.textures = append(fb.textures, t.id)
+
+		if t.format == FormatDepth {
+			dev.FramebufferTexture(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, t.id, 0)
+			continue
+		}
+
+		attachment := uint32(gl.COLOR_ATTACHMENT0 + len(drawBuffers))
+		dev.FramebufferTexture(gl.FRAMEBUFFER, attachment, t.id, 0)
+		drawBuffers = append(drawBuffers, attachment)
+	}
+
+	if len(drawBuffers) > 0 {
+		dev.DrawBuffers(int32(len(drawBuffers)), &drawBuffers[0])
+	}
+
+	fg.framebuffers[key] = fb
+	return fb.id
+}
+
+//  --------------------------------------------------
+//  Pass Context
+//  --------------------------------------------------
+
+// PassContext gives a pass's Execute access to its attachments
+type PassContext struct {
+	Renderer *Renderer
+
+	// Size of the pass's outputs
+	Width  int32
+	Height int32
+
+	// Framebuffer the outputs are attached to
+	FrameBuffer uint32
+
+	graph *FrameGraph
+	node  *passNode
+	err   error
+}
+
+// Fail reports an error from the pass, which Execute
+// returns once the rest of the frame has been run
+func (ctx *PassContext) Fail(err error) {
+	if ctx.err == nil {
+		ctx.err = err
+	}
+}
+
+// Bind binds a framebuffer with all of the pass's outputs attached,
+// and sets the viewport to cover them. The graph binds it before
+// Execute. Passes with no outputs, or outputs of different sizes,
+// have no such framebuffer and bind each output themselves
+// through Output, in which case Bind returns false.
+func (ctx *PassContext) Bind() bool {
+	if len(ctx.node.writes) == 0 {
+		return false
+	}
+
+	textures := []*graphTexture{}
+	ctx.Width, ctx.Height = int32(ctx.Renderer.Config.ScreenWidth), int32(ctx.Renderer.Config.ScreenHeight)
+
+	for i, v := range ctx.node.writes {
+		if v.resource.texture == nil {
+			continue
+		}
+		if i > 0 && (v.resource.width != ctx.Width || v.resource.height != ctx.Height) {
+			return false
+		}
+		textures = append(textures, v.resource.texture)
+		ctx.Width, ctx.Height = v.resource.width, v.resource.height
+	}
+
+	ctx.FrameBuffer = 0
+	if len(textures) > 0 {
+		ctx.FrameBuffer = ctx.graph.framebuffer(textures)
+	}
+
+	dev := device.Get()
+	dev.BindFramebuffer(gl.FRAMEBUFFER, ctx.FrameBuffer)
+	dev.Viewport(0, 0, ctx.Width, ctx.Height)
+
+	return true
+}
+
+// Clear clears the pass's outputs. The graph clears them before
+// Execute, unless the pass modifies an earlier attachment.
+func (ctx *PassContext) Clear() {
+	dev := device.Get()
+
+	dev.Clear(gl.COLOR_BUFFER_BIT)
+	dev.Clear(gl.DEPTH_BUFFER_BIT)
+}
+
+// Input returns an attachment the pass reads, such as to bind
+// its texture. Inputs have no framebuffer.
+func (ctx *PassContext) Input(name string) *EffectBuffers {
+	for _, v := range ctx.node.reads {
+		if v.name == name {
+			return ctx.buffers(v, false)
+		}
+	}
+	return &EffectBuffers{}
+}
+
+// Output returns an attachment the pass writes, with a framebuffer
+// of its own, so it can be rendered into by the PostControl's effects
+func (ctx *PassContext) Output(name string) *EffectBuffers {
+	for _, v := range ctx.node.writes {
+		if v.name == name {
+			return ctx.buffers(v, true)
+		}
+	}
+	return &EffectBuffers{}
+}
+
+func (ctx *PassContext) buffers(v *attachmentVersion, framebuffer bool) *EffectBuffers {
+	r := v.resource
+	if r.imported {
+		return &EffectBuffers{Width: r.width, Height: r.height}
+	}
+
+	eb := &EffectBuffers{
+		RenderedTexture: r.texture.id,
+		Width:           r.width,
+		Height:          r.height,
+	}
+	if framebuffer {
+		eb.FrameBuffer = ctx.graph.framebuffer([]*graphTexture{r.texture})
+	}
+	return eb
+}
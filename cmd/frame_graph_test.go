@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"rapidengine/configuration"
+	"rapidengine/device"
+)
+
+// newTestGraph returns an empty frame graph drawing
+// to a null device, which is restored by the test
+func newTestGraph(t *testing.T) *FrameGraph {
+	prev := device.Get()
+	device.Set(device.NewNullDevice())
+	t.Cleanup(func() { device.Set(prev) })
+
+	cfg := configuration.NewEngineConfig(800, 600, 3)
+	return NewFrameGraph(&Renderer{Config: &cfg})
+}
+
+func addTestPass(t *testing.T, fg *FrameGraph, name string, setup func(b *PassBuilder)) {
+	if err := fg.AddPass(&RenderPass{Name: name, Setup: setup}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFrameGraphCulling(t *testing.T) {
+	fg := newTestGraph(t)
+
+	ran := []string{}
+	pass := func(name string, setup func(b *PassBuilder)) {
+		fg.AddPass(&RenderPass{
+			Name:    name,
+			Setup:   setup,
+			Execute: func(ctx *PassContext) { ran = append(ran, name) },
+		})
+	}
+
+	// Nothing reads the shadow map, so the shadow pass is culled
+	pass("shadow", func(b *PassBuilder) {
+		b.Write("shadowmap", DepthAttachment)
+	})
+	pass("scene", func(b *PassBuilder) {
+		b.Write("color", ColorAttachment)
+		b.Write("depth", DepthAttachment)
+	})
+	pass("bloom", func(b *PassBuilder) {
+		b.Read("color")
+		b.Write("bloom", AttachmentDesc{Format: FormatRGBA16F, Scale: 0.5})
+	})
+
+	// Its output is read, but only by a pass which is culled
+	pass("blur", func(b *PassBuilder) {
+		b.Read("color")
+		b.Write("blurred", ColorAttachment)
+	})
+	pass("unused", func(b *PassBuilder) {
+		b.Read("blurred")
+		b.Write("nothing", ColorAttachment)
+	})
+
+	pass("composite", func(b *PassBuilder) {
+		b.Read("color")
+		b.Read("bloom")
+		b.Write(Backbuffer, AttachmentDesc{})
+	})
+	pass("overlay", func(b *PassBuilder) {
+		b.Modify(Backbuffer)
+	})
+
+	// Used outside of the graph, so never culled
+	pass("readback", func(b *PassBuilder) {
+		b.Read("depth")
+		b.SideEffect()
+	})
+
+	if err := fg.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"scene", "bloom", "composite", "overlay", "readback"}
+	if !reflect.DeepEqual(ran, want) {
+		t.Fatalf("ran %v, want %v", ran, want)
+	}
+	if !reflect.DeepEqual(fg.Executed(), want) {
+		t.Fatalf("Executed() = %v, want %v", fg.Executed(), want)
+	}
+
+	// Culled passes don't allocate their attachments
+	if len(fg.textures) != 3 {
+		t.Fatalf("%d textures allocated, want color, depth and bloom", len(fg.textures))
+	}
+}
+
+func TestFrameGraphModifyChain(t *testing.T) {
+	fg := newTestGraph(t)
+
+	addTestPass(t, fg, "a", func(b *PassBuilder) { b.Write("x", ColorAttachment) })
+	addTestPass(t, fg, "b", func(b *PassBuilder) { b.Modify("x") })
+	addTestPass(t, fg, "c", func(b *PassBuilder) {
+		b.Read("x")
+		b.Write(Backbuffer, AttachmentDesc{})
+	})
+
+	if err := fg.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(fg.Executed(), want) {
+		t.Fatalf("Executed() = %v, want %v", fg.Executed(), want)
+	}
+
+	// Without a reader, the writer and the modifier are both culled
+	fg.RemovePass("c")
+	if err := fg.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if len(fg.Executed()) != 0 {
+		t.Fatalf("Executed() = %v, want nothing", fg.Executed())
+	}
+
+	// Unused textures are deleted at the end of the frame
+	if len(fg.textures) != 0 {
+		t.Fatalf("%d textures kept after they stopped being used", len(fg.textures))
+	}
+}
+
+func TestFrameGraphReadsClosestWriter(t *testing.T) {
+	fg := newTestGraph(t)
+
+	addTestPass(t, fg, "first", func(b *PassBuilder) { b.Write("x", ColorAttachment) })
+	addTestPass(t, fg, "second", func(b *PassBuilder) { b.Write("x", ColorAttachment) })
+	addTestPass(t, fg, "present", func(b *PassBuilder) {
+		b.Read("x")
+		b.Write(Backbuffer, AttachmentDesc{})
+	})
+
+	if err := fg.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"second", "present"}; !reflect.DeepEqual(fg.Executed(), want) {
+		t.Fatalf("Executed() = %v, want %v", fg.Executed(), want)
+	}
+
+	// A pass inserted before the reader replaces what it reads
+	err := fg.InsertPassBefore("present", &RenderPass{Name: "third", Setup: func(b *PassBuilder) {
+		b.Write("x", ColorAttachment)
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fg.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"third", "present"}; !reflect.DeepEqual(fg.Executed(), want) {
+		t.Fatalf("Executed() = %v, want %v", fg.Executed(), want)
+	}
+}
+
+func TestFrameGraphAliasing(t *testing.T) {
+	fg := newTestGraph(t)
+
+	// Each attachment is only needed until the next pass has read
+	// it, so the third can reuse the texture of the first
+	addTestPass(t, fg, "a", func(b *PassBuilder) { b.Write("t1", ColorAttachment) })
+	addTestPass(t, fg, "b", func(b *PassBuilder) {
+		b.Read("t1")
+		b.Write("t2", ColorAttachment)
+	})
+	addTestPass(t, fg, "c", func(b *PassBuilder) {
+		b.Read("t2")
+		b.Write("t3", ColorAttachment)
+	})
+	addTestPass(t, fg, "d", func(b *PassBuilder) {
+		b.Read("t3")
+		b.Write(Backbuffer, AttachmentDesc{})
+	})
+
+	for frame := 0; frame < 3; frame++ {
+		if err := fg.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		if len(fg.textures) != 2 {
+			t.Fatalf("frame %d: %d textures allocated, want 2", frame, len(fg.textures))
+		}
+		for _, tex := range fg.textures {
+			if tex.inUse {
+				t.Fatalf("frame %d: texture %d is still in use after the frame", frame, tex.id)
+			}
+		}
+	}
+}
+
+func TestFrameGraphErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(b *PassBuilder)
+	}{
+		{"read unwritten", func(b *PassBuilder) {
+			b.Read("missing")
+			b.Write(Backbuffer, AttachmentDesc{})
+		}},
+		{"modify unwritten", func(b *PassBuilder) {
+			b.Modify("missing")
+			b.SideEffect()
+		}},
+		{"backbuffer and attachment", func(b *PassBuilder) {
+			b.Write("x", ColorAttachment)
+			b.Write(Backbuffer, AttachmentDesc{})
+		}},
+		{"two depths", func(b *PassBuilder) {
+			b.Write("d1", DepthAttachment)
+			b.Write("d2", DepthAttachment)
+			b.SideEffect()
+		}},
+	}
+
+	for _, test := range tests {
+		fg := newTestGraph(t)
+		addTestPass(t, fg, test.name, test.setup)
+
+		if err := fg.Execute(); err == nil {
+			t.Errorf("%s: Execute didn't fail", test.name)
+		}
+	}
+
+	fg := newTestGraph(t)
+	addTestPass(t, fg, "a", nil)
+	if err := fg.AddPass(&RenderPass{Name: "a"}); err == nil {
+		t.Error("a pass was added twice")
+	}
+	if err := fg.InsertPassAfter("missing", &RenderPass{Name: "b"}); err == nil {
+		t.Error("a pass was inserted after one which doesn't exist")
+	}
+}
+
+func TestFrameGraphPassFailure(t *testing.T) {
+	fg := newTestGraph(t)
+
+	fail := errors.New("fail")
+	ran := []string{}
+	pass := func(name string, err error) {
+		fg.AddPass(&RenderPass{
+			Name:  name,
+			Setup: func(b *PassBuilder) { b.SideEffect() },
+			Execute: func(ctx *PassContext) {
+				ran = append(ran, name)
+				if err != nil {
+					ctx.Fail(err)
+				}
+			},
+		})
+	}
+	pass("a", nil)
+	pass("b", fail)
+	pass("c", errors.New("later"))
+
+	err := fg.Execute()
+
+	var passErr *PassError
+	if !errors.As(err, &passErr) || passErr.Pass != "b" || !errors.Is(err, fail) {
+		t.Fatalf("Execute returned %v, want the error of pass b", err)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(ran, want) {
+		t.Fatalf("ran %v, want the rest of the frame too", ran)
+	}
+}
@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"errors"
+	"path/filepath"
+	"rapidengine/assets"
 	"rapidengine/geometry"
 	"rapidengine/material"
+	"strings"
 
 	assimp "assimp-golang"
 )
@@ -19,30 +23,85 @@ func (gm *GeometryControl) Initialize(e *Engine) {
 	gm.engine = e
 }
 
-func (gm *GeometryControl) LoadModel(path string, mat material.Material) geometry.Model {
-	scene := assimp.ImportFile(path, uint(assimp.Process_Triangulate|assimp.Process_FlipUVs))
+// LoadModel imports a model file with assimp. The given material is used for
+// meshes without one of their own. Textures referenced by the model that fail
+// to load are returned as errors.
+func (gm *GeometryControl) LoadModel(path string, mat material.Material) (geometry.Model, error) {
+	scene, err := importModel(path)
+	if err != nil {
+		return geometry.Model{}, err
+	}
+
+	return gm.buildModel(path, scene, mat)
+}
+
+// importModel reads a model file with assimp. It makes no GL
+// calls, so models can be imported off of the GL thread.
+func importModel(path string) (*assimp.Scene, error) {
+	data, err := assets.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hint := strings.TrimPrefix(filepath.Ext(path), ".")
+	scene := assimp.ImportFileFromMemory(data, uint(assimp.Process_Triangulate|assimp.Process_FlipUVs), hint)
+	if scene == nil {
+		return nil, &assets.DecodeError{Path: path, Format: "model", Err: errors.New("assimp could not import scene")}
+	}
+
+	return scene, nil
+}
+
+// modelTextures returns the paths of the textures used by an imported model
+func modelTextures(scene *assimp.Scene) []string {
+	paths := []string{}
+	seen := make(map[string]bool)
+
+	for _, mat := range scene.Materials() {
+		path, _, _, _, _, _, _, _ := mat.GetMaterialTexture(assimp.TextureType(assimp.TextureMapping_Diffuse), 0)
+		if path != "" && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	return paths
+}
+
+// buildModel creates the meshes and materials of an imported model
+func (gm *GeometryControl) buildModel(path string, scene *assimp.Scene, mat material.Material) (geometry.Model, error) {
 	model := geometry.Model{
 		Materials: make(map[int]material.Material),
+		Path:      path,
 	}
 
 	model.Materials[0] = mat
 
 	// Recursively process all nodes in the scene
-	gm.processNode(&model, scene.RootNode(), scene)
+	if err := gm.processNode(&model, scene.RootNode(), scene); err != nil {
+		return geometry.Model{}, err
+	}
 
-	return model
+	return model, nil
 }
 
-func (gm *GeometryControl) processNode(m *geometry.Model, node *assimp.Node, scene *assimp.Scene) {
+func (gm *GeometryControl) processNode(m *geometry.Model, node *assimp.Node, scene *assimp.Scene) error {
 	for _, mesh := range node.Meshes() {
-		m.Meshes = append(m.Meshes, gm.createMesh(m, scene.Meshes()[mesh], scene))
+		ms, err := gm.createMesh(m, scene.Meshes()[mesh], scene)
+		if err != nil {
+			return err
+		}
+		m.Meshes = append(m.Meshes, ms)
 	}
 	for _, childNode := range node.Children() {
-		gm.processNode(m, childNode, scene)
+		if err := gm.processNode(m, childNode, scene); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (gm *GeometryControl) createMesh(m *geometry.Model, mesh *assimp.Mesh, scene *assimp.Scene) geometry.Mesh {
+func (gm *GeometryControl) createMesh(m *geometry.Model, mesh *assimp.Mesh, scene *assimp.Scene) (geometry.Mesh, error) {
 	vertices, uvs, normals, tangents, bitangents := gm.loadMeshData(mesh)
 	indices := gm.loadMeshIndices(mesh)
 
@@ -79,9 +138,13 @@ func (gm *GeometryControl) createMesh(m *geometry.Model, mesh *assimp.Mesh, scen
 		ms.BitangentsEnabled = true
 	}
 
-	ms.ModelMaterial = gm.loadMeshMaterial(m, mesh, scene)
+	matIndex, err := gm.loadMeshMaterial(m, mesh, scene)
+	if err != nil {
+		return geometry.Mesh{}, err
+	}
+	ms.ModelMaterial = matIndex
 
-	return ms
+	return ms, nil
 }
 
 func (gm *GeometryControl) loadMeshData(mesh *assimp.Mesh) ([]float32, []float32, []float32, []float32, []float32) {
@@ -130,11 +193,14 @@ func (gm *GeometryControl) loadMeshIndices(mesh *assimp.Mesh) []uint32 {
 	return indices
 }
 
-func (gm *GeometryControl) loadMeshMaterial(model *geometry.Model, mesh *assimp.Mesh, scene *assimp.Scene) int {
+func (gm *GeometryControl) loadMeshMaterial(model *geometry.Model, mesh *assimp.Mesh, scene *assimp.Scene) (int, error) {
 	if mesh.MaterialIndex() >= 0 {
 		material := scene.Materials()[mesh.MaterialIndex()]
 
-		diffuse := gm.loadMaterialTexture(material, assimp.TextureMapping_Diffuse)
+		diffuse, err := gm.loadMaterialTexture(material, assimp.TextureMapping_Diffuse)
+		if err != nil {
+			return 0, err
+		}
 		//specular := gm.loadMaterialTexture(material, assimp.TextureMapping_Diffuse)
 		//normal := gm.loadMaterialTexture(material, assimp.TextureMapping_Diffuse)
 
@@ -152,19 +218,27 @@ func (gm *GeometryControl) loadMeshMaterial(model *geometry.Model, mesh *assimp.
 			model.Materials[mesh.MaterialIndex()] = newMat
 		}
 
-		return mesh.MaterialIndex()
+		return mesh.MaterialIndex(), nil
 	}
 
-	return 0
+	return 0, nil
 }
 
-func (gm *GeometryControl) loadMaterialTexture(mat *assimp.Material, tm assimp.TextureMapping) string {
+func (gm *GeometryControl) loadMaterialTexture(mat *assimp.Material, tm assimp.TextureMapping) (string, error) {
 	textureType := assimp.TextureType(tm)
 	path, _, _, _, _, _, _, _ := mat.GetMaterialTexture(textureType, 0)
 
-	if path != "" {
-		gm.engine.TextureControl.NewTexture(path, path, "mipmap")
+	if path == "" {
+		return "", nil
+	}
+
+	// Textures shared by several meshes, or uploaded
+	// ahead of time by LoadSceneAsync, are only loaded once
+	if _, ok := gm.engine.TextureControl.LookupTexture(path); !ok {
+		if err := gm.engine.TextureControl.NewTexture(path, path, "mipmap"); err != nil {
+			return "", err
+		}
 	}
 
-	return path
+	return path, nil
 }
@@ -1,25 +1,24 @@
 package cmd
 
 import (
+	"rapidengine/device"
 	"rapidengine/input"
-
-	"github.com/go-gl/glfw/v3.2/glfw"
 )
 
 type InputControl struct {
-	keyMap map[string]glfw.Key
+	keyMap map[string]device.Key
 }
 
 func NewInputControl() InputControl {
 	return InputControl{input.KeyMap}
 }
 
-func (inputControl *InputControl) Update(window *glfw.Window) *input.Input {
+func (inputControl *InputControl) Update(window device.Window) *input.Input {
 	defer input.SwapMousePositions()
-	glfw.PollEvents()
+	window.PollEvents()
 	current := map[string]bool{}
 	for name, key := range inputControl.keyMap {
-		current[name] = (window.GetKey(key) == glfw.Press)
+		current[name] = window.KeyPressed(key)
 	}
 	return &input.Input{
 		current,
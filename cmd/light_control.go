@@ -1,10 +1,9 @@
 package cmd
 
 import (
+	"rapidengine/device"
 	"rapidengine/lighting"
 	"rapidengine/material"
-
-	"github.com/go-gl/gl/v4.1-core/gl"
 )
 
 type LightControl struct {
@@ -35,6 +34,8 @@ func (lightControl *LightControl) Initialize(engine *Engine) {
 }
 
 func (lightControl *LightControl) Update(cx, cy, cz float32) {
+	dev := device.Get()
+
 	if lightControl.lightingEnabled[0] {
 		for _, shader := range lightControl.Shaders {
 			if lightControl.DirLight[0] != nil && lightControl.directionalEnabled[0] {
@@ -44,7 +45,7 @@ func (lightControl *LightControl) Update(cx, cy, cz float32) {
 				light.UpdateShader(cx, cy, cz, ind, shader)
 			}
 
-			gl.Uniform1i(gl.GetUniformLocation(shader.GetID(), gl.Str("numPointLights\x00")), int32(len(lightControl.pointLightMap)))
+			dev.Uniform1i(dev.GetUniformLocation(shader.GetID(), "numPointLights"), int32(len(lightControl.pointLightMap)))
 		}
 	}
 }
@@ -64,6 +65,16 @@ func (lightControl *LightControl) InstanceLight(l *lighting.PointLight, ind int)
 	lightControl.pointLightMap[ind] = l
 }
 
+// PointLights returns the point lights by index
+func (lightControl *LightControl) PointLights() map[int]*lighting.PointLight {
+	return lightControl.pointLightMap
+}
+
+// ClearPointLights removes every point light
+func (lightControl *LightControl) ClearPointLights() {
+	lightControl.pointLightMap = make(map[int]*lighting.PointLight)
+}
+
 func (lightControl *LightControl) SetDirectionalLight(light *lighting.DirectionLight) {
 	lightControl.DirLight[0] = light
 }
@@ -76,6 +87,10 @@ func (lightControl *LightControl) DisableLighting() {
 	lightControl.lightingEnabled[0] = false
 }
 
+func (lightControl *LightControl) IsLightingEnabled() bool {
+	return lightControl.lightingEnabled[0]
+}
+
 func (lightControl *LightControl) EnableDirectionalLighting() {
 	lightControl.directionalEnabled[0] = true
 }
@@ -83,3 +98,7 @@ func (lightControl *LightControl) EnableDirectionalLighting() {
 func (lightControl *LightControl) DisableDirectionalLighting() {
 	lightControl.directionalEnabled[0] = false
 }
+
+func (lightControl *LightControl) IsDirectionalLightingEnabled() bool {
+	return lightControl.directionalEnabled[0]
+}
@@ -1,13 +1,17 @@
 package cmd
 
 import (
+	"rapidengine/child"
+	"rapidengine/event"
 	"rapidengine/material"
-	"rapidengine/state"
 )
 
 type MaterialControl struct {
 	Materials map[string]material.Material
 
+	// Materials sized to the screen
+	processing []*material.CustomProcessMaterial
+
 	engine *Engine
 }
 
@@ -19,19 +23,57 @@ func NewMaterialControl() MaterialControl {
 
 func (mc *MaterialControl) Initialize(engine *Engine) {
 	mc.engine = engine
-
-	e := uint32(1000)
-	state.BoundTexture0 = e
-	state.BoundTexture1 = e
-	state.BoundTexture2 = e
-	state.BoundTexture3 = e
-	state.BoundTexture4 = e
-	state.BoundTexture5 = e
-	state.BoundTexture6 = e
 }
 
 func (mc *MaterialControl) NewBasicMaterial() *material.BasicMaterial {
-	return material.NewBasicMaterial(mc.engine.ShaderControl.GetShader("basic"))
+	m := material.NewBasicMaterial(mc.engine.ShaderControl.GetShader("basic"))
+	m.EnableFixedAnimation()
+	m.OnAnimationFinished(func(anim string) {
+		mc.engine.Events.Publish(event.AnimationFinished{Material: m, Animation: anim})
+	})
+	return m
+}
+
+// FixedUpdate advances by a single tick the animations of the basic
+// materials drawn in the current scene, by its children, their copies
+// and its UI elements. Materials shared by several children are only
+// advanced once, and materials of other scenes are left paused.
+func (mc *MaterialControl) FixedUpdate(delta float64) {
+	sc := &mc.engine.SceneControl
+	if sc.GetCurrentScene() == nil {
+		return
+	}
+
+	ticked := make(map[*material.BasicMaterial]bool)
+	tick := func(m material.Material) {
+		bm, ok := m.(*material.BasicMaterial)
+		if !ok || ticked[bm] || !bm.IsFixedAnimation() {
+			return
+		}
+		ticked[bm] = true
+		bm.UpdateAnimation(delta)
+	}
+
+	for _, c := range sc.GetCurrentChildren() {
+		switch c := c.(type) {
+		case *child.Child2D:
+			tick(c.GetMaterial())
+		case *child.Child3D:
+			tick(c.Material)
+			for _, mesh := range c.Model.Meshes {
+				tick(c.Model.Materials[mesh.ModelMaterial])
+			}
+		}
+		for _, cpy := range *c.GetCopies() {
+			tick(cpy.Material)
+		}
+	}
+
+	for _, e := range sc.GetCurrentScene().GetElements() {
+		for _, c := range e.GetChildren() {
+			tick(c.GetMaterial())
+		}
+	}
 }
 
 func (mc *MaterialControl) NewStandardMaterial() *material.StandardMaterial {
@@ -64,5 +106,15 @@ func (mc *MaterialControl) NewCustomProcessMaterial(shader string) *material.Cus
 	m := material.NewCustomProcessMaterial(mc.engine.ShaderControl.GetShader(shader))
 	m.FboWidth = float32(mc.engine.Config.ScreenWidth)
 	m.FboHeight = float32(mc.engine.Config.ScreenHeight)
+	mc.processing = append(mc.processing, m)
 	return m
 }
+
+// Resize updates the size of every custom process
+// material created by the MaterialControl
+func (mc *MaterialControl) Resize() {
+	for _, m := range mc.processing {
+		m.FboWidth = float32(mc.engine.Config.ScreenWidth)
+		m.FboHeight = float32(mc.engine.Config.ScreenHeight)
+	}
+}
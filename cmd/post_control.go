@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"fmt"
+
 	"rapidengine/child"
+	"rapidengine/device"
 	"rapidengine/geometry"
 	"rapidengine/material"
 
@@ -13,10 +16,10 @@ import (
 //  These include HDR, Bloom, Reflections, and Water.
 //  --------------------------------------------------
 
-// PostControl has 2 ping pong buffers, 1 which hooks into the renderer
-// causing the 3D scene to be rendered to it. Then, an effect
-// chain is applied while swapping the 2 buffers, and the final buffer
-// is rendered to the screen as a 2D quad.
+// PostControl holds the settings of the post processing effects,
+// which run as built in passes of the renderer's frame graph. The
+// scene is rendered into the "color" attachment, each enabled effect
+// replaces it, and the final one is rendered to the screen as a 2D quad.
 type PostControl struct {
 	PostProcessingEnabled bool
 
@@ -30,13 +33,11 @@ type PostControl struct {
 	ScreenChild    *child.Child2D
 	ScreenMaterial *material.PostProcessMaterial
 
-	// Ping pong buffers
-	PInputBuffer        EffectBuffers
-	PBuffer1            EffectBuffers
-	PBuffer2            EffectBuffers
-	PIntermediateBuffer EffectBuffers
+	// The input and output of UserFunc, see ApplyUserFunc
+	PBuffer1 EffectBuffers
+	PBuffer2 EffectBuffers
 
-	// Gaussian Blur
+	// Gaussian Blur. The buffers are set by the frame graph.
 	gaussianIterations int
 	gaussianScale      int
 	GaussianBuffer1    EffectBuffers
@@ -48,7 +49,6 @@ type PostControl struct {
 	BloomIntensity float32
 	BloomOffsetX   int32
 	BloomOffsetY   int32
-	BloomBuffer1   EffectBuffers
 
 	// Volumetric Scattering
 	ScatteringDecay    float32
@@ -56,13 +56,21 @@ type PostControl struct {
 	ScatteringWeight   float32
 	ScatteringExposure float32
 
-	SunChild          child.Child
-	ScatteringTexture uint32
-	ScatteringBuffer  EffectBuffers
+	SunChild child.Child
 
 	// User Processing
 	UserFunc func(*PostControl)
 
+	// Scene transitions, which are driven by the SceneControl. The
+	// frame is blended with the one captured in TransitionBuffer by
+	// TransitionBlend, then faded to TransitionColor by TransitionFade.
+	transitionEnabled bool
+	captureTransition bool
+	TransitionBlend   float32
+	TransitionFade    float32
+	TransitionColor   [3]float32
+	TransitionBuffer  EffectBuffers
+
 	engine *Engine
 }
 
@@ -79,11 +87,9 @@ func (pc *PostControl) Initialize(engine *Engine) {
 func (pc *PostControl) EnablePostProcessing() {
 	pc.PostProcessingEnabled = true
 
-	// Create buffers
-	pc.PInputBuffer, pc.ScatteringTexture = pc.NewDoubleEffectBuffers(int32(pc.engine.Config.ScreenWidth), int32(pc.engine.Config.ScreenHeight), true)
-	pc.PBuffer1 = pc.NewEffectBuffers(int32(pc.engine.Config.ScreenWidth), int32(pc.engine.Config.ScreenHeight), true)
-	pc.PBuffer2 = pc.NewEffectBuffers(int32(pc.engine.Config.ScreenWidth), int32(pc.engine.Config.ScreenHeight), true)
-	pc.PIntermediateBuffer = pc.NewEffectBuffers(int32(pc.engine.Config.ScreenWidth), int32(pc.engine.Config.ScreenHeight), true)
+	if pc.ScreenMaterial != nil {
+		return
+	}
 
 	pc.ScreenMaterial = material.NewPostProcessMaterial(pc.engine.ShaderControl.GetShader("post_final"), &pc.PBuffer2.RenderedTexture)
 	pc.ScreenMaterial.FboWidth = float32(pc.engine.Config.ScreenWidth)
@@ -93,13 +99,26 @@ func (pc *PostControl) EnablePostProcessing() {
 	pc.ScreenChild = pc.engine.ChildControl.NewChild2D()
 	pc.ScreenChild.AttachMaterial(pc.ScreenMaterial)
 	pc.ScreenChild.AttachMesh(geometry.NewScreenQuad())
-	pc.ScreenChild.ScaleX = float32(pc.engine.Config.ScreenWidth)
-	pc.ScreenChild.ScaleY = float32(pc.engine.Config.ScreenHeight)
+	pc.ScreenChild.SetScale(float32(pc.engine.Config.ScreenWidth), float32(pc.engine.Config.ScreenHeight), 1)
 	pc.ScreenChild.Static = true
 	pc.ScreenChild.SetPosition(0, 0)
 	pc.ScreenChild.PreRender(pc.engine.Renderer.MainCamera)
 }
 
+// Resize fits the screen quad to the current screen size.
+// The buffers of the effects are resized by the frame graph.
+func (pc *PostControl) Resize() {
+	if pc.ScreenMaterial == nil {
+		return
+	}
+
+	pc.ScreenMaterial.FboWidth = float32(pc.engine.Config.ScreenWidth)
+	pc.ScreenMaterial.FboHeight = float32(pc.engine.Config.ScreenHeight)
+
+	pc.ScreenChild.SetScale(float32(pc.engine.Config.ScreenWidth), float32(pc.engine.Config.ScreenHeight), 1)
+	pc.ScreenChild.PreRender(pc.engine.Renderer.MainCamera)
+}
+
 func (pc *PostControl) DisablePostProcessing() {
 	pc.PostProcessingEnabled = false
 }
@@ -108,97 +127,28 @@ func (pc *PostControl) IsPostProcessingEnabled() bool {
 	return pc.PostProcessingEnabled
 }
 
-// UpdateFrameBuffers is called at the beginning of the
-// render loop. If post processing is enabled, the output
-// framebuffer of the screen will be switched from the
-// default framebuffer (0) to the initial buffer of the
-// PostControl in preparation for the post processing stage.
-func (pc *PostControl) UpdateFrameBuffers() {
-	if pc.engine.Config.Dimensions == 3 {
-		gl.Enable(gl.DEPTH_TEST)
-	}
-
-	if pc.PostProcessingEnabled {
-		gl.BindFramebuffer(gl.FRAMEBUFFER, pc.PInputBuffer.FrameBuffer)
-	} else {
-		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
-	}
-
-	gl.Clear(gl.COLOR_BUFFER_BIT)
-	gl.Clear(gl.DEPTH_BUFFER_BIT)
-
-	drawBuffers := []uint32{gl.COLOR_ATTACHMENT0, gl.COLOR_ATTACHMENT1}
-	gl.DrawBuffers(2, &drawBuffers[0])
-}
-
-// Update applies the post processing effect chain every frame.
-// At this point, the scene has been rendered to the PostControl's
-// initial buffers. After the effects have been applied, the
-// final buffers are rendered onto the screen through the
-// ScreenChild and ScreenMaterial
-func (pc *PostControl) Update() {
-	if !pc.PostProcessingEnabled {
-		return
-	}
-
-	// Apply input buffer
-	pc.ApplyInput()
-
-	// Apply HDR
-	if pc.hdrEnabled {
-		pc.ApplyHDR(&pc.PBuffer1, &pc.PBuffer2)
-		pc.SwapPingPongBuffers()
-	}
-
-	if pc.bloomEnabled {
-		pc.ApplyPreBloom(&pc.PBuffer1, &pc.BloomBuffer1)
-		pc.ApplyGaussianBlur(&pc.BloomBuffer1, &pc.PBuffer2)
-		pc.ApplyPostBloom(&pc.PBuffer1, &pc.PBuffer2, &pc.BloomBuffer1)
-
-		pc.PIntermediateBuffer = pc.PBuffer2
-		pc.PBuffer2 = pc.BloomBuffer1
-		pc.BloomBuffer1 = pc.PIntermediateBuffer
-
-		pc.SwapPingPongBuffers()
-	}
-
-	if pc.gaussianEnabled {
-		//pc.ApplyGaussianBlur(&pc.PBuffer1, &pc.PBuffer2)
-		//pc.SwapPingPongBuffers()
-	}
-
-	if pc.scatteringEnabled {
-		pc.ApplyPreScattering(&EffectBuffers{RenderedTexture: pc.ScatteringTexture}, &pc.ScatteringBuffer)
-		pc.ApplyPostScattering(&pc.PBuffer1, &pc.ScatteringBuffer, &pc.PBuffer2)
-		pc.SwapPingPongBuffers()
-	}
-
-	// The user has the freedom to write their own post processing routines.
-	// They can expect the current rendered buffer in PBuffer1, and are
-	// expected to make sure this is still the case after their own routine.
-	if pc.UserFunc != nil {
-		pc.UserFunc(pc)
-	}
-
-	// Render final buffer to screen
-	pc.ScreenMaterial.ScreenMap = &pc.PBuffer1.RenderedTexture
+// ApplyCopy renders the input buffer unchanged into the output buffer
+func (pc *PostControl) ApplyCopy(input, output *EffectBuffers) {
+	pc.ScreenMaterial.ScreenMap = &input.RenderedTexture
 	pc.ScreenMaterial.AttachShader(pc.engine.ShaderControl.GetShader("post_final"))
 
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
-	gl.Clear(gl.COLOR_BUFFER_BIT)
-	gl.Clear(gl.DEPTH_BUFFER_BIT)
+	output.BindAndClear()
 
 	pc.engine.Renderer.RenderChild(pc.ScreenChild)
 }
 
-// ApplyInput moves the screen data from the input buffer to the 1st pingpong buffer
-func (pc *PostControl) ApplyInput() {
-	pc.ScreenMaterial.ScreenMap = &pc.PInputBuffer.RenderedTexture
-	pc.ScreenMaterial.AttachShader(pc.engine.ShaderControl.GetShader("post_final"))
+// ApplyUserFunc runs UserFunc with the current rendered buffer
+// in PBuffer1 and a free buffer in PBuffer2. As before, UserFunc
+// is expected to leave its result in PBuffer1, which is copied
+// to the output if UserFunc didn't finish by swapping into it.
+func (pc *PostControl) ApplyUserFunc(input, output *EffectBuffers) {
+	pc.PBuffer1, pc.PBuffer2 = *input, *output
 
-	pc.PBuffer1.BindAndClear()
+	pc.UserFunc(pc)
 
-	pc.engine.Renderer.RenderChild(pc.ScreenChild)
+	if pc.PBuffer1.RenderedTexture != output.RenderedTexture {
+		pc.ApplyCopy(&pc.PBuffer1, output)
+	}
 }
 
 // ApplyHDR applies the High Dynamic Range post processing effect.
@@ -230,7 +180,9 @@ func (pc *PostControl) ApplyVerticalGaussian(input, output *EffectBuffers) {
 }
 
 func (pc *PostControl) ApplyGaussianBlur(input, output *EffectBuffers) {
-	gl.Viewport(0, 0, int32(pc.engine.Config.ScreenWidth/pc.gaussianScale), int32(pc.engine.Config.ScreenHeight/pc.gaussianScale))
+	dev := device.Get()
+
+	dev.Viewport(0, 0, int32(pc.engine.Config.ScreenWidth/pc.gaussianScale), int32(pc.engine.Config.ScreenHeight/pc.gaussianScale))
 	pc.ScreenMaterial.FboWidth = float32(pc.engine.Config.ScreenWidth / pc.gaussianScale)
 	pc.ScreenMaterial.FboHeight = float32(pc.engine.Config.ScreenWidth / pc.gaussianScale)
 	pc.ApplyHorizontalGaussian(input, &pc.GaussianBuffer1)
@@ -242,18 +194,20 @@ func (pc *PostControl) ApplyGaussianBlur(input, output *EffectBuffers) {
 		pc.ApplyVerticalGaussian(&pc.GaussianBuffer2, &pc.GaussianBuffer1)
 	}
 
-	gl.Viewport(pc.BloomOffsetX, pc.BloomOffsetY, int32(pc.engine.Config.ScreenWidth), int32(pc.engine.Config.ScreenHeight))
+	dev.Viewport(pc.BloomOffsetX, pc.BloomOffsetY, int32(pc.engine.Config.ScreenWidth), int32(pc.engine.Config.ScreenHeight))
 	pc.ScreenMaterial.FboWidth = float32(pc.engine.Config.ScreenWidth)
 	pc.ScreenMaterial.FboHeight = float32(pc.engine.Config.ScreenWidth)
 	pc.ApplyHorizontalGaussian(&pc.GaussianBuffer1, &pc.GaussianBuffer3)
 	pc.ApplyVerticalGaussian(&pc.GaussianBuffer3, output)
-	gl.Viewport(0, 0, int32(pc.engine.Config.ScreenWidth), int32(pc.engine.Config.ScreenHeight))
+	dev.Viewport(0, 0, int32(pc.engine.Config.ScreenWidth), int32(pc.engine.Config.ScreenHeight))
 }
 
 var SunX float32
 var SunY float32
 
 func (pc *PostControl) ApplyPreScattering(input, output *EffectBuffers) {
+	dev := device.Get()
+
 	pc.ScreenMaterial.ScreenMap = &input.RenderedTexture
 	pc.ScreenMaterial.AttachShader(pc.engine.ShaderControl.GetShader("post_prescattering"))
 
@@ -261,15 +215,15 @@ func (pc *PostControl) ApplyPreScattering(input, output *EffectBuffers) {
 	pos := []float32{
 		SunX, SunY,
 	}
-	gl.Uniform2fv(
+	dev.Uniform2fv(
 		pc.ScreenMaterial.GetShader().GetUniform("lightPos"),
 		1, &pos[0],
 	)
 
-	gl.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("decay"), pc.ScatteringDecay)
-	gl.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("density"), pc.ScatteringDensity)
-	gl.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("weight"), pc.ScatteringWeight)
-	gl.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("exposure"), pc.ScatteringExposure)
+	dev.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("decay"), pc.ScatteringDecay)
+	dev.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("density"), pc.ScatteringDensity)
+	dev.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("weight"), pc.ScatteringWeight)
+	dev.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("exposure"), pc.ScatteringExposure)
 
 	output.BindAndClear()
 
@@ -277,13 +231,15 @@ func (pc *PostControl) ApplyPreScattering(input, output *EffectBuffers) {
 }
 
 func (pc *PostControl) ApplyPostScattering(input, scatterInput, output *EffectBuffers) {
+	dev := device.Get()
+
 	pc.ScreenMaterial.ScreenMap = &input.RenderedTexture
 	pc.ScreenMaterial.AttachShader(pc.engine.ShaderControl.GetShader("post_postscattering"))
 
 	pc.ScreenMaterial.GetShader().Bind()
-	gl.ActiveTexture(gl.TEXTURE1)
-	gl.BindTexture(gl.TEXTURE_2D, scatterInput.RenderedTexture)
-	gl.Uniform1i(pc.ScreenMaterial.GetShader().GetUniform("scatterInput"), 1)
+	dev.ActiveTexture(gl.TEXTURE1)
+	dev.BindTexture(gl.TEXTURE_2D, scatterInput.RenderedTexture)
+	dev.Uniform1i(pc.ScreenMaterial.GetShader().GetUniform("scatterInput"), 1)
 
 	output.BindAndClear()
 
@@ -291,10 +247,12 @@ func (pc *PostControl) ApplyPostScattering(input, scatterInput, output *EffectBu
 }
 
 func (pc *PostControl) ApplyPreBloom(input, output *EffectBuffers) {
+	dev := device.Get()
+
 	pc.ScreenMaterial.ScreenMap = &input.RenderedTexture
 	pc.ScreenMaterial.AttachShader(pc.engine.ShaderControl.GetShader("post_prebloom"))
 	pc.ScreenMaterial.GetShader().Bind()
-	gl.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("bloomThreshold"), pc.BloomThreshold)
+	dev.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("bloomThreshold"), pc.BloomThreshold)
 
 	output.BindAndClear()
 
@@ -302,15 +260,17 @@ func (pc *PostControl) ApplyPreBloom(input, output *EffectBuffers) {
 }
 
 func (pc *PostControl) ApplyPostBloom(mainInput, bloomInput, output *EffectBuffers) {
+	dev := device.Get()
+
 	pc.ScreenMaterial.ScreenMap = &mainInput.RenderedTexture
 	pc.ScreenMaterial.AttachShader(pc.engine.ShaderControl.GetShader("post_postbloom"))
 
 	pc.ScreenMaterial.GetShader().Bind()
-	gl.ActiveTexture(gl.TEXTURE1)
-	gl.BindTexture(gl.TEXTURE_2D, bloomInput.RenderedTexture)
-	gl.Uniform1i(pc.ScreenMaterial.GetShader().GetUniform("bloomInput"), 1)
+	dev.ActiveTexture(gl.TEXTURE1)
+	dev.BindTexture(gl.TEXTURE_2D, bloomInput.RenderedTexture)
+	dev.Uniform1i(pc.ScreenMaterial.GetShader().GetUniform("bloomInput"), 1)
 
-	gl.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("bloomIntensity"), pc.BloomIntensity)
+	dev.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("bloomIntensity"), pc.BloomIntensity)
 
 	output.BindAndClear()
 
@@ -328,19 +288,69 @@ func (pc *PostControl) ApplyCustomProcessing(mat *material.CustomProcessMaterial
 	pc.ScreenChild.AttachMaterial(pc.ScreenMaterial)
 }
 
+// ApplyTransition blends the input with the captured frame and fades
+// it for scene transitions. If a capture was asked for, the input is
+// copied into TransitionBuffer first. If TransitionBuffer can't be
+// created, the frame is drawn without the capture and the error is
+// returned.
+func (pc *PostControl) ApplyTransition(input, output *EffectBuffers) error {
+	dev := device.Get()
+
+	var err error
+	if pc.captureTransition {
+		pc.captureTransition = false
+		err = pc.captureTransitionBuffer(input)
+	}
+
+	pc.ScreenMaterial.ScreenMap = &input.RenderedTexture
+	pc.ScreenMaterial.AttachShader(pc.engine.ShaderControl.GetShader("post_transition"))
+
+	pc.ScreenMaterial.GetShader().Bind()
+	dev.ActiveTexture(gl.TEXTURE1)
+	dev.BindTexture(gl.TEXTURE_2D, pc.TransitionBuffer.RenderedTexture)
+	dev.Uniform1i(pc.ScreenMaterial.GetShader().GetUniform("previous"), 1)
+
+	dev.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("blend"), pc.TransitionBlend)
+	dev.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("fade"), pc.TransitionFade)
+	dev.Uniform3fv(pc.ScreenMaterial.GetShader().GetUniform("fadeColor"), 1, &pc.TransitionColor[0])
+
+	output.BindAndClear()
+
+	pc.engine.Renderer.RenderChild(pc.ScreenChild)
+
+	return err
+}
+
+// captureTransitionBuffer copies the input into TransitionBuffer,
+// which is created again if the input's size has changed
+func (pc *PostControl) captureTransitionBuffer(input *EffectBuffers) error {
+	tb := &pc.TransitionBuffer
+	if tb.Width != input.Width || tb.Height != input.Height {
+		if tb.FrameBuffer != 0 {
+			tb.Delete()
+		}
+
+		buffers, err := pc.NewEffectBuffers(input.Width, input.Height, true)
+		if err != nil {
+			*tb = EffectBuffers{}
+			return fmt.Errorf("capturing transition: %w", err)
+		}
+		*tb = buffers
+	}
+
+	pc.ApplyCopy(input, tb)
+	return nil
+}
+
 // SwapPingPongBuffers swaps PBuffer1 and PBuffer2 so that
 // the next effect in the post processing chain will have
 // the correct input and output buffers.
 func (pc *PostControl) SwapPingPongBuffers() {
-	pc.PIntermediateBuffer = pc.PBuffer2
-	pc.PBuffer2 = pc.PBuffer1
-	pc.PBuffer1 = pc.PIntermediateBuffer
+	pc.PBuffer1, pc.PBuffer2 = pc.PBuffer2, pc.PBuffer1
 }
 
 func (pc *PostControl) swapGaussianPingPongBuffers() {
-	pc.PIntermediateBuffer = pc.GaussianBuffer2
-	pc.GaussianBuffer2 = pc.GaussianBuffer1
-	pc.GaussianBuffer1 = pc.PIntermediateBuffer
+	pc.GaussianBuffer1, pc.GaussianBuffer2 = pc.GaussianBuffer2, pc.GaussianBuffer1
 }
 
 func (pc *PostControl) EnableHDR() {
@@ -351,15 +361,10 @@ func (pc *PostControl) EnableGaussianBlur(iterations int, scale int) {
 	pc.gaussianEnabled = true
 	pc.gaussianIterations = iterations
 	pc.gaussianScale = scale
-
-	pc.GaussianBuffer1 = pc.NewEffectBuffers(int32(pc.engine.Config.ScreenWidth/pc.gaussianScale), int32(pc.engine.Config.ScreenHeight/pc.gaussianScale), true)
-	pc.GaussianBuffer2 = pc.NewEffectBuffers(int32(pc.engine.Config.ScreenWidth/pc.gaussianScale), int32(pc.engine.Config.ScreenHeight/pc.gaussianScale), true)
-	pc.GaussianBuffer3 = pc.NewEffectBuffers(int32(pc.engine.Config.ScreenWidth), int32(pc.engine.Config.ScreenHeight), true)
 }
 
 func (pc *PostControl) EnableLightScattering(sun child.Child) {
 	pc.scatteringEnabled = true
-	pc.ScatteringBuffer = pc.NewEffectBuffers(int32(pc.engine.Config.ScreenWidth), int32(pc.engine.Config.ScreenHeight), true)
 	pc.SunChild = sun
 
 	pc.ScatteringDecay = 1.0
@@ -373,7 +378,6 @@ func (pc *PostControl) EnableBloom(blurIterations int, blurScale int) {
 	pc.bloomEnabled = true
 	pc.BloomThreshold = 0.7
 	pc.BloomIntensity = 1
-	pc.BloomBuffer1 = pc.NewEffectBuffers(int32(pc.engine.Config.ScreenWidth), int32(pc.engine.Config.ScreenHeight), true)
 }
 
 type EffectBuffers struct {
@@ -382,146 +386,87 @@ type EffectBuffers struct {
 	DepthRenderBuffer uint32
 
 	RenderedTexture uint32
+
+	Width  int32
+	Height int32
 }
 
 func (eb *EffectBuffers) BindAndClear() {
-	gl.BindFramebuffer(gl.FRAMEBUFFER, eb.FrameBuffer)
-	gl.Clear(gl.COLOR_BUFFER_BIT)
-	gl.Clear(gl.DEPTH_BUFFER_BIT)
+	dev := device.Get()
+
+	dev.BindFramebuffer(gl.FRAMEBUFFER, eb.FrameBuffer)
+	dev.Clear(gl.COLOR_BUFFER_BIT)
+	dev.Clear(gl.DEPTH_BUFFER_BIT)
+}
+
+// Delete frees the framebuffer and its attachments
+func (eb *EffectBuffers) Delete() {
+	dev := device.Get()
+
+	dev.DeleteFramebuffers(1, &eb.FrameBuffer)
+	dev.DeleteRenderbuffers(1, &eb.DepthRenderBuffer)
+	dev.DeleteTextures(1, &eb.RenderedTexture)
 }
 
-func (pc *PostControl) NewEffectBuffers(width, height int32, highPrecision bool) EffectBuffers {
+// NewEffectBuffers creates a framebuffer with a color texture and
+// a depth buffer, which effects can render into
+func (pc *PostControl) NewEffectBuffers(width, height int32, highPrecision bool) (EffectBuffers, error) {
+	dev := device.Get()
+
 	frameBuffer := uint32(0)
 	depthRenderBuffer := uint32(0)
 	renderedTexture := uint32(0)
 
 	// Generate frame buffer
-	gl.GenFramebuffers(1, &frameBuffer)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, frameBuffer)
+	dev.GenFramebuffers(1, &frameBuffer)
+	dev.BindFramebuffer(gl.FRAMEBUFFER, frameBuffer)
 
 	// Generate rendered texture
-	gl.GenTextures(1, &renderedTexture)
-	gl.BindTexture(gl.TEXTURE_2D, renderedTexture)
+	dev.GenTextures(1, &renderedTexture)
+	dev.BindTexture(gl.TEXTURE_2D, renderedTexture)
 
 	if highPrecision {
-		gl.TexImage2D(
+		dev.TexImage2D(
 			gl.TEXTURE_2D, 0, gl.RGBA16F,
 			width, height,
 			0, gl.RGBA, gl.FLOAT, gl.PtrOffset(0),
 		)
 	} else {
-		gl.TexImage2D(
+		dev.TexImage2D(
 			gl.TEXTURE_2D, 0, gl.RGB,
 			width, height,
 			0, gl.RGB, gl.UNSIGNED_BYTE, gl.PtrOffset(0),
 		)
 	}
 
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
 
 	// Generate depth buffer
-	gl.GenRenderbuffers(1, &depthRenderBuffer)
-	gl.BindRenderbuffer(gl.RENDERBUFFER, depthRenderBuffer)
-	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT, width, height)
-	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, depthRenderBuffer)
+	dev.GenRenderbuffers(1, &depthRenderBuffer)
+	dev.BindRenderbuffer(gl.RENDERBUFFER, depthRenderBuffer)
+	dev.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT, width, height)
+	dev.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, depthRenderBuffer)
 
 	// Configure framebuffer
-	gl.FramebufferTexture(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, renderedTexture, 0)
+	dev.FramebufferTexture(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, renderedTexture, 0)
 	drawBuffers := []uint32{gl.COLOR_ATTACHMENT0}
-	gl.DrawBuffers(1, &drawBuffers[0])
-
-	// Check for errors
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
-		panic("Framebuffer Invalid")
-	}
+	dev.DrawBuffers(1, &drawBuffers[0])
 
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	status := dev.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	dev.BindFramebuffer(gl.FRAMEBUFFER, 0)
 
-	return EffectBuffers{
+	eb := EffectBuffers{
 		FrameBuffer:       frameBuffer,
 		DepthRenderBuffer: depthRenderBuffer,
 		RenderedTexture:   renderedTexture,
+		Width:             width,
+		Height:            height,
 	}
-}
-
-func (pc *PostControl) NewDoubleEffectBuffers(width, height int32, highPrecision bool) (EffectBuffers, uint32) {
-	frameBuffer := uint32(0)
-	depthRenderBuffer := uint32(0)
-
-	renderedTexture1 := uint32(0)
-	renderedTexture2 := uint32(0)
-
-	// Generate frame buffer
-	gl.GenFramebuffers(1, &frameBuffer)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, frameBuffer)
 
-	// Generate rendered texture 1
-	//gl.ActiveTexture(gl.TEXTURE0)
-	gl.GenTextures(1, &renderedTexture1)
-	gl.BindTexture(gl.TEXTURE_2D, renderedTexture1)
-
-	if highPrecision {
-		gl.TexImage2D(
-			gl.TEXTURE_2D, 0, gl.RGBA16F,
-			width, height,
-			0, gl.RGBA, gl.FLOAT, gl.PtrOffset(0),
-		)
-	} else {
-		gl.TexImage2D(
-			gl.TEXTURE_2D, 0, gl.RGB,
-			width, height,
-			0, gl.RGB, gl.UNSIGNED_BYTE, gl.PtrOffset(0),
-		)
-	}
-
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-
-	// Generate rendered texture 2
-	//gl.ActiveTexture(gl.TEXTURE1)
-	gl.GenTextures(1, &renderedTexture2)
-	gl.BindTexture(gl.TEXTURE_2D, renderedTexture2)
-
-	if highPrecision {
-		gl.TexImage2D(
-			gl.TEXTURE_2D, 0, gl.RGBA16F,
-			width, height,
-			0, gl.RGBA, gl.FLOAT, gl.PtrOffset(0),
-		)
-	} else {
-		gl.TexImage2D(
-			gl.TEXTURE_2D, 0, gl.RGB,
-			width, height,
-			0, gl.RGB, gl.UNSIGNED_BYTE, gl.PtrOffset(0),
-		)
-	}
-
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-
-	// Generate depth buffer
-	gl.GenRenderbuffers(1, &depthRenderBuffer)
-	gl.BindRenderbuffer(gl.RENDERBUFFER, depthRenderBuffer)
-	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT, width, height)
-	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, depthRenderBuffer)
-
-	// Configure framebuffer
-	gl.FramebufferTexture(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, renderedTexture1, 0)
-	gl.FramebufferTexture(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT1, renderedTexture2, 0)
-	drawBuffers := []uint32{gl.COLOR_ATTACHMENT0, gl.COLOR_ATTACHMENT1}
-	gl.DrawBuffers(2, &drawBuffers[0])
-
-	// Check for errors
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
-		panic("Framebuffer Invalid")
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		eb.Delete()
+		return EffectBuffers{}, fmt.Errorf("effect framebuffer incomplete: 0x%x", status)
 	}
-
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
-
-	return EffectBuffers{
-		FrameBuffer:       frameBuffer,
-		DepthRenderBuffer: depthRenderBuffer,
-		RenderedTexture:   renderedTexture1,
-	}, renderedTexture2
+	return eb, nil
 }
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"testing"
+
+	"rapidengine/device"
+)
+
+// incompleteDevice is a null device whose framebuffers are never complete
+type incompleteDevice struct {
+	*device.NullDevice
+}
+
+func (d incompleteDevice) CheckFramebufferStatus(target uint32) uint32 {
+	return 0
+}
+
+func TestNewEffectBuffersIncomplete(t *testing.T) {
+	prev := device.Get()
+	null := device.NewNullDevice()
+	device.Set(incompleteDevice{null})
+	defer device.Set(prev)
+
+	pc := NewPostControl()
+	eb, err := pc.NewEffectBuffers(64, 32, true)
+	if err == nil {
+		t.Fatal("an incomplete framebuffer didn't fail")
+	}
+	if eb.FrameBuffer != 0 || eb.RenderedTexture != 0 {
+		t.Fatalf("returned buffers %+v for an incomplete framebuffer", eb)
+	}
+
+	deleted := map[string]int{}
+	for _, c := range null.Calls() {
+		switch c.Name {
+		case "DeleteFramebuffers", "DeleteRenderbuffers", "DeleteTextures":
+			deleted[c.Name]++
+		}
+	}
+	if deleted["DeleteFramebuffers"] != 1 || deleted["DeleteRenderbuffers"] != 1 || deleted["DeleteTextures"] != 1 {
+		t.Fatalf("deleted %v, want the framebuffer and both attachments", deleted)
+	}
+}
+
+func TestNewEffectBuffers(t *testing.T) {
+	prev := device.Get()
+	device.Set(device.NewNullDevice())
+	defer device.Set(prev)
+
+	pc := NewPostControl()
+	eb, err := pc.NewEffectBuffers(64, 32, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eb.FrameBuffer == 0 || eb.RenderedTexture == 0 || eb.Width != 64 || eb.Height != 32 {
+		t.Fatalf("buffers %+v", eb)
+	}
+}
@@ -0,0 +1,473 @@
+package cmd
+
+//   --------------------------------------------------
+//   Prefab_control.go contains prefabs, templates which
+//   children are instantiated from. Instances can override
+//   properties of their prefab, and edits to a prefab
+//   change the properties its instances haven't overridden.
+//   --------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"rapidengine/child"
+	"rapidengine/ecs"
+	"rapidengine/material"
+)
+
+// PrefabFile is the contents of a prefab file, which is
+// written and read like a scene file
+type PrefabFile struct {
+	Version int `json:"version"`
+
+	Textures  map[string]TextureData  `json:"textures,omitempty"`
+	Materials map[string]MaterialData `json:"materials,omitempty"`
+
+	Prefabs map[string]ChildData `json:"prefabs"`
+}
+
+type PrefabControl struct {
+	prefabs map[string]*Prefab
+
+	engine *Engine
+}
+
+func NewPrefabControl() PrefabControl {
+	return PrefabControl{
+		prefabs: make(map[string]*Prefab),
+	}
+}
+
+func (pc *PrefabControl) Initialize(engine *Engine) {
+	pc.engine = engine
+}
+
+// NewChildData returns the data of a child with default properties,
+// to build a prefab's template from
+func NewChildData(dimensions int) ChildData {
+	return ChildData{
+		Dimensions:  dimensions,
+		Orientation: [4]float32{0, 0, 0, 1},
+		Scale:       [3]float32{1, 1, 1},
+		Darkness:    1,
+	}
+}
+
+// NewPrefab registers a prefab built from a template, replacing any
+// prefab of the same name. Materials in the template are referred to
+// by the names they are registered under in the MaterialControl.
+func (pc *PrefabControl) NewPrefab(name string, template ChildData) *Prefab {
+	return pc.addPrefab(name, cloneChildData(&template), pc.newSource(&SceneFile{}))
+}
+
+// NewPrefabFromChild registers a prefab whose template is a child
+// and the children attached to it. Instances share its materials.
+func (pc *PrefabControl) NewPrefabFromChild(name string, c child.Child) (*Prefab, error) {
+	w := sceneWriter{
+		engine: pc.engine,
+		file: &SceneFile{
+			Textures:  make(map[string]TextureData),
+			Materials: make(map[string]MaterialData),
+		},
+		materials: make(map[material.Material]string),
+	}
+
+	template, err := w.child(c)
+	if err != nil {
+		return nil, err
+	}
+
+	source := pc.newSource(w.file)
+	for m, name := range w.materials {
+		source.materials[name] = m
+	}
+
+	return pc.addPrefab(name, template, source), nil
+}
+
+// GetPrefab returns the prefab registered under name
+func (pc *PrefabControl) GetPrefab(name string) (*Prefab, bool) {
+	p, ok := pc.prefabs[name]
+	return p, ok
+}
+
+// Names returns the names of the prefabs, sorted
+func (pc *PrefabControl) Names() []string {
+	names := make([]string, 0, len(pc.prefabs))
+	for name := range pc.prefabs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadPrefabs registers the prefabs in a file written by Prefab.Save,
+// in either format. Prefabs from the same file share its materials.
+func (pc *PrefabControl) LoadPrefabs(path string) error {
+	var f PrefabFile
+	if err := readSceneFile(path, &f, &f.Version); err != nil {
+		return err
+	}
+
+	source := pc.newSource(&SceneFile{
+		Textures:  f.Textures,
+		Materials: f.Materials,
+	})
+	for name, template := range f.Prefabs {
+		pc.addPrefab(name, template, source)
+	}
+	return nil
+}
+
+func (pc *PrefabControl) newSource(f *SceneFile) *sceneReader {
+	return newSceneReader(pc.engine, f)
+}
+
+func (pc *PrefabControl) addPrefab(name string, template ChildData, source *sceneReader) *Prefab {
+	templates := []ChildData{template}
+	upgradeChildren(templates)
+	template = templates[0]
+
+	p := &Prefab{
+		Name:     name,
+		template: template,
+		source:   source,
+		engine:   pc.engine,
+	}
+	pc.prefabs[name] = p
+	return p
+}
+
+//   --------------------------------------------------
+//   Prefabs
+//   --------------------------------------------------
+
+// ComponentFunc adds a component to the entity of a prefab instance
+type ComponentFunc func(w *ecs.World, e ecs.Entity)
+
+// Component returns a ComponentFunc which adds a copy of c
+func Component[T any](c T) ComponentFunc {
+	return func(w *ecs.World, e ecs.Entity) {
+		ecs.Add(w, e, c)
+	}
+}
+
+type Prefab struct {
+	Name string
+
+	// Components added to the entity of every new instance
+	Components []ComponentFunc
+
+	template  ChildData
+	instances []*PrefabInstance
+
+	// Materials and textures the template refers to
+	source *sceneReader
+
+	engine *Engine
+}
+
+// Template returns a copy of the prefab's template
+func (p *Prefab) Template() ChildData {
+	return cloneChildData(&p.template)
+}
+
+// Instances returns the prefab's live instances
+func (p *Prefab) Instances() []*PrefabInstance {
+	return p.instances
+}
+
+// Instantiate creates a child from the prefab, as an entity drawn in
+// scn. If override isn't nil, it's called with a copy of the template
+// to change, and the properties it changes are overridden.
+func (p *Prefab) Instantiate(scn *Scene, override func(d *ChildData)) (*PrefabInstance, error) {
+	data := cloneChildData(&p.template)
+	if override != nil {
+		override(&data)
+	}
+
+	c, err := p.source.child(&data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("instantiating prefab %s: %w", p.Name, err)
+	}
+
+	inst := &PrefabInstance{
+		Child:     c,
+		prefab:    p,
+		data:      data,
+		overrides: make(map[string]bool),
+	}
+	inst.markOverrides("", &p.template, &inst.data)
+
+	inst.Entity = p.engine.NewRenderEntity(c, scn)
+	for _, add := range p.Components {
+		add(p.engine.ECS, inst.Entity)
+	}
+
+	if scn.IsActive() {
+		for _, d := range appendTree(nil, c) {
+			d.Activate()
+		}
+	}
+
+	p.instances = append(p.instances, inst)
+	return inst, nil
+}
+
+// Edit calls f with the template to change, and sets the properties
+// it changes on every instance which hasn't overridden them. Edits
+// can't add or remove children. If an instance can't be changed,
+// the template and every instance are left as they were.
+func (p *Prefab) Edit(f func(d *ChildData)) error {
+	next := cloneChildData(&p.template)
+	f(&next)
+
+	if !sameStructure(&p.template, &next) {
+		return errors.New("prefab edits can't add, remove or change the dimensions of children")
+	}
+
+	old := p.template
+	p.template = next
+
+	edited := make([]ChildData, 0, len(p.instances))
+	for _, inst := range p.instances {
+		before := cloneChildData(&inst.data)
+		inst.inherit("", &old, &p.template, &inst.data)
+		if err := inst.update(&before, &inst.data, inst.Child); err != nil {
+			// Put back the template and the instances edited so far
+			p.template = old
+			inst.restore(before)
+			for j, data := range edited {
+				p.instances[j].restore(data)
+			}
+			return fmt.Errorf("editing prefab %s: %w", p.Name, err)
+		}
+		edited = append(edited, before)
+	}
+	return nil
+}
+
+// Save writes the prefab and the materials and textures it uses
+// to a file, as JSON if the path ends in .json
+func (p *Prefab) Save(path string) error {
+	return writeSceneFile(path, &PrefabFile{
+		Version:   SceneFileVersion,
+		Textures:  p.source.file.Textures,
+		Materials: p.source.file.Materials,
+		Prefabs:   map[string]ChildData{p.Name: p.template},
+	})
+}
+
+//   --------------------------------------------------
+//   Instances
+//   --------------------------------------------------
+
+// PrefabInstance is a child instantiated from a prefab. Properties
+// are named as in ChildData's JSON, and those of attached children
+// are prefixed with their path, such as "children.0.material".
+type PrefabInstance struct {
+	Child  child.Child
+	Entity ecs.Entity
+
+	prefab *Prefab
+
+	// The instance's properties, and which of them are overridden
+	data      ChildData
+	overrides map[string]bool
+}
+
+// Prefab returns the prefab the instance was created from
+func (inst *PrefabInstance) Prefab() *Prefab {
+	return inst.prefab
+}
+
+// Override calls f with the instance's properties to change,
+// and overrides the properties it changes
+func (inst *PrefabInstance) Override(f func(d *ChildData)) error {
+	next := cloneChildData(&inst.data)
+	f(&next)
+
+	if !sameStructure(&inst.data, &next) {
+		return errors.New("overrides can't add, remove or change the dimensions of children")
+	}
+
+	before := inst.data
+	inst.data = next
+	inst.markOverrides("", &before, &inst.data)
+
+	return inst.update(&before, &inst.data, inst.Child)
+}
+
+// Revert removes the override of a property, and sets it from the prefab
+func (inst *PrefabInstance) Revert(property string) error {
+	path, name := splitPropertyPath(property)
+
+	data, template := nodeAt(&inst.data, path), nodeAt(&inst.prefab.template, path)
+	if data == nil || template == nil {
+		return fmt.Errorf("prefab %s has no property %q", inst.prefab.Name, property)
+	}
+
+	for _, p := range childProperties {
+		if p.name != name {
+			continue
+		}
+
+		before := cloneChildData(&inst.data)
+		delete(inst.overrides, property)
+		copyProperty(p, data, template)
+		return inst.update(&before, &inst.data, inst.Child)
+	}
+	return fmt.Errorf("prefab %s has no property %q", inst.prefab.Name, property)
+}
+
+// IsOverridden reports whether the instance overrides a property
+func (inst *PrefabInstance) IsOverridden(property string) bool {
+	return inst.overrides[property]
+}
+
+// Overrides returns the properties the instance overrides, sorted
+func (inst *PrefabInstance) Overrides() []string {
+	names := make([]string, 0, len(inst.overrides))
+	for name := range inst.overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Destroy destroys the instance's behaviors, removes it from its
+// scene, its collision groups and links, and destroys its entity
+func (inst *PrefabInstance) Destroy() {
+	inst.prefab.engine.BehaviorControl.Destroy(inst.Child)
+
+	cc := &inst.prefab.engine.CollisionControl
+	for _, c := range appendTree(nil, inst.Child) {
+		for group := range cc.GroupMap {
+			cc.RemoveChildFromGroup(c, group)
+		}
+		cc.RemoveCollision(c)
+	}
+
+	inst.prefab.engine.ECS.Destroy(inst.Entity)
+
+	instances := inst.prefab.instances
+	for i, other := range instances {
+		if other == inst {
+			inst.prefab.instances = append(instances[:i], instances[i+1:]...)
+			break
+		}
+	}
+}
+
+// restore sets the instance's properties back to data after a failed
+// edit. They were set from data before, so setting them again can't fail.
+func (inst *PrefabInstance) restore(data ChildData) {
+	after := inst.data
+	inst.data = data
+	inst.update(&after, &inst.data, inst.Child)
+}
+
+// markOverrides overrides the properties which differ between
+// base and data. Children of a node whose children differ in
+// number are overridden as a whole.
+func (inst *PrefabInstance) markOverrides(path string, base, data *ChildData) {
+	for _, p := range changedProperties(base, data) {
+		inst.overrides[path+p.name] = true
+	}
+
+	if len(base.Children) != len(data.Children) {
+		inst.overrides[path+"children"] = true
+		return
+	}
+	for i := range data.Children {
+		inst.markOverrides(childPath(path, i), &base.Children[i], &data.Children[i])
+	}
+}
+
+// inherit copies the properties which differ between the old and
+// new template into data, unless the instance overrides them
+func (inst *PrefabInstance) inherit(path string, old, new, data *ChildData) {
+	for _, p := range changedProperties(old, new) {
+		if !inst.overrides[path+p.name] {
+			copyProperty(p, data, new)
+		}
+	}
+
+	if inst.overrides[path+"children"] {
+		return
+	}
+	for i := range data.Children {
+		inst.inherit(childPath(path, i), &old.Children[i], &new.Children[i], &data.Children[i])
+	}
+}
+
+// update sets the properties which differ between before and after
+// on the live child c and the children attached to it
+func (inst *PrefabInstance) update(before, after *ChildData, c child.Child) error {
+	for _, p := range changedProperties(before, after) {
+		if err := p.apply(inst.prefab.source, c, after); err != nil {
+			return err
+		}
+	}
+
+	live := c.GetNode().GetChildren()
+	if len(live) != len(after.Children) || len(before.Children) != len(after.Children) {
+		return nil
+	}
+	for i := range after.Children {
+		if err := inst.update(&before.Children[i], &after.Children[i], live[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameStructure reports whether a and b have children
+// of the same dimensions, in the same places
+func sameStructure(a, b *ChildData) bool {
+	if a.Dimensions != b.Dimensions || len(a.Children) != len(b.Children) {
+		return false
+	}
+	for i := range a.Children {
+		if !sameStructure(&a.Children[i], &b.Children[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func childPath(path string, i int) string {
+	return path + "children." + strconv.Itoa(i) + "."
+}
+
+// splitPropertyPath splits a property such as "children.0.material"
+// into the indices of the children leading to it and its name
+func splitPropertyPath(property string) ([]int, string) {
+	parts := strings.Split(property, ".")
+	path := []int{}
+
+	for len(parts) > 2 && parts[0] == "children" {
+		i, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, property
+		}
+		path = append(path, i)
+		parts = parts[2:]
+	}
+	return path, strings.Join(parts, ".")
+}
+
+// nodeAt returns the child data at a path of indices, or nil
+func nodeAt(d *ChildData, path []int) *ChildData {
+	for _, i := range path {
+		if i < 0 || i >= len(d.Children) {
+			return nil
+		}
+		d = &d.Children[i]
+	}
+	return d
+}
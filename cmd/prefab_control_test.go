@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"rapidengine/child"
+	"rapidengine/ecs"
+)
+
+// newTestPrefab registers a 2D prefab in a 2D engine with a current scene
+func newTestPrefab(t *testing.T) (*Engine, *Scene, *Prefab) {
+	e, _ := newTestEngine(t, 2)
+	scn := newTestScene(e)
+	e.Initialize()
+
+	template := NewChildData(2)
+	template.Material = "default1"
+	template.Position = [3]float32{1, 2, 0}
+
+	return e, scn, e.PrefabControl.NewPrefab("crate", template)
+}
+
+// newTestHierarchyPrefab registers a prefab of a child with
+// another attached to it, in a collision group
+func newTestHierarchyPrefab(t *testing.T) (*Engine, *Scene, *Prefab) {
+	e, scn, _ := newTestPrefab(t)
+
+	template := NewChildData(2)
+	template.Position = [3]float32{1, 2, 0}
+	template.CollisionGroups = []string{"enemies"}
+
+	attached := NewChildData(2)
+	attached.Position = [3]float32{5, 5, 0}
+	template.Children = []ChildData{attached}
+
+	return e, scn, e.PrefabControl.NewPrefab("enemy", template)
+}
+
+type prefabHealth struct {
+	HP int
+}
+
+func TestPrefabInstantiate(t *testing.T) {
+	e, scn, p := newTestHierarchyPrefab(t)
+	p.Components = []ComponentFunc{Component(prefabHealth{HP: 10})}
+
+	a, err := p.Instantiate(scn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := p.Instantiate(scn, func(d *ChildData) {
+		d.Position[0] = 100
+		d.Children[0].Material = "default2"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The scene draws the children attached to each instance as well
+	if len(p.Instances()) != 2 || len(scn.GetChildren()) != 4 {
+		t.Fatalf("%d instances and %d children in the scene, want 2 and 4", len(p.Instances()), len(scn.GetChildren()))
+	}
+	if !a.Child.IsActive() || !a.Child.GetNode().GetChildren()[0].IsActive() {
+		t.Fatal("an instance in an active scene isn't active")
+	}
+	if len(e.CollisionControl.GroupMap["enemies"]) != 2 {
+		t.Fatalf("%d children in the collision group, want 2", len(e.CollisionControl.GroupMap["enemies"]))
+	}
+	if hp := ecs.Get[prefabHealth](e.ECS, b.Entity); hp == nil || hp.HP != 10 {
+		t.Fatalf("instance has health %+v, want 10", hp)
+	}
+
+	// Only what the override changed is overridden
+	if want := []string{"children.0.material", "position"}; !reflect.DeepEqual(b.Overrides(), want) {
+		t.Fatalf("overrides %q, want %q", b.Overrides(), want)
+	}
+	if len(a.Overrides()) != 0 {
+		t.Fatalf("overrides %q without an override", a.Overrides())
+	}
+	if b.Child.GetX() != 100 || a.Child.GetX() != 1 {
+		t.Fatalf("instances at %v and %v, want 1 and 100", a.Child.GetX(), b.Child.GetX())
+	}
+
+	if _, err := p.Instantiate(scn, func(d *ChildData) { d.Material = "missing" }); err == nil {
+		t.Fatal("an instance with a missing material was created")
+	}
+	if len(p.Instances()) != 2 {
+		t.Fatal("a failed instance was added to the prefab")
+	}
+}
+
+func TestPrefabOverrides(t *testing.T) {
+	e, scn, p := newTestHierarchyPrefab(t)
+
+	a, err := p.Instantiate(scn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := p.Instantiate(scn, func(d *ChildData) {
+		d.Position[0] = 100
+		d.Children[0].Material = "default2"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Edits change what instances haven't overridden.
+	// b overrides its whole position, so keeps its y.
+	err = p.Edit(func(d *ChildData) {
+		d.Position = [3]float32{7, 8, 0}
+		d.Static = true
+		d.Children[0].Material = "default1"
+		d.Children[0].Position[1] = 9
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pa, pb := a.Child.(*child.Child2D), b.Child.(*child.Child2D)
+	ka := a.Child.GetNode().GetChildren()[0].(*child.Child2D)
+	kb := b.Child.GetNode().GetChildren()[0].(*child.Child2D)
+	if pa.X != 7 || pa.Y != 8 || !pa.Static || pb.X != 100 || pb.Y != 2 || !pb.Static {
+		t.Fatalf("instances at %v, %v and %v, %v after an edit, want 7, 8 and 100, 2", pa.X, pa.Y, pb.X, pb.Y)
+	}
+	if ka.Y != 9 || kb.Y != 9 {
+		t.Fatalf("attached children at %v and %v after an edit, want 9", ka.Y, kb.Y)
+	}
+	if ka.GetMaterial() != e.Renderer.DefaultMaterial1 || kb.GetMaterial() != e.Renderer.DefaultMaterial2 {
+		t.Fatal("an edit changed an overridden material, or didn't change one which wasn't")
+	}
+
+	// Reverting sets a property from the prefab again
+	if err := b.Revert("position"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Revert("children.0.material"); err != nil {
+		t.Fatal(err)
+	}
+	if pb.X != 7 || pb.Y != 8 || kb.GetMaterial() != e.Renderer.DefaultMaterial1 || len(b.Overrides()) != 0 {
+		t.Fatalf("reverted instance at %v with overrides %q", pb.X, b.Overrides())
+	}
+	if b.Revert("nope") == nil || b.Revert("children.3.material") == nil {
+		t.Fatal("reverting a property which doesn't exist didn't fail")
+	}
+
+	if err := a.Override(func(d *ChildData) { d.Gravity = 3 }); err != nil {
+		t.Fatal(err)
+	}
+	if pa.Gravity != 3 || !a.IsOverridden("gravity") {
+		t.Fatalf("overridden gravity %v, want 3", pa.Gravity)
+	}
+
+	// Neither edits nor overrides can change the hierarchy
+	if p.Edit(func(d *ChildData) { d.Children = nil }) == nil {
+		t.Fatal("an edit removed a child")
+	}
+	if a.Override(func(d *ChildData) { d.Children[0].Dimensions = 3 }) == nil {
+		t.Fatal("an override changed a child's dimensions")
+	}
+}
+
+func TestPrefabDestroy(t *testing.T) {
+	e, scn, p := newTestHierarchyPrefab(t)
+
+	a, err := p.Instantiate(scn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := p.Instantiate(scn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entities := e.ECS.Len()
+
+	b.Destroy()
+
+	if len(p.Instances()) != 1 || p.Instances()[0] != a {
+		t.Fatal("the destroyed instance is still in the prefab")
+	}
+	for _, c := range scn.GetChildren() {
+		if c == b.Child || c == b.Child.GetNode().GetChildren()[0] {
+			t.Fatal("the destroyed instance is still in the scene")
+		}
+	}
+	if len(scn.GetChildren()) != 2 {
+		t.Fatalf("%d children in the scene, want those of the other instance", len(scn.GetChildren()))
+	}
+	if e.ECS.Len() != entities-1 {
+		t.Fatalf("%d entities after destroying an instance, want %d", e.ECS.Len(), entities-1)
+	}
+	if len(e.CollisionControl.GroupMap["enemies"]) != 1 {
+		t.Fatal("the destroyed instance is still in its collision group")
+	}
+}
+
+func TestPrefabSaveAndLoad(t *testing.T) {
+	e, scn, p := newTestHierarchyPrefab(t)
+
+	for _, name := range []string{"enemy.json", "enemy.rpfb"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := p.Save(path); err != nil {
+			t.Fatal(err)
+		}
+
+		delete(e.PrefabControl.prefabs, "enemy")
+		if err := e.PrefabControl.LoadPrefabs(path); err != nil {
+			t.Fatal(err)
+		}
+		loaded, ok := e.PrefabControl.GetPrefab("enemy")
+		if !ok || !reflect.DeepEqual(loaded.Template(), p.Template()) {
+			t.Fatalf("%s loaded as %+v, want %+v", name, loaded, p.Template())
+		}
+	}
+
+	// Instances of a prefab made from a child share its materials
+	c := e.ChildControl.NewChild2D()
+	c.AttachMaterial(e.MaterialControl.NewBasicMaterial())
+	fp, err := e.PrefabControl.NewPrefabFromChild("from child", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst, err := fp.Instantiate(scn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inst.Child.(*child.Child2D).GetMaterial() != c.GetMaterial() {
+		t.Fatal("an instance of a prefab made from a child doesn't share its material")
+	}
+
+	if want := []string{"crate", "enemy", "from child"}; !reflect.DeepEqual(e.PrefabControl.Names(), want) {
+		t.Fatalf("prefabs %q, want %q", e.PrefabControl.Names(), want)
+	}
+}
+
+func TestPrefabEditRollsBack(t *testing.T) {
+	_, scn, p := newTestPrefab(t)
+
+	a, err := p.Instantiate(scn, func(d *ChildData) { d.Material = "default2" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := p.Instantiate(scn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := p.Template()
+	dataA, dataB := cloneChildData(&a.data), cloneChildData(&b.data)
+
+	// a overrides the material, so it's edited without trouble
+	// before b fails, after its position has been set
+	err = p.Edit(func(d *ChildData) {
+		d.Position[0] = 5
+		d.Material = "missing"
+	})
+	if err == nil {
+		t.Fatal("an edit to a missing material didn't fail")
+	}
+
+	if !reflect.DeepEqual(p.Template(), template) {
+		t.Fatalf("template changed to %+v by a failed edit", p.Template())
+	}
+	if !reflect.DeepEqual(a.data, dataA) || !reflect.DeepEqual(b.data, dataB) {
+		t.Fatal("instance properties changed by a failed edit")
+	}
+	for _, inst := range []*PrefabInstance{a, b} {
+		if x := inst.Child.GetX(); x != 1 {
+			t.Fatalf("instance moved to %v by a failed edit, want 1", x)
+		}
+	}
+
+	// The instances still follow later edits
+	if err := p.Edit(func(d *ChildData) { d.Position[0] = 7 }); err != nil {
+		t.Fatal(err)
+	}
+	if a.Child.GetX() != 7 || b.Child.GetX() != 7 {
+		t.Fatalf("instances at %v and %v after an edit, want 7", a.Child.GetX(), b.Child.GetX())
+	}
+}
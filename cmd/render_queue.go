@@ -0,0 +1,180 @@
+package cmd
+
+//   --------------------------------------------------
+//   Render_queue.go contains the render queue, which
+//   collects the children drawn in a view and orders
+//   them to keep state changes between draws down.
+//   Opaque 3D children are sorted by shader, material,
+//   texture and VAO, transparent ones from back to
+//   front, and 2D children keep the order they are in.
+//   --------------------------------------------------
+
+import (
+	"reflect"
+	"sort"
+
+	"rapidengine/child"
+	"rapidengine/material"
+)
+
+// QueueStats counts the children drawn in a frame,
+// and how often the state changed between them
+type QueueStats struct {
+	Items       int `json:"items"`
+	Opaque      int `json:"opaque"`
+	Transparent int `json:"transparent"`
+	Ordered     int `json:"ordered"`
+
+	ShaderSwitches   int `json:"shader_switches"`
+	MaterialSwitches int `json:"material_switches"`
+	TextureSwitches  int `json:"texture_switches"`
+	VAOSwitches      int `json:"vao_switches"`
+}
+
+// RenderQueue orders the children drawn in a view
+type RenderQueue struct {
+	opaque      []queueItem
+	transparent []queueItem
+	ordered     []queueItem
+
+	// Order materials are sorted in, by when they
+	// were first seen, as they have no ID of their own
+	materials map[material.Material]int
+
+	// The last child drawn, to count switches
+	last    queueItem
+	hasLast bool
+
+	stats     QueueStats
+	lastStats QueueStats
+}
+
+type queueItem struct {
+	child    child.Child
+	key      child.DrawKey
+	material int
+	distance float32
+}
+
+// NewRenderQueue creates an empty render queue
+func NewRenderQueue() *RenderQueue {
+	return &RenderQueue{
+		materials: make(map[material.Material]int),
+	}
+}
+
+// Submit adds a child to the queue. camX, camY and camZ
+// are the camera position transparent children sort by.
+func (q *RenderQueue) Submit(c child.Child, camX, camY, camZ float32) {
+	key := c.GetDrawKey()
+	item := queueItem{child: c, key: key, material: q.materialIndex(key.Material)}
+
+	if c.GetDimensions() == 2 {
+		q.ordered = append(q.ordered, item)
+		return
+	}
+
+	if key.Transparent {
+		p := c.GetWorldPosition()
+		dx, dy, dz := p[0]-camX, p[1]-camY, p[2]-camZ
+		item.distance = dx*dx + dy*dy + dz*dz
+
+		q.transparent = append(q.transparent, item)
+		return
+	}
+
+	q.opaque = append(q.opaque, item)
+}
+
+// materialIndex returns the order m sorts in. Materials which
+// can't be map keys are -1, which sorts last and always counts
+// as a switch.
+func (q *RenderQueue) materialIndex(m material.Material) int {
+	if m == nil || !reflect.TypeOf(m).Comparable() {
+		return -1
+	}
+
+	i, ok := q.materials[m]
+	if !ok {
+		i = len(q.materials)
+		q.materials[m] = i
+	}
+	return i
+}
+
+// Flush sorts the queue, draws every child through draw
+// and empties the queue for the next view
+func (q *RenderQueue) Flush(draw func(child.Child)) {
+	sort.SliceStable(q.opaque, func(i, j int) bool {
+		a, b := q.opaque[i], q.opaque[j]
+		if a.key.Shader != b.key.Shader {
+			return a.key.Shader < b.key.Shader
+		}
+		if a.material != b.material {
+			return uint(a.material) < uint(b.material)
+		}
+		if a.key.Texture != b.key.Texture {
+			return a.key.Texture < b.key.Texture
+		}
+		return a.key.VAO < b.key.VAO
+	})
+
+	sort.SliceStable(q.transparent, func(i, j int) bool {
+		return q.transparent[i].distance > q.transparent[j].distance
+	})
+
+	q.stats.Opaque += len(q.opaque)
+	q.stats.Transparent += len(q.transparent)
+	q.stats.Ordered += len(q.ordered)
+
+	for _, items := range [][]queueItem{q.opaque, q.transparent, q.ordered} {
+		for _, item := range items {
+			q.count(item)
+			draw(item.child)
+		}
+	}
+
+	q.opaque = q.opaque[:0]
+	q.transparent = q.transparent[:0]
+	q.ordered = q.ordered[:0]
+}
+
+func (q *RenderQueue) count(item queueItem) {
+	q.stats.Items++
+
+	last := q.last
+	if !q.hasLast || item.key.Shader != last.key.Shader {
+		q.stats.ShaderSwitches++
+	}
+	if !q.hasLast || item.material < 0 || item.material != last.material {
+		q.stats.MaterialSwitches++
+	}
+	if !q.hasLast || item.key.Texture != last.key.Texture {
+		q.stats.TextureSwitches++
+	}
+	if !q.hasLast || item.key.VAO != last.key.VAO {
+		q.stats.VAOSwitches++
+	}
+
+	q.last = item
+	q.hasLast = true
+}
+
+// EndFrame keeps the frame's stats and starts counting
+// the next frame. Materials are forgotten so the queue
+// doesn't hold on to materials which are no longer used.
+func (q *RenderQueue) EndFrame() {
+	q.lastStats = q.stats
+	q.stats = QueueStats{}
+	q.last = queueItem{}
+	q.hasLast = false
+
+	for m := range q.materials {
+		delete(q.materials, m)
+	}
+}
+
+// Stats returns the stats of the last complete frame
+func (q *RenderQueue) Stats() QueueStats {
+	return q.lastStats
+}
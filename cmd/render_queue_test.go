@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/child"
+	"rapidengine/material"
+)
+
+// keyChild is a child drawn with a given draw key
+type keyChild struct {
+	child.Child
+	key child.DrawKey
+}
+
+func (c keyChild) GetDrawKey() child.DrawKey {
+	return c.key
+}
+
+func newKeyChild3D(key child.DrawKey, z float32) keyChild {
+	c := child.NewChild3D(nil)
+	c.SetLocalMatrix(mgl32.Translate3D(0, 0, z))
+	return keyChild{Child: c, key: key}
+}
+
+func newKeyChild2D(key child.DrawKey) keyChild {
+	return keyChild{Child: child.NewChild2D(nil), key: key}
+}
+
+// flushNames flushes the queue, returning the
+// names of the children in the order drawn
+func flushNames(q *RenderQueue, names map[child.Child]string) []string {
+	drawn := []string{}
+	q.Flush(func(c child.Child) { drawn = append(drawn, names[c]) })
+	return drawn
+}
+
+func TestRenderQueueOrder(t *testing.T) {
+	q := NewRenderQueue()
+	m1, m2 := &material.BasicMaterial{}, &material.BasicMaterial{}
+
+	names := map[child.Child]string{}
+	submit := func(name string, c keyChild) {
+		names[c] = name
+		q.Submit(c, 0, 0, 0)
+	}
+
+	// 2D children keep their order, and are drawn last
+	submit("ui1", newKeyChild2D(child.DrawKey{Shader: 9}))
+	submit("ui2", newKeyChild2D(child.DrawKey{Shader: 1}))
+
+	// Transparent children are drawn from back to front
+	submit("glass near", newKeyChild3D(child.DrawKey{Shader: 1, Transparent: true}, -2))
+	submit("glass far", newKeyChild3D(child.DrawKey{Shader: 1, Transparent: true}, 10))
+
+	// Opaque children are sorted by shader, then material in the order
+	// they were first seen, then texture, then VAO. s2 is seen first,
+	// so m1 sorts before m2.
+	submit("s2", newKeyChild3D(child.DrawKey{Shader: 2, Material: m1}, 0))
+	submit("s1 m2", newKeyChild3D(child.DrawKey{Shader: 1, Material: m2, Texture: 1}, 0))
+	submit("s1 m1 vao2", newKeyChild3D(child.DrawKey{Shader: 1, Material: m1, Texture: 1, VAO: 2}, 0))
+	submit("s1 m1 t2", newKeyChild3D(child.DrawKey{Shader: 1, Material: m1, Texture: 2}, 0))
+	submit("s1 m1 vao1", newKeyChild3D(child.DrawKey{Shader: 1, Material: m1, Texture: 1, VAO: 1}, 0))
+	submit("s1 m1 vao1 again", newKeyChild3D(child.DrawKey{Shader: 1, Material: m1, Texture: 1, VAO: 1}, 0))
+
+	want := []string{
+		"s1 m1 vao1", "s1 m1 vao1 again", "s1 m1 vao2", "s1 m1 t2", "s1 m2", "s2",
+		"glass far", "glass near",
+		"ui1", "ui2",
+	}
+	if got := flushNames(q, names); !reflect.DeepEqual(got, want) {
+		t.Fatalf("drawn %q, want %q", got, want)
+	}
+
+	// The queue is empty after a flush
+	if got := flushNames(q, names); len(got) != 0 {
+		t.Fatalf("drawn %q by a second flush, want nothing", got)
+	}
+}
+
+func TestRenderQueueStats(t *testing.T) {
+	q := NewRenderQueue()
+	m1, m2 := &material.BasicMaterial{}, &material.BasicMaterial{}
+
+	for _, key := range []child.DrawKey{
+		{Shader: 1, Material: m1, Texture: 1, VAO: 1},
+		{Shader: 1, Material: m1, Texture: 1, VAO: 2},
+		{Shader: 1, Material: m2, Texture: 1, VAO: 2},
+		{Shader: 2, Material: m2, Texture: 3, VAO: 2},
+	} {
+		q.Submit(newKeyChild3D(key, 0), 0, 0, 0)
+	}
+	q.Submit(newKeyChild3D(child.DrawKey{Shader: 2, Transparent: true}, 0), 0, 0, 0)
+	q.Submit(newKeyChild2D(child.DrawKey{Shader: 2}), 0, 0, 0)
+	q.Flush(func(child.Child) {})
+
+	// Stats are only returned once the frame has ended
+	if q.Stats() != (QueueStats{}) {
+		t.Fatalf("stats %+v before the end of the frame", q.Stats())
+	}
+	q.EndFrame()
+
+	want := QueueStats{
+		Items:       6,
+		Opaque:      4,
+		Transparent: 1,
+		Ordered:     1,
+
+		ShaderSwitches:   2,
+		MaterialSwitches: 4,
+		TextureSwitches:  3,
+		VAOSwitches:      3,
+	}
+	if q.Stats() != want {
+		t.Fatalf("stats %+v, want %+v", q.Stats(), want)
+	}
+
+	// Materials are forgotten at the end of the frame
+	if len(q.materials) != 0 {
+		t.Fatalf("%d materials kept after the frame", len(q.materials))
+	}
+	q.EndFrame()
+	if q.Stats() != (QueueStats{}) {
+		t.Fatalf("stats %+v for an empty frame", q.Stats())
+	}
+}
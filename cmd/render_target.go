@@ -0,0 +1,183 @@
+package cmd
+
+//   --------------------------------------------------
+//   Render_target.go contains offscreen render targets.
+//   A view renders into a target, whose color and depth
+//   textures can then be used by any material, such as
+//   for minimaps, security camera monitors and portals.
+//   --------------------------------------------------
+
+import (
+	"fmt"
+
+	"rapidengine/device"
+	"rapidengine/material"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// RenderTarget is a framebuffer with a color and a depth texture
+type RenderTarget struct {
+	Name string
+
+	Width  int32
+	Height int32
+
+	// ScreenScale is the size of the target as a fraction of the
+	// screen, for targets which follow the window's size, or 0
+	// for targets with a fixed size
+	ScreenScale float32
+
+	FrameBuffer uint32
+
+	// Textures rendered into, which can be attached to materials.
+	// They stay valid when the target is resized.
+	Color *material.Texture
+	Depth *material.Texture
+
+	colorTexture uint32
+	depthTexture uint32
+}
+
+// NewRenderTarget creates a width by height render target
+func (textureControl *TextureControl) NewRenderTarget(name string, width, height int) (*RenderTarget, error) {
+	rt := &RenderTarget{
+		Name:   name,
+		Width:  int32(width),
+		Height: int32(height),
+	}
+	return rt, textureControl.addRenderTarget(rt)
+}
+
+// NewScreenRenderTarget creates a render target scale times the size of
+// the screen, which is recreated at that scale when the window is resized
+func (textureControl *TextureControl) NewScreenRenderTarget(name string, scale float32) (*RenderTarget, error) {
+	rt := &RenderTarget{
+		Name:        name,
+		Width:       int32(float32(textureControl.config.ScreenWidth) * scale),
+		Height:      int32(float32(textureControl.config.ScreenHeight) * scale),
+		ScreenScale: scale,
+	}
+	return rt, textureControl.addRenderTarget(rt)
+}
+
+func (textureControl *TextureControl) addRenderTarget(rt *RenderTarget) error {
+	if _, ok := textureControl.RenderTargets[rt.Name]; ok {
+		return fmt.Errorf("render target %s already exists", rt.Name)
+	}
+
+	rt.Color = &material.Texture{Name: rt.Name, Filter: "linear", Addr: &rt.colorTexture}
+	rt.Depth = &material.Texture{Name: rt.Name + "_depth", Filter: "linear", Addr: &rt.depthTexture}
+
+	if err := rt.create(); err != nil {
+		return err
+	}
+
+	textureControl.RenderTargets[rt.Name] = rt
+	return nil
+}
+
+// LookupRenderTarget returns the render target
+// registered under name, and whether it exists
+func (textureControl *TextureControl) LookupRenderTarget(name string) (*RenderTarget, bool) {
+	rt, ok := textureControl.RenderTargets[name]
+	return rt, ok
+}
+
+// DeleteRenderTarget frees a render target's framebuffer and textures
+func (textureControl *TextureControl) DeleteRenderTarget(name string) {
+	if rt, ok := textureControl.RenderTargets[name]; ok {
+		rt.delete()
+		delete(textureControl.RenderTargets, name)
+	}
+}
+
+// Resize recreates every screen sized render target at the current screen size
+func (textureControl *TextureControl) Resize() error {
+	for _, rt := range textureControl.RenderTargets {
+		if rt.ScreenScale == 0 {
+			continue
+		}
+
+		width := int(float32(textureControl.config.ScreenWidth) * rt.ScreenScale)
+		height := int(float32(textureControl.config.ScreenHeight) * rt.ScreenScale)
+		if err := rt.Resize(width, height); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resize recreates the target's framebuffer and textures at a new size
+func (rt *RenderTarget) Resize(width, height int) error {
+	rt.delete()
+	rt.Width, rt.Height = int32(width), int32(height)
+	return rt.create()
+}
+
+// Bind binds the target's framebuffer for drawing
+func (rt *RenderTarget) Bind() {
+	device.Get().BindFramebuffer(gl.FRAMEBUFFER, rt.FrameBuffer)
+}
+
+func (rt *RenderTarget) create() error {
+	dev := device.Get()
+
+	if rt.Width <= 0 || rt.Height <= 0 {
+		return fmt.Errorf("render target %s has invalid size %dx%d", rt.Name, rt.Width, rt.Height)
+	}
+
+	dev.GenFramebuffers(1, &rt.FrameBuffer)
+	dev.BindFramebuffer(gl.FRAMEBUFFER, rt.FrameBuffer)
+
+	// Color texture
+	dev.GenTextures(1, &rt.colorTexture)
+	dev.BindTexture(gl.TEXTURE_2D, rt.colorTexture)
+	dev.TexImage2D(
+		gl.TEXTURE_2D, 0, gl.RGBA,
+		rt.Width, rt.Height,
+		0, gl.RGBA, gl.UNSIGNED_BYTE, gl.PtrOffset(0),
+	)
+	dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+
+	// Depth texture
+	dev.GenTextures(1, &rt.depthTexture)
+	dev.BindTexture(gl.TEXTURE_2D, rt.depthTexture)
+	dev.TexImage2D(
+		gl.TEXTURE_2D, 0, gl.DEPTH_COMPONENT24,
+		rt.Width, rt.Height,
+		0, gl.DEPTH_COMPONENT, gl.FLOAT, gl.PtrOffset(0),
+	)
+	dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+
+	// Configure framebuffer
+	dev.FramebufferTexture(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, rt.colorTexture, 0)
+	dev.FramebufferTexture(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, rt.depthTexture, 0)
+	drawBuffers := []uint32{gl.COLOR_ATTACHMENT0}
+	dev.DrawBuffers(1, &drawBuffers[0])
+
+	status := dev.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	dev.BindTexture(gl.TEXTURE_2D, 0)
+	dev.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		rt.delete()
+		return fmt.Errorf("render target %s framebuffer incomplete: 0x%x", rt.Name, status)
+	}
+	return nil
+}
+
+func (rt *RenderTarget) delete() {
+	dev := device.Get()
+
+	dev.DeleteFramebuffers(1, &rt.FrameBuffer)
+	dev.DeleteTextures(1, &rt.colorTexture)
+	dev.DeleteTextures(1, &rt.depthTexture)
+	rt.FrameBuffer, rt.colorTexture, rt.depthTexture = 0, 0, 0
+}
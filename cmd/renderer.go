@@ -7,6 +7,9 @@ package cmd
 //   --------------------------------------------------
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
@@ -17,6 +20,8 @@ import (
 	"rapidengine/camera"
 	"rapidengine/child"
 	"rapidengine/configuration"
+	"rapidengine/device"
+	"rapidengine/event"
 	"rapidengine/input"
 	"rapidengine/material"
 	"rapidengine/terrain"
@@ -25,8 +30,16 @@ import (
 // Renderer contains the information required for
 // the main render loop
 type Renderer struct {
-	// GLFW Window object
-	Window *glfw.Window
+	// Window and graphics device
+	Window device.Window
+	Device device.RenderDevice
+
+	// Counts the draw calls made through Device
+	DrawCounter *device.CountingDevice
+
+	// Skips redundant state changes made through Device.
+	// Code which calls OpenGL directly must invalidate it.
+	State *device.StateCache
 
 	// Current shader program
 	ShaderProgram uint32
@@ -41,6 +54,24 @@ type Renderer struct {
 	// Scene Camera
 	MainCamera camera.Camera
 
+	// Views rendered every frame, in order. The first
+	// is the main view, which renders through MainCamera.
+	Views       []*View
+	mainView    *View
+	currentView *View
+
+	// Aspect ratio of the view being rendered,
+	// 0 while the whole screen is rendered
+	aspect float32
+
+	// Orders the children drawn in each view
+	Queue *RenderQueue
+
+	// Render passes run every frame
+	FrameGraph *FrameGraph
+	graphError string
+	forcing    bool
+
 	// Current camera position
 	camX float32
 	camY float32
@@ -49,6 +80,10 @@ type Renderer struct {
 	// Render Distance
 	RenderDistance float32
 
+	// Skip 3D children outside of the view frustum
+	FrustumCulling bool
+	culling        culler
+
 	// Skybox
 	SkyBoxEnabled bool
 	SkyBox        *terrain.SkyBox
@@ -66,6 +101,12 @@ type Renderer struct {
 	MinFrameTime   float64
 	TotalFrameTime float64
 
+	// Fixed timestep. Alpha is how far the current frame
+	// lies between the last two ticks, in [0, 1).
+	TickTime    float64
+	Alpha       float32
+	accumulator float64
+
 	// Termination Channel
 	Done chan bool
 
@@ -79,49 +120,47 @@ func (renderer *Renderer) StartRenderer() {
 		defer profile.Start().Stop()
 	}
 
-	gl.ClearColor(float32(0)/255, float32(0)/255, float32(0)/255, 1)
+	renderer.Device.ClearColor(float32(0)/255, float32(0)/255, float32(0)/255, 1)
+
+	renderer.engine.Events.Dispatch(event.EngineStarted{})
 
 	// Render loop
 	for !renderer.Window.ShouldClose() {
 		renderer.renderFrame()
 	}
 
+	renderer.engine.Events.Dispatch(event.EngineStopped{})
+
 	renderer.Config.Logger.Info("Terminating...")
-	glfw.Terminate()
+	renderer.engine.SystemControl.Shutdown()
+	renderer.Window.Terminate()
 	renderer.Done <- true
 }
 
 // RenderFrame renders a single frame to the screen
 func (renderer *Renderer) renderFrame() {
-	renderer.engine.PostControl.UpdateFrameBuffers()
+	// Apply any window resize before drawing
+	renderer.engine.applyPendingResize()
 
-	// Render skybox
-	if renderer.SkyBoxEnabled {
-		renderer.SkyBox.Render(renderer.MainCamera)
-	}
-
-	if renderer.Config.Blending {
-		renderer.EnableBlending()
-	}
+	// Run the simulation up to the current time
+	renderer.simulate()
 
-	// Render children
-	renderer.RenderChildren()
+	renderer.engine.SystemControl.Run(PhasePreRender, renderer.DeltaFrameTime)
 
-	// Call user render loop
-	renderer.RenderFunc(renderer)
+	// Render the scene and post processing
+	renderer.renderGraph()
+	renderer.endFrameStats()
 
-	// Update camera
-	renderer.MainCamera.Look(renderer.DeltaFrameTime)
-	renderer.camX, renderer.camY, renderer.camZ = renderer.MainCamera.GetPosition()
-
-	// Post processing update
-	renderer.engine.PostControl.Update()
+	renderer.engine.SystemControl.Run(PhasePostRender, renderer.DeltaFrameTime)
 
 	// Update window buffers
 	renderer.Window.SwapBuffers()
 
+	// Dispatch the frame's events
+	renderer.engine.Events.Flush()
+
 	// Frame logic
-	renderer.TotalFrameTime = glfw.GetTime()
+	renderer.TotalFrameTime = renderer.Window.GetTime()
 	renderer.DeltaFrameTime = renderer.TotalFrameTime - renderer.LastFrameTime
 	renderer.LastFrameTime = renderer.TotalFrameTime
 
@@ -131,34 +170,154 @@ func (renderer *Renderer) renderFrame() {
 	}
 }
 
+// simulate advances the fixed timestep simulation by the
+// time the last frame took, in steps of TickTime. At most
+// MaxTicksPerFrame steps are run, and any time left over after
+// that is dropped so a slow frame can't snowball into slower ones.
+func (renderer *Renderer) simulate() {
+	renderer.accumulator += renderer.DeltaFrameTime
+
+	ticks := 0
+	for renderer.accumulator >= renderer.TickTime {
+		if ticks >= renderer.Config.MaxTicksPerFrame {
+			renderer.accumulator = math.Mod(renderer.accumulator, renderer.TickTime)
+			break
+		}
+
+		renderer.engine.FixedUpdate(renderer.TickTime)
+
+		renderer.accumulator -= renderer.TickTime
+		ticks++
+	}
+
+	renderer.Alpha = float32(renderer.accumulator / renderer.TickTime)
+}
+
 // ForceUpdate forces a frame render
 func (renderer *Renderer) ForceUpdate() {
-	renderer.engine.PostControl.UpdateFrameBuffers()
-	renderer.RenderChildren()
-	renderer.engine.PostControl.Update()
+	renderer.forcing = true
+	renderer.renderGraph()
+	renderer.forcing = false
+	renderer.endFrameStats()
+
 	renderer.engine.TextControl.Update()
 
 	renderer.Window.SwapBuffers()
 }
 
+// endFrameStats keeps the stats of the frame
+// and starts counting the next one
+func (renderer *Renderer) endFrameStats() {
+	renderer.Queue.EndFrame()
+
+	renderer.culling.lastStats = renderer.culling.stats
+	renderer.culling.stats = CullStats{}
+}
+
+// renderGraph runs the frame graph. If the graph can't be
+// compiled, the scene is rendered straight to the screen.
+func (renderer *Renderer) renderGraph() {
+	err := renderer.FrameGraph.Execute()
+	if err == nil {
+		renderer.graphError = ""
+		return
+	}
+
+	// Only log each new error, rather than every frame
+	if err.Error() != renderer.graphError {
+		renderer.graphError = err.Error()
+		renderer.Config.Logger.Error("frame graph: ", err)
+	}
+
+	// A pass failed, but the rest of the frame was drawn
+	var passErr *PassError
+	if errors.As(err, &passErr) {
+		return
+	}
+
+	renderer.Device.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	renderer.Device.Clear(gl.COLOR_BUFFER_BIT)
+	renderer.Device.Clear(gl.DEPTH_BUFFER_BIT)
+	renderer.renderScene(0)
+}
+
+// renderScene renders the skybox and children of every
+// view, and calls the user render loop. Views without a
+// target render into the screen framebuffer.
+func (renderer *Renderer) renderScene(screen uint32) {
+	if renderer.Config.Dimensions == 3 {
+		renderer.Device.Enable(gl.DEPTH_TEST)
+	}
+
+	if renderer.forcing {
+		renderer.RenderChildren()
+		return
+	}
+
+	renderer.renderViews(screen)
+
+	// Call user render loop
+	renderer.RenderFunc(renderer)
+
+	// Update cameras
+	renderer.lookCameras()
+}
+
 // RenderChildren binds the appropriate shaders and Vertex Array for each child,
-// or child copy, and draws them to the screen using an element buffer
+// or child copy, and draws them to the screen using an element buffer. Inside
+// a view, only the children of the view's scene on its layers are rendered.
+// Children are drawn in the order of the render queue, and 3D
+// children outside of the camera's view frustum are skipped.
 func (renderer *Renderer) RenderChildren() {
-	if renderer.engine.SceneControl.GetCurrentScene().IsAutomaticRendering() {
-		for _, child := range renderer.engine.SceneControl.GetCurrentChildren() {
-			go child.RemoveCurrentCopies()
-			if !child.CheckCopyingEnabled() {
-				renderer.RenderChild(child)
-			} else {
-				renderer.RenderChildCopies(child)
+	scene := renderer.engine.SceneControl.GetCurrentScene()
+	mask := child.AllLayers
+	if renderer.currentView != nil {
+		mask = renderer.currentView.LayerMask
+		if renderer.currentView.Scene != nil {
+			scene = renderer.currentView.Scene
+		}
+	}
+
+	if scene.IsAutomaticRendering() {
+		renderer.beginCulling()
+
+		for _, c := range scene.GetChildren() {
+			if !c.GetLayer().InMask(mask) {
+				continue
 			}
+			if c3, ok := c.(*child.Child3D); ok && !c.CheckCopyingEnabled() {
+				c3.Interpolate(renderer.Alpha)
+				if !renderer.childVisible(c3) {
+					continue
+				}
+			}
+
+			go c.RemoveCurrentCopies()
+			renderer.Queue.Submit(c, renderer.camX, renderer.camY, renderer.camZ)
 		}
+
+		renderer.Queue.Flush(renderer.drawChild)
+	}
+}
+
+func (renderer *Renderer) drawChild(c child.Child) {
+	if !c.CheckCopyingEnabled() {
+		renderer.RenderChild(c)
+	} else {
+		renderer.RenderChildCopies(c)
 	}
 }
 
 // RenderChild renders a single child to the screen
 func (renderer *Renderer) RenderChild(c child.Child) {
-	c.Update(renderer.MainCamera, renderer.DeltaFrameTime, renderer.TotalFrameTime)
+	c.Interpolate(renderer.Alpha)
+
+	// 3D children are projected to the view being rendered
+	if c3, ok := c.(*child.Child3D); ok {
+		c3.Render(renderer.CurrentCamera(), renderer.projection(c3), renderer.TotalFrameTime)
+		return
+	}
+	c.Update(renderer.CurrentCamera(), renderer.DeltaFrameTime, renderer.TotalFrameTime)
 }
 
 // RenderChildCopies renders all copies of a child
@@ -179,14 +338,21 @@ func (renderer *Renderer) RenderCopy(c child.Child, cpy child.ChildCopy) {
 		if (c.GetSpecificRenderDistance() != 0 && InBounds2D(cpy.X, cpy.Y, float32(renderer.camX), float32(renderer.camY), c.GetSpecificRenderDistance())) ||
 			InBounds2D(cpy.X, cpy.Y, float32(renderer.camX), float32(renderer.camY), renderer.RenderDistance) {
 
-			c.RenderCopy(cpy, renderer.MainCamera)
+			c.RenderCopy(cpy, renderer.CurrentCamera())
 			c.AddCurrentCopy(cpy)
 		}
 	}
 
 	if renderer.Config.Dimensions == 3 {
-		if InBounds3D(cpy.X, cpy.Y, cpy.Z, float32(renderer.camX), float32(renderer.camY), float32(renderer.camZ), renderer.RenderDistance) {
-			c.RenderCopy(cpy, renderer.MainCamera)
+		visible := false
+		if c3, ok := c.(*child.Child3D); ok {
+			visible = renderer.copyVisible(c3, cpy)
+		} else {
+			visible = InBounds3D(cpy.X, cpy.Y, cpy.Z, float32(renderer.camX), float32(renderer.camY), float32(renderer.camZ), renderer.RenderDistance)
+		}
+
+		if visible {
+			c.RenderCopy(cpy, renderer.CurrentCamera())
 
 			c.AddCurrentCopy(cpy)
 		}
@@ -224,33 +390,64 @@ func InBounds3D(x, y, z, camX, camY, camZ, renderDistance float32) bool {
 
 // NewRenderer creates a new renderer, and takes in a renderFunc which
 // is called every frame, allowing the User to have frame-by-frame control
-func NewRenderer(camera camera.Camera, config *configuration.EngineConfig) Renderer {
-	win := InitGLFW(config)
+func NewRenderer(camera camera.Camera, config *configuration.EngineConfig) (Renderer, error) {
+	win, err := InitGLFW(config)
+	if err != nil {
+		return Renderer{}, err
+	}
+	return NewRendererWithDevice(camera, config, device.NewGLDevice(), device.NewGLFWWindow(win))
+}
+
+// NewRendererWithDevice creates a new renderer which draws
+// through dev and presents to win, and makes dev the current device
+func NewRendererWithDevice(camera camera.Camera, config *configuration.EngineConfig, dev device.RenderDevice, win device.Window) (Renderer, error) {
+	counter := device.NewCountingDevice(dev)
+	cache := device.NewStateCache(counter)
+	device.Set(cache)
+
 	s := uint32(0)
 	r := Renderer{
 		Window:         win,
+		Device:         cache,
+		DrawCounter:    counter,
+		State:          cache,
 		ShaderProgram:  s,
 		RenderFunc:     func(r *Renderer) {},
 		RenderDistance: 1000,
+		FrustumCulling: true,
 		MinFrameTime:   1 / float64(config.MaxFPS),
+		TickTime:       1 / float64(config.TickRate),
+		Queue:          NewRenderQueue(),
 		Done:           make(chan bool),
 		MainCamera:     camera,
 		Config:         config,
 	}
 
+	// The main view covers the screen, which is
+	// already cleared at the start of the frame
+	r.mainView = NewView("main", nil, FullViewport)
+	r.mainView.ClearColor = false
+	r.mainView.ClearDepth = false
+	r.AddView(r.mainView)
+
 	r.Window.SetCursorPosCallback(input.MouseCallback)
 	r.Window.SetMouseButtonCallback(input.MouseButtonCallback)
 	r.Window.SetScrollCallback(input.ScrollCallback)
 
-	gl.Init()
+	if err := dev.Init(); err != nil {
+		return Renderer{}, fmt.Errorf("initializing render device: %w", err)
+	}
 
-	return r
+	return r, nil
 }
 
-func (renderer *Renderer) Initialize(engine *Engine) {
+func (renderer *Renderer) Initialize(engine *Engine) error {
 	renderer.engine = engine
+	renderer.FrameGraph = NewFrameGraph(renderer)
 
-	engine.TextureControl.NewTexture("../rapidengine/assets/abstract.jpg", "default", "linear")
+	if err := engine.TextureControl.NewTexture("abstract.jpg", "default", "linear"); err != nil {
+		return err
+	}
 
 	dm1 := renderer.engine.MaterialControl.NewBasicMaterial()
 	dm1.Hue = [4]float32{46, 49, 49, 255}
@@ -260,6 +457,8 @@ func (renderer *Renderer) Initialize(engine *Engine) {
 
 	renderer.DefaultMaterial1 = dm1
 	renderer.DefaultMaterial2 = dm2
+
+	return nil
 }
 
 // AttachCallback attaches a callback function to the renderer,
@@ -268,15 +467,19 @@ func (renderer *Renderer) AttachCallback(f func(*Renderer)) {
 	renderer.RenderFunc = f
 }
 
-func InitGLFW(config *configuration.EngineConfig) *glfw.Window {
+func InitGLFW(config *configuration.EngineConfig) (*glfw.Window, error) {
 	if err := glfw.Init(); err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("initializing glfw: %w", err)
 	}
 
 	glfw.WindowHint(glfw.Samples, 4)
 	glfw.WindowHint(glfw.ContextVersionMajor, 4)
 	glfw.WindowHint(glfw.ContextVersionMinor, 1)
-	glfw.WindowHint(glfw.Resizable, glfw.False)
+	if config.Resizable {
+		glfw.WindowHint(glfw.Resizable, glfw.True)
+	} else {
+		glfw.WindowHint(glfw.Resizable, glfw.False)
+	}
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
 	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
 	glfw.WindowHint(glfw.OpenGLDebugContext, glfw.True)
@@ -290,7 +493,8 @@ func InitGLFW(config *configuration.EngineConfig) *glfw.Window {
 
 	window, err := glfw.CreateWindow(config.ScreenWidth, config.ScreenHeight, config.WindowTitle, m, nil)
 	if err != nil {
-		log.Fatal(err)
+		glfw.Terminate()
+		return nil, fmt.Errorf("creating window: %w", err)
 	}
 
 	if config.Dimensions == 3 {
@@ -307,74 +511,78 @@ func InitGLFW(config *configuration.EngineConfig) *glfw.Window {
 		glfw.SwapInterval(0)
 	}
 
-	return window
+	return window, nil
 }
 
-func InitOpenGL(config *configuration.EngineConfig) uint32 {
-	if err := gl.Init(); err != nil {
-		log.Fatal(err)
+func InitOpenGL(config *configuration.EngineConfig) (uint32, error) {
+	dev := device.Get()
+
+	if err := dev.Init(); err != nil {
+		return 0, fmt.Errorf("initializing render device: %w", err)
 	}
 
-	version := gl.GoStr(gl.GetString(gl.VERSION))
+	version := dev.GetString(gl.VERSION)
 	log.Info("Using OpenGL Version ", version)
 
 	if config.PolygonLines {
-		gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+		dev.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
 	}
 
-	gl.Enable(gl.BLEND)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	dev.Enable(gl.BLEND)
+	dev.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 
 	if config.Dimensions == 3 {
-		gl.Enable(gl.DEPTH_TEST)
-		gl.Disable(gl.CULL_FACE)
+		dev.Enable(gl.DEPTH_TEST)
+		dev.Disable(gl.CULL_FACE)
 	} else {
-		gl.Disable(gl.DEPTH_TEST)
+		dev.Disable(gl.DEPTH_TEST)
 	}
 
 	if config.GammaCorrection {
-		gl.Enable(gl.FRAMEBUFFER_SRGB)
+		dev.Enable(gl.FRAMEBUFFER_SRGB)
 	}
 
 	if config.AntiAliasing {
-		gl.Enable(gl.MULTISAMPLE)
+		dev.Enable(gl.MULTISAMPLE)
 	}
 
-	return 0
+	return 0, nil
 }
 
 func (renderer *Renderer) ResetOpenGL(config *configuration.EngineConfig) {
+	dev := renderer.Device
+
 	if config.PolygonLines {
-		gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+		dev.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
 	}
 
-	gl.Enable(gl.BLEND)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	dev.Enable(gl.BLEND)
+	dev.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 
 	if config.Dimensions == 3 {
-		gl.Enable(gl.DEPTH_TEST)
-		gl.Disable(gl.CULL_FACE)
+		dev.Enable(gl.DEPTH_TEST)
+		dev.Disable(gl.CULL_FACE)
 	} else {
-		gl.Disable(gl.DEPTH_TEST)
+		dev.Disable(gl.DEPTH_TEST)
 	}
 
 	if config.GammaCorrection {
-		gl.Enable(gl.FRAMEBUFFER_SRGB)
+		dev.Enable(gl.FRAMEBUFFER_SRGB)
 	}
 
 	if config.AntiAliasing {
-		gl.Enable(gl.MULTISAMPLE)
+		dev.Enable(gl.MULTISAMPLE)
 	}
 }
 
 func (renderer *Renderer) EnablePolygonLines() {
 	renderer.engine.Config.PolygonLines = true
-	gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+	renderer.Device.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
 }
 
 func (renderer *Renderer) DisablePolygonLines() {
 	renderer.engine.Config.PolygonLines = false
-	gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
+	renderer.Device.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
 }
 
 func (renderer *Renderer) CheckPolygonLines() bool {
@@ -382,12 +590,12 @@ func (renderer *Renderer) CheckPolygonLines() bool {
 }
 
 func (renderer *Renderer) EnableBlending() {
-	gl.Enable(gl.BLEND)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	renderer.Device.Enable(gl.BLEND)
+	renderer.Device.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 }
 
 func (renderer *Renderer) DisableBlending() {
-	gl.Disable(gl.BLEND)
+	renderer.Device.Disable(gl.BLEND)
 }
 
 // SetRenderDistance sets the render distance
@@ -396,17 +604,17 @@ func (renderer *Renderer) SetRenderDistance(distance float32) {
 }
 
 func (renderer *Renderer) DisableCursor() {
-	renderer.Window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+	renderer.Window.SetCursorEnabled(false)
 }
 
 func (renderer *Renderer) EnableCursor() {
-	renderer.Window.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+	renderer.Window.SetCursorEnabled(true)
 }
 
 // CheckError decodes the various unhelpful error codes
 // which OpenGL sometimes creates
 func CheckError(tag string) {
-	if err := gl.GetError(); err != 0 {
+	if err := device.Get().GetError(); err != 0 {
 		var errString = ""
 		switch err {
 		case 0:
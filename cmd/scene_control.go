@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"time"
+
 	"rapidengine/child"
+	"rapidengine/event"
 	"rapidengine/ui"
 )
 
@@ -10,17 +13,34 @@ type SceneControl struct {
 
 	scenes []*Scene
 
+	// UploadBudget is the time spent each frame on the main
+	// thread uploading the assets of scenes loaded by LoadSceneAsync
+	UploadBudget time.Duration
+
+	// Scenes being loaded, and the transition in progress
+	loads      []*SceneLoad
+	transition *sceneTransition
+
 	engine *Engine
 }
 
 func NewSceneControl() SceneControl {
-	return SceneControl{}
+	return SceneControl{
+		UploadBudget: DefaultUploadBudget,
+	}
 }
 
 func (sc *SceneControl) Initialize(engine *Engine) {
 	sc.engine = engine
 }
 
+// Update advances the scenes being loaded in the
+// background, and the transition in progress
+func (sc *SceneControl) Update(delta float64) {
+	sc.updateLoads()
+	sc.updateTransition(delta)
+}
+
 func (sc *SceneControl) InstanceScene(scn *Scene) {
 	sc.scenes = append(sc.scenes, scn)
 }
@@ -34,15 +54,36 @@ func (sc *SceneControl) PreRenderChildren() {
 }
 
 func (sc *SceneControl) SetCurrentScene(scn *Scene) {
+	previous := ""
+	if sc.currentScene != nil {
+		previous = sc.currentScene.ID
+	}
+
 	sc.ClearActivation()
 	sc.currentScene = scn
 	sc.currentScene.Activate()
+
+	sc.engine.Events.Publish(event.SceneChanged{Previous: previous, Current: scn.ID})
 }
 
 func (sc *SceneControl) GetCurrentScene() *Scene {
 	return sc.currentScene
 }
 
+// childRemoved destroys the behaviors of a child removed
+// from a scene, unless it is still instanced in another
+func (sc *SceneControl) childRemoved(c child.Child) {
+	if sc.currentScene != nil && sc.currentScene.contains(c) {
+		return
+	}
+	for _, scn := range sc.scenes {
+		if scn.contains(c) {
+			return
+		}
+	}
+	sc.engine.BehaviorControl.Destroy(c)
+}
+
 func (sc *SceneControl) GetCurrentChildren() []child.Child {
 	return sc.currentScene.GetChildren()
 }
@@ -63,30 +104,93 @@ type Scene struct {
 	children []child.Child
 	texts    []*ui.TextBox
 
+	// The children instanced in the scene
+	members map[child.Child]bool
+
+	// UI elements instanced in the scene
+	elements []ui.Element
+
 	subscenes []*Scene
 
+	// The scene this is a subscene of, if any
+	parent *Scene
+
 	active bool
 
+	// The scene control the scene was created by, which
+	// tears down the behaviors of the children it removes
+	control *SceneControl
+
+	// Index of the scene's children by name, tag and position
+	index sceneIndex
+
 	automaticRendering bool
 }
 
 func (sc *SceneControl) NewScene(id string) *Scene {
-	s := &Scene{
+	s := newScene(id)
+	sc.adopt(s)
+	return s
+}
+
+// adopt sets up a scene made by newScene to be used by the engine
+func (sc *SceneControl) adopt(s *Scene) {
+	s.control = sc
+
+	if sc.engine.Config.ShowFPS {
+		s.InstanceText(sc.engine.FPSBox)
+	}
+}
+
+func newScene(id string) *Scene {
+	return &Scene{
 		ID:                 id,
 		automaticRendering: true,
 		active:             true,
 		texts:              []*ui.TextBox{},
+		index:              newSceneIndex(),
 	}
+}
 
-	if sc.engine.Config.ShowFPS {
-		s.InstanceText(sc.engine.FPSBox)
+// InstanceChild adds a child to the scene, along with the
+// children attached to it, and their children
+func (s *Scene) InstanceChild(c child.Child) {
+	if s.members == nil {
+		s.members = make(map[child.Child]bool)
 	}
+	s.children = append(s.children, c)
+	s.members[c] = true
+	s.changed()
+}
 
-	return s
+// RemoveChild removes a child instanced in the scene. Unless the
+// child is still in another scene, its behaviors are destroyed.
+func (s *Scene) RemoveChild(c child.Child) {
+	for i, sc := range s.children {
+		if sc == c {
+			s.children = append(s.children[:i], s.children[i+1:]...)
+			delete(s.members, c)
+			s.changed()
+
+			if s.control != nil {
+				s.control.childRemoved(c)
+			}
+			return
+		}
+	}
 }
 
-func (s *Scene) InstanceChild(c child.Child) {
-	s.children = append(s.children, c)
+// contains reports whether a child is instanced in the scene or its subscenes
+func (s *Scene) contains(c child.Child) bool {
+	if s.members[c] {
+		return true
+	}
+	for _, scn := range s.subscenes {
+		if scn.contains(c) {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *Scene) InstanceText(t *ui.TextBox) {
@@ -95,10 +199,16 @@ func (s *Scene) InstanceText(t *ui.TextBox) {
 
 func (s *Scene) InstanceSubscene(scn *Scene) {
 	s.subscenes = append(s.subscenes, scn)
+	scn.parent = s
+	if scn.control == nil {
+		scn.control = s.control
+	}
+	s.changed()
 }
 
 func (s *Scene) Activate() {
 	s.active = true
+	s.changed()
 	for _, c := range s.GetChildren() {
 		c.Activate()
 	}
@@ -112,16 +222,46 @@ func (s *Scene) Deactivate() {
 		scn.Deactivate()
 	}
 	s.active = false
+	s.changed()
+}
+
+// changed drops the indexes of the scene and the scenes it is a
+// subscene of when the children they return have changed
+func (s *Scene) changed() {
+	for scn := s; scn != nil; scn = scn.parent {
+		scn.index.reset()
+	}
 }
 
 func (s *Scene) IsActive() bool {
 	return s.active
 }
 
+// GetChildren returns the children of the scene and its active
+// subscenes, with each child followed by its descendants. The
+// list is kept until they change, and mustn't be modified.
 func (s *Scene) GetChildren() []child.Child {
+	idx := &s.index
+	if !idx.listed {
+		idx.children = s.listChildren()
+		idx.listed = true
+		idx.watch(idx.children)
+	}
+
+	// Appending to the list copies it
+	return idx.children[:len(idx.children):len(idx.children)]
+}
+
+// listChildren walks the scene for GetChildren
+func (s *Scene) listChildren() []child.Child {
 	children := []child.Child{}
 	if s.active {
-		children = s.children
+		for _, c := range s.children {
+			// Children of another child in the scene come with it
+			if !s.hasAncestor(c) {
+				children = appendTree(children, c)
+			}
+		}
 	}
 	for _, scn := range s.subscenes {
 		if scn.active {
@@ -131,6 +271,24 @@ func (s *Scene) GetChildren() []child.Child {
 	return children
 }
 
+func (s *Scene) hasAncestor(c child.Child) bool {
+	for p := c.GetNode().GetParent(); p != nil; p = p.GetNode().GetParent() {
+		if s.members[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// appendTree appends c and its descendants, parents first
+func appendTree(children []child.Child, c child.Child) []child.Child {
+	children = append(children, c)
+	for _, d := range c.GetNode().GetChildren() {
+		children = appendTree(children, d)
+	}
+	return children
+}
+
 func (s *Scene) GetTexts() []*ui.TextBox {
 	texts := s.texts
 	for _, scn := range s.subscenes {
@@ -141,6 +299,16 @@ func (s *Scene) GetTexts() []*ui.TextBox {
 	return texts
 }
 
+// GetElements returns the UI elements instanced in the scene
+func (s *Scene) GetElements() []ui.Element {
+	return s.elements
+}
+
+// GetSubscenes returns the scene's subscenes
+func (s *Scene) GetSubscenes() []*Scene {
+	return s.subscenes
+}
+
 func (s *Scene) IsAutomaticRendering() bool {
 	return s.automaticRendering
 }
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"rapidengine/child"
+)
+
+func TestGetChildrenCache(t *testing.T) {
+	scn := newScene("main")
+	sub := newScene("sub")
+	scn.InstanceSubscene(sub)
+
+	a := child.NewChild2D(nil)
+	b := child.NewChild2D(nil)
+	c := child.NewChild2D(nil)
+	scn.InstanceChild(a)
+	sub.InstanceChild(b)
+
+	check := func(what string, want ...child.Child) {
+		t.Helper()
+		if got := scn.GetChildren(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: GetChildren() = %v, want %v", what, got, want)
+		}
+	}
+	check("instanced", a, b)
+
+	if allocs := testing.AllocsPerRun(10, func() { scn.GetChildren() }); allocs != 0 {
+		t.Fatalf("GetChildren allocated %v times without any changes", allocs)
+	}
+
+	// Appending to the list mustn't change the cached one
+	_ = append(scn.GetChildren(), c)
+	check("appended to", a, b)
+
+	a.AddChild(c)
+	check("attached", a, c, b)
+
+	c.Detach()
+	check("detached", a, b)
+
+	scn.InstanceChild(c)
+	check("instanced another", a, c, b)
+
+	scn.RemoveChild(a)
+	check("removed", c, b)
+
+	sub.Deactivate()
+	check("deactivated subscene", c)
+
+	sub.Activate()
+	check("activated subscene", c, b)
+}
@@ -0,0 +1,1209 @@
+package cmd
+
+//   --------------------------------------------------
+//   Scene_file.go saves scenes to disk and loads them
+//   back. A scene file refers to textures, models and
+//   callbacks by name or path, and they are rebuilt
+//   through the engine's controls when it's loaded.
+//   --------------------------------------------------
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/assets"
+	"rapidengine/child"
+	"rapidengine/geometry"
+	"rapidengine/lighting"
+	"rapidengine/material"
+	"rapidengine/ui"
+)
+
+// SceneFileVersion is the version of the format SaveScene writes.
+// Files with a newer version than this can't be loaded. Version 2
+// stores rotations as quaternions, rather than angles.
+const SceneFileVersion = 2
+
+// Binary scene files start with these bytes, followed
+// by the gob-encoded SceneFile
+var sceneFileMagic = []byte("RSCN")
+
+// SceneFile is the contents of a scene file
+type SceneFile struct {
+	Version int `json:"version"`
+
+	// Textures and materials used by the scene and its subscenes, by
+	// name. Materials registered with the MaterialControl, and the
+	// renderer's "default1" and "default2", are referred to by name
+	// without being saved.
+	Textures  map[string]TextureData  `json:"textures,omitempty"`
+	Materials map[string]MaterialData `json:"materials,omitempty"`
+
+	// Lighting is engine-wide, so it's replaced when the file is loaded
+	Lights *LightsData `json:"lights,omitempty"`
+
+	// Paths of the sounds registered with the AudioControl, by name.
+	// They're loaded unless a sound is registered under the same name.
+	Sounds map[string]string `json:"sounds,omitempty"`
+
+	Scene SceneData `json:"scene"`
+}
+
+type SceneData struct {
+	ID                 string `json:"id"`
+	Active             bool   `json:"active"`
+	AutomaticRendering bool   `json:"automatic_rendering"`
+
+	Children  []ChildData   `json:"children,omitempty"`
+	Texts     []TextData    `json:"texts,omitempty"`
+	Elements  []ElementData `json:"elements,omitempty"`
+	Subscenes []SceneData   `json:"subscenes,omitempty"`
+}
+
+// ChildData is a child and the children attached to it.
+// Its transform is relative to its parent.
+type ChildData struct {
+	Dimensions int `json:"dimensions"`
+
+	Name string   `json:"name,omitempty"`
+	Tags []string `json:"tags,omitempty"`
+
+	// Orientation is a quaternion, as X, Y, Z and W
+	Position    [3]float32 `json:"position"`
+	Orientation [4]float32 `json:"orientation"`
+	Scale       [3]float32 `json:"scale"`
+	Velocity    [3]float32 `json:"velocity"`
+	Gravity     float32    `json:"gravity,omitempty"`
+
+	Layer          child.Layer `json:"layer,omitempty"`
+	RenderDistance float32     `json:"render_distance,omitempty"`
+	Transparent    bool        `json:"transparent,omitempty"`
+	Static         bool        `json:"static,omitempty"`
+	Darkness       float32     `json:"darkness,omitempty"`
+
+	// The material of a 2D child, and the ID of its mesh
+	Material string `json:"material,omitempty"`
+	Mesh     string `json:"mesh,omitempty"`
+
+	// The model of a 3D child
+	Model *ModelData `json:"model,omitempty"`
+
+	// Collision rectangle as offset x, offset y, width and height
+	Collider *[4]float32 `json:"collider,omitempty"`
+
+	// The child's group, and the collision groups it's in
+	Group           string    `json:"group,omitempty"`
+	CollisionGroups []string  `json:"collision_groups,omitempty"`
+	Link            *LinkData `json:"link,omitempty"`
+	Mouse           bool      `json:"mouse,omitempty"`
+
+	// Behaviors attached by name, in order
+	Behaviors []string `json:"behaviors,omitempty"`
+
+	// Angles about X, Y and Z, which version 1
+	// files stored in place of Orientation
+	Rotation *[3]float32 `json:"rotation,omitempty"`
+
+	Children []ChildData `json:"children,omitempty"`
+}
+
+// ModelData is either a model file, or meshes built by ID
+type ModelData struct {
+	Path   string     `json:"path,omitempty"`
+	Meshes []MeshData `json:"meshes,omitempty"`
+
+	// Names of the materials, by index
+	Materials map[int]string `json:"materials,omitempty"`
+}
+
+// MeshData is a mesh built by ID, which is either the name of a
+// primitive (rectangle, cube, screen or billboard) or an OBJ file
+type MeshData struct {
+	ID       string `json:"id"`
+	Material int    `json:"material,omitempty"`
+}
+
+type MaterialData struct {
+	Type string `json:"type"`
+
+	Hue    *[4]float32        `json:"hue,omitempty"`
+	Floats map[string]float32 `json:"floats,omitempty"`
+	Bools  map[string]bool    `json:"bools,omitempty"`
+
+	// Texture names, by slot
+	Textures map[string]string `json:"textures,omitempty"`
+}
+
+type TextureData struct {
+	Path   string `json:"path"`
+	Filter string `json:"filter"`
+}
+
+// LinkData is a collision link, whose callback
+// is registered with the CollisionControl
+type LinkData struct {
+	Group    string `json:"group"`
+	Callback string `json:"callback,omitempty"`
+}
+
+type TextData struct {
+	Text        string     `json:"text"`
+	Font        string     `json:"font"`
+	Position    [2]float32 `json:"position"`
+	Scale       float32    `json:"scale"`
+	Color       [3]float32 `json:"color"`
+	LeftAligned bool       `json:"left_aligned,omitempty"`
+}
+
+// ElementData is a button, progress_bar or menu
+type ElementData struct {
+	Type     string     `json:"type"`
+	Position [2]float32 `json:"position"`
+	Size     [2]float32 `json:"size"`
+
+	// The text of a button, or the texts either side of a progress bar
+	Text      *TextData `json:"text,omitempty"`
+	RightText *TextData `json:"right_text,omitempty"`
+
+	// Action registered with the UIControl a button calls
+	Action string `json:"action,omitempty"`
+
+	Percentage float32       `json:"percentage,omitempty"`
+	Elements   []ElementData `json:"elements,omitempty"`
+}
+
+type LightsData struct {
+	Enabled            bool `json:"enabled"`
+	DirectionalEnabled bool `json:"directional_enabled"`
+
+	Directional *DirectionLightData `json:"directional,omitempty"`
+	Points      []PointLightData    `json:"points,omitempty"`
+}
+
+type DirectionLightData struct {
+	Ambient   []float32 `json:"ambient"`
+	Diffuse   []float32 `json:"diffuse"`
+	Specular  []float32 `json:"specular"`
+	Direction []float32 `json:"direction"`
+}
+
+type PointLightData struct {
+	Index    int       `json:"index"`
+	Ambient  []float32 `json:"ambient"`
+	Diffuse  []float32 `json:"diffuse"`
+	Specular []float32 `json:"specular"`
+	Position []float32 `json:"position"`
+
+	Constant  float32 `json:"constant"`
+	Linear    float32 `json:"linear"`
+	Quadratic float32 `json:"quadratic"`
+}
+
+//   --------------------------------------------------
+//   Saving
+//   --------------------------------------------------
+
+// SaveScene writes a scene and its subscenes to a file, along with
+// the engine's lights. Paths ending in .json are written as JSON,
+// and others in the binary format. The file is written through the
+// assets VFS, where LoadScene reads it back, see assets.WriteFile.
+//
+// Collision links are saved by the name of their callback, so
+// callbacks must be registered with RegisterCallback.
+func (sc *SceneControl) SaveScene(scn *Scene, path string) error {
+	f, err := sc.EncodeScene(scn)
+	if err != nil {
+		return err
+	}
+	return writeSceneFile(path, f)
+}
+
+// writeSceneFile writes v to path, as JSON if
+// the path ends in .json and as binary otherwise
+func writeSceneFile(path string, v interface{}) error {
+	var blob []byte
+	var err error
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		blob, err = json.MarshalIndent(v, "", "  ")
+	} else {
+		var buf bytes.Buffer
+		buf.Write(sceneFileMagic)
+		err = gob.NewEncoder(&buf).Encode(v)
+		blob = buf.Bytes()
+	}
+	if err != nil {
+		return err
+	}
+
+	return assets.WriteFile(path, blob)
+}
+
+// EncodeScene describes a scene and the engine's lights as a SceneFile
+func (sc *SceneControl) EncodeScene(scn *Scene) (*SceneFile, error) {
+	w := sceneWriter{
+		engine: sc.engine,
+		file: &SceneFile{
+			Version:   SceneFileVersion,
+			Textures:  make(map[string]TextureData),
+			Materials: make(map[string]MaterialData),
+			Lights:    sc.encodeLights(),
+			Sounds:    make(map[string]string),
+		},
+		materials: make(map[material.Material]string),
+	}
+
+	for name, audio := range sc.engine.AudioControl.Sounds {
+		if audio.Path != "" {
+			w.file.Sounds[name] = audio.Path
+		}
+	}
+
+	data, err := w.scene(scn)
+	if err != nil {
+		return nil, err
+	}
+	w.file.Scene = data
+
+	return w.file, nil
+}
+
+type sceneWriter struct {
+	engine *Engine
+	file   *SceneFile
+
+	// Names given to the materials written so far
+	materials map[material.Material]string
+}
+
+func (w *sceneWriter) scene(scn *Scene) (SceneData, error) {
+	data := SceneData{
+		ID:                 scn.ID,
+		Active:             scn.active,
+		AutomaticRendering: scn.automaticRendering,
+	}
+
+	// Children and texts of UI elements are saved with the elements
+	elementChildren := make(map[child.Child]bool)
+	elementTexts := make(map[*ui.TextBox]bool)
+	for _, e := range scn.elements {
+		for _, c := range e.GetChildren() {
+			elementChildren[c] = true
+		}
+		for _, t := range e.GetTextBoxes() {
+			elementTexts[t] = true
+		}
+	}
+
+	for _, c := range scn.children {
+		if scn.hasAncestor(c) || elementChildren[c] {
+			continue
+		}
+		cd, err := w.child(c)
+		if err != nil {
+			return SceneData{}, err
+		}
+		data.Children = append(data.Children, cd)
+	}
+
+	for _, t := range scn.texts {
+		if t != w.engine.FPSBox && !elementTexts[t] {
+			data.Texts = append(data.Texts, encodeText(t))
+		}
+	}
+
+	// Elements in a menu are saved with the menu
+	inMenu := make(map[ui.Element]bool)
+	for _, e := range scn.elements {
+		if m, ok := e.(*ui.Menu); ok {
+			for _, sub := range m.GetElements() {
+				inMenu[sub] = true
+			}
+		}
+	}
+
+	for _, e := range scn.elements {
+		if inMenu[e] {
+			continue
+		}
+		ed, err := encodeElement(e)
+		if err != nil {
+			return SceneData{}, err
+		}
+		data.Elements = append(data.Elements, ed)
+	}
+
+	for _, sub := range scn.subscenes {
+		sd, err := w.scene(sub)
+		if err != nil {
+			return SceneData{}, err
+		}
+		data.Subscenes = append(data.Subscenes, sd)
+	}
+
+	return data, nil
+}
+
+func (w *sceneWriter) child(c child.Child) (ChildData, error) {
+	data := ChildData{
+		Dimensions:     c.GetDimensions(),
+		Name:           c.GetNode().GetName(),
+		Layer:          c.GetLayer(),
+		RenderDistance: c.GetSpecificRenderDistance(),
+	}
+	if tags := c.GetNode().GetTags(); len(tags) > 0 {
+		data.Tags = tags
+	}
+
+	switch c := c.(type) {
+
+	case *child.Child2D:
+		data.Position = [3]float32{c.X, c.Y, 0}
+		data.Orientation = quatData(c.Orientation())
+		data.Scale = [3]float32{c.SX, c.SY, 1}
+		data.Velocity = [3]float32{c.VX, c.VY, 0}
+		data.Gravity = c.Gravity
+		data.Static = c.Static
+		data.Darkness = c.Darkness
+		data.Group = c.Group
+
+		if !rebuildableMesh(c.Mesh.ID) {
+			return ChildData{}, fmt.Errorf("saving child: mesh %q can't be rebuilt", c.Mesh.ID)
+		}
+		data.Mesh = c.Mesh.ID
+
+		name, err := w.material(c.GetMaterial())
+		if err != nil {
+			return ChildData{}, err
+		}
+		data.Material = name
+
+		col := c.GetCollider()
+		data.Collider = &[4]float32{col.OffsetX, col.OffsetY, col.Width, col.Height}
+
+	case *child.Child3D:
+		data.Position = [3]float32{c.X, c.Y, c.Z}
+		data.Orientation = quatData(c.Orientation())
+		data.Scale = [3]float32{c.SX, c.SY, c.SZ}
+		data.Velocity = [3]float32{c.VX, c.VY, c.VZ}
+		data.Gravity = c.Gravity
+		data.Transparent = c.Transparent
+		data.Group = c.Group
+
+		model, err := w.model(&c.Model)
+		if err != nil {
+			return ChildData{}, err
+		}
+		data.Model = model
+
+	default:
+		return ChildData{}, fmt.Errorf("saving child: children of type %T can't be saved", c)
+	}
+
+	if err := w.collision(c, &data); err != nil {
+		return ChildData{}, err
+	}
+	data.Behaviors = w.engine.BehaviorControl.names(c)
+
+	for _, sub := range c.GetNode().GetChildren() {
+		sd, err := w.child(sub)
+		if err != nil {
+			return ChildData{}, err
+		}
+		data.Children = append(data.Children, sd)
+	}
+
+	return data, nil
+}
+
+func (w *sceneWriter) model(m *geometry.Model) (*ModelData, error) {
+	if m.Path == "" && len(m.Meshes) == 0 {
+		return nil, nil
+	}
+
+	data := &ModelData{
+		Path:      m.Path,
+		Materials: make(map[int]string),
+	}
+
+	if m.Path == "" {
+		for _, ms := range m.Meshes {
+			if !rebuildableMesh(ms.ID) {
+				return nil, fmt.Errorf("saving model: mesh %q can't be rebuilt", ms.ID)
+			}
+			data.Meshes = append(data.Meshes, MeshData{ID: ms.ID, Material: ms.ModelMaterial})
+		}
+	}
+
+	for i, mat := range m.Materials {
+		name, err := w.material(mat)
+		if err != nil {
+			return nil, err
+		}
+		if name != "" {
+			data.Materials[i] = name
+		}
+	}
+
+	return data, nil
+}
+
+// material returns the name a material is saved under,
+// and saves it and its textures the first time
+func (w *sceneWriter) material(m material.Material) (string, error) {
+	if m == nil {
+		return "", nil
+	}
+	if name, ok := w.materials[m]; ok {
+		return name, nil
+	}
+
+	if name, ok := builtinMaterialName(w.engine, m); ok {
+		w.materials[m] = name
+		return name, nil
+	}
+
+	params, ok := material.ParametersOf(m)
+	if !ok {
+		return "", fmt.Errorf("saving material: materials of type %T can't be saved", m)
+	}
+
+	data := MaterialData{
+		Type:     params.Type,
+		Floats:   make(map[string]float32),
+		Bools:    make(map[string]bool),
+		Textures: make(map[string]string),
+	}
+	if params.Hue != nil {
+		hue := *params.Hue
+		data.Hue = &hue
+	}
+	for p, v := range params.Floats {
+		data.Floats[p] = *v
+	}
+	for p, v := range params.Bools {
+		data.Bools[p] = *v
+	}
+	for slot, t := range params.Textures {
+		if *t == nil {
+			continue
+		}
+		if (*t).Path == "" {
+			return "", fmt.Errorf("saving material: texture %q has no path", (*t).Name)
+		}
+		data.Textures[slot] = (*t).Name
+		w.file.Textures[(*t).Name] = TextureData{Path: (*t).Path, Filter: (*t).Filter}
+	}
+
+	name := ""
+	for i := len(w.file.Materials); name == ""; i++ {
+		name = fmt.Sprintf("%s%d", params.Type, i)
+		if _, taken := w.file.Materials[name]; taken {
+			name = ""
+		}
+	}
+	w.file.Materials[name] = data
+	w.materials[m] = name
+
+	return name, nil
+}
+
+func (w *sceneWriter) collision(c child.Child, data *ChildData) error {
+	cc := &w.engine.CollisionControl
+
+	for group, members := range cc.GroupMap {
+		for _, m := range members {
+			if m == c {
+				data.CollisionGroups = append(data.CollisionGroups, group)
+				break
+			}
+		}
+	}
+	sort.Strings(data.CollisionGroups)
+
+	if link, ok := cc.LinkMap[c]; ok {
+		if link.Name == "" && link.Callback != nil {
+			return fmt.Errorf("saving child: its collision link with group %q has a callback which isn't registered with RegisterCallback", link.Group)
+		}
+		data.Link = &LinkData{Group: link.Group, Callback: link.Name}
+	}
+
+	for _, m := range cc.MouseChildren {
+		if m == c {
+			data.Mouse = true
+		}
+	}
+	return nil
+}
+
+// builtinMaterialName returns the name of a material which
+// is referred to by name, rather than saved
+func builtinMaterialName(e *Engine, m material.Material) (string, bool) {
+	switch m {
+	case material.Material(e.Renderer.DefaultMaterial1):
+		return "default1", true
+	case material.Material(e.Renderer.DefaultMaterial2):
+		return "default2", true
+	}
+
+	for name, registered := range e.MaterialControl.Materials {
+		if registered == m {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func encodeText(t *ui.TextBox) TextData {
+	return TextData{
+		Text:        t.Text,
+		Font:        t.Font,
+		Position:    [2]float32{t.X, t.Y},
+		Scale:       t.Scale,
+		Color:       [3]float32{t.Color[0] * 255, t.Color[1] * 255, t.Color[2] * 255},
+		LeftAligned: t.LeftAligned,
+	}
+}
+
+func encodeOptionalText(t *ui.TextBox) *TextData {
+	if t == nil {
+		return nil
+	}
+	data := encodeText(t)
+	return &data
+}
+
+func encodeElement(e ui.Element) (ElementData, error) {
+	tr := e.GetTransform()
+	data := ElementData{
+		Position: [2]float32{tr.X, tr.Y},
+		Size:     [2]float32{tr.SX, tr.SY},
+	}
+
+	switch e := e.(type) {
+
+	case *ui.Button:
+		data.Type = "button"
+		data.Text = encodeOptionalText(e.TextBx)
+		data.Action = e.Action
+
+	case *ui.ProgressBar:
+		data.Type = "progress_bar"
+		data.Text = encodeOptionalText(e.TextBxLeft)
+		data.RightText = encodeOptionalText(e.TextBxRight)
+		data.Percentage = e.GetPercentage()
+
+	case *ui.Menu:
+		data.Type = "menu"
+		for _, sub := range e.GetElements() {
+			sd, err := encodeElement(sub)
+			if err != nil {
+				return ElementData{}, err
+			}
+			data.Elements = append(data.Elements, sd)
+		}
+
+	default:
+		return ElementData{}, fmt.Errorf("saving element: elements of type %T can't be saved", e)
+	}
+
+	return data, nil
+}
+
+func (sc *SceneControl) encodeLights() *LightsData {
+	lc := &sc.engine.LightControl
+
+	data := &LightsData{
+		Enabled:            lc.IsLightingEnabled(),
+		DirectionalEnabled: lc.IsDirectionalLightingEnabled(),
+	}
+
+	if d := lc.DirLight[0]; d != nil {
+		data.Directional = &DirectionLightData{
+			Ambient:   d.Ambient,
+			Diffuse:   d.Diffuse,
+			Specular:  d.Specular,
+			Direction: d.Direction,
+		}
+	}
+
+	for i, p := range lc.PointLights() {
+		c, l, q := p.GetAttenuation()
+		data.Points = append(data.Points, PointLightData{
+			Index:     i,
+			Ambient:   p.Ambient,
+			Diffuse:   p.Diffuse,
+			Specular:  p.GetSpecular(),
+			Position:  p.Position,
+			Constant:  c,
+			Linear:    l,
+			Quadratic: q,
+		})
+	}
+	sort.Slice(data.Points, func(i, j int) bool { return data.Points[i].Index < data.Points[j].Index })
+
+	return data
+}
+
+//   --------------------------------------------------
+//   Loading
+//   --------------------------------------------------
+
+// LoadScene reads a scene file written by SaveScene, in either
+// format, and instances the scene. Textures the file uses are
+// loaded unless already registered under the same name.
+func (sc *SceneControl) LoadScene(path string) (*Scene, error) {
+	var f SceneFile
+	if err := readSceneFile(path, &f, &f.Version); err != nil {
+		return nil, err
+	}
+
+	scn, err := sc.DecodeScene(&f)
+	if err != nil {
+		return nil, fmt.Errorf("loading scene %s: %w", path, err)
+	}
+	return scn, nil
+}
+
+// readSceneFile decodes a file written by writeSceneFile into v,
+// and checks the version it decodes into version
+func readSceneFile(path string, v interface{}, version *int) error {
+	blob, err := assets.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	format := "json"
+	if bytes.HasPrefix(blob, sceneFileMagic) {
+		format = "binary scene"
+		err = gob.NewDecoder(bytes.NewReader(blob[len(sceneFileMagic):])).Decode(v)
+	} else {
+		err = json.Unmarshal(blob, v)
+	}
+	if err != nil {
+		return &assets.DecodeError{Path: path, Format: format, Err: err}
+	}
+
+	if *version < 1 || *version > SceneFileVersion {
+		return &assets.DecodeError{Path: path, Format: format, Err: fmt.Errorf("unsupported scene version %d", *version)}
+	}
+	return nil
+}
+
+// DecodeScene builds and instances the scene a SceneFile
+// describes, and replaces the engine's lights with its own
+func (sc *SceneControl) DecodeScene(f *SceneFile) (*Scene, error) {
+	return sc.decodeScene(newSceneReader(sc.engine, f))
+}
+
+func (sc *SceneControl) decodeScene(r *sceneReader) (*Scene, error) {
+	var scn *Scene
+	for _, step := range sc.sceneSteps(r, func(s *Scene) { scn = s }) {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+	return scn, nil
+}
+
+// sceneSteps splits building the scene a reader's file describes into
+// steps, which are run in order on the main thread. Each top level
+// child is built by its own step. The last step instances the scene
+// and passes it to done. Only the steps use the engine, so they
+// can be made on any goroutine.
+func (sc *SceneControl) sceneSteps(r *sceneReader, done func(*Scene)) []loadStep {
+	f := r.file
+	f.upgrade()
+
+	// The scene is only instanced, and its children only
+	// registered with the engine, once all of it is built
+	r.deferring = true
+	scn := newScene(f.Scene.ID)
+
+	steps := []loadStep{func() error {
+		sc.adopt(scn)
+
+		ac := &sc.engine.AudioControl
+		for name, path := range f.Sounds {
+			if _, ok := ac.Sounds[name]; !ok {
+				if err := ac.Load(path, name); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}}
+
+	steps = append(steps, r.sceneSteps(scn, &f.Scene)...)
+
+	return append(steps, func() error {
+		r.commit()
+
+		if f.Lights != nil {
+			sc.decodeLights(f.Lights)
+		}
+
+		sc.InstanceScene(scn)
+		done(scn)
+		return nil
+	})
+}
+
+// upgrade converts a file of an older version to the current one
+func (f *SceneFile) upgrade() {
+	var scenes func(data *SceneData)
+	scenes = func(data *SceneData) {
+		upgradeChildren(data.Children)
+		for i := range data.Subscenes {
+			scenes(&data.Subscenes[i])
+		}
+	}
+	scenes(&f.Scene)
+
+	f.Version = SceneFileVersion
+}
+
+// upgradeChildren converts the angles of children
+// read from version 1 files into orientations
+func upgradeChildren(children []ChildData) {
+	for i := range children {
+		d := &children[i]
+		if d.Rotation != nil {
+			d.Orientation = quatData(geometry.EulerToQuat(d.Rotation[0], d.Rotation[1], d.Rotation[2]))
+			d.Rotation = nil
+		}
+		upgradeChildren(d.Children)
+	}
+}
+
+type sceneReader struct {
+	engine *Engine
+	file   *SceneFile
+
+	// Materials built so far, by name
+	materials map[string]material.Material
+
+	// Models built ahead of time, by path. Children
+	// using the same model share its meshes.
+	models map[string]geometry.Model
+
+	// Obj files decoded ahead of time, by path,
+	// which each child's mesh is built from
+	objs map[string]geometry.ObjData
+
+	// Changes to the engine outside of the scene being built,
+	// such as collision links, which are made by commit once
+	// the whole scene has been built without errors
+	deferring bool
+	commits   []func()
+}
+
+func newSceneReader(e *Engine, f *SceneFile) *sceneReader {
+	return &sceneReader{
+		engine:    e,
+		file:      f,
+		materials: make(map[string]material.Material),
+		models:    make(map[string]geometry.Model),
+		objs:      make(map[string]geometry.ObjData),
+	}
+}
+
+// later makes a change to the engine outside of the scene being
+// built when the scene is committed, or right away if the reader
+// isn't building a scene, such as when prefabs are updated
+func (r *sceneReader) later(f func()) {
+	if r.deferring {
+		r.commits = append(r.commits, f)
+		return
+	}
+	f()
+}
+
+// commit makes the changes put off by later
+func (r *sceneReader) commit() {
+	for _, f := range r.commits {
+		f()
+	}
+	r.commits = nil
+	r.deferring = false
+}
+
+// sceneSteps returns the steps which build a scene and its subscenes
+func (r *sceneReader) sceneSteps(scn *Scene, data *SceneData) []loadStep {
+	steps := []loadStep{}
+
+	for i := range data.Children {
+		d := &data.Children[i]
+		steps = append(steps, func() error {
+			c, err := r.child(d, nil)
+			if err != nil {
+				return err
+			}
+			scn.InstanceChild(c)
+			return nil
+		})
+	}
+
+	steps = append(steps, func() error {
+		scn.automaticRendering = data.AutomaticRendering
+
+		for _, td := range data.Texts {
+			scn.InstanceText(r.text(td))
+		}
+
+		for i := range data.Elements {
+			e, err := r.element(&data.Elements[i])
+			if err != nil {
+				return err
+			}
+			scn.instanceElement(e)
+			r.later(func() { r.engine.UIControl.addElement(e) })
+		}
+		return nil
+	})
+
+	for i := range data.Subscenes {
+		sub := newScene(data.Subscenes[i].ID)
+		steps = append(steps, r.sceneSteps(sub, &data.Subscenes[i])...)
+		steps = append(steps, func() error {
+			scn.InstanceSubscene(sub)
+			return nil
+		})
+	}
+
+	return append(steps, func() error {
+		if data.Active {
+			scn.Activate()
+		} else {
+			scn.Deactivate()
+		}
+		return nil
+	})
+}
+
+func (r *sceneReader) child(data *ChildData, parent child.Child) (child.Child, error) {
+	var c child.Child
+
+	switch data.Dimensions {
+	case 2:
+		c = r.engine.ChildControl.NewChild2D()
+	case 3:
+		c = r.engine.ChildControl.NewChild3D()
+	default:
+		return nil, fmt.Errorf("children can't have %d dimensions", data.Dimensions)
+	}
+
+	// The transform is relative to the parent, so
+	// it's set once the child is attached
+	if parent != nil {
+		if err := parent.GetNode().AddChild(c); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, p := range childProperties {
+		if err := p.apply(r, c, data); err != nil {
+			return nil, err
+		}
+	}
+
+	if data.Mouse {
+		r.later(func() { r.engine.CollisionControl.CreateMouseCollision(c) })
+	}
+
+	for i := range data.Children {
+		if _, err := r.child(&data.Children[i], c); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func (r *sceneReader) model(data *ModelData) (geometry.Model, error) {
+	materials := make(map[int]material.Material)
+	for i, name := range data.Materials {
+		mat, err := r.material(name)
+		if err != nil {
+			return geometry.Model{}, err
+		}
+		materials[i] = mat
+	}
+
+	if data.Path != "" {
+		model, ok := r.models[data.Path]
+		if ok {
+			shared := model.Materials
+			model.Materials = map[int]material.Material{0: materials[0]}
+			for i, mat := range shared {
+				if i != 0 {
+					model.Materials[i] = mat
+				}
+			}
+		} else {
+			var err error
+			if model, err = r.engine.GeometryControl.LoadModel(data.Path, materials[0]); err != nil {
+				return geometry.Model{}, err
+			}
+		}
+		for i, mat := range materials {
+			model.Materials[i] = mat
+		}
+		return model, nil
+	}
+
+	model := geometry.Model{Materials: materials}
+	for _, md := range data.Meshes {
+		mesh, err := r.mesh(md.ID)
+		if err != nil {
+			return geometry.Model{}, err
+		}
+		mesh.ModelMaterial = md.Material
+		model.Meshes = append(model.Meshes, mesh)
+	}
+	return model, nil
+}
+
+// material returns the material with the given name, building
+// it from the file the first time it's asked for
+func (r *sceneReader) material(name string) (material.Material, error) {
+	if m, ok := r.materials[name]; ok {
+		return m, nil
+	}
+
+	data, ok := r.file.Materials[name]
+	if !ok {
+		switch name {
+		case "default1":
+			return r.engine.Renderer.DefaultMaterial1, nil
+		case "default2":
+			return r.engine.Renderer.DefaultMaterial2, nil
+		}
+		if m, ok := r.engine.MaterialControl.Materials[name]; ok {
+			return m, nil
+		}
+		return nil, fmt.Errorf("no material named %q", name)
+	}
+
+	mc := &r.engine.MaterialControl
+
+	var m material.Material
+	switch data.Type {
+	case "basic":
+		m = mc.NewBasicMaterial()
+	case "standard":
+		m = mc.NewStandardMaterial()
+	case "pbr":
+		m = material.NewPBRMaterial(r.engine.ShaderControl.GetShader("pbr"))
+	case "terrain":
+		m = mc.NewTerrainMaterial()
+	default:
+		return nil, fmt.Errorf("material %q has unknown type %q", name, data.Type)
+	}
+
+	params, _ := material.ParametersOf(m)
+
+	if data.Hue != nil && params.Hue != nil {
+		*params.Hue = *data.Hue
+	}
+	for p, v := range data.Floats {
+		ptr, ok := params.Floats[p]
+		if !ok {
+			return nil, fmt.Errorf("material %q has no parameter %q", name, p)
+		}
+		*ptr = v
+	}
+	for p, v := range data.Bools {
+		ptr, ok := params.Bools[p]
+		if !ok {
+			return nil, fmt.Errorf("material %q has no parameter %q", name, p)
+		}
+		*ptr = v
+	}
+	for slot, tex := range data.Textures {
+		ptr, ok := params.Textures[slot]
+		if !ok {
+			return nil, fmt.Errorf("material %q has no texture slot %q", name, slot)
+		}
+		t, err := r.texture(tex)
+		if err != nil {
+			return nil, err
+		}
+		*ptr = t
+	}
+
+	r.materials[name] = m
+	return m, nil
+}
+
+// texture returns the registered texture with the given
+// name, loading it from the file's path if there isn't one
+func (r *sceneReader) texture(name string) (*material.Texture, error) {
+	tc := &r.engine.TextureControl
+
+	if t, ok := tc.LookupTexture(name); ok {
+		return t, nil
+	}
+
+	data, ok := r.file.Textures[name]
+	if !ok {
+		return nil, fmt.Errorf("no texture named %q", name)
+	}
+	if err := tc.NewTexture(data.Path, name, data.Filter); err != nil {
+		return nil, err
+	}
+	return tc.GetTexture(name), nil
+}
+
+func (r *sceneReader) text(data TextData) *ui.TextBox {
+	t := r.engine.TextControl.NewTextBox(data.Text, data.Font, data.Position[0], data.Position[1], data.Scale, data.Color)
+	t.LeftAligned = data.LeftAligned
+	return t
+}
+
+func (r *sceneReader) optionalText(data *TextData) *ui.TextBox {
+	if data == nil {
+		return nil
+	}
+	return r.text(*data)
+}
+
+func (r *sceneReader) element(data *ElementData) (ui.Element, error) {
+	uc := &r.engine.UIControl
+
+	switch data.Type {
+
+	case "button":
+		b := uc.newUIButton(data.Position[0], data.Position[1], data.Size[0], data.Size[1])
+		r.later(func() { r.engine.CollisionControl.CreateMouseCollision(b.ButtonChild) })
+		if t := r.optionalText(data.Text); t != nil {
+			b.AttachText(t)
+		}
+		if data.Action != "" {
+			if err := uc.SetAction(b, data.Action); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+
+	case "progress_bar":
+		pb := uc.NewProgressBar()
+		pb.SetDimensions(data.Size[0], data.Size[1])
+		pb.SetPosition(data.Position[0], data.Position[1])
+		pb.SetPercentage(data.Percentage)
+		pb.AttachText(r.optionalText(data.Text), r.optionalText(data.RightText))
+		return pb, nil
+
+	case "menu":
+		m := uc.NewMenu()
+		m.SetDimensions(data.Size[0], data.Size[1])
+		m.SetPosition(data.Position[0], data.Position[1])
+
+		for i := range data.Elements {
+			sub, err := r.element(&data.Elements[i])
+			if err != nil {
+				return nil, err
+			}
+			m.AddElement(sub)
+
+			// Elements in a menu are updated on their own
+			r.later(func() { uc.addElement(sub) })
+		}
+		return m, nil
+
+	}
+
+	return nil, fmt.Errorf("unknown element type %q", data.Type)
+}
+
+func (sc *SceneControl) decodeLights(data *LightsData) {
+	lc := &sc.engine.LightControl
+
+	if data.Enabled {
+		lc.EnableLighting()
+	} else {
+		lc.DisableLighting()
+	}
+	if data.DirectionalEnabled {
+		lc.EnableDirectionalLighting()
+	} else {
+		lc.DisableDirectionalLighting()
+	}
+
+	lc.SetDirectionalLight(nil)
+	if d := data.Directional; d != nil {
+		light := lighting.NewDirectionLight(d.Ambient, d.Diffuse, d.Specular, d.Direction)
+		lc.SetDirectionalLight(&light)
+	}
+
+	lc.ClearPointLights()
+	for _, p := range data.Points {
+		light := lighting.NewPointLight(p.Ambient, p.Diffuse, p.Specular, p.Constant, p.Linear, p.Quadratic)
+		light.SetPosition(p.Position)
+		lc.InstanceLight(light, p.Index)
+	}
+}
+
+//   --------------------------------------------------
+//   Meshes
+//   --------------------------------------------------
+
+// mesh builds the mesh with the given ID, from the
+// obj file decoded ahead of time if there is one
+func (r *sceneReader) mesh(id string) (geometry.Mesh, error) {
+	if data, ok := r.objs[id]; ok {
+		return data.Mesh(), nil
+	}
+	return meshFromID(id)
+}
+
+// meshFromID builds a mesh saved by its ID. OBJ files are loaded at scale 1.
+func meshFromID(id string) (geometry.Mesh, error) {
+	switch id {
+	case "rectangle":
+		return geometry.NewRectangle(), nil
+	case "cube":
+		return geometry.NewCube(), nil
+	case "screen":
+		return geometry.NewScreenQuad(), nil
+	case "billboard":
+		return geometry.NewBillBoard(), nil
+	}
+
+	if isObj(id) {
+		return geometry.LoadObj(id, 1)
+	}
+	return geometry.Mesh{}, fmt.Errorf("mesh %q can't be rebuilt from its ID", id)
+}
+
+func rebuildableMesh(id string) bool {
+	switch id {
+	case "rectangle", "cube", "screen", "billboard":
+		return true
+	}
+	return isObj(id)
+}
+
+// isObj returns whether a mesh ID is the path of an obj file
+func isObj(id string) bool {
+	return strings.EqualFold(filepath.Ext(id), ".obj")
+}
+
+//   --------------------------------------------------
+//   Rotations
+//   --------------------------------------------------
+
+func quatData(q mgl32.Quat) [4]float32 {
+	return [4]float32{q.V[0], q.V[1], q.V[2], q.W}
+}
+
+func dataQuat(d [4]float32) mgl32.Quat {
+	return mgl32.Quat{W: d[3], V: mgl32.Vec3{d[0], d[1], d[2]}}
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/assets"
+	"rapidengine/child"
+	"rapidengine/geometry"
+	"rapidengine/lighting"
+)
+
+// newFileTestScene builds a scene using most of what a scene
+// file can hold: a hierarchy of 2D and 3D children with their
+// own materials, collisions, texts, UI and a subscene
+func newFileTestScene(t *testing.T, e *Engine) *Scene {
+	scn := e.SceneControl.NewScene("level")
+
+	m := e.MaterialControl.NewBasicMaterial()
+	m.Hue = [4]float32{1, 2, 3, 4}
+	m.DiffuseMap = e.TextureControl.GetTexture("default")
+	player := e.ChildControl.NewChild2D()
+	player.AttachMaterial(m)
+	player.SetPosition(10, 20)
+	player.SetEuler(0, 0, 0.3)
+	player.AttachCollider(0, 0, 5, 5)
+	scn.InstanceChild(player)
+
+	hat := e.ChildControl.NewChild2D()
+	player.AddChild(hat)
+	hat.SetPosition(3, 4)
+
+	crate := e.ChildControl.NewChild3D()
+	sm := e.MaterialControl.NewStandardMaterial()
+	sm.Scale = 7
+	crate.AttachModel(geometry.NewModel(geometry.NewCube(), sm))
+	crate.SetPosition(1, 2, 3)
+	crate.SetEuler(0, 0.5, 0)
+	scn.InstanceChild(crate)
+
+	e.CollisionControl.RegisterCallback("hit", func([]bool) {})
+	e.CollisionControl.CreateGroup("ground")
+	e.CollisionControl.AddChildToGroup(crate, "ground")
+	if err := e.CollisionControl.CreateNamedCollision(player, "ground", "hit"); err != nil {
+		t.Fatal(err)
+	}
+
+	scn.InstanceText(e.TextControl.NewTextBox("hello", "avenir", 5, 6, 1, [3]float32{255, 0, 0}))
+	e.UIControl.RegisterAction("quit", func() {})
+	b := e.UIControl.NewUIButton(1, 2, 30, 40)
+	e.UIControl.SetAction(b, "quit")
+	menu := e.UIControl.NewMenu()
+	menu.AddElement(b)
+	e.UIControl.InstanceElement(menu, scn)
+
+	sub := e.SceneControl.NewScene("sub")
+	sub.InstanceChild(e.ChildControl.NewChild2D())
+	scn.InstanceSubscene(sub)
+
+	e.LightControl.EnableLighting()
+	pl := lighting.NewPointLight([]float32{1, 1, 1}, []float32{1, 1, 1}, []float32{1, 1, 1}, 1, 0.1, 0.01)
+	pl.SetPosition([]float32{0, 5, 0})
+	e.LightControl.InstanceLight(pl, 0)
+
+	return scn
+}
+
+// encodeJSON encodes a scene as the JSON it would be saved as
+func encodeJSON(t *testing.T, e *Engine, scn *Scene) string {
+	f, err := e.SceneControl.EncodeScene(scn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(blob)
+}
+
+func TestSceneFileRoundTrip(t *testing.T) {
+	e, _ := newTestEngine(t, 3)
+	newTestScene(e)
+	e.Initialize()
+
+	scn := newFileTestScene(t, e)
+	want := encodeJSON(t, e, scn)
+
+	// Both formats load back into a scene which saves
+	// the same as the original
+	for _, name := range []string{"level.json", "level.rscn"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := e.SceneControl.SaveScene(scn, path); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := e.SceneControl.LoadScene(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := encodeJSON(t, e, loaded); got != want {
+			t.Fatalf("%s loaded as\n%s\nwant\n%s", name, got, want)
+		}
+		if len(loaded.GetChildren()) != len(scn.GetChildren()) {
+			t.Fatalf("%s loaded %d children, want %d", name, len(loaded.GetChildren()), len(scn.GetChildren()))
+		}
+	}
+}
+
+func TestSceneFileVersions(t *testing.T) {
+	e, _ := newTestEngine(t, 3)
+	newTestScene(e)
+	e.Initialize()
+
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// Version 1 files stored angles, which are upgraded to orientations
+	v1 := write("v1.json", `{"version": 1, "scene": {"id": "old", "active": true, "children": [
+		{"dimensions": 3, "position": [0, 0, 0], "rotation": [0, 0.5, 0], "scale": [1, 1, 1], "velocity": [0, 0, 0],
+		 "model": {"meshes": [{"id": "cube", "material": 0}], "materials": {"0": "default1"}}}]}}`)
+	scn, err := e.SceneControl.LoadScene(v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	euler := scn.GetChildren()[0].(*child.Child3D).Euler()
+	if !euler.ApproxEqualThreshold(mgl32.Vec3{0, 0.5, 0}, 1e-5) {
+		t.Fatalf("version 1 rotation loaded as %v, want {0 0.5 0}", euler)
+	}
+
+	// Newer versions, and files which aren't scenes, can't be loaded
+	for _, path := range []string{
+		write("v9.json", `{"version": 9, "scene": {}}`),
+		write("garbage.json", `not a scene`),
+	} {
+		var decodeErr *assets.DecodeError
+		if _, err := e.SceneControl.LoadScene(path); !errors.As(err, &decodeErr) {
+			t.Fatalf("loading %s returned %v, want a DecodeError", path, err)
+		}
+	}
+
+	if _, err := e.SceneControl.LoadScene(filepath.Join(dir, "missing.json")); !errors.Is(err, assets.ErrAssetNotFound) {
+		t.Fatalf("loading a missing file returned %v, want ErrAssetNotFound", err)
+	}
+}
+
+func TestSceneFileCollisionCallbacks(t *testing.T) {
+	e, _ := newTestEngine(t, 2)
+	newTestScene(e)
+	e.Initialize()
+
+	scn := e.SceneControl.NewScene("level")
+	c := e.ChildControl.NewChild2D()
+	scn.InstanceChild(c)
+	e.CollisionControl.CreateGroup("ground")
+
+	// Callbacks which aren't registered can't be saved by name
+	e.CollisionControl.CreateCollision(c, "ground", func([]bool) {})
+	if _, err := e.SceneControl.EncodeScene(scn); err == nil {
+		t.Fatal("a scene with an unregistered callback was saved")
+	}
+
+	e.CollisionControl.RegisterCallback("hit", func([]bool) {})
+	if err := e.CollisionControl.CreateNamedCollision(c, "ground", "hit"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := e.SceneControl.EncodeScene(scn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nor can a file with a callback not registered be loaded
+	f.Scene.Children[0].Link.Callback = "missing"
+	if _, err := e.SceneControl.DecodeScene(f); err == nil {
+		t.Fatal("a scene with an unknown callback was loaded")
+	}
+}
@@ -0,0 +1,353 @@
+package cmd
+
+//   --------------------------------------------------
+//   Scene_index.go finds the children of a scene by
+//   name, path, tag or position. The index watches the
+//   scene's children, and is brought up to date by the
+//   first query after children are added, removed,
+//   renamed, retagged or moved.
+//   --------------------------------------------------
+
+import (
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/child"
+)
+
+// DefaultIndexCellSize is the size of the cells a scene's
+// children are sorted into by position, for FindInRadius
+// and FindInBox
+const DefaultIndexCellSize = 16
+
+type indexCell [3]int32
+
+type sceneIndex struct {
+	cellSize float32
+
+	// Whether the children are listed, indexed, and sorted into cells
+	listed, valid, placed bool
+
+	// Children which have moved since they were sorted into cells
+	moved map[child.Child]bool
+
+	// The scene's children, in the order GetChildren returns them
+	children []child.Child
+	order    map[child.Child]int
+
+	names map[string][]child.Child
+	paths map[string]child.Child
+	tags  map[string][]child.Child
+
+	cells  map[indexCell][]child.Child
+	cellOf map[child.Child]indexCell
+
+	// The children the index is watching
+	watched map[child.Child]bool
+}
+
+func newSceneIndex() sceneIndex {
+	return sceneIndex{cellSize: DefaultIndexCellSize}
+}
+
+// SetIndexCellSize sets the size of the cells the scene's children
+// are sorted into by position. Cells around the size of the radius
+// or box usually searched are fastest.
+func (s *Scene) SetIndexCellSize(size float32) {
+	if size <= 0 {
+		size = DefaultIndexCellSize
+	}
+	s.index.cellSize = size
+	s.index.placed = false
+}
+
+//   --------------------------------------------------
+//   Queries
+//   --------------------------------------------------
+
+// FindByName returns the children of the scene with a name
+func (s *Scene) FindByName(name string) []child.Child {
+	s.updateIndex(false)
+	return append([]child.Child{}, s.index.names[name]...)
+}
+
+// FindByPath returns the child at a path of names separated by
+// slashes, starting from the children at the top of the scene,
+// such as "ship/turret". It returns nil if there's no such child.
+func (s *Scene) FindByPath(path string) child.Child {
+	s.updateIndex(false)
+	return s.index.paths[path]
+}
+
+// FindByTag returns the children of the scene with a tag
+func (s *Scene) FindByTag(tag string) []child.Child {
+	s.updateIndex(false)
+	return append([]child.Child{}, s.index.tags[tag]...)
+}
+
+// FindInRadius returns the children of the scene
+// whose world position is within radius of center
+func (s *Scene) FindInRadius(center mgl32.Vec3, radius float32) []child.Child {
+	r := mgl32.Vec3{radius, radius, radius}
+	return s.findInBox(center.Sub(r), center.Add(r), func(p mgl32.Vec3) bool {
+		return p.Sub(center).Len() <= radius
+	})
+}
+
+// FindInBox returns the children of the scene whose
+// world position is in the box from min to max
+func (s *Scene) FindInBox(min, max mgl32.Vec3) []child.Child {
+	return s.findInBox(min, max, func(mgl32.Vec3) bool { return true })
+}
+
+func (s *Scene) findInBox(min, max mgl32.Vec3, inside func(mgl32.Vec3) bool) []child.Child {
+	s.updateIndex(true)
+	idx := &s.index
+
+	found := []child.Child{}
+	check := func(c child.Child) {
+		p := c.GetWorldPosition()
+		for i := 0; i < 3; i++ {
+			if p[i] < min[i] || p[i] > max[i] {
+				return
+			}
+		}
+		if inside(p) {
+			found = append(found, c)
+		}
+	}
+
+	lo, hi := idx.cell(min), idx.cell(max)
+	cells := 1.0
+	for i := 0; i < 3; i++ {
+		cells *= float64(hi[i]) - float64(lo[i]) + 1
+	}
+
+	// Boxes covering more cells than there are children are faster
+	// to check child by child, and found in order without sorting
+	if cells > float64(len(idx.children)) {
+		for _, c := range idx.children {
+			check(c)
+		}
+		return found
+	}
+
+	for x := lo[0]; x <= hi[0]; x++ {
+		for y := lo[1]; y <= hi[1]; y++ {
+			for z := lo[2]; z <= hi[2]; z++ {
+				for _, c := range idx.cells[indexCell{x, y, z}] {
+					check(c)
+				}
+			}
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return idx.order[found[i]] < idx.order[found[j]]
+	})
+	return found
+}
+
+//   --------------------------------------------------
+//   Indexing
+//   --------------------------------------------------
+
+// updateIndex rebuilds the index if the scene's children or their
+// names, tags or parents have changed, and if positions are needed,
+// moves the children which have moved into their new cells
+func (s *Scene) updateIndex(positions bool) {
+	idx := &s.index
+
+	if !idx.valid {
+		idx.build(s.GetChildren())
+	}
+
+	if positions {
+		idx.place()
+	}
+}
+
+func (idx *sceneIndex) build(children []child.Child) {
+	idx.children = children
+	idx.order = make(map[child.Child]int, len(children))
+	idx.names = make(map[string][]child.Child)
+	idx.paths = make(map[string]child.Child)
+	idx.tags = make(map[string][]child.Child)
+
+	// Parents come before their children, so their paths are known
+	pathOf := make(map[child.Child]string, len(children))
+	for i, c := range children {
+		idx.order[c] = i
+
+		node := c.GetNode()
+		name := node.GetName()
+		if name != "" {
+			idx.names[name] = append(idx.names[name], c)
+		}
+
+		path := name
+		if p, ok := pathOf[node.GetParent()]; ok {
+			path = p + "/" + name
+		}
+		pathOf[c] = path
+
+		// The first of children with the same path is found
+		if _, ok := idx.paths[path]; !ok {
+			idx.paths[path] = c
+		}
+
+		for _, tag := range node.GetTags() {
+			idx.tags[tag] = append(idx.tags[tag], c)
+		}
+	}
+
+	idx.watch(children)
+	idx.valid = true
+	idx.placed = false
+}
+
+// watch watches the children, and stops
+// watching those which aren't among them
+func (idx *sceneIndex) watch(children []child.Child) {
+	watched := make(map[child.Child]bool, len(children))
+	for _, c := range children {
+		watched[c] = true
+		if !idx.watched[c] {
+			c.GetNode().Watch(idx)
+		}
+	}
+	for c := range idx.watched {
+		if !watched[c] {
+			c.GetNode().Unwatch(idx)
+		}
+	}
+	idx.watched = watched
+}
+
+// reset drops the index, to be rebuilt by the next query
+func (idx *sceneIndex) reset() {
+	if idx.watched == nil {
+		return
+	}
+
+	idx.watch(nil)
+	*idx = sceneIndex{cellSize: idx.cellSize}
+}
+
+// place sorts every child into the cell its world position is
+// in, or once they have been, only the children which have moved
+func (idx *sceneIndex) place() {
+	if !idx.placed {
+		idx.cells = make(map[indexCell][]child.Child)
+		idx.cellOf = make(map[child.Child]indexCell, len(idx.children))
+		for _, c := range idx.children {
+			cell := idx.cell(c.GetWorldPosition())
+			idx.cells[cell] = append(idx.cells[cell], c)
+			idx.cellOf[c] = cell
+		}
+
+		idx.moved = make(map[child.Child]bool)
+		idx.placed = true
+		return
+	}
+
+	for c := range idx.moved {
+		from, to := idx.cellOf[c], idx.cell(c.GetWorldPosition())
+		if from == to {
+			continue
+		}
+
+		members := idx.cells[from]
+		for i, m := range members {
+			if m == c {
+				members[i] = members[len(members)-1]
+				members = members[:len(members)-1]
+				break
+			}
+		}
+		if len(members) == 0 {
+			delete(idx.cells, from)
+		} else {
+			idx.cells[from] = members
+		}
+
+		idx.cells[to] = append(idx.cells[to], c)
+		idx.cellOf[c] = to
+	}
+
+	for c := range idx.moved {
+		delete(idx.moved, c)
+	}
+}
+
+// ChildChanged lists the children again and
+// rebuilds the index when they are next needed
+func (idx *sceneIndex) ChildChanged(c child.Child) {
+	idx.listed = false
+	idx.valid = false
+}
+
+// ChildMoved moves the child to its new cell at the next query
+func (idx *sceneIndex) ChildMoved(c child.Child) {
+	if idx.placed {
+		idx.moved[c] = true
+	}
+}
+
+// cell returns the cell containing a position
+func (idx *sceneIndex) cell(p mgl32.Vec3) indexCell {
+	var cell indexCell
+	for i := 0; i < 3; i++ {
+		v := math.Floor(float64(p[i] / idx.cellSize))
+		cell[i] = int32(math.Max(math.MinInt32, math.Min(math.MaxInt32, v)))
+	}
+	return cell
+}
+
+//   --------------------------------------------------
+//   Current Scene
+//   --------------------------------------------------
+
+// FindByName returns the children of the current scene with a name
+func (sc *SceneControl) FindByName(name string) []child.Child {
+	if sc.currentScene == nil {
+		return nil
+	}
+	return sc.currentScene.FindByName(name)
+}
+
+// FindByPath returns the child of the current scene at a path, see Scene.FindByPath
+func (sc *SceneControl) FindByPath(path string) child.Child {
+	if sc.currentScene == nil {
+		return nil
+	}
+	return sc.currentScene.FindByPath(path)
+}
+
+// FindByTag returns the children of the current scene with a tag
+func (sc *SceneControl) FindByTag(tag string) []child.Child {
+	if sc.currentScene == nil {
+		return nil
+	}
+	return sc.currentScene.FindByTag(tag)
+}
+
+// FindInRadius returns the children of the current scene
+// whose world position is within radius of center
+func (sc *SceneControl) FindInRadius(center mgl32.Vec3, radius float32) []child.Child {
+	if sc.currentScene == nil {
+		return nil
+	}
+	return sc.currentScene.FindInRadius(center, radius)
+}
+
+// FindInBox returns the children of the current scene
+// whose world position is in the box from min to max
+func (sc *SceneControl) FindInBox(min, max mgl32.Vec3) []child.Child {
+	if sc.currentScene == nil {
+		return nil
+	}
+	return sc.currentScene.FindInBox(min, max)
+}
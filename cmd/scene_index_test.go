@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/child"
+)
+
+// newIndexChild makes a named 2D child at a position
+func newIndexChild(name string, x, y float32, tags ...string) *child.Child2D {
+	c := child.NewChild2D(nil)
+	c.SetName(name)
+	c.SetTags(tags...)
+	c.SetPosition(x, y)
+	return c
+}
+
+func TestFindByNamePathAndTag(t *testing.T) {
+	scn := newScene("main")
+	sub := newScene("sub")
+	scn.InstanceSubscene(sub)
+
+	ship := newIndexChild("ship", 0, 0, "player")
+	turret := newIndexChild("turret", 0, 0, "weapon")
+	ship.AddChild(turret)
+	enemy := newIndexChild("ship", 10, 0, "enemy")
+	gun := newIndexChild("gun", 0, 0, "weapon")
+	scn.InstanceChild(ship)
+	sub.InstanceChild(enemy)
+	enemy.AddChild(gun)
+
+	check := func(what string, got []child.Child, want ...child.Child) {
+		t.Helper()
+		if !reflect.DeepEqual(got, append([]child.Child{}, want...)) {
+			t.Fatalf("%s: found %v, want %v", what, got, want)
+		}
+	}
+
+	// Children are found in the order GetChildren returns them
+	check("ships", scn.FindByName("ship"), ship, enemy)
+	check("weapons", scn.FindByTag("weapon"), turret, gun)
+	check("unknown name", scn.FindByName("boat"))
+
+	// The first child at a path is found
+	if scn.FindByPath("ship/turret") != turret || scn.FindByPath("ship/gun") != gun || scn.FindByPath("ship") != ship {
+		t.Fatal("children weren't found by path")
+	}
+	if scn.FindByPath("turret") != nil {
+		t.Fatal("a child was found by a path which doesn't start at the top of the scene")
+	}
+
+	// The index is kept up to date as children change
+	turret.SetName("cannon")
+	gun.RemoveTag("weapon")
+	enemy.AddTag("weapon")
+	if scn.FindByPath("ship/turret") != nil || scn.FindByPath("ship/cannon") != turret {
+		t.Fatal("a renamed child wasn't found by its new path")
+	}
+	check("retagged", scn.FindByTag("weapon"), turret, enemy)
+
+	turret.Detach()
+	check("detached", scn.FindByName("cannon"))
+
+	scn.RemoveChild(ship)
+	check("removed", scn.FindByName("ship"), enemy)
+
+	sub.Deactivate()
+	check("deactivated subscene", scn.FindByName("ship"))
+}
+
+func TestFindByPosition(t *testing.T) {
+	scn := newScene("main")
+	scn.SetIndexCellSize(4)
+
+	// A grid of children, one every 2 units from 0 to 18
+	grid := map[[2]int]*child.Child2D{}
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			c := newIndexChild("", float32(x*2), float32(y*2))
+			grid[[2]int{x, y}] = c
+			scn.InstanceChild(c)
+		}
+	}
+
+	// Small boxes are searched by cell, and large ones child by
+	// child, and both find children in the order of GetChildren
+	check := func(what string, got []child.Child, want ...[2]int) {
+		t.Helper()
+		wanted := []child.Child{}
+		for _, w := range want {
+			wanted = append(wanted, grid[w])
+		}
+		if !reflect.DeepEqual(got, wanted) {
+			t.Fatalf("%s: found %d children, want %d", what, len(got), len(wanted))
+		}
+	}
+	check("small box", scn.FindInBox(mgl32.Vec3{1, 1, -1}, mgl32.Vec3{4, 4, 1}), [2]int{1, 1}, [2]int{1, 2}, [2]int{2, 1}, [2]int{2, 2})
+	check("radius", scn.FindInRadius(mgl32.Vec3{10, 10, 0}, 2), [2]int{4, 5}, [2]int{5, 4}, [2]int{5, 5}, [2]int{5, 6}, [2]int{6, 5})
+	if got := scn.FindInBox(mgl32.Vec3{-100, -100, -1}, mgl32.Vec3{100, 100, 1}); len(got) != 100 {
+		t.Fatalf("large box found %d children, want all 100", len(got))
+	}
+
+	// Moved children are found at their new position
+	grid[[2]int{0, 0}].SetPosition(100, 100)
+	check("moved away", scn.FindInBox(mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{1, 1, 1}))
+	check("moved to", scn.FindInRadius(mgl32.Vec3{100, 100, 0}, 1), [2]int{0, 0})
+
+	// Attached children are found by their world position
+	attached := newIndexChild("", 0, 0)
+	grid[[2]int{9, 9}].AddChild(attached)
+	attached.SetPosition(1, 1)
+	if got := scn.FindInRadius(mgl32.Vec3{19, 19, 0}, 0.5); len(got) != 1 || got[0] != attached {
+		t.Fatalf("found %v at the world position of an attached child", got)
+	}
+
+	// The current scene is searched by the scene control
+	var sc SceneControl
+	if sc.FindInRadius(mgl32.Vec3{}, 1) != nil || sc.FindByName("") != nil {
+		t.Fatal("children were found without a current scene")
+	}
+	sc.currentScene = scn
+	if len(sc.FindInBox(mgl32.Vec3{-100, -100, -1}, mgl32.Vec3{200, 200, 1})) != 101 {
+		t.Fatal("the scene control didn't search the current scene")
+	}
+}
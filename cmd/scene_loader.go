@@ -0,0 +1,403 @@
+package cmd
+
+//   --------------------------------------------------
+//   Scene_loader.go loads scene files in the background.
+//   Textures, models, meshes and sounds are decoded on
+//   worker goroutines, then uploaded a few at a time on
+//   the main thread, along with building the scene, so
+//   that loading doesn't stall frames.
+//   --------------------------------------------------
+
+import (
+	"fmt"
+	"image"
+	"runtime"
+	"sync"
+	"time"
+
+	"rapidengine/event"
+	"rapidengine/geometry"
+	"rapidengine/material"
+	"rapidengine/ui"
+)
+
+// DefaultUploadBudget is the time spent each frame
+// uploading the assets of scenes being loaded
+const DefaultUploadBudget = 4 * time.Millisecond
+
+// SceneLoad is a scene file being loaded by LoadSceneAsync.
+// Its methods must be called on the main thread.
+type SceneLoad struct {
+	Path string
+
+	// ProgressBar, if set, shows the progress of the load
+	ProgressBar *ui.ProgressBar
+
+	// Transition, if set, is used to switch to
+	// the scene as soon as it has loaded
+	Transition *Transition
+
+	// Steps which have been decoded, waiting to run on the main
+	// thread, and the number of steps in the load, which is 0
+	// until the file has been read
+	mu    sync.Mutex
+	ready []loadStep
+	total int
+
+	done     int
+	finished bool
+
+	scene *Scene
+	err   error
+}
+
+// loadStep is the part of loading an asset that has to run on the main thread
+type loadStep func() error
+
+// LoadSceneAsync starts loading a scene file written by SaveScene.
+// The scene is instanced once every asset has been uploaded, and
+// an event.SceneLoaded is published when it's ready or has failed.
+func (sc *SceneControl) LoadSceneAsync(path string) *SceneLoad {
+	l := &SceneLoad{Path: path}
+
+	// Assets which are already registered aren't decoded again.
+	// The registries are only read here, on the main thread.
+	textures := make(map[string]bool)
+	for name := range sc.engine.TextureControl.TexMap {
+		textures[name] = true
+	}
+	sounds := make(map[string]bool)
+	for name := range sc.engine.AudioControl.Sounds {
+		sounds[name] = true
+	}
+
+	sc.loads = append(sc.loads, l)
+	go l.decode(sc.engine, textures, sounds)
+
+	return l
+}
+
+// Progress returns how much of the scene has loaded, from 0 to 1
+func (l *SceneLoad) Progress() float32 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.total == 0 {
+		return 0
+	}
+	return float32(l.done) / float32(l.total)
+}
+
+// Done returns whether the load has finished, successfully or not
+func (l *SceneLoad) Done() bool {
+	return l.finished
+}
+
+// Err returns the error which stopped the load, if any
+func (l *SceneLoad) Err() error {
+	return l.err
+}
+
+// Scene returns the loaded scene, which is nil until the load is done
+func (l *SceneLoad) Scene() *Scene {
+	return l.scene
+}
+
+//   --------------------------------------------------
+//   Workers
+//   --------------------------------------------------
+
+// decode reads the scene file and decodes its assets, queueing a
+// step to upload each one, and finally the steps to build the scene
+func (l *SceneLoad) decode(e *Engine, textures, sounds map[string]bool) {
+	var f SceneFile
+	if err := readSceneFile(l.Path, &f, &f.Version); err != nil {
+		l.setTotal(1)
+		l.push(func() error { return err })
+		return
+	}
+
+	r := newSceneReader(e, &f)
+
+	jobs := []func() (loadStep, error){}
+	for name, data := range f.Textures {
+		if !textures[name] {
+			jobs = append(jobs, l.textureJob(e, name, data))
+		}
+	}
+	for _, path := range modelPaths(&f.Scene) {
+		jobs = append(jobs, l.modelJob(e, r, path, textures))
+	}
+	for _, path := range objPaths(&f.Scene) {
+		jobs = append(jobs, l.objJob(r, path))
+	}
+	for name, path := range f.Sounds {
+		if !sounds[name] {
+			jobs = append(jobs, l.soundJob(e, name, path))
+		}
+	}
+
+	build := e.SceneControl.sceneSteps(r, func(scn *Scene) { l.scene = scn })
+	l.setTotal(len(jobs) + len(build))
+
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, runtime.NumCPU())
+
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job func() (loadStep, error)) {
+			defer wg.Done()
+
+			workers <- struct{}{}
+			step, err := job()
+			<-workers
+
+			if err != nil {
+				step = func() error { return err }
+			}
+			l.push(step)
+		}(job)
+	}
+	wg.Wait()
+
+	for _, step := range build {
+		l.push(step)
+	}
+}
+
+func (l *SceneLoad) textureJob(e *Engine, name string, data TextureData) func() (loadStep, error) {
+	return func() (loadStep, error) {
+		rgba, err := material.LoadImage(data.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		return func() error {
+			tc := &e.TextureControl
+			if _, ok := tc.LookupTexture(name); !ok {
+				tc.uploadTexture(rgba, data.Path, name, data.Filter)
+			}
+			return nil
+		}, nil
+	}
+}
+
+// modelJob imports a model along with its textures. Its meshes are
+// built on the main thread, and shared by every child using the model.
+func (l *SceneLoad) modelJob(e *Engine, r *sceneReader, path string, textures map[string]bool) func() (loadStep, error) {
+	return func() (loadStep, error) {
+		scene, err := importModel(path)
+		if err != nil {
+			return nil, err
+		}
+
+		images := make(map[string]*image.RGBA)
+		for _, tp := range modelTextures(scene) {
+			if textures[tp] {
+				continue
+			}
+			if images[tp], err = material.LoadImage(tp); err != nil {
+				return nil, err
+			}
+		}
+
+		return func() error {
+			tc := &e.TextureControl
+			for tp, rgba := range images {
+				if _, ok := tc.LookupTexture(tp); !ok {
+					tc.uploadTexture(rgba, tp, tp, "mipmap")
+				}
+			}
+
+			model, err := e.GeometryControl.buildModel(path, scene, nil)
+			if err != nil {
+				return err
+			}
+			r.models[path] = model
+			return nil
+		}, nil
+	}
+}
+
+// objJob decodes an obj file, which each child
+// using it builds its own mesh from
+func (l *SceneLoad) objJob(r *sceneReader, path string) func() (loadStep, error) {
+	return func() (loadStep, error) {
+		data, err := geometry.DecodeObj(path, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return func() error {
+			r.objs[path] = data
+			return nil
+		}, nil
+	}
+}
+
+func (l *SceneLoad) soundJob(e *Engine, name string, path string) func() (loadStep, error) {
+	return func() (loadStep, error) {
+		audio, err := decodeAudio(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return func() error {
+			ac := &e.AudioControl
+			if _, ok := ac.Sounds[name]; ok {
+				return (*audio.S).Close()
+			}
+			ac.Sounds[name] = audio
+			return nil
+		}, nil
+	}
+}
+
+func (l *SceneLoad) setTotal(total int) {
+	l.mu.Lock()
+	l.total = total
+	l.mu.Unlock()
+}
+
+func (l *SceneLoad) push(step loadStep) {
+	l.mu.Lock()
+	l.ready = append(l.ready, step)
+	l.mu.Unlock()
+}
+
+// next returns the next step which is ready to run, or nil
+func (l *SceneLoad) next() loadStep {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.ready) == 0 {
+		return nil
+	}
+	step := l.ready[0]
+	l.ready = l.ready[1:]
+	return step
+}
+
+// modelPaths returns the paths of the model files used in a scene
+func modelPaths(data *SceneData) []string {
+	paths := newPathSet()
+	walkChildren(data, func(d *ChildData) {
+		if d.Model != nil && d.Model.Path != "" {
+			paths.add(d.Model.Path)
+		}
+	})
+	return paths.paths
+}
+
+// objPaths returns the paths of the obj files
+// which a scene's meshes are built from
+func objPaths(data *SceneData) []string {
+	paths := newPathSet()
+	walkChildren(data, func(d *ChildData) {
+		if d.Dimensions == 2 && isObj(d.Mesh) {
+			paths.add(d.Mesh)
+		}
+		if d.Model != nil && d.Model.Path == "" {
+			for _, md := range d.Model.Meshes {
+				if isObj(md.ID) {
+					paths.add(md.ID)
+				}
+			}
+		}
+	})
+	return paths.paths
+}
+
+// walkChildren calls f on every child in a scene and its subscenes
+func walkChildren(data *SceneData, f func(d *ChildData)) {
+	var walk func(children []ChildData)
+	walk = func(children []ChildData) {
+		for i := range children {
+			f(&children[i])
+			walk(children[i].Children)
+		}
+	}
+
+	var scenes func(data *SceneData)
+	scenes = func(data *SceneData) {
+		walk(data.Children)
+		for i := range data.Subscenes {
+			scenes(&data.Subscenes[i])
+		}
+	}
+	scenes(data)
+}
+
+// pathSet is a list of paths without duplicates, in the order they were added
+type pathSet struct {
+	paths []string
+	seen  map[string]bool
+}
+
+func newPathSet() *pathSet {
+	return &pathSet{paths: []string{}, seen: make(map[string]bool)}
+}
+
+func (s *pathSet) add(path string) {
+	if !s.seen[path] {
+		s.seen[path] = true
+		s.paths = append(s.paths, path)
+	}
+}
+
+//   --------------------------------------------------
+//   Main Thread
+//   --------------------------------------------------
+
+// updateLoads runs the steps of the scenes being loaded until
+// UploadBudget is spent, running at least one step each frame
+func (sc *SceneControl) updateLoads() {
+	deadline := time.Now().Add(sc.UploadBudget)
+	ran := false
+
+	loads := sc.loads
+	sc.loads = nil
+
+	for _, l := range loads {
+		for !l.finished && (!ran || time.Now().Before(deadline)) {
+			step := l.next()
+			if step == nil {
+				break
+			}
+			ran = true
+
+			err := step()
+
+			l.mu.Lock()
+			l.done++
+			l.mu.Unlock()
+
+			if err != nil {
+				l.err = fmt.Errorf("loading scene %s: %w", l.Path, err)
+			}
+			l.finished = l.err != nil || l.scene != nil
+		}
+
+		if l.ProgressBar != nil {
+			l.ProgressBar.SetPercentage(l.Progress() * 100)
+		}
+
+		if l.finished {
+			sc.finishLoad(l)
+		} else {
+			sc.loads = append(sc.loads, l)
+		}
+	}
+}
+
+func (sc *SceneControl) finishLoad(l *SceneLoad) {
+	id := ""
+	if l.scene != nil {
+		id = l.scene.ID
+		if l.Transition != nil {
+			sc.TransitionTo(l.scene, *l.Transition)
+		}
+	}
+
+	sc.engine.Events.Publish(event.SceneLoaded{Path: l.Path, ID: id, Err: l.err})
+}
@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"rapidengine/assets"
+	"rapidengine/child"
+)
+
+// saveTestScene saves a scene of two children with their
+// own materials, and returns the path and its contents
+func saveTestScene(t *testing.T, e *Engine) (string, *SceneFile) {
+	scn := e.SceneControl.NewScene("level")
+	for i := 0; i < 2; i++ {
+		c := e.ChildControl.NewChild2D()
+		c.AttachMaterial(e.MaterialControl.NewBasicMaterial())
+		scn.InstanceChild(c)
+	}
+
+	f, err := e.SceneControl.EncodeScene(scn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "level.json")
+	if err := writeSceneFile(path, f); err != nil {
+		t.Fatal(err)
+	}
+	return path, f
+}
+
+// finishLoad runs frames until the load is done
+func finishLoad(t *testing.T, e *Engine, l *SceneLoad) {
+	deadline := time.Now().Add(10 * time.Second)
+	for !l.Done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s didn't finish loading", l.Path)
+		}
+		e.SceneControl.Update(0)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLoadSceneAsync(t *testing.T) {
+	e, _ := newTestEngine(t, 2)
+	newTestScene(e)
+	e.Initialize()
+
+	path, _ := saveTestScene(t, e)
+
+	e.SceneControl.UploadBudget = 0
+	l := e.SceneControl.LoadSceneAsync(path)
+
+	// Settings changed while the file is decoded apply to the
+	// scene, which is only made on the main thread
+	if err := e.ConsoleControl.Execute("show_fps 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	finishLoad(t, e, l)
+
+	if l.Err() != nil {
+		t.Fatal(l.Err())
+	}
+	if l.Progress() != 1 {
+		t.Fatalf("progress %v after loading, want 1", l.Progress())
+	}
+
+	scn := l.Scene()
+	if scn == nil || scn.ID != "level" || len(scn.GetChildren()) != 2 {
+		t.Fatalf("loaded %+v, want level with 2 children", scn)
+	}
+	if scn.control != &e.SceneControl {
+		t.Fatal("the loaded scene isn't set up by the scene control")
+	}
+	if texts := scn.GetTexts(); len(texts) != 1 || texts[0] != e.FPSBox {
+		t.Fatalf("loaded scene has texts %v, want the FPS text", texts)
+	}
+}
+
+func TestLoadSceneAsyncFailure(t *testing.T) {
+	e, _ := newTestEngine(t, 2)
+	newTestScene(e)
+	e.Initialize()
+
+	_, f := saveTestScene(t, e)
+
+	// The first child is built and put in a collision group,
+	// before the material of the second can't be
+	f.Scene.Children[0].CollisionGroups = []string{"crates"}
+	bad := f.Scene.Children[1].Material
+	m := f.Materials[bad]
+	m.Type = "unknown"
+	f.Materials[bad] = m
+
+	path := filepath.Join(t.TempDir(), "bad.json")
+	if err := writeSceneFile(path, f); err != nil {
+		t.Fatal(err)
+	}
+
+	materials := len(e.MaterialControl.Materials)
+	processing := len(e.MaterialControl.processing)
+	scenes := len(e.SceneControl.scenes)
+
+	l := e.SceneControl.LoadSceneAsync(path)
+	finishLoad(t, e, l)
+
+	if l.Err() == nil || l.Scene() != nil {
+		t.Fatalf("load returned %v and %v, want an error", l.Scene(), l.Err())
+	}
+	if len(e.MaterialControl.Materials) != materials || len(e.MaterialControl.processing) != processing {
+		t.Fatal("materials of the failed load were registered")
+	}
+	if len(e.SceneControl.scenes) != scenes {
+		t.Fatal("the scene of the failed load was instanced")
+	}
+	if len(e.CollisionControl.GroupMap["crates"]) != 0 {
+		t.Fatal("a child of the failed load was added to its collision group")
+	}
+}
+
+// objTestScene is a scene of two 2D children, one with another
+// attached, and a 3D child in a subscene, all built from obj files
+func objTestScene(path string) SceneFile {
+	c2 := NewChildData(2)
+	c2.Material = "default1"
+	c2.Mesh = path
+
+	attached := c2
+	parent := c2
+	parent.Children = []ChildData{attached}
+
+	c3 := NewChildData(3)
+	c3.Model = &ModelData{
+		Meshes:    []MeshData{{ID: path}, {ID: "cube"}},
+		Materials: map[int]string{0: "default1"},
+	}
+
+	return SceneFile{
+		Version: SceneFileVersion,
+		Scene: SceneData{
+			ID:        "objs",
+			Active:    true,
+			Children:  []ChildData{c2, parent},
+			Subscenes: []SceneData{{ID: "sub", Active: true, Children: []ChildData{c3}}},
+		},
+	}
+}
+
+func TestObjPaths(t *testing.T) {
+	f := objTestScene("obj/sphere_uv.obj")
+
+	// Each path is only decoded once, however many children use it
+	if got, want := objPaths(&f.Scene), []string{"obj/sphere_uv.obj"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("obj paths %q, want %q", got, want)
+	}
+
+	// Meshes of model files are built with the model
+	f.Scene.Subscenes[0].Children[0].Model.Path = "obj/other.obj"
+	f.Scene.Children[0].Mesh = "obj/OTHER.OBJ"
+	if got, want := objPaths(&f.Scene), []string{"obj/OTHER.OBJ", "obj/sphere_uv.obj"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("obj paths %q, want %q", got, want)
+	}
+	if got, want := modelPaths(&f.Scene), []string{"obj/other.obj"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("model paths %q, want %q", got, want)
+	}
+}
+
+func TestLoadSceneAsyncObj(t *testing.T) {
+	e, _ := newTestEngine(t, 3)
+	newTestScene(e)
+	e.Initialize()
+
+	f := objTestScene("obj/sphere_uv.obj")
+	path := filepath.Join(t.TempDir(), "objs.json")
+	if err := writeSceneFile(path, &f); err != nil {
+		t.Fatal(err)
+	}
+
+	// With no budget, one step runs each frame
+	// and the progress never goes backwards
+	e.SceneControl.UploadBudget = 0
+	l := e.SceneControl.LoadSceneAsync(path)
+
+	last := float32(0)
+	deadline := time.Now().Add(10 * time.Second)
+	for !l.Done() {
+		if time.Now().After(deadline) {
+			t.Fatal("the scene didn't finish loading")
+		}
+		e.SceneControl.Update(0)
+		if p := l.Progress(); p < last {
+			t.Fatalf("progress went from %v to %v", last, p)
+		} else {
+			last = p
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if l.Err() != nil {
+		t.Fatal(l.Err())
+	}
+	if last != 1 {
+		t.Fatalf("progress %v after loading, want 1", last)
+	}
+
+	// Every child builds its own mesh from the decoded file
+	var vaos []interface{}
+	for _, c := range l.Scene().GetChildren() {
+		switch c := c.(type) {
+		case *child.Child2D:
+			if c.Mesh.ID != "obj/sphere_uv.obj" || c.Mesh.NumVertices == 0 {
+				t.Fatalf("2D child has mesh %q of %d vertices", c.Mesh.ID, c.Mesh.NumVertices)
+			}
+			vaos = append(vaos, c.Mesh.VAO)
+		case *child.Child3D:
+			if len(c.Model.Meshes) != 2 || c.Model.Meshes[0].ID != "obj/sphere_uv.obj" {
+				t.Fatalf("3D child has %d meshes, want the obj file and a cube", len(c.Model.Meshes))
+			}
+			vaos = append(vaos, c.Model.Meshes[0].VAO)
+		}
+	}
+	if len(vaos) != 4 {
+		t.Fatalf("%d children built from obj files, want 4", len(vaos))
+	}
+	for i := range vaos {
+		for j := i + 1; j < len(vaos); j++ {
+			if vaos[i] == vaos[j] {
+				t.Fatal("two children share a mesh built from an obj file")
+			}
+		}
+	}
+
+	// A missing obj file fails the load
+	f = objTestScene("obj/missing.obj")
+	if err := writeSceneFile(path, &f); err != nil {
+		t.Fatal(err)
+	}
+	l = e.SceneControl.LoadSceneAsync(path)
+	finishLoad(t, e, l)
+	if !errors.Is(l.Err(), assets.ErrAssetNotFound) {
+		t.Fatalf("loading a missing obj file returned %v, want ErrAssetNotFound", l.Err())
+	}
+}
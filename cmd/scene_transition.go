@@ -0,0 +1,160 @@
+package cmd
+
+//   --------------------------------------------------
+//   Scene_transition.go switches between scenes with
+//   a fade or a crossfade, which are drawn by the
+//   PostControl's "transition" pass.
+//   --------------------------------------------------
+
+// TransitionKind is the effect used to switch scenes
+type TransitionKind int
+
+const (
+	// TransitionCut switches scenes at once
+	TransitionCut TransitionKind = iota
+
+	// TransitionFade fades the screen to a color, switches
+	// scenes, and fades back in from the color
+	TransitionFade
+
+	// TransitionCrossfade blends from the last frame
+	// of the previous scene into the new one
+	TransitionCrossfade
+)
+
+// Transition describes how to switch to a scene
+type Transition struct {
+	Kind TransitionKind
+
+	// Duration is the length of the whole transition in seconds
+	Duration float64
+
+	// Color the screen fades through, for TransitionFade
+	Color [3]float32
+}
+
+type sceneTransition struct {
+	Transition
+
+	to *Scene
+
+	elapsed  float64
+	switched bool
+
+	// Whether post processing was enabled just for the transition
+	restorePost bool
+}
+
+// TransitionTo makes scn the current scene through a transition. A
+// transition already in progress is finished first. Post processing
+// is enabled while the transition runs, if it wasn't already.
+func (sc *SceneControl) TransitionTo(scn *Scene, t Transition) {
+	if sc.transition != nil {
+		if !sc.transition.switched {
+			sc.SetCurrentScene(sc.transition.to)
+		}
+		sc.endTransition()
+	}
+
+	if t.Kind == TransitionCut || t.Duration <= 0 {
+		sc.SetCurrentScene(scn)
+		return
+	}
+
+	pc := &sc.engine.PostControl
+
+	st := &sceneTransition{
+		Transition:  t,
+		to:          scn,
+		restorePost: !pc.IsPostProcessingEnabled(),
+	}
+	if st.restorePost {
+		pc.EnablePostProcessing()
+	}
+
+	pc.transitionEnabled = true
+	pc.TransitionBlend = 0
+	pc.TransitionFade = 0
+	pc.TransitionColor = t.Color
+
+	// The previous scene is captured as the next frame is drawn
+	if t.Kind == TransitionCrossfade {
+		pc.captureTransition = true
+	}
+
+	sc.transition = st
+}
+
+// IsTransitioning returns whether a transition is in progress
+func (sc *SceneControl) IsTransitioning() bool {
+	return sc.transition != nil
+}
+
+func (sc *SceneControl) updateTransition(delta float64) {
+	st := sc.transition
+	if st == nil {
+		return
+	}
+
+	pc := &sc.engine.PostControl
+
+	switch st.Kind {
+
+	case TransitionFade:
+		st.elapsed += delta
+		half := st.Duration / 2
+		if !st.switched {
+			pc.TransitionFade = transitionProgress(st.elapsed, half)
+			if st.elapsed >= half {
+				sc.SetCurrentScene(st.to)
+				st.switched = true
+				st.elapsed = 0
+			}
+			return
+		}
+		pc.TransitionFade = 1 - transitionProgress(st.elapsed, half)
+
+	case TransitionCrossfade:
+		if !st.switched {
+			// Wait for a frame of the previous scene to be captured
+			if pc.captureTransition {
+				return
+			}
+			sc.SetCurrentScene(st.to)
+			st.switched = true
+			pc.TransitionBlend = 1
+			return
+		}
+		st.elapsed += delta
+		pc.TransitionBlend = 1 - transitionProgress(st.elapsed, st.Duration)
+
+	}
+
+	if pc.TransitionFade <= 0 && pc.TransitionBlend <= 0 {
+		sc.endTransition()
+	}
+}
+
+func (sc *SceneControl) endTransition() {
+	pc := &sc.engine.PostControl
+
+	pc.transitionEnabled = false
+	pc.captureTransition = false
+	pc.TransitionBlend = 0
+	pc.TransitionFade = 0
+
+	if sc.transition.restorePost {
+		pc.DisablePostProcessing()
+	}
+
+	sc.transition = nil
+}
+
+// transitionProgress returns how far through a duration elapsed
+// is, from 0 to 1. Zero length durations are always complete.
+func transitionProgress(elapsed, duration float64) float32 {
+	if duration <= 0 || elapsed >= duration {
+		return 1
+	}
+	return float32(elapsed / duration)
+}
@@ -1,6 +1,9 @@
 package cmd
 
-import "rapidengine/material"
+import (
+	"rapidengine/material"
+	"sort"
+)
 
 type ShaderControl struct {
 	programs map[string]*material.ShaderProgram
@@ -14,7 +17,9 @@ func (shaderControl *ShaderControl) BindShader(name string) {
 	shaderControl.programs[name].Bind()
 }
 
-func (shaderControl *ShaderControl) Initialize() {
+// Initialize compiles all of the engine's built in shaders, returning
+// the first error encountered.
+func (shaderControl *ShaderControl) Initialize() error {
 	shaderControl.programs = map[string]*material.ShaderProgram{
 		"basic":    &material.BasicProgram,
 		"standard": &material.StandardProgram,
@@ -24,6 +29,7 @@ func (shaderControl *ShaderControl) Initialize() {
 		"foliage":  &material.FoliageProgram,
 		"water":    &material.WaterProgram,
 		"sun":      &material.SunProgram,
+		"debug":    &material.DebugProgram,
 
 		"post_final":          &material.PostFinalProgram,
 		"post_hdr":            &material.PostHDRProgram,
@@ -33,17 +39,43 @@ func (shaderControl *ShaderControl) Initialize() {
 		"post_postscattering": &material.PostPostScatteringProgram,
 		"post_prebloom":       &material.PostPreBloomProgram,
 		"post_postbloom":      &material.PostPostBloomProgram,
+		"post_transition":     &material.PostTransitionProgram,
 	}
 	for _, prog := range shaderControl.programs {
-		prog.Compile()
+		if err := prog.Compile(); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (shaderControl *ShaderControl) AddCustomShader(name string, program *material.ShaderProgram) {
+// AddCustomShader compiles a program and registers it under name.
+// The program isn't registered if it fails to compile.
+func (shaderControl *ShaderControl) AddCustomShader(name string, program *material.ShaderProgram) error {
+	if err := program.Compile(); err != nil {
+		return err
+	}
 	shaderControl.programs[name] = program
-	shaderControl.programs[name].Compile()
+	return nil
 }
 
 func (shaderControl *ShaderControl) GetShader(name string) *material.ShaderProgram {
 	return shaderControl.programs[name]
 }
+
+// Names returns the names of every registered program, sorted
+func (shaderControl *ShaderControl) Names() []string {
+	names := make([]string, 0, len(shaderControl.programs))
+	for name := range shaderControl.programs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupShader returns the program registered under name,
+// and whether it exists.
+func (shaderControl *ShaderControl) LookupShader(name string) (*material.ShaderProgram, bool) {
+	prog, ok := shaderControl.programs[name]
+	return prog, ok
+}
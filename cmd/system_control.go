@@ -0,0 +1,380 @@
+package cmd
+
+//   --------------------------------------------------
+//   System_control.go contains the registry of per-frame
+//   systems. Each system runs in one phase of the frame,
+//   in order, after any systems it depends on. The engine's own
+//   controls are registered as built in systems, and users
+//   can register their own at any time.
+//   --------------------------------------------------
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Phase is the part of the frame a system is updated in
+type Phase int
+
+const (
+	// PhasePreUpdate, PhaseUpdate and PhasePostUpdate run after
+	// the scene has been drawn, around the user's frame functions
+	PhasePreUpdate Phase = iota
+	PhaseUpdate
+	PhasePostUpdate
+
+	// PhasePreRender runs before anything is drawn
+	PhasePreRender
+
+	// PhasePostRender runs after everything has
+	// been drawn, before the buffers are swapped
+	PhasePostRender
+
+	numPhases
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhasePreRender:
+		return "pre-render"
+	case PhasePreUpdate:
+		return "pre-update"
+	case PhaseUpdate:
+		return "update"
+	case PhasePostUpdate:
+		return "post-update"
+	case PhasePostRender:
+		return "post-render"
+	}
+	return fmt.Sprintf("Phase(%d)", int(p))
+}
+
+// System is a subsystem updated by the engine every frame
+type System interface {
+	// Name uniquely identifies the system
+	Name() string
+
+	// Phase is the part of the frame the system is updated in
+	Phase() Phase
+
+	// Dependencies are the names of systems which must be initialized
+	// before this one, and updated before it if they share its phase
+	Dependencies() []string
+
+	Init(engine *Engine) error
+	Update(delta float64)
+	Shutdown()
+}
+
+// OrderedSystem is a System with an order within its phase. Systems
+// of a phase are updated in increasing order, unless a dependency
+// has to be updated first, and systems of the same order, including
+// those without one, which are 0, in the order they were registered.
+type OrderedSystem interface {
+	System
+	Order() int
+}
+
+func systemOrder(s System) int {
+	if o, ok := s.(OrderedSystem); ok {
+		return o.Order()
+	}
+	return 0
+}
+
+// FuncSystem is a System built from functions, any of which may be nil
+type FuncSystem struct {
+	SystemName  string
+	SystemPhase Phase
+	SystemOrder int
+	Requires    []string
+
+	InitFunc     func(engine *Engine) error
+	UpdateFunc   func(delta float64)
+	ShutdownFunc func()
+}
+
+func (s *FuncSystem) Name() string           { return s.SystemName }
+func (s *FuncSystem) Phase() Phase           { return s.SystemPhase }
+func (s *FuncSystem) Order() int             { return s.SystemOrder }
+func (s *FuncSystem) Dependencies() []string { return s.Requires }
+
+func (s *FuncSystem) Init(engine *Engine) error {
+	if s.InitFunc != nil {
+		return s.InitFunc(engine)
+	}
+	return nil
+}
+
+func (s *FuncSystem) Update(delta float64) {
+	if s.UpdateFunc != nil {
+		s.UpdateFunc(delta)
+	}
+}
+
+func (s *FuncSystem) Shutdown() {
+	if s.ShutdownFunc != nil {
+		s.ShutdownFunc()
+	}
+}
+
+type SystemControl struct {
+	// All systems in registration order
+	systems []System
+
+	// Systems sorted by dependency, and each phase's by order
+	initOrder  []System
+	phaseOrder [numPhases][]System
+
+	initialized bool
+
+	// Called with how long each system's update took, if set
+	timer func(s System, took time.Duration)
+
+	engine *Engine
+}
+
+func NewSystemControl() SystemControl {
+	return SystemControl{}
+}
+
+// Initialize initializes every registered system in dependency order
+func (sc *SystemControl) Initialize(e *Engine) error {
+	sc.engine = e
+
+	if err := sc.sort(); err != nil {
+		return err
+	}
+
+	for _, s := range sc.initOrder {
+		if err := s.Init(e); err != nil {
+			return fmt.Errorf("initializing system %s: %w", s.Name(), err)
+		}
+	}
+
+	sc.initialized = true
+	return nil
+}
+
+// Register adds a system. Once the engine has been
+// initialized, the system is initialized immediately
+// and its dependencies must already be registered.
+func (sc *SystemControl) Register(s System) error {
+	if s.Phase() < 0 || s.Phase() >= numPhases {
+		return fmt.Errorf("system %s has invalid phase %v", s.Name(), s.Phase())
+	}
+	if _, ok := sc.GetSystem(s.Name()); ok {
+		return fmt.Errorf("system %s is already registered", s.Name())
+	}
+
+	sc.systems = append(sc.systems, s)
+
+	if !sc.initialized {
+		return nil
+	}
+
+	if err := sc.sort(); err != nil {
+		sc.systems = sc.systems[:len(sc.systems)-1]
+		sc.sort()
+		return err
+	}
+
+	if err := s.Init(sc.engine); err != nil {
+		sc.remove(s.Name())
+		return fmt.Errorf("initializing system %s: %w", s.Name(), err)
+	}
+
+	return nil
+}
+
+// Unregister shuts down and removes a system. It fails
+// if any other registered system depends on it.
+func (sc *SystemControl) Unregister(name string) error {
+	s, ok := sc.GetSystem(name)
+	if !ok {
+		return fmt.Errorf("system %s is not registered", name)
+	}
+
+	for _, other := range sc.systems {
+		for _, dep := range other.Dependencies() {
+			if dep == name {
+				return fmt.Errorf("system %s is required by %s", name, other.Name())
+			}
+		}
+	}
+
+	if sc.initialized {
+		s.Shutdown()
+	}
+	sc.remove(name)
+
+	return nil
+}
+
+// GetSystem returns the system registered under name
+func (sc *SystemControl) GetSystem(name string) (System, bool) {
+	for _, s := range sc.systems {
+		if s.Name() == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// Systems returns the systems of a phase in the order they are updated
+func (sc *SystemControl) Systems(phase Phase) []System {
+	return sc.phaseOrder[phase]
+}
+
+// Run updates every system in a phase
+func (sc *SystemControl) Run(phase Phase, delta float64) {
+	if sc.timer == nil {
+		for _, s := range sc.phaseOrder[phase] {
+			s.Update(delta)
+		}
+		return
+	}
+
+	for _, s := range sc.phaseOrder[phase] {
+		start := time.Now()
+		s.Update(delta)
+		sc.timer(s, time.Since(start))
+	}
+}
+
+// SetUpdateTimer sets a function called after every system
+// update with how long it took, or stops timing if f is nil
+func (sc *SystemControl) SetUpdateTimer(f func(s System, took time.Duration)) {
+	sc.timer = f
+}
+
+// Shutdown shuts down every system in reverse initialization order
+func (sc *SystemControl) Shutdown() {
+	if !sc.initialized {
+		return
+	}
+
+	for i := len(sc.initOrder) - 1; i >= 0; i-- {
+		sc.initOrder[i].Shutdown()
+	}
+	sc.initialized = false
+}
+
+func (sc *SystemControl) remove(name string) {
+	for i, s := range sc.systems {
+		if s.Name() == name {
+			sc.systems = append(sc.systems[:i], sc.systems[i+1:]...)
+			break
+		}
+	}
+	sc.sort()
+}
+
+// sort orders the systems so that every system comes after its
+// dependencies, keeping registration order otherwise, then orders
+// the systems of each phase
+func (sc *SystemControl) sort() error {
+	byName := make(map[string]System, len(sc.systems))
+	for _, s := range sc.systems {
+		byName[s.Name()] = s
+	}
+
+	order := make([]System, 0, len(sc.systems))
+	state := make(map[string]int, len(sc.systems))
+
+	var visit func(s System, path []string) error
+	visit = func(s System, path []string) error {
+		switch state[s.Name()] {
+		case 1:
+			return fmt.Errorf("system dependency cycle: %s", strings.Join(append(path, s.Name()), " -> "))
+		case 2:
+			return nil
+		}
+
+		state[s.Name()] = 1
+		for _, dep := range s.Dependencies() {
+			d, ok := byName[dep]
+			if !ok {
+				return fmt.Errorf("system %s depends on unregistered system %s", s.Name(), dep)
+			}
+			if err := visit(d, append(path, s.Name())); err != nil {
+				return err
+			}
+		}
+		state[s.Name()] = 2
+
+		order = append(order, s)
+		return nil
+	}
+
+	for _, s := range sc.systems {
+		if err := visit(s, nil); err != nil {
+			return err
+		}
+	}
+
+	sc.initOrder = order
+	for p := range sc.phaseOrder {
+		sc.phaseOrder[p] = nil
+	}
+	for _, s := range order {
+		sc.phaseOrder[s.Phase()] = append(sc.phaseOrder[s.Phase()], s)
+	}
+	for p := range sc.phaseOrder {
+		sc.phaseOrder[p] = orderPhase(sc.phaseOrder[p], byName)
+	}
+
+	return nil
+}
+
+// orderPhase sorts the systems of a phase, which are in dependency
+// order, by their order. Each system still comes after the systems
+// of the phase it depends on, directly or through other phases.
+func orderPhase(systems []System, byName map[string]System) []System {
+	requires := make(map[string]map[string]bool)
+
+	var deps func(s System) map[string]bool
+	deps = func(s System) map[string]bool {
+		if all, ok := requires[s.Name()]; ok {
+			return all
+		}
+		all := make(map[string]bool)
+		for _, dep := range s.Dependencies() {
+			all[dep] = true
+			for d := range deps(byName[dep]) {
+				all[d] = true
+			}
+		}
+		requires[s.Name()] = all
+		return all
+	}
+
+	sorted := make([]System, 0, len(systems))
+	placed := make(map[string]bool, len(systems))
+
+	for len(sorted) < len(systems) {
+		var next System
+		for _, s := range systems {
+			if placed[s.Name()] || (next != nil && systemOrder(s) >= systemOrder(next)) {
+				continue
+			}
+
+			ready := true
+			for _, other := range systems {
+				if !placed[other.Name()] && deps(s)[other.Name()] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = s
+			}
+		}
+
+		placed[next.Name()] = true
+		sorted = append(sorted, next)
+	}
+
+	return sorted
+}
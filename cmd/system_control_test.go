@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func systemNames(systems []System) []string {
+	names := []string{}
+	for _, s := range systems {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+func TestSystemOrder(t *testing.T) {
+	sc := NewSystemControl()
+
+	updated := []string{}
+	register := func(name string, order int, requires ...string) {
+		err := sc.Register(&FuncSystem{
+			SystemName:  name,
+			SystemPhase: PhasePostUpdate,
+			SystemOrder: order,
+			Requires:    requires,
+			UpdateFunc:  func(float64) { updated = append(updated, name) },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sc.Register(&FuncSystem{SystemName: "setup"})
+
+	register("late", 10)
+	register("user", 0)
+	register("text", -1)
+	register("terrain", -3)
+	register("light", -2)
+
+	// Ordered before terrain, but it has to wait for late,
+	// through a system of another phase
+	sc.Register(&FuncSystem{SystemName: "bridge", Requires: []string{"late"}})
+	register("early", -10, "bridge")
+
+	if err := sc.Initialize(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"terrain", "light", "text", "user", "late", "early"}
+	if got := systemNames(sc.Systems(PhasePostUpdate)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("post update systems %v, want %v", got, want)
+	}
+
+	sc.Run(PhasePostUpdate, 0)
+	if !reflect.DeepEqual(updated, want) {
+		t.Fatalf("updated %v, want %v", updated, want)
+	}
+
+	// Systems of the same order keep registration order
+	register("user2", 0)
+	want = []string{"terrain", "light", "text", "user", "user2", "late", "early"}
+	if got := systemNames(sc.Systems(PhasePostUpdate)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("post update systems %v, want %v", got, want)
+	}
+
+	// Nothing depends on light, so it can be removed on its own
+	if err := sc.Unregister("light"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.Unregister("late"); err == nil {
+		t.Fatal("late was unregistered while bridge depends on it")
+	}
+}
+
+func TestSystemRegisterErrors(t *testing.T) {
+	sc := NewSystemControl()
+
+	sc.Register(&FuncSystem{SystemName: "a"})
+	if err := sc.Register(&FuncSystem{SystemName: "a"}); err == nil {
+		t.Fatal("a system was registered twice")
+	}
+	if err := sc.Register(&FuncSystem{SystemName: "bad", SystemPhase: numPhases}); err == nil {
+		t.Fatal("a system was registered with an invalid phase")
+	}
+
+	if err := sc.Initialize(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sc.Register(&FuncSystem{SystemName: "b", Requires: []string{"missing"}}); err == nil {
+		t.Fatal("a system was registered with a missing dependency")
+	}
+	if _, ok := sc.GetSystem("b"); ok {
+		t.Fatal("a system which failed to register was kept")
+	}
+
+	// Dependency cycles are caught when the engine is initialized
+	cyclic := NewSystemControl()
+	cyclic.Register(&FuncSystem{SystemName: "x", Requires: []string{"y"}})
+	cyclic.Register(&FuncSystem{SystemName: "y", Requires: []string{"x"}})
+	if err := cyclic.Initialize(nil); err == nil {
+		t.Fatal("a dependency cycle wasn't caught")
+	}
+}
@@ -74,14 +74,19 @@ func (tc *TerrainControl) NewTerrain(width int, height int, vertices int) *terra
 	return &t
 }
 
-func (tc *TerrainControl) NewPlanetaryTerrain(width int, height int, vertices int) *terrain.Terrain {
+func (tc *TerrainControl) NewPlanetaryTerrain(width int, height int, vertices int) (*terrain.Terrain, error) {
+	sphere, err := geometry.LoadObj("obj/sphere_uv.obj", 10000)
+	if err != nil {
+		return nil, err
+	}
+
 	t := terrain.NewTerrain(width, height)
 
 	t.TChild = tc.engine.ChildControl.NewChild3D()
 
 	t.TChild.AttachMaterial(tc.engine.MaterialControl.NewTerrainMaterial())
 	//t.TChild.AttachMesh(geometry.NewPlane(width, height, vertices, nil, 1))
-	t.TChild.AttachMesh(geometry.LoadObj("../rapidengine/assets/obj/sphere.obj", 10000))
+	t.TChild.AttachMesh(sphere)
 	t.TChild.SetInstanceRenderDistance(1000000000)
 
 	t.TChild.PreRender(tc.engine.Renderer.MainCamera)
@@ -89,17 +94,20 @@ func (tc *TerrainControl) NewPlanetaryTerrain(width int, height int, vertices in
 	tc.terrainEnabled = true
 	tc.root = &t
 
-	return &t
+	return &t, nil
 }
 
-func (tc *TerrainControl) NewFoliage(width int, height int, instances int) *terrain.Foliage {
+func (tc *TerrainControl) NewFoliage(width int, height int, instances int) (*terrain.Foliage, error) {
+	billboard, err := tc.engine.GeometryControl.LoadModel("billboard.obj", tc.engine.MaterialControl.NewFoliageMaterial())
+	if err != nil {
+		return nil, err
+	}
+
 	f := terrain.NewFoliage(width, height)
 
 	f.FChild = tc.engine.ChildControl.NewChild3D()
 
-	f.FChild.AttachModel(
-		tc.engine.GeometryControl.LoadModel("./billboard.obj", tc.engine.MaterialControl.NewFoliageMaterial()),
-	)
+	f.FChild.AttachModel(billboard)
 
 	f.FChild.Model.EnableInstancing(instances)
 	f.FChild.Model.Meshes[0].InstancingEnabled = true
@@ -115,7 +123,7 @@ func (tc *TerrainControl) NewFoliage(width int, height int, instances int) *terr
 
 	f.FChild.PreRender(tc.engine.Renderer.MainCamera)
 
-	return &f
+	return &f, nil
 }
 
 func (tc *TerrainControl) NewWater(width int, height int, vertices int) *terrain.Water {
@@ -140,11 +148,11 @@ func (terrainControl *TerrainControl) NewSkyBox(
 	textureControl *TextureControl,
 
 	config *configuration.EngineConfig,
-) *terrain.SkyBox {
+) (*terrain.SkyBox, error) {
 
 	shaderControl.GetShader("skybox").Bind()
 
-	textureControl.NewCubeMap(
+	err := textureControl.NewCubeMap(
 		fmt.Sprintf("%s/%s/%s_LF.%s", path, name, name, ext),
 		fmt.Sprintf("%s/%s/%s_RT.%s", path, name, name, ext),
 		fmt.Sprintf("%s/%s/%s_UP.%s", path, name, name, ext),
@@ -154,6 +162,9 @@ func (terrainControl *TerrainControl) NewSkyBox(
 
 		"skybox",
 	)
+	if err != nil {
+		return nil, err
+	}
 
 	cmaterial := terrainControl.engine.MaterialControl.NewCubemapMaterial()
 	cmaterial.CubeDiffuseMap = textureControl.GetTexture("skybox").Addr
@@ -169,14 +180,20 @@ func (terrainControl *TerrainControl) NewSkyBox(
 	return terrain.NewSkyBox(
 		cmaterial,
 		vao,
-		mgl32.Perspective(
-			mgl32.DegToRad(45),
-			float32(config.ScreenWidth)/float32(config.ScreenHeight),
-			0.1, 100,
-		),
+		skyBoxProjection(float32(config.ScreenWidth)/float32(config.ScreenHeight)),
 		mgl32.Ident4(),
 		[]*material.ShaderProgram{
 			terrainControl.engine.ShaderControl.GetShader("standard"),
 		},
+	), nil
+}
+
+// skyBoxProjection is the projection skyboxes
+// are rendered with at the given aspect ratio
+func skyBoxProjection(aspect float32) mgl32.Mat4 {
+	return mgl32.Perspective(
+		mgl32.DegToRad(45),
+		aspect,
+		0.1, 100,
 	)
 }
@@ -1,10 +1,14 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/draw"
+	"image/png"
+	"rapidengine/assets"
 	"rapidengine/configuration"
-	"rapidengine/state"
 	"rapidengine/ui"
 
 	"github.com/4ydx/gltext"
@@ -30,18 +34,19 @@ func (tc *TextControl) Initialize(engine *Engine) {
 }
 
 func (tc *TextControl) Update() {
+	if tc.engine.Renderer.Device.Headless() {
+		return
+	}
+
 	for _, t := range tc.engine.SceneControl.GetCurrentTexts() {
 		t.Update(tc.engine.Config)
 	}
-	state.BoundTexture0 = 999
+
+	// gltext binds its own program, VAO and textures
+	tc.engine.Renderer.State.Invalidate()
 }
 
 func (tc *TextControl) NewTextBox(text string, font string, x, y, scale float32, color [3]float32) *ui.TextBox {
-	t := v41.NewText(tc.Fonts[font], 0.2, 10)
-	t.SetString(text)
-	t.SetColor(mgl32.Vec3{1, 1, 1})
-	t.AddScale(scale)
-
 	textbox := &ui.TextBox{
 		Text:  text,
 		Font:  font,
@@ -50,24 +55,46 @@ func (tc *TextControl) NewTextBox(text string, font string, x, y, scale float32,
 		Y:     y,
 		Scale: scale,
 	}
+
+	// gltext draws with OpenGL directly, so text only
+	// exists on a real device
+	if tc.engine.Renderer.Device.Headless() {
+		return textbox
+	}
+
+	t := v41.NewText(tc.Fonts[font], 0.2, 10)
+	t.SetString("%s", text)
+	t.SetColor(mgl32.Vec3{1, 1, 1})
+	t.AddScale(scale)
 	textbox.SetV41Text(t)
+	tc.engine.Renderer.State.Invalidate()
 
 	return textbox
 }
 
-func (tc *TextControl) LoadFont(path string, name string, scale float32, offset int) {
+// fontConfigDir holds the font configs generated by LoadFont,
+// read and written through the asset VFS
+const fontConfigDir = "fontconfigs"
+
+// LoadFont loads a truetype font, using the cached font config
+// in fontconfigs if one has already been generated for name
+func (tc *TextControl) LoadFont(path string, name string, scale float32, offset int) error {
+	if tc.engine.Renderer.Device.Headless() {
+		return nil
+	}
+
 	var font *v41.Font
-	config, err := gltext.LoadTruetypeFontConfig("fontconfigs", name)
+	config, err := loadFontConfig(name)
 	if err == nil {
 		font, err = v41.NewFont(config)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		fmt.Println("Font loaded from disk...")
+		tc.engine.Logger.Debug("Loaded font ", name, " from its cached config")
 	} else {
-		fd, err := os.Open(path)
+		fd, err := assets.Open(path)
 		if err != nil {
-			panic(err)
+			return err
 		}
 		defer fd.Close()
 
@@ -85,20 +112,88 @@ func (tc *TextControl) LoadFont(path string, name string, scale float32, offset
 		runesPerRow := fixed.Int26_6(128)
 		config, err = gltext.NewTruetypeFontConfig(fd, scale, runeRanges, runesPerRow, fixed.Int26_6(offset))
 		if err != nil {
-			panic(err)
+			return &assets.DecodeError{Path: path, Format: "truetype", Err: err}
 		}
-		err = config.Save("fontconfigs", name)
-		if err != nil {
-			panic(err)
+		// The cache is optional, packed builds have nowhere to write it
+		if err := saveFontConfig(config, name); err != nil {
+			tc.engine.Logger.Warn("Couldn't cache the config of font ", name, ": ", err)
 		}
 		font, err = v41.NewFont(config)
 		if err != nil {
-			panic(err)
+			return err
 		}
 	}
 
 	font.ResizeWindow(float32(tc.engine.Config.ScreenWidth), float32(tc.engine.Config.ScreenHeight))
+	tc.engine.Renderer.State.Invalidate()
 
 	tc.Fonts[name] = font
 
+	return nil
+}
+
+// loadFontConfig reads the config and glyph image cached for name
+func loadFontConfig(name string) (*gltext.FontConfig, error) {
+	path := fontConfigDir + "/" + name
+	blob, err := assets.ReadFile(path + ".config")
+	if err != nil {
+		return nil, err
+	}
+
+	config := &gltext.FontConfig{}
+	if err := json.Unmarshal(blob, config); err != nil {
+		return nil, &assets.DecodeError{Path: path + ".config", Format: "json", Err: err}
+	}
+	config.Name = name
+
+	fd, err := assets.Open(path + ".png")
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	img, err := png.Decode(fd)
+	if err != nil {
+		return nil, &assets.DecodeError{Path: path + ".png", Format: "png", Err: err}
+	}
+	if nrgba, ok := img.(*image.NRGBA); ok {
+		config.Image = nrgba
+	} else {
+		config.Image = image.NewNRGBA(img.Bounds())
+		draw.Draw(config.Image, img.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
+
+	return config, nil
+}
+
+// saveFontConfig caches a generated font config and its glyph image
+func saveFontConfig(config *gltext.FontConfig, name string) error {
+	if config.Image == nil {
+		return errors.New("font config has no glyph image")
+	}
+	config.Name = name
+
+	blob, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, config.Image); err != nil {
+		return err
+	}
+
+	path := fontConfigDir + "/" + name
+	if err := assets.WriteFile(path+".png", img.Bytes()); err != nil {
+		return err
+	}
+	return assets.WriteFile(path+".config", blob)
+}
+
+// Resize updates every font for the current screen size
+func (tc *TextControl) Resize() {
+	for _, font := range tc.Fonts {
+		font.ResizeWindow(float32(tc.engine.Config.ScreenWidth), float32(tc.engine.Config.ScreenHeight))
+	}
+	tc.engine.Renderer.State.Invalidate()
 }
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"rapidengine/assets"
+
+	"github.com/4ydx/gltext"
+)
+
+// useAssets replaces the default VFS for the rest of the test
+func useAssets(t *testing.T, v *assets.FS) {
+	prev := assets.Default
+	assets.Default = v
+	t.Cleanup(func() { assets.Default = prev })
+}
+
+func TestFontConfigCache(t *testing.T) {
+	dir := t.TempDir()
+	v := assets.NewFS()
+	v.MountDir("", dir)
+	useAssets(t, v)
+
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(1, 1, color.NRGBA{255, 0, 0, 255})
+	config := &gltext.FontConfig{
+		RuneRanges: gltext.RuneRanges{{Low: 32, High: 128}},
+		Glyphs:     gltext.Charset{{X: 1, Y: 2, Width: 3, Height: 4, Advance: 5}},
+		Image:      img,
+	}
+
+	if _, err := loadFontConfig("test"); !errors.Is(err, assets.ErrAssetNotFound) {
+		t.Fatalf("loading an uncached config returned %v", err)
+	}
+	if err := saveFontConfig(config, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir + "/" + fontConfigDir + "/test.config"); err != nil {
+		t.Fatalf("the config wasn't written to the mounted directory: %v", err)
+	}
+
+	loaded, err := loadFontConfig("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Name != "test" ||
+		!reflect.DeepEqual(loaded.RuneRanges, config.RuneRanges) ||
+		!reflect.DeepEqual(loaded.Glyphs, config.Glyphs) {
+		t.Fatalf("loaded %+v, want %+v", loaded, config)
+	}
+	if !reflect.DeepEqual(loaded.Image.Pix, img.Pix) || loaded.Image.Bounds() != img.Bounds() {
+		t.Fatal("the glyph image changed through the cache")
+	}
+
+	// Packed builds have nowhere to write the cache
+	packed := assets.NewFS()
+	packed.Mount("", "pack", fstest.MapFS{})
+	useAssets(t, packed)
+
+	if err := saveFontConfig(config, "test"); !errors.Is(err, assets.ErrReadOnly) {
+		t.Fatalf("saving into a pack returned %v, want ErrReadOnly", err)
+	}
+}
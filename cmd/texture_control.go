@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"encoding/json"
-	"io/ioutil"
+	"image"
+	"rapidengine/assets"
 	"rapidengine/configuration"
+	"rapidengine/device"
 	"rapidengine/material"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
@@ -12,16 +14,22 @@ import (
 type TextureControl struct {
 	TexMap map[string]*material.Texture `json:"textures"`
 
+	// Offscreen targets, whose textures aren't saved to disk
+	RenderTargets map[string]*RenderTarget `json:"-"`
+
 	config *configuration.EngineConfig
 }
 
 func NewTextureControl(config *configuration.EngineConfig) TextureControl {
 	return TextureControl{
-		TexMap: make(map[string]*material.Texture),
-		config: config,
+		TexMap:        make(map[string]*material.Texture),
+		RenderTargets: make(map[string]*RenderTarget),
+		config:        config,
 	}
 }
 
+// GetTexture returns the texture registered under name, and
+// panics if there isn't one. Use LookupTexture to check first.
 func (textureControl *TextureControl) GetTexture(name string) *material.Texture {
 	if tx, ok := textureControl.TexMap[name]; ok {
 		return tx
@@ -30,42 +38,58 @@ func (textureControl *TextureControl) GetTexture(name string) *material.Texture
 	}
 }
 
+// LookupTexture returns the texture registered under name,
+// and whether it exists.
+func (textureControl *TextureControl) LookupTexture(name string) (*material.Texture, bool) {
+	tx, ok := textureControl.TexMap[name]
+	return tx, ok
+}
+
 func (textureControl *TextureControl) NewTexture(path string, name string, filter string) error {
 	rgba, err := material.LoadImage(path)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
+	textureControl.uploadTexture(rgba, path, name, filter)
+	return nil
+}
+
+// uploadTexture creates a GL texture from an image which has already
+// been decoded, so that images can be decoded off of the GL thread
+func (textureControl *TextureControl) uploadTexture(rgba *image.RGBA, path string, name string, filter string) {
+	dev := device.Get()
+
 	var texture uint32
 
-	gl.GenTextures(1, &texture)
-	gl.BindTexture(gl.TEXTURE_2D, texture)
+	dev.GenTextures(1, &texture)
+	dev.BindTexture(gl.TEXTURE_2D, texture)
 
 	switch filter {
 
 	case "pixel":
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
 
 	case "mipmap":
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR_MIPMAP_LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
 
 	case "linear":
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
 
 	case "anisotropic":
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR_MIPMAP_LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+		dev.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
 
 	}
 
@@ -74,7 +98,7 @@ func (textureControl *TextureControl) NewTexture(path string, name string, filte
 		texFormat = gl.SRGB_ALPHA
 	}
 
-	gl.TexImage2D(
+	dev.TexImage2D(
 		gl.TEXTURE_2D,
 		0,
 		int32(texFormat),
@@ -86,38 +110,45 @@ func (textureControl *TextureControl) NewTexture(path string, name string, filte
 		gl.Ptr(rgba.Pix),
 	)
 
-	gl.GenerateMipmap(gl.TEXTURE_2D)
-	gl.BindTexture(gl.TEXTURE_2D, 0)
+	dev.GenerateMipmap(gl.TEXTURE_2D)
+	dev.BindTexture(gl.TEXTURE_2D, 0)
 	textureControl.TexMap[name] = &material.Texture{
 		Name:   name,
 		Path:   path,
 		Filter: filter,
 		Addr:   &texture,
 	}
-	return nil
 }
 
-func (textureControl *TextureControl) NewCubeMap(right, left, top, bottom, front, back, name string) {
-	var cubeMap uint32
-
-	gl.GenTextures(1, &cubeMap)
-	gl.BindTexture(gl.TEXTURE_CUBE_MAP, cubeMap)
-
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+// NewCubeMap loads six images into a cube map texture. All of the
+// images are loaded before any GL texture is created.
+func (textureControl *TextureControl) NewCubeMap(right, left, top, bottom, front, back, name string) error {
+	dev := device.Get()
 
 	paths := []string{right, left, top, bottom, front, back}
+	faces := make([]*image.RGBA, len(paths))
 
 	for i, path := range paths {
 		rgba, err := material.LoadImage(path)
 		if err != nil {
-			panic(err)
+			return err
 		}
+		faces[i] = rgba
+	}
+
+	var cubeMap uint32
+
+	dev.GenTextures(1, &cubeMap)
+	dev.BindTexture(gl.TEXTURE_CUBE_MAP, cubeMap)
+
+	dev.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	dev.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	dev.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	dev.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	dev.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
 
-		gl.TexImage2D(uint32(gl.TEXTURE_CUBE_MAP_POSITIVE_X+i), 0, gl.RGBA,
+	for i, rgba := range faces {
+		dev.TexImage2D(uint32(gl.TEXTURE_CUBE_MAP_POSITIVE_X+i), 0, gl.RGBA,
 			int32(rgba.Rect.Size().X), int32(rgba.Rect.Size().Y),
 			0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
 	}
@@ -127,28 +158,26 @@ func (textureControl *TextureControl) NewCubeMap(right, left, top, bottom, front
 		Path: right,
 		Addr: &cubeMap,
 	}
+	return nil
 }
 
 //   --------------------------------------------------
 //   Disk
 //   --------------------------------------------------
 
-func (textureControl *TextureControl) Save(path string) {
+func (textureControl *TextureControl) Save(path string) error {
 	blob, err := json.Marshal(&textureControl.TexMap)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	err = ioutil.WriteFile(path, blob, 0644)
-	if err != nil {
-		panic(err)
-	}
+	return assets.WriteFile(path, blob)
 }
 
-func (textureControl *TextureControl) Load(path string) {
-	blob, err := ioutil.ReadFile(path)
+func (textureControl *TextureControl) Load(path string) error {
+	blob, err := assets.ReadFile(path)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	temp := TextureControl{
@@ -157,11 +186,14 @@ func (textureControl *TextureControl) Load(path string) {
 
 	err = json.Unmarshal(blob, &temp.TexMap)
 	if err != nil {
-		panic(err)
+		return &assets.DecodeError{Path: path, Format: "json", Err: err}
 	}
 
-	println("Loading textures from file...")
+	textureControl.config.Logger.Debug("Loading textures from ", path)
 	for n, t := range temp.TexMap {
-		textureControl.NewTexture(t.Path, n, t.Filter)
+		if err := textureControl.NewTexture(t.Path, n, t.Filter); err != nil {
+			return err
+		}
 	}
+	return nil
 }
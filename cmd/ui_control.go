@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"rapidengine/geometry"
 	"rapidengine/input"
 	"rapidengine/ui"
@@ -9,11 +10,20 @@ import (
 type UIControl struct {
 	Elements []ui.Element
 
+	// Elements aligned with AlignCenter, which
+	// are re-aligned when the window is resized
+	centered []ui.Element
+
+	// Click callbacks buttons can use by name
+	actions map[string]func()
+
 	engine *Engine
 }
 
 func NewUIControl() UIControl {
-	return UIControl{}
+	return UIControl{
+		actions: make(map[string]func()),
+	}
 }
 
 func (uiControl *UIControl) Initialize(engine *Engine) {
@@ -27,22 +37,67 @@ func (uiControl *UIControl) Update(inputs *input.Input) {
 }
 
 func (uiControl *UIControl) InstanceElement(e ui.Element, scene *Scene) {
+	scene.instanceElement(e)
+	uiControl.addElement(e)
+}
+
+// instanceElement adds an element, its children and
+// its texts to the scene, without updating it
+func (s *Scene) instanceElement(e ui.Element) {
 	for _, c := range e.GetChildren() {
-		scene.InstanceChild(c)
+		s.InstanceChild(c)
 	}
 	for _, t := range e.GetTextBoxes() {
 		if t != nil {
-			scene.InstanceText(t)
+			s.InstanceText(t)
 		}
 	}
-	uiControl.addElement(e)
+	s.elements = append(s.elements, e)
 }
 
 func (uiControl *UIControl) addElement(e ui.Element) {
 	uiControl.Elements = append(uiControl.Elements, e)
 }
 
+// RegisterAction names a click callback, so that buttons
+// using it can be saved to and loaded from scene files
+func (uiControl *UIControl) RegisterAction(name string, f func()) {
+	uiControl.actions[name] = f
+}
+
+// SetAction sets a button's click callback to a registered action
+func (uiControl *UIControl) SetAction(button *ui.Button, name string) error {
+	f, ok := uiControl.actions[name]
+	if !ok {
+		return fmt.Errorf("no UI action named %q", name)
+	}
+
+	button.SetClickCallback(f)
+	button.Action = name
+	return nil
+}
+
+// AlignCenter centers an element horizontally on the screen,
+// and keeps it centered when the window is resized
 func (uiControl *UIControl) AlignCenter(e ui.Element) {
+	uiControl.alignCenter(e)
+
+	for _, c := range uiControl.centered {
+		if c == e {
+			return
+		}
+	}
+	uiControl.centered = append(uiControl.centered, e)
+}
+
+// Resize re-aligns centered elements for the current screen size
+func (uiControl *UIControl) Resize() {
+	for _, e := range uiControl.centered {
+		uiControl.alignCenter(e)
+	}
+}
+
+func (uiControl *UIControl) alignCenter(e ui.Element) {
 	e.SetPosition(float32(uiControl.engine.Config.ScreenWidth/2)-e.GetTransform().SX/2, e.GetTransform().Y)
 }
 
@@ -51,14 +106,19 @@ func (uiControl *UIControl) AlignCenter(e ui.Element) {
 //  --------------------------------------------------
 
 func (uiControl *UIControl) NewUIButton(x, y, width, height float32) *ui.Button {
+	button := uiControl.newUIButton(x, y, width, height)
+	uiControl.engine.CollisionControl.CreateMouseCollision(button.ButtonChild)
+	return button
+}
+
+// newUIButton creates a button which isn't yet checked for clicks
+func (uiControl *UIControl) newUIButton(x, y, width, height float32) *ui.Button {
 	button := ui.NewUIButton(x, y, width, height)
 
 	button.ButtonChild = uiControl.engine.ChildControl.NewChild2D()
 	button.ButtonChild.AttachMaterial(uiControl.engine.Renderer.DefaultMaterial2)
 	button.ButtonChild.AttachMesh(geometry.NewRectangle())
 
-	uiControl.engine.CollisionControl.CreateMouseCollision(button.ButtonChild)
-
 	button.Initialize()
 
 	return &button
@@ -0,0 +1,267 @@
+package cmd
+
+//   --------------------------------------------------
+//   View.go contains views, each of which renders the
+//   scene through a camera into a rectangle of the screen
+//   or of an offscreen buffer. The renderer draws all of
+//   its views every frame, which gives split-screen,
+//   picture-in-picture and separate UI cameras without
+//   duplicating the scene.
+//   --------------------------------------------------
+
+import (
+	"rapidengine/camera"
+	"rapidengine/child"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Viewport is a rectangle of a view's target, in fractions
+// of its width and height from the bottom left corner
+type Viewport struct {
+	X      float32
+	Y      float32
+	Width  float32
+	Height float32
+}
+
+// FullViewport covers the whole target
+var FullViewport = Viewport{X: 0, Y: 0, Width: 1, Height: 1}
+
+// rect returns the viewport in pixels of a width by height target
+func (vp Viewport) rect(width, height int32) (int32, int32, int32, int32) {
+	return int32(vp.X * float32(width)),
+		int32(vp.Y * float32(height)),
+		int32(vp.Width * float32(width)),
+		int32(vp.Height * float32(height))
+}
+
+// View renders the scene through a camera into a viewport
+type View struct {
+	Name string
+
+	// Camera the view renders through. A nil
+	// camera uses the renderer's MainCamera.
+	Camera camera.Camera
+
+	Viewport Viewport
+
+	// Which buffers are cleared in the viewport before the
+	// view is rendered, and the color it is cleared to
+	ClearColor      bool
+	ClearDepth      bool
+	BackgroundColor [4]float32
+
+	// Only children on layers in the mask are rendered
+	LayerMask uint32
+
+	// Whether the skybox is rendered behind the view
+	SkyBox bool
+
+	// Target is the offscreen target the view renders
+	// into, or nil to render to the screen
+	Target *RenderTarget
+
+	// Scene rendered by the view, or nil for the current scene
+	Scene *Scene
+
+	Enabled bool
+}
+
+// NewView creates a view which renders every layer through
+// cam into viewport, clearing the viewport first
+func NewView(name string, cam camera.Camera, viewport Viewport) *View {
+	return &View{
+		Name:            name,
+		Camera:          cam,
+		Viewport:        viewport,
+		ClearColor:      true,
+		ClearDepth:      true,
+		BackgroundColor: [4]float32{0, 0, 0, 1},
+		LayerMask:       child.AllLayers,
+		SkyBox:          true,
+		Enabled:         true,
+	}
+}
+
+// NewTargetView creates a view which renders every
+// layer through cam into the whole of target
+func NewTargetView(name string, cam camera.Camera, target *RenderTarget) *View {
+	v := NewView(name, cam, FullViewport)
+	v.Target = target
+	return v
+}
+
+//  --------------------------------------------------
+//  Renderer Views
+//  --------------------------------------------------
+
+// AddView adds a view. Views with a target are rendered first, so that
+// their textures are up to date for the views of the screen, and
+// otherwise views are rendered in the order they were added.
+func (renderer *Renderer) AddView(v *View) {
+	renderer.Views = append(renderer.Views, v)
+}
+
+// RemoveView removes the view with the given name
+func (renderer *Renderer) RemoveView(name string) {
+	for i, v := range renderer.Views {
+		if v.Name == name {
+			renderer.Views = append(renderer.Views[:i], renderer.Views[i+1:]...)
+			return
+		}
+	}
+}
+
+// GetView returns the view with the given name, or nil
+func (renderer *Renderer) GetView(name string) *View {
+	for _, v := range renderer.Views {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// MainView returns the view the renderer was created with,
+// which covers the whole screen through MainCamera
+func (renderer *Renderer) MainView() *View {
+	return renderer.mainView
+}
+
+// CurrentCamera returns the camera of the view being
+// rendered, or MainCamera outside of the views
+func (renderer *Renderer) CurrentCamera() camera.Camera {
+	if renderer.currentView != nil {
+		return renderer.viewCamera(renderer.currentView)
+	}
+	return renderer.MainCamera
+}
+
+// renderViews renders the scene once for every enabled view.
+// Views without a target render into the screen framebuffer.
+func (renderer *Renderer) renderViews(screen uint32) {
+	if renderer.Config.Blending {
+		renderer.EnableBlending()
+	}
+
+	for _, v := range renderer.Views {
+		if v.Enabled && v.Target != nil {
+			renderer.renderView(v, screen)
+		}
+	}
+	for _, v := range renderer.Views {
+		if v.Enabled && v.Target == nil {
+			renderer.renderView(v, screen)
+		}
+	}
+	renderer.currentView = nil
+
+	// Restore the screen for the rest of the frame
+	renderer.Device.BindFramebuffer(gl.FRAMEBUFFER, screen)
+	renderer.Device.Viewport(0, 0, int32(renderer.Config.ScreenWidth), int32(renderer.Config.ScreenHeight))
+	renderer.setAspectRatio(0)
+}
+
+func (renderer *Renderer) renderView(v *View, screen uint32) {
+	dev := renderer.Device
+
+	framebuffer := screen
+	if v.Target != nil {
+		framebuffer = v.Target.FrameBuffer
+	}
+
+	x, y, w, h := renderer.viewRect(v)
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	dev.BindFramebuffer(gl.FRAMEBUFFER, framebuffer)
+	dev.Viewport(x, y, w, h)
+
+	// Only clear the view's own rectangle
+	if v.ClearColor || v.ClearDepth {
+		dev.Enable(gl.SCISSOR_TEST)
+		dev.Scissor(x, y, w, h)
+		if v.ClearColor {
+			dev.ClearColor(v.BackgroundColor[0], v.BackgroundColor[1], v.BackgroundColor[2], v.BackgroundColor[3])
+			dev.Clear(gl.COLOR_BUFFER_BIT)
+			dev.ClearColor(0, 0, 0, 1)
+		}
+		if v.ClearDepth {
+			dev.Clear(gl.DEPTH_BUFFER_BIT)
+		}
+		dev.Disable(gl.SCISSOR_TEST)
+	}
+
+	renderer.setAspectRatio(float32(w) / float32(h))
+
+	renderer.currentView = v
+	cam := renderer.CurrentCamera()
+	renderer.camX, renderer.camY, renderer.camZ = cam.GetPosition()
+
+	if renderer.SkyBoxEnabled && v.SkyBox {
+		renderer.SkyBox.Render(cam)
+	}
+
+	renderer.RenderChildren()
+}
+
+// viewRect returns the rectangle a view
+// renders into, in pixels of its target
+func (renderer *Renderer) viewRect(v *View) (int32, int32, int32, int32) {
+	if v.Target != nil {
+		return v.Viewport.rect(v.Target.Width, v.Target.Height)
+	}
+	return v.Viewport.rect(int32(renderer.Config.ScreenWidth), int32(renderer.Config.ScreenHeight))
+}
+
+// viewCamera returns the camera a view renders through
+func (renderer *Renderer) viewCamera(v *View) camera.Camera {
+	if v.Camera != nil {
+		return v.Camera
+	}
+	return renderer.MainCamera
+}
+
+// setAspectRatio sets the aspect ratio 3D children and the
+// skybox are projected with, or the screen's if aspect is 0
+func (renderer *Renderer) setAspectRatio(aspect float32) {
+	renderer.aspect = aspect
+
+	if renderer.SkyBox != nil {
+		renderer.SkyBox.SetProjection(skyBoxProjection(renderer.aspectRatio()))
+	}
+}
+
+// aspectRatio returns the aspect ratio of the view being
+// rendered, or the screen's between views
+func (renderer *Renderer) aspectRatio() float32 {
+	if renderer.aspect != 0 {
+		return renderer.aspect
+	}
+	return float32(renderer.Config.ScreenWidth) / float32(renderer.Config.ScreenHeight)
+}
+
+// projection returns the projection a 3D child
+// is rendered with in the view being rendered
+func (renderer *Renderer) projection(c *child.Child3D) mgl32.Mat4 {
+	return c.Projection(renderer.aspectRatio())
+}
+
+// lookCameras updates the main camera and
+// every other camera used by a view
+func (renderer *Renderer) lookCameras() {
+	renderer.MainCamera.Look(renderer.DeltaFrameTime)
+
+	looked := map[camera.Camera]bool{renderer.MainCamera: true}
+	for _, v := range renderer.Views {
+		if v.Camera != nil && !looked[v.Camera] {
+			v.Camera.Look(renderer.DeltaFrameTime)
+			looked[v.Camera] = true
+		}
+	}
+
+	renderer.camX, renderer.camY, renderer.camZ = renderer.MainCamera.GetPosition()
+}
@@ -3,30 +3,51 @@ package configuration
 import "github.com/sirupsen/logrus"
 
 type EngineConfig struct {
-	ScreenWidth  int
-	ScreenHeight int
-	FullScreen   bool
+	ScreenWidth  int  `json:"screen_width"`
+	ScreenHeight int  `json:"screen_height"`
+	FullScreen   bool `json:"full_screen"`
+	Resizable    bool `json:"resizable"`
 
-	VSync           bool
-	GammaCorrection bool
-	AntiAliasing    bool
+	VSync           bool `json:"vsync"`
+	GammaCorrection bool `json:"gamma_correction"`
+	AntiAliasing    bool `json:"anti_aliasing"`
 
-	Blending bool
+	Blending bool `json:"blending"`
 
-	WindowTitle    string
-	PolygonLines   bool
-	CollisionLines bool
+	WindowTitle    string `json:"window_title"`
+	PolygonLines   bool   `json:"polygon_lines"`
+	CollisionLines bool   `json:"collision_lines"`
 
-	ShowFPS bool
+	ShowFPS bool `json:"show_fps"`
 
-	MaxFPS int
+	MaxFPS int `json:"max_fps"`
 
-	Dimensions int
+	// Fixed timestep simulation, in ticks per second.
+	// MaxTicksPerFrame caps how many ticks a slow frame
+	// can catch up on before the remaining time is dropped.
+	TickRate         int `json:"tick_rate"`
+	MaxTicksPerFrame int `json:"max_ticks_per_frame"`
 
-	Profiling      bool
-	SingleMaterial bool
+	Dimensions int `json:"dimensions"`
 
-	Logger *logrus.Logger
+	Profiling      bool `json:"profiling"`
+	SingleMaterial bool `json:"single_material"`
+
+	// Asset directories and .zip packs mounted into the
+	// asset VFS, searched in order before the engine's
+	// built in assets
+	AssetPaths []string `json:"asset_paths"`
+
+	// Address of the debug HTTP server, such as
+	// "localhost:6060". Empty disables the server.
+	DebugAddr string `json:"debug_addr"`
+
+	// Console commands given on the command line after the
+	// flags, such as "+bloom_threshold 0.5 +exec autoexec.cfg",
+	// which the console runs when the renderer starts
+	ConsoleArgs []string `json:"-"`
+
+	Logger *logrus.Logger `json:"-"`
 }
 
 func NewEngineConfig(
@@ -41,6 +62,7 @@ func NewEngineConfig(
 		ScreenWidth:  ScreenWidth,
 		ScreenHeight: ScreenHeight,
 		FullScreen:   true,
+		Resizable:    true,
 		Dimensions:   Dimensions,
 
 		// Rendering
@@ -50,11 +72,13 @@ func NewEngineConfig(
 		AntiAliasing:    true,
 
 		// Misc
-		CollisionLines: false,
-		ShowFPS:        false,
-		MaxFPS:         70,
-		Profiling:      false,
-		SingleMaterial: false,
-		Logger:         logrus.New(),
+		CollisionLines:   false,
+		ShowFPS:          false,
+		MaxFPS:           70,
+		TickRate:         60,
+		MaxTicksPerFrame: 5,
+		Profiling:        false,
+		SingleMaterial:   false,
+		Logger:           logrus.New(),
 	}
 }
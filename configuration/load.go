@@ -0,0 +1,374 @@
+package configuration
+
+//  --------------------------------------------------
+//  Load.go reads and writes EngineConfig as JSON or TOML,
+//  and layers environment variable and command line
+//  overrides on top. Every setting is named by its json
+//  tag, so "max_fps" is RAPIDENGINE_MAX_FPS in the
+//  environment and -max-fps on the command line.
+//  --------------------------------------------------
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix is the prefix of environment variables read by ApplyEnv
+const EnvPrefix = "RAPIDENGINE_"
+
+// Load builds a config in the order defaults, file, environment,
+// then command line flags, each overriding the last. An empty path
+// skips the file, and nil args skip the flags. Arguments
+// after the flags are kept as console commands.
+func Load(defaults EngineConfig, path string, args []string) (EngineConfig, error) {
+	config := defaults
+
+	if path != "" {
+		if err := config.LoadFile(path); err != nil {
+			return defaults, err
+		}
+	}
+
+	if err := config.ApplyEnv(); err != nil {
+		return defaults, err
+	}
+
+	if args != nil {
+		fs := flag.NewFlagSet("rapidengine", flag.ContinueOnError)
+		config.RegisterFlags(fs)
+		if err := fs.Parse(args); err != nil {
+			return defaults, err
+		}
+		config.ConsoleArgs = fs.Args()
+	}
+
+	if err := config.Validate(); err != nil {
+		return defaults, err
+	}
+
+	return config, nil
+}
+
+// Validate checks that the settings are usable
+func (config *EngineConfig) Validate() error {
+	var errs []string
+
+	if config.Dimensions != 2 && config.Dimensions != 3 {
+		errs = append(errs, fmt.Sprintf("dimensions must be 2 or 3, got %d", config.Dimensions))
+	}
+	if config.ScreenWidth <= 0 || config.ScreenHeight <= 0 {
+		errs = append(errs, fmt.Sprintf("screen size must be positive, got %dx%d", config.ScreenWidth, config.ScreenHeight))
+	}
+	if config.MaxFPS <= 0 {
+		errs = append(errs, fmt.Sprintf("max_fps must be positive, got %d", config.MaxFPS))
+	}
+	if config.TickRate <= 0 {
+		errs = append(errs, fmt.Sprintf("tick_rate must be positive, got %d", config.TickRate))
+	}
+	if config.MaxTicksPerFrame <= 0 {
+		errs = append(errs, fmt.Sprintf("max_ticks_per_frame must be positive, got %d", config.MaxTicksPerFrame))
+	}
+
+	if len(errs) > 0 {
+		return errors.New("invalid engine config: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// LoadFile overrides settings with those in a .json or .toml file.
+// Settings missing from the file are left unchanged.
+func (config *EngineConfig) LoadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, config)
+	case ".toml":
+		err = decodeTOML(data, config)
+	default:
+		return fmt.Errorf("unknown config format %q, expected .json or .toml", filepath.Ext(path))
+	}
+
+	if err != nil {
+		return fmt.Errorf("reading config %s: %w", path, err)
+	}
+	return nil
+}
+
+// WriteFile writes every setting to a .json or .toml file,
+// such as after the player changes them in a settings menu
+func (config *EngineConfig) WriteFile(path string) error {
+	var data []byte
+	var err error
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		data, err = json.MarshalIndent(config, "", "  ")
+		data = append(data, '\n')
+	case ".toml":
+		data, err = encodeTOML(config)
+	default:
+		return fmt.Errorf("unknown config format %q, expected .json or .toml", filepath.Ext(path))
+	}
+
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// ApplyEnv overrides settings with RAPIDENGINE_* environment variables
+func (config *EngineConfig) ApplyEnv() error {
+	for _, f := range configFields(config) {
+		value, ok := os.LookupEnv(EnvPrefix + strings.ToUpper(f.key))
+		if !ok {
+			continue
+		}
+		if err := f.set(value); err != nil {
+			return fmt.Errorf("%s%s: %w", EnvPrefix, strings.ToUpper(f.key), err)
+		}
+	}
+	return nil
+}
+
+// RegisterFlags defines a flag for every setting on fs, which sets
+// it when fs is parsed. Flags default to the config's current values.
+func (config *EngineConfig) RegisterFlags(fs *flag.FlagSet) {
+	for _, f := range configFields(config) {
+		name := strings.Replace(f.key, "_", "-", -1)
+		if f.value.Kind() == reflect.Bool {
+			fs.Var(boolFlag{f}, name, "engine setting "+f.key)
+		} else {
+			fs.Var(f, name, "engine setting "+f.key)
+		}
+	}
+}
+
+//  --------------------------------------------------
+//  Fields
+//  --------------------------------------------------
+
+// configField is a single setting, which also
+// implements flag.Value for RegisterFlags
+type configField struct {
+	key   string
+	value reflect.Value
+}
+
+func configFields(config *EngineConfig) []configField {
+	v := reflect.ValueOf(config).Elem()
+	t := v.Type()
+
+	fields := []configField{}
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		fields = append(fields, configField{key, v.Field(i)})
+	}
+	return fields
+}
+
+func (f configField) String() string {
+	if !f.value.IsValid() {
+		return ""
+	}
+	if f.value.Kind() == reflect.Slice {
+		return strings.Join(f.value.Interface().([]string), ",")
+	}
+	return fmt.Sprint(f.value.Interface())
+}
+
+// set parses a setting from a string. Lists are comma separated.
+func (f configField) set(s string) error {
+	switch f.value.Kind() {
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("%s must be an integer", f.key)
+		}
+		f.value.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("%s must be true or false", f.key)
+		}
+		f.value.SetBool(b)
+	case reflect.String:
+		f.value.SetString(s)
+	case reflect.Slice:
+		list := []string{}
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("%s can't be set from text", f.key)
+	}
+	return nil
+}
+
+func (f configField) Set(s string) error {
+	return f.set(s)
+}
+
+type boolFlag struct {
+	configField
+}
+
+func (boolFlag) IsBoolFlag() bool {
+	return true
+}
+
+//  --------------------------------------------------
+//  TOML
+//  --------------------------------------------------
+
+// decodeTOML reads the flat subset of TOML that EngineConfig
+// needs: key = value pairs of strings, integers, booleans
+// and arrays of strings, with comments
+func decodeTOML(data []byte, config *EngineConfig) error {
+	fields := map[string]configField{}
+	for _, f := range configFields(config) {
+		fields[f.key] = f
+	}
+
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(stripTOMLComment(line))
+		if line == "" {
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return fmt.Errorf("line %d: expected key = value", n+1)
+		}
+
+		key := strings.TrimSpace(line[:eq])
+		raw := strings.TrimSpace(line[eq+1:])
+
+		f, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("line %d: unknown setting %q", n+1, key)
+		}
+
+		if err := setTOMLValue(f, raw); err != nil {
+			return fmt.Errorf("line %d: %v", n+1, err)
+		}
+	}
+
+	return nil
+}
+
+func setTOMLValue(f configField, raw string) error {
+	switch f.value.Kind() {
+	case reflect.String:
+		s, err := strconv.Unquote(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be a quoted string", f.key)
+		}
+		f.value.SetString(s)
+
+	case reflect.Slice:
+		if !strings.HasPrefix(raw, "[") || !strings.HasSuffix(raw, "]") {
+			return fmt.Errorf("%s must be an array of strings", f.key)
+		}
+		list := []string{}
+		for _, item := range splitTOMLArray(raw[1 : len(raw)-1]) {
+			s, err := strconv.Unquote(item)
+			if err != nil {
+				return fmt.Errorf("%s must be an array of strings", f.key)
+			}
+			list = append(list, s)
+		}
+		f.value.Set(reflect.ValueOf(list))
+
+	default:
+		return f.set(raw)
+	}
+	return nil
+}
+
+// stripTOMLComment removes a # comment which isn't inside a string
+func stripTOMLComment(line string) string {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			inString = !inString
+		case '#':
+			if !inString {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+// splitTOMLArray splits the inside of an array on
+// commas which aren't inside a string
+func splitTOMLArray(s string) []string {
+	items := []string{}
+	inString := false
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			inString = !inString
+		case ',':
+			if !inString {
+				items = append(items, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
+
+func encodeTOML(config *EngineConfig) ([]byte, error) {
+	var b strings.Builder
+
+	for _, f := range configFields(config) {
+		var value string
+
+		switch f.value.Kind() {
+		case reflect.String:
+			value = strconv.Quote(f.value.String())
+		case reflect.Slice:
+			items := []string{}
+			for _, item := range f.value.Interface().([]string) {
+				items = append(items, strconv.Quote(item))
+			}
+			value = "[" + strings.Join(items, ", ") + "]"
+		default:
+			value = f.String()
+		}
+
+		fmt.Fprintf(&b, "%s = %s\n", f.key, value)
+	}
+
+	return []byte(b.String()), nil
+}
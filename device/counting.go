@@ -0,0 +1,68 @@
+package device
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// CountingDevice wraps a RenderDevice and counts the draw
+// calls made through it, for frame statistics
+type CountingDevice struct {
+	RenderDevice
+
+	drawCalls uint64
+	vertices  uint64
+	instances uint64
+}
+
+// DrawStats are the draws counted since the last reset
+type DrawStats struct {
+	DrawCalls uint64 `json:"draw_calls"`
+	Vertices  uint64 `json:"vertices"`
+	Instances uint64 `json:"instances"`
+}
+
+// NewCountingDevice wraps d
+func NewCountingDevice(d RenderDevice) *CountingDevice {
+	return &CountingDevice{RenderDevice: d}
+}
+
+func (d *CountingDevice) DrawArrays(mode uint32, first, count int32) {
+	atomic.AddUint64(&d.drawCalls, 1)
+	atomic.AddUint64(&d.vertices, uint64(count))
+	atomic.AddUint64(&d.instances, 1)
+	d.RenderDevice.DrawArrays(mode, first, count)
+}
+
+func (d *CountingDevice) DrawElements(mode uint32, count int32, xtype uint32, indices unsafe.Pointer) {
+	atomic.AddUint64(&d.drawCalls, 1)
+	atomic.AddUint64(&d.vertices, uint64(count))
+	atomic.AddUint64(&d.instances, 1)
+	d.RenderDevice.DrawElements(mode, count, xtype, indices)
+}
+
+func (d *CountingDevice) DrawElementsInstanced(mode uint32, count int32, xtype uint32, indices unsafe.Pointer, instancecount int32) {
+	atomic.AddUint64(&d.drawCalls, 1)
+	atomic.AddUint64(&d.vertices, uint64(count)*uint64(instancecount))
+	atomic.AddUint64(&d.instances, uint64(instancecount))
+	d.RenderDevice.DrawElementsInstanced(mode, count, xtype, indices, instancecount)
+}
+
+// Stats returns the draws counted since the last reset
+func (d *CountingDevice) Stats() DrawStats {
+	return DrawStats{
+		DrawCalls: atomic.LoadUint64(&d.drawCalls),
+		Vertices:  atomic.LoadUint64(&d.vertices),
+		Instances: atomic.LoadUint64(&d.instances),
+	}
+}
+
+// ResetStats returns the draws counted since the
+// last reset, and starts counting again from zero
+func (d *CountingDevice) ResetStats() DrawStats {
+	return DrawStats{
+		DrawCalls: atomic.SwapUint64(&d.drawCalls, 0),
+		Vertices:  atomic.SwapUint64(&d.vertices, 0),
+		Instances: atomic.SwapUint64(&d.instances, 0),
+	}
+}
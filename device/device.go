@@ -0,0 +1,141 @@
+package device
+
+//  --------------------------------------------------
+//  Device.go contains the RenderDevice and Window
+//  interfaces, which sit between the engine and the
+//  graphics / windowing backend. Every draw call,
+//  binding and uniform upload goes through the current
+//  RenderDevice, so the engine can run on the real
+//  OpenGL 4.1 + GLFW backend or on a headless one.
+//  --------------------------------------------------
+
+import (
+	"unsafe"
+)
+
+// RenderDevice is the set of graphics calls the engine makes.
+// The signatures mirror go-gl, except where go-gl expects
+// C strings, which are plain Go strings here.
+type RenderDevice interface {
+	Init() error
+	GetString(name uint32) string
+	GetError() uint32
+
+	// Headless reports whether the device renders without
+	// a real GL context, for code (such as gltext) which
+	// can only talk to OpenGL directly.
+	Headless() bool
+
+	// State
+	Enable(cap uint32)
+	Disable(cap uint32)
+	BlendFunc(sfactor, dfactor uint32)
+	PolygonMode(face, mode uint32)
+	DepthMask(flag bool)
+	Viewport(x, y, width, height int32)
+	Scissor(x, y, width, height int32)
+	ClearColor(red, green, blue, alpha float32)
+	Clear(mask uint32)
+
+	// Shaders
+	CreateShader(shaderType uint32) uint32
+	ShaderSource(shader uint32, source string)
+	CompileShader(shader uint32)
+	ShaderCompileStatus(shader uint32) (bool, string)
+	CreateProgram() uint32
+	AttachShader(program, shader uint32)
+	LinkProgram(program uint32)
+	ProgramLinkStatus(program uint32) (bool, string)
+	UseProgram(program uint32)
+	BindAttribLocation(program, index uint32, name string)
+	GetUniformLocation(program uint32, name string) int32
+
+	// Uniforms
+	Uniform1i(location int32, v0 int32)
+	Uniform1f(location int32, v0 float32)
+	Uniform2fv(location int32, count int32, value *float32)
+	Uniform3fv(location int32, count int32, value *float32)
+	Uniform4fv(location int32, count int32, value *float32)
+	UniformMatrix4fv(location int32, count int32, transpose bool, value *float32)
+
+	// Textures
+	GenTextures(n int32, textures *uint32)
+	DeleteTextures(n int32, textures *uint32)
+	ActiveTexture(texture uint32)
+	BindTexture(target, texture uint32)
+	TexParameteri(target, pname uint32, param int32)
+	TexImage2D(target uint32, level, internalformat, width, height, border int32, format, xtype uint32, pixels unsafe.Pointer)
+	GenerateMipmap(target uint32)
+
+	// Buffers
+	GenVertexArrays(n int32, arrays *uint32)
+	BindVertexArray(array uint32)
+	GenBuffers(n int32, buffers *uint32)
+	BindBuffer(target, buffer uint32)
+	BufferData(target uint32, size int, data unsafe.Pointer, usage uint32)
+	VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, pointer unsafe.Pointer)
+	EnableVertexAttribArray(index uint32)
+
+	// Framebuffers
+	GenFramebuffers(n int32, framebuffers *uint32)
+	DeleteFramebuffers(n int32, framebuffers *uint32)
+	BindFramebuffer(target, framebuffer uint32)
+	GenRenderbuffers(n int32, renderbuffers *uint32)
+	DeleteRenderbuffers(n int32, renderbuffers *uint32)
+	BindRenderbuffer(target, renderbuffer uint32)
+	RenderbufferStorage(target, internalformat uint32, width, height int32)
+	FramebufferRenderbuffer(target, attachment, renderbuffertarget, renderbuffer uint32)
+	FramebufferTexture(target, attachment, texture uint32, level int32)
+	DrawBuffers(n int32, bufs *uint32)
+	CheckFramebufferStatus(target uint32) uint32
+
+	// Drawing
+	DrawArrays(mode uint32, first, count int32)
+	DrawElements(mode uint32, count int32, xtype uint32, indices unsafe.Pointer)
+	DrawElementsInstanced(mode uint32, count int32, xtype uint32, indices unsafe.Pointer, instancecount int32)
+}
+
+// Window is the surface the engine presents frames to,
+// and the source of user input.
+type Window interface {
+	ShouldClose() bool
+	SetShouldClose(bool)
+	SwapBuffers()
+	PollEvents()
+	GetTime() float64
+	Terminate()
+
+	KeyPressed(key Key) bool
+	SetCursorEnabled(bool)
+
+	SetCursorPosCallback(func(x, y float64))
+	SetMouseButtonCallback(func(button int, pressed bool))
+	SetScrollCallback(func(xoff, yoff float64))
+
+	// The size of the window's framebuffer in pixels, which is
+	// larger than the window's size on high DPI displays
+	GetFramebufferSize() (width, height int)
+	SetFramebufferSizeCallback(func(width, height int))
+
+	// Key events, including repeats while a key is held,
+	// and the characters typed, for text input
+	SetKeyCallback(func(key Key, action Action))
+	SetCharCallback(func(char rune))
+
+	// SetFullScreen switches between fullscreen on the
+	// primary monitor and the previous windowed size
+	SetFullScreen(bool)
+}
+
+var current RenderDevice = NewGLDevice()
+
+// Set makes d the device used by every package
+// in the engine. The renderer calls this when it is created.
+func Set(d RenderDevice) {
+	current = d
+}
+
+// Get returns the current device
+func Get() RenderDevice {
+	return current
+}
@@ -0,0 +1,301 @@
+package device
+
+import (
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// GLDevice is the OpenGL 4.1 core implementation of RenderDevice
+type GLDevice struct{}
+
+func NewGLDevice() *GLDevice {
+	return &GLDevice{}
+}
+
+func (d *GLDevice) Init() error {
+	return gl.Init()
+}
+
+func (d *GLDevice) GetString(name uint32) string {
+	return gl.GoStr(gl.GetString(name))
+}
+
+func (d *GLDevice) GetError() uint32 {
+	return gl.GetError()
+}
+
+func (d *GLDevice) Headless() bool {
+	return false
+}
+
+//  --------------------------------------------------
+//  State
+//  --------------------------------------------------
+
+func (d *GLDevice) Enable(cap uint32) {
+	gl.Enable(cap)
+}
+
+func (d *GLDevice) Disable(cap uint32) {
+	gl.Disable(cap)
+}
+
+func (d *GLDevice) BlendFunc(sfactor, dfactor uint32) {
+	gl.BlendFunc(sfactor, dfactor)
+}
+
+func (d *GLDevice) PolygonMode(face, mode uint32) {
+	gl.PolygonMode(face, mode)
+}
+
+func (d *GLDevice) DepthMask(flag bool) {
+	gl.DepthMask(flag)
+}
+
+func (d *GLDevice) Viewport(x, y, width, height int32) {
+	gl.Viewport(x, y, width, height)
+}
+
+func (d *GLDevice) Scissor(x, y, width, height int32) {
+	gl.Scissor(x, y, width, height)
+}
+
+func (d *GLDevice) ClearColor(red, green, blue, alpha float32) {
+	gl.ClearColor(red, green, blue, alpha)
+}
+
+func (d *GLDevice) Clear(mask uint32) {
+	gl.Clear(mask)
+}
+
+//  --------------------------------------------------
+//  Shaders
+//  --------------------------------------------------
+
+func (d *GLDevice) CreateShader(shaderType uint32) uint32 {
+	return gl.CreateShader(shaderType)
+}
+
+func (d *GLDevice) ShaderSource(shader uint32, source string) {
+	csources, free := gl.Strs(source + "\x00")
+	gl.ShaderSource(shader, 1, csources, nil)
+	free()
+}
+
+func (d *GLDevice) CompileShader(shader uint32) {
+	gl.CompileShader(shader)
+}
+
+func (d *GLDevice) ShaderCompileStatus(shader uint32) (bool, string) {
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status != gl.FALSE {
+		return true, ""
+	}
+
+	var logLength int32
+	gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+
+	log := strings.Repeat("\x00", int(logLength+1))
+	gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+
+	return false, strings.TrimRight(log, "\x00")
+}
+
+func (d *GLDevice) ProgramLinkStatus(program uint32) (bool, string) {
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status != gl.FALSE {
+		return true, ""
+	}
+
+	var logLength int32
+	gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+
+	log := strings.Repeat("\x00", int(logLength+1))
+	gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+
+	return false, strings.TrimRight(log, "\x00")
+}
+
+func (d *GLDevice) CreateProgram() uint32 {
+	return gl.CreateProgram()
+}
+
+func (d *GLDevice) AttachShader(program, shader uint32) {
+	gl.AttachShader(program, shader)
+}
+
+func (d *GLDevice) LinkProgram(program uint32) {
+	gl.LinkProgram(program)
+}
+
+func (d *GLDevice) UseProgram(program uint32) {
+	gl.UseProgram(program)
+}
+
+func (d *GLDevice) BindAttribLocation(program, index uint32, name string) {
+	gl.BindAttribLocation(program, index, gl.Str(name+"\x00"))
+}
+
+func (d *GLDevice) GetUniformLocation(program uint32, name string) int32 {
+	return gl.GetUniformLocation(program, gl.Str(name+"\x00"))
+}
+
+//  --------------------------------------------------
+//  Uniforms
+//  --------------------------------------------------
+
+func (d *GLDevice) Uniform1i(location int32, v0 int32) {
+	gl.Uniform1i(location, v0)
+}
+
+func (d *GLDevice) Uniform1f(location int32, v0 float32) {
+	gl.Uniform1f(location, v0)
+}
+
+func (d *GLDevice) Uniform2fv(location int32, count int32, value *float32) {
+	gl.Uniform2fv(location, count, value)
+}
+
+func (d *GLDevice) Uniform3fv(location int32, count int32, value *float32) {
+	gl.Uniform3fv(location, count, value)
+}
+
+func (d *GLDevice) Uniform4fv(location int32, count int32, value *float32) {
+	gl.Uniform4fv(location, count, value)
+}
+
+func (d *GLDevice) UniformMatrix4fv(location int32, count int32, transpose bool, value *float32) {
+	gl.UniformMatrix4fv(location, count, transpose, value)
+}
+
+//  --------------------------------------------------
+//  Textures
+//  --------------------------------------------------
+
+func (d *GLDevice) GenTextures(n int32, textures *uint32) {
+	gl.GenTextures(n, textures)
+}
+
+func (d *GLDevice) DeleteTextures(n int32, textures *uint32) {
+	gl.DeleteTextures(n, textures)
+}
+
+func (d *GLDevice) ActiveTexture(texture uint32) {
+	gl.ActiveTexture(texture)
+}
+
+func (d *GLDevice) BindTexture(target, texture uint32) {
+	gl.BindTexture(target, texture)
+}
+
+func (d *GLDevice) TexParameteri(target, pname uint32, param int32) {
+	gl.TexParameteri(target, pname, param)
+}
+
+func (d *GLDevice) TexImage2D(target uint32, level, internalformat, width, height, border int32, format, xtype uint32, pixels unsafe.Pointer) {
+	gl.TexImage2D(target, level, internalformat, width, height, border, format, xtype, pixels)
+}
+
+func (d *GLDevice) GenerateMipmap(target uint32) {
+	gl.GenerateMipmap(target)
+}
+
+//  --------------------------------------------------
+//  Buffers
+//  --------------------------------------------------
+
+func (d *GLDevice) GenVertexArrays(n int32, arrays *uint32) {
+	gl.GenVertexArrays(n, arrays)
+}
+
+func (d *GLDevice) BindVertexArray(array uint32) {
+	gl.BindVertexArray(array)
+}
+
+func (d *GLDevice) GenBuffers(n int32, buffers *uint32) {
+	gl.GenBuffers(n, buffers)
+}
+
+func (d *GLDevice) BindBuffer(target, buffer uint32) {
+	gl.BindBuffer(target, buffer)
+}
+
+func (d *GLDevice) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	gl.BufferData(target, size, data, usage)
+}
+
+func (d *GLDevice) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, pointer unsafe.Pointer) {
+	gl.VertexAttribPointer(index, size, xtype, normalized, stride, pointer)
+}
+
+func (d *GLDevice) EnableVertexAttribArray(index uint32) {
+	gl.EnableVertexAttribArray(index)
+}
+
+//  --------------------------------------------------
+//  Framebuffers
+//  --------------------------------------------------
+
+func (d *GLDevice) GenFramebuffers(n int32, framebuffers *uint32) {
+	gl.GenFramebuffers(n, framebuffers)
+}
+
+func (d *GLDevice) DeleteFramebuffers(n int32, framebuffers *uint32) {
+	gl.DeleteFramebuffers(n, framebuffers)
+}
+
+func (d *GLDevice) BindFramebuffer(target, framebuffer uint32) {
+	gl.BindFramebuffer(target, framebuffer)
+}
+
+func (d *GLDevice) GenRenderbuffers(n int32, renderbuffers *uint32) {
+	gl.GenRenderbuffers(n, renderbuffers)
+}
+
+func (d *GLDevice) DeleteRenderbuffers(n int32, renderbuffers *uint32) {
+	gl.DeleteRenderbuffers(n, renderbuffers)
+}
+
+func (d *GLDevice) BindRenderbuffer(target, renderbuffer uint32) {
+	gl.BindRenderbuffer(target, renderbuffer)
+}
+
+func (d *GLDevice) RenderbufferStorage(target, internalformat uint32, width, height int32) {
+	gl.RenderbufferStorage(target, internalformat, width, height)
+}
+
+func (d *GLDevice) FramebufferRenderbuffer(target, attachment, renderbuffertarget, renderbuffer uint32) {
+	gl.FramebufferRenderbuffer(target, attachment, renderbuffertarget, renderbuffer)
+}
+
+func (d *GLDevice) FramebufferTexture(target, attachment, texture uint32, level int32) {
+	gl.FramebufferTexture(target, attachment, texture, level)
+}
+
+func (d *GLDevice) DrawBuffers(n int32, bufs *uint32) {
+	gl.DrawBuffers(n, bufs)
+}
+
+func (d *GLDevice) CheckFramebufferStatus(target uint32) uint32 {
+	return gl.CheckFramebufferStatus(target)
+}
+
+//  --------------------------------------------------
+//  Drawing
+//  --------------------------------------------------
+
+func (d *GLDevice) DrawArrays(mode uint32, first, count int32) {
+	gl.DrawArrays(mode, first, count)
+}
+
+func (d *GLDevice) DrawElements(mode uint32, count int32, xtype uint32, indices unsafe.Pointer) {
+	gl.DrawElements(mode, count, xtype, indices)
+}
+
+func (d *GLDevice) DrawElementsInstanced(mode uint32, count int32, xtype uint32, indices unsafe.Pointer, instancecount int32) {
+	gl.DrawElementsInstanced(mode, count, xtype, indices, instancecount)
+}
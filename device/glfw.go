@@ -0,0 +1,111 @@
+package device
+
+import (
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// GLFWWindow is the GLFW implementation of Window
+type GLFWWindow struct {
+	Window *glfw.Window
+
+	// Position and size to restore when leaving fullscreen
+	windowedX, windowedY          int
+	windowedWidth, windowedHeight int
+}
+
+func NewGLFWWindow(w *glfw.Window) *GLFWWindow {
+	return &GLFWWindow{Window: w}
+}
+
+func (w *GLFWWindow) ShouldClose() bool {
+	return w.Window.ShouldClose()
+}
+
+func (w *GLFWWindow) SetShouldClose(close bool) {
+	w.Window.SetShouldClose(close)
+}
+
+func (w *GLFWWindow) SwapBuffers() {
+	w.Window.SwapBuffers()
+}
+
+func (w *GLFWWindow) PollEvents() {
+	glfw.PollEvents()
+}
+
+func (w *GLFWWindow) GetTime() float64 {
+	return glfw.GetTime()
+}
+
+func (w *GLFWWindow) Terminate() {
+	glfw.Terminate()
+}
+
+func (w *GLFWWindow) KeyPressed(key Key) bool {
+	return w.Window.GetKey(glfw.Key(key)) == glfw.Press
+}
+
+func (w *GLFWWindow) SetCursorEnabled(enabled bool) {
+	if enabled {
+		w.Window.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+	} else {
+		w.Window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+	}
+}
+
+func (w *GLFWWindow) SetCursorPosCallback(f func(x, y float64)) {
+	w.Window.SetCursorPosCallback(func(_ *glfw.Window, x, y float64) {
+		f(x, y)
+	})
+}
+
+func (w *GLFWWindow) SetMouseButtonCallback(f func(button int, pressed bool)) {
+	w.Window.SetMouseButtonCallback(func(_ *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		f(int(button), action == glfw.Press)
+	})
+}
+
+func (w *GLFWWindow) SetScrollCallback(f func(xoff, yoff float64)) {
+	w.Window.SetScrollCallback(func(_ *glfw.Window, xoff, yoff float64) {
+		f(xoff, yoff)
+	})
+}
+
+func (w *GLFWWindow) GetFramebufferSize() (width, height int) {
+	return w.Window.GetFramebufferSize()
+}
+
+func (w *GLFWWindow) SetFramebufferSizeCallback(f func(width, height int)) {
+	w.Window.SetFramebufferSizeCallback(func(_ *glfw.Window, width, height int) {
+		f(width, height)
+	})
+}
+
+func (w *GLFWWindow) SetKeyCallback(f func(key Key, action Action)) {
+	w.Window.SetKeyCallback(func(_ *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		f(Key(key), Action(action))
+	})
+}
+
+func (w *GLFWWindow) SetCharCallback(f func(char rune)) {
+	w.Window.SetCharCallback(func(_ *glfw.Window, char rune) {
+		f(char)
+	})
+}
+
+func (w *GLFWWindow) SetFullScreen(fullScreen bool) {
+	if fullScreen == (w.Window.GetMonitor() != nil) {
+		return
+	}
+
+	if fullScreen {
+		w.windowedX, w.windowedY = w.Window.GetPos()
+		w.windowedWidth, w.windowedHeight = w.Window.GetSize()
+
+		monitor := glfw.GetPrimaryMonitor()
+		mode := monitor.GetVideoMode()
+		w.Window.SetMonitor(monitor, 0, 0, mode.Width, mode.Height, mode.RefreshRate)
+	} else {
+		w.Window.SetMonitor(nil, w.windowedX, w.windowedY, w.windowedWidth, w.windowedHeight, glfw.DontCare)
+	}
+}
@@ -0,0 +1,605 @@
+package device
+
+//  --------------------------------------------------
+//  Null.go contains a headless RenderDevice and Window.
+//  Nothing is drawn; instead every call is recorded,
+//  along with the bindings active at each draw call and
+//  every uniform upload, so rendering can be inspected
+//  on machines without a GPU or a display.
+//  --------------------------------------------------
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// GL enums the null device answers with, so that it
+// doesn't need the GL bindings
+const (
+	nullTexture0            = 0x84C0
+	nullFramebufferComplete = 0x8CD5
+)
+
+// Call is a single recorded device call
+type Call struct {
+	Name string
+	Args []interface{}
+}
+
+func (c Call) String() string {
+	return fmt.Sprintf("%s%v", c.Name, c.Args)
+}
+
+// DrawCall records the state bound when a draw was issued
+type DrawCall struct {
+	Program     uint32
+	VAO         uint32
+	Framebuffer uint32
+	Textures    map[uint32]uint32
+
+	Mode      uint32
+	Count     int32
+	Instances int32
+}
+
+// UniformUpload records a single uniform upload.
+// Value holds a copy of the uploaded data.
+type UniformUpload struct {
+	Program  uint32
+	Name     string
+	Location int32
+	Value    interface{}
+}
+
+// NullDevice is a RenderDevice which records everything
+// and renders nothing.
+type NullDevice struct {
+	calls    []Call
+	draws    []DrawCall
+	uniforms []UniformUpload
+
+	nextID uint32
+
+	program       uint32
+	vao           uint32
+	framebuffer   uint32
+	activeTexture uint32
+	textures      map[uint32]uint32
+
+	uniformNames     map[int32]string
+	uniformLocations map[uint32]map[string]int32
+	nextLocation     int32
+}
+
+func NewNullDevice() *NullDevice {
+	return &NullDevice{
+		textures:         make(map[uint32]uint32),
+		uniformNames:     make(map[int32]string),
+		uniformLocations: make(map[uint32]map[string]int32),
+		nextID:           1,
+	}
+}
+
+// Calls returns every recorded call, in order
+func (d *NullDevice) Calls() []Call {
+	return d.calls
+}
+
+// Draws returns every recorded draw call, in order
+func (d *NullDevice) Draws() []DrawCall {
+	return d.draws
+}
+
+// Uniforms returns every recorded uniform upload, in order
+func (d *NullDevice) Uniforms() []UniformUpload {
+	return d.uniforms
+}
+
+// LastUniform returns the most recent value uploaded
+// to the named uniform of a program
+func (d *NullDevice) LastUniform(program uint32, name string) (interface{}, bool) {
+	for i := len(d.uniforms) - 1; i >= 0; i-- {
+		if d.uniforms[i].Program == program && d.uniforms[i].Name == name {
+			return d.uniforms[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// Reset clears the recordings, but keeps bound state
+// and generated object names.
+func (d *NullDevice) Reset() {
+	d.calls = nil
+	d.draws = nil
+	d.uniforms = nil
+}
+
+func (d *NullDevice) record(name string, args ...interface{}) {
+	d.calls = append(d.calls, Call{name, args})
+}
+
+func (d *NullDevice) gen(n int32, names *uint32) {
+	out := (*[1 << 20]uint32)(unsafe.Pointer(names))[:n:n]
+	for i := range out {
+		out[i] = d.nextID
+		d.nextID++
+	}
+}
+
+func (d *NullDevice) upload(location int32, value interface{}) {
+	d.uniforms = append(d.uniforms, UniformUpload{
+		Program:  d.program,
+		Name:     d.uniformNames[location],
+		Location: location,
+		Value:    value,
+	})
+}
+
+func (d *NullDevice) draw(mode uint32, count, instances int32) {
+	textures := make(map[uint32]uint32)
+	for unit, tex := range d.textures {
+		textures[unit] = tex
+	}
+	d.draws = append(d.draws, DrawCall{
+		Program:     d.program,
+		VAO:         d.vao,
+		Framebuffer: d.framebuffer,
+		Textures:    textures,
+		Mode:        mode,
+		Count:       count,
+		Instances:   instances,
+	})
+}
+
+func floats(value *float32, n int) []float32 {
+	if value == nil {
+		return nil
+	}
+	out := make([]float32, n)
+	copy(out, (*[1 << 20]float32)(unsafe.Pointer(value))[:n:n])
+	return out
+}
+
+func (d *NullDevice) Init() error {
+	d.record("Init")
+	return nil
+}
+
+func (d *NullDevice) GetString(name uint32) string {
+	return "null"
+}
+
+func (d *NullDevice) GetError() uint32 {
+	return 0
+}
+
+func (d *NullDevice) Headless() bool {
+	return true
+}
+
+//  --------------------------------------------------
+//  State
+//  --------------------------------------------------
+
+func (d *NullDevice) Enable(cap uint32) {
+	d.record("Enable", cap)
+}
+
+func (d *NullDevice) Disable(cap uint32) {
+	d.record("Disable", cap)
+}
+
+func (d *NullDevice) BlendFunc(sfactor, dfactor uint32) {
+	d.record("BlendFunc", sfactor, dfactor)
+}
+
+func (d *NullDevice) PolygonMode(face, mode uint32) {
+	d.record("PolygonMode", face, mode)
+}
+
+func (d *NullDevice) DepthMask(flag bool) {
+	d.record("DepthMask", flag)
+}
+
+func (d *NullDevice) Viewport(x, y, width, height int32) {
+	d.record("Viewport", x, y, width, height)
+}
+
+func (d *NullDevice) Scissor(x, y, width, height int32) {
+	d.record("Scissor", x, y, width, height)
+}
+
+func (d *NullDevice) ClearColor(red, green, blue, alpha float32) {
+	d.record("ClearColor", red, green, blue, alpha)
+}
+
+func (d *NullDevice) Clear(mask uint32) {
+	d.record("Clear", mask)
+}
+
+//  --------------------------------------------------
+//  Shaders
+//  --------------------------------------------------
+
+func (d *NullDevice) CreateShader(shaderType uint32) uint32 {
+	var id uint32
+	d.gen(1, &id)
+	d.record("CreateShader", shaderType, id)
+	return id
+}
+
+func (d *NullDevice) ShaderSource(shader uint32, source string) {
+	d.record("ShaderSource", shader)
+}
+
+func (d *NullDevice) CompileShader(shader uint32) {
+	d.record("CompileShader", shader)
+}
+
+func (d *NullDevice) ShaderCompileStatus(shader uint32) (bool, string) {
+	return true, ""
+}
+
+func (d *NullDevice) CreateProgram() uint32 {
+	var id uint32
+	d.gen(1, &id)
+	d.record("CreateProgram", id)
+	return id
+}
+
+func (d *NullDevice) AttachShader(program, shader uint32) {
+	d.record("AttachShader", program, shader)
+}
+
+func (d *NullDevice) LinkProgram(program uint32) {
+	d.record("LinkProgram", program)
+}
+
+func (d *NullDevice) ProgramLinkStatus(program uint32) (bool, string) {
+	return true, ""
+}
+
+func (d *NullDevice) UseProgram(program uint32) {
+	d.program = program
+	d.record("UseProgram", program)
+}
+
+func (d *NullDevice) BindAttribLocation(program, index uint32, name string) {
+	d.record("BindAttribLocation", program, index, name)
+}
+
+// GetUniformLocation hands out a unique location for every
+// program / name pair, so uploads can be traced back to names.
+func (d *NullDevice) GetUniformLocation(program uint32, name string) int32 {
+	if d.uniformLocations[program] == nil {
+		d.uniformLocations[program] = make(map[string]int32)
+	}
+	if loc, ok := d.uniformLocations[program][name]; ok {
+		return loc
+	}
+	d.nextLocation++
+	d.uniformLocations[program][name] = d.nextLocation
+	d.uniformNames[d.nextLocation] = name
+	return d.nextLocation
+}
+
+//  --------------------------------------------------
+//  Uniforms
+//  --------------------------------------------------
+
+func (d *NullDevice) Uniform1i(location int32, v0 int32) {
+	d.record("Uniform1i", location, v0)
+	d.upload(location, v0)
+}
+
+func (d *NullDevice) Uniform1f(location int32, v0 float32) {
+	d.record("Uniform1f", location, v0)
+	d.upload(location, v0)
+}
+
+func (d *NullDevice) Uniform2fv(location int32, count int32, value *float32) {
+	d.record("Uniform2fv", location, count)
+	d.upload(location, floats(value, int(2*count)))
+}
+
+func (d *NullDevice) Uniform3fv(location int32, count int32, value *float32) {
+	d.record("Uniform3fv", location, count)
+	d.upload(location, floats(value, int(3*count)))
+}
+
+func (d *NullDevice) Uniform4fv(location int32, count int32, value *float32) {
+	d.record("Uniform4fv", location, count)
+	d.upload(location, floats(value, int(4*count)))
+}
+
+func (d *NullDevice) UniformMatrix4fv(location int32, count int32, transpose bool, value *float32) {
+	d.record("UniformMatrix4fv", location, count, transpose)
+	d.upload(location, floats(value, int(16*count)))
+}
+
+//  --------------------------------------------------
+//  Textures
+//  --------------------------------------------------
+
+func (d *NullDevice) GenTextures(n int32, textures *uint32) {
+	d.gen(n, textures)
+	d.record("GenTextures", n)
+}
+
+func (d *NullDevice) DeleteTextures(n int32, textures *uint32) {
+	d.record("DeleteTextures", n, *textures)
+}
+
+func (d *NullDevice) ActiveTexture(texture uint32) {
+	d.activeTexture = texture - nullTexture0
+	d.record("ActiveTexture", texture)
+}
+
+func (d *NullDevice) BindTexture(target, texture uint32) {
+	d.textures[d.activeTexture] = texture
+	d.record("BindTexture", target, texture)
+}
+
+func (d *NullDevice) TexParameteri(target, pname uint32, param int32) {
+	d.record("TexParameteri", target, pname, param)
+}
+
+func (d *NullDevice) TexImage2D(target uint32, level, internalformat, width, height, border int32, format, xtype uint32, pixels unsafe.Pointer) {
+	d.record("TexImage2D", target, level, internalformat, width, height)
+}
+
+func (d *NullDevice) GenerateMipmap(target uint32) {
+	d.record("GenerateMipmap", target)
+}
+
+//  --------------------------------------------------
+//  Buffers
+//  --------------------------------------------------
+
+func (d *NullDevice) GenVertexArrays(n int32, arrays *uint32) {
+	d.gen(n, arrays)
+	d.record("GenVertexArrays", n)
+}
+
+func (d *NullDevice) BindVertexArray(array uint32) {
+	d.vao = array
+	d.record("BindVertexArray", array)
+}
+
+func (d *NullDevice) GenBuffers(n int32, buffers *uint32) {
+	d.gen(n, buffers)
+	d.record("GenBuffers", n)
+}
+
+func (d *NullDevice) BindBuffer(target, buffer uint32) {
+	d.record("BindBuffer", target, buffer)
+}
+
+func (d *NullDevice) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	d.record("BufferData", target, size, usage)
+}
+
+func (d *NullDevice) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, pointer unsafe.Pointer) {
+	d.record("VertexAttribPointer", index, size, xtype, normalized, stride)
+}
+
+func (d *NullDevice) EnableVertexAttribArray(index uint32) {
+	d.record("EnableVertexAttribArray", index)
+}
+
+//  --------------------------------------------------
+//  Framebuffers
+//  --------------------------------------------------
+
+func (d *NullDevice) GenFramebuffers(n int32, framebuffers *uint32) {
+	d.gen(n, framebuffers)
+	d.record("GenFramebuffers", n)
+}
+
+func (d *NullDevice) DeleteFramebuffers(n int32, framebuffers *uint32) {
+	d.record("DeleteFramebuffers", n, *framebuffers)
+}
+
+func (d *NullDevice) BindFramebuffer(target, framebuffer uint32) {
+	d.framebuffer = framebuffer
+	d.record("BindFramebuffer", target, framebuffer)
+}
+
+func (d *NullDevice) GenRenderbuffers(n int32, renderbuffers *uint32) {
+	d.gen(n, renderbuffers)
+	d.record("GenRenderbuffers", n)
+}
+
+func (d *NullDevice) DeleteRenderbuffers(n int32, renderbuffers *uint32) {
+	d.record("DeleteRenderbuffers", n, *renderbuffers)
+}
+
+func (d *NullDevice) BindRenderbuffer(target, renderbuffer uint32) {
+	d.record("BindRenderbuffer", target, renderbuffer)
+}
+
+func (d *NullDevice) RenderbufferStorage(target, internalformat uint32, width, height int32) {
+	d.record("RenderbufferStorage", target, internalformat, width, height)
+}
+
+func (d *NullDevice) FramebufferRenderbuffer(target, attachment, renderbuffertarget, renderbuffer uint32) {
+	d.record("FramebufferRenderbuffer", target, attachment, renderbuffertarget, renderbuffer)
+}
+
+func (d *NullDevice) FramebufferTexture(target, attachment, texture uint32, level int32) {
+	d.record("FramebufferTexture", target, attachment, texture, level)
+}
+
+func (d *NullDevice) DrawBuffers(n int32, bufs *uint32) {
+	d.record("DrawBuffers", n)
+}
+
+func (d *NullDevice) CheckFramebufferStatus(target uint32) uint32 {
+	return nullFramebufferComplete
+}
+
+//  --------------------------------------------------
+//  Drawing
+//  --------------------------------------------------
+
+func (d *NullDevice) DrawArrays(mode uint32, first, count int32) {
+	d.record("DrawArrays", mode, first, count)
+	d.draw(mode, count, 1)
+}
+
+func (d *NullDevice) DrawElements(mode uint32, count int32, xtype uint32, indices unsafe.Pointer) {
+	d.record("DrawElements", mode, count)
+	d.draw(mode, count, 1)
+}
+
+func (d *NullDevice) DrawElementsInstanced(mode uint32, count int32, xtype uint32, indices unsafe.Pointer, instancecount int32) {
+	d.record("DrawElementsInstanced", mode, count, instancecount)
+	d.draw(mode, count, instancecount)
+}
+
+//  --------------------------------------------------
+//  Window
+//  --------------------------------------------------
+
+// NullWindow is a Window without a display. It closes itself
+// after a fixed number of frames, and its clock advances by
+// a fixed step on every SwapBuffers.
+type NullWindow struct {
+	Frames    int
+	MaxFrames int
+	TimeStep  float64
+
+	Keys map[Key]bool
+
+	// FullScreen is set by SetFullScreen
+	FullScreen bool
+
+	// Framebuffer size set by Resize, 0
+	// until the window is first resized
+	Width, Height int
+
+	time        float64
+	shouldClose bool
+
+	cursorPos   func(x, y float64)
+	mouseButton func(button int, pressed bool)
+	scroll      func(xoff, yoff float64)
+	size        func(width, height int)
+	key         func(key Key, action Action)
+	char        func(char rune)
+}
+
+// NewNullWindow creates a window which closes after maxFrames
+// frames. A maxFrames of 0 runs until SetShouldClose is called.
+func NewNullWindow(maxFrames int, timeStep float64) *NullWindow {
+	return &NullWindow{
+		MaxFrames: maxFrames,
+		TimeStep:  timeStep,
+		Keys:      make(map[Key]bool),
+	}
+}
+
+func (w *NullWindow) ShouldClose() bool {
+	return w.shouldClose || (w.MaxFrames > 0 && w.Frames >= w.MaxFrames)
+}
+
+func (w *NullWindow) SetShouldClose(close bool) {
+	w.shouldClose = close
+}
+
+func (w *NullWindow) SwapBuffers() {
+	w.Frames++
+	w.time += w.TimeStep
+}
+
+func (w *NullWindow) PollEvents() {}
+
+func (w *NullWindow) GetTime() float64 {
+	return w.time
+}
+
+func (w *NullWindow) Terminate() {}
+
+func (w *NullWindow) KeyPressed(key Key) bool {
+	return w.Keys[key]
+}
+
+func (w *NullWindow) SetCursorEnabled(enabled bool) {}
+
+func (w *NullWindow) SetCursorPosCallback(f func(x, y float64)) {
+	w.cursorPos = f
+}
+
+func (w *NullWindow) SetMouseButtonCallback(f func(button int, pressed bool)) {
+	w.mouseButton = f
+}
+
+func (w *NullWindow) SetScrollCallback(f func(xoff, yoff float64)) {
+	w.scroll = f
+}
+
+func (w *NullWindow) GetFramebufferSize() (width, height int) {
+	return w.Width, w.Height
+}
+
+func (w *NullWindow) SetFramebufferSizeCallback(f func(width, height int)) {
+	w.size = f
+}
+
+func (w *NullWindow) SetKeyCallback(f func(key Key, action Action)) {
+	w.key = f
+}
+
+func (w *NullWindow) SetCharCallback(f func(char rune)) {
+	w.char = f
+}
+
+func (w *NullWindow) SetFullScreen(fullScreen bool) {
+	w.FullScreen = fullScreen
+}
+
+// MoveCursor simulates the mouse moving to x, y
+func (w *NullWindow) MoveCursor(x, y float64) {
+	if w.cursorPos != nil {
+		w.cursorPos(x, y)
+	}
+}
+
+// PressMouseButton simulates a mouse button event
+func (w *NullWindow) PressMouseButton(button int, pressed bool) {
+	if w.mouseButton != nil {
+		w.mouseButton(button, pressed)
+	}
+}
+
+// ScrollBy simulates a scroll wheel event
+func (w *NullWindow) ScrollBy(xoff, yoff float64) {
+	if w.scroll != nil {
+		w.scroll(xoff, yoff)
+	}
+}
+
+// PressKey simulates a key being pressed and released
+func (w *NullWindow) PressKey(key Key) {
+	if w.key != nil {
+		w.key(key, Press)
+		w.key(key, Release)
+	}
+}
+
+// TypeText simulates the user typing text
+func (w *NullWindow) TypeText(text string) {
+	if w.char != nil {
+		for _, c := range text {
+			w.char(c)
+		}
+	}
+}
+
+// Resize simulates the user resizing the window
+func (w *NullWindow) Resize(width, height int) {
+	w.Width, w.Height = width, height
+	if w.size != nil {
+		w.size(width, height)
+	}
+}
@@ -0,0 +1,261 @@
+package device
+
+//  --------------------------------------------------
+//  State_cache.go contains StateCache, which wraps a
+//  RenderDevice and remembers the GL state it has set,
+//  so binding what is already bound costs nothing.
+//  Code which talks to OpenGL directly, like gltext,
+//  changes state behind the cache's back, and must
+//  call Invalidate afterwards.
+//  --------------------------------------------------
+
+import (
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// StateCounts counts state changing calls by kind
+type StateCounts struct {
+	Programs       uint64 `json:"programs"`
+	VertexArrays   uint64 `json:"vertex_arrays"`
+	ActiveTextures uint64 `json:"active_textures"`
+	Textures       uint64 `json:"textures"`
+	Framebuffers   uint64 `json:"framebuffers"`
+	Capabilities   uint64 `json:"capabilities"`
+	BlendFuncs     uint64 `json:"blend_funcs"`
+	PolygonModes   uint64 `json:"polygon_modes"`
+	DepthMasks     uint64 `json:"depth_masks"`
+}
+
+// StateStats are the state changing calls passed on to
+// the device, and the redundant ones which were skipped
+type StateStats struct {
+	Made    StateCounts `json:"made"`
+	Skipped StateCounts `json:"skipped"`
+}
+
+type textureBinding struct {
+	unit   uint32
+	target uint32
+}
+
+// StateCache wraps a RenderDevice, and skips calls which
+// set state to what the device already has
+type StateCache struct {
+	RenderDevice
+
+	// Whether anything is known about each kind of state.
+	// Nothing is known until it is first set.
+	program      uint32
+	hasProgram   bool
+	vertexArray  uint32
+	hasVAO       bool
+	activeUnit   uint32
+	hasUnit      bool
+	textures     map[textureBinding]uint32
+	framebuffers map[uint32]uint32
+	capabilities map[uint32]bool
+	blend        [2]uint32
+	hasBlend     bool
+	polygonModes map[uint32]uint32
+	depthMask    bool
+	hasDepthMask bool
+
+	stats StateStats
+}
+
+// NewStateCache wraps d
+func NewStateCache(d RenderDevice) *StateCache {
+	c := &StateCache{RenderDevice: d}
+	c.Invalidate()
+	return c
+}
+
+// Invalidate forgets all of the cached state, so the next
+// call of every kind is passed on to the device
+func (c *StateCache) Invalidate() {
+	c.hasProgram = false
+	c.hasVAO = false
+	c.hasUnit = false
+	c.hasBlend = false
+	c.hasDepthMask = false
+
+	c.textures = make(map[textureBinding]uint32)
+	c.framebuffers = make(map[uint32]uint32)
+	c.capabilities = make(map[uint32]bool)
+	c.polygonModes = make(map[uint32]uint32)
+}
+
+// InvalidateTextures forgets the active texture unit
+// and every texture binding
+func (c *StateCache) InvalidateTextures() {
+	c.hasUnit = false
+	c.textures = make(map[textureBinding]uint32)
+}
+
+// Stats returns the calls counted since the last reset
+func (c *StateCache) Stats() StateStats {
+	return c.stats
+}
+
+// ResetStats returns the calls counted since the last
+// reset, and starts counting again from zero
+func (c *StateCache) ResetStats() StateStats {
+	s := c.stats
+	c.stats = StateStats{}
+	return s
+}
+
+//  --------------------------------------------------
+//  Cached calls
+//  --------------------------------------------------
+
+func (c *StateCache) UseProgram(program uint32) {
+	if c.hasProgram && c.program == program {
+		c.stats.Skipped.Programs++
+		return
+	}
+	c.program, c.hasProgram = program, true
+	c.stats.Made.Programs++
+	c.RenderDevice.UseProgram(program)
+}
+
+func (c *StateCache) BindVertexArray(array uint32) {
+	if c.hasVAO && c.vertexArray == array {
+		c.stats.Skipped.VertexArrays++
+		return
+	}
+	c.vertexArray, c.hasVAO = array, true
+	c.stats.Made.VertexArrays++
+	c.RenderDevice.BindVertexArray(array)
+}
+
+func (c *StateCache) ActiveTexture(texture uint32) {
+	if c.hasUnit && c.activeUnit == texture {
+		c.stats.Skipped.ActiveTextures++
+		return
+	}
+	c.activeUnit, c.hasUnit = texture, true
+	c.stats.Made.ActiveTextures++
+	c.RenderDevice.ActiveTexture(texture)
+}
+
+func (c *StateCache) BindTexture(target, texture uint32) {
+	// Without a known unit, the binding can't be remembered
+	if !c.hasUnit {
+		c.stats.Made.Textures++
+		c.RenderDevice.BindTexture(target, texture)
+		return
+	}
+
+	key := textureBinding{unit: c.activeUnit, target: target}
+	if bound, ok := c.textures[key]; ok && bound == texture {
+		c.stats.Skipped.Textures++
+		return
+	}
+	c.textures[key] = texture
+	c.stats.Made.Textures++
+	c.RenderDevice.BindTexture(target, texture)
+}
+
+// DeleteTextures unbinds the deleted textures from the cache
+// as well, since OpenGL may hand their names out again
+func (c *StateCache) DeleteTextures(n int32, textures *uint32) {
+	for _, t := range names(n, textures) {
+		for key, bound := range c.textures {
+			if bound == t {
+				c.textures[key] = 0
+			}
+		}
+	}
+	c.RenderDevice.DeleteTextures(n, textures)
+}
+
+func (c *StateCache) BindFramebuffer(target, framebuffer uint32) {
+	if bound, ok := c.framebuffers[target]; ok && bound == framebuffer {
+		c.stats.Skipped.Framebuffers++
+		return
+	}
+
+	// FRAMEBUFFER binds both the draw and read framebuffers
+	if target == gl.FRAMEBUFFER {
+		delete(c.framebuffers, gl.DRAW_FRAMEBUFFER)
+		delete(c.framebuffers, gl.READ_FRAMEBUFFER)
+	} else {
+		delete(c.framebuffers, gl.FRAMEBUFFER)
+	}
+
+	c.framebuffers[target] = framebuffer
+	c.stats.Made.Framebuffers++
+	c.RenderDevice.BindFramebuffer(target, framebuffer)
+}
+
+func (c *StateCache) DeleteFramebuffers(n int32, framebuffers *uint32) {
+	for _, f := range names(n, framebuffers) {
+		for target, bound := range c.framebuffers {
+			if bound == f {
+				c.framebuffers[target] = 0
+			}
+		}
+	}
+	c.RenderDevice.DeleteFramebuffers(n, framebuffers)
+}
+
+func (c *StateCache) Enable(cap uint32) {
+	if enabled, ok := c.capabilities[cap]; ok && enabled {
+		c.stats.Skipped.Capabilities++
+		return
+	}
+	c.capabilities[cap] = true
+	c.stats.Made.Capabilities++
+	c.RenderDevice.Enable(cap)
+}
+
+func (c *StateCache) Disable(cap uint32) {
+	if enabled, ok := c.capabilities[cap]; ok && !enabled {
+		c.stats.Skipped.Capabilities++
+		return
+	}
+	c.capabilities[cap] = false
+	c.stats.Made.Capabilities++
+	c.RenderDevice.Disable(cap)
+}
+
+func (c *StateCache) BlendFunc(sfactor, dfactor uint32) {
+	if c.hasBlend && c.blend == [2]uint32{sfactor, dfactor} {
+		c.stats.Skipped.BlendFuncs++
+		return
+	}
+	c.blend, c.hasBlend = [2]uint32{sfactor, dfactor}, true
+	c.stats.Made.BlendFuncs++
+	c.RenderDevice.BlendFunc(sfactor, dfactor)
+}
+
+func (c *StateCache) PolygonMode(face, mode uint32) {
+	if bound, ok := c.polygonModes[face]; ok && bound == mode {
+		c.stats.Skipped.PolygonModes++
+		return
+	}
+	c.polygonModes[face] = mode
+	c.stats.Made.PolygonModes++
+	c.RenderDevice.PolygonMode(face, mode)
+}
+
+func (c *StateCache) DepthMask(flag bool) {
+	if c.hasDepthMask && c.depthMask == flag {
+		c.stats.Skipped.DepthMasks++
+		return
+	}
+	c.depthMask, c.hasDepthMask = flag, true
+	c.stats.Made.DepthMasks++
+	c.RenderDevice.DepthMask(flag)
+}
+
+// names returns the n names starting at first
+func names(n int32, first *uint32) []uint32 {
+	if n <= 0 || first == nil {
+		return nil
+	}
+	return unsafe.Slice(first, n)
+}
@@ -0,0 +1,173 @@
+package device
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// countCalls counts the recorded calls named name
+func countCalls(d *NullDevice, name string) int {
+	n := 0
+	for _, c := range d.Calls() {
+		if c.Name == name {
+			n++
+		}
+	}
+	return n
+}
+
+func TestStateCacheSkipsRedundantCalls(t *testing.T) {
+	null := NewNullDevice()
+	c := NewStateCache(null)
+
+	c.UseProgram(1)
+	c.UseProgram(1)
+	c.UseProgram(2)
+	c.BindVertexArray(3)
+	c.BindVertexArray(3)
+	c.Enable(gl.DEPTH_TEST)
+	c.Enable(gl.DEPTH_TEST)
+	c.Disable(gl.DEPTH_TEST)
+	c.Disable(gl.BLEND)
+	c.Disable(gl.BLEND)
+	c.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	c.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	c.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+	c.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+	c.DepthMask(false)
+	c.DepthMask(false)
+	c.DepthMask(true)
+
+	for name, want := range map[string]int{
+		"UseProgram":      2,
+		"BindVertexArray": 1,
+		"Enable":          1,
+		"Disable":         2,
+		"BlendFunc":       1,
+		"PolygonMode":     1,
+		"DepthMask":       2,
+	} {
+		if got := countCalls(null, name); got != want {
+			t.Errorf("%d %s calls reached the device, want %d", got, name, want)
+		}
+	}
+
+	want := StateStats{
+		Made: StateCounts{
+			Programs:     2,
+			VertexArrays: 1,
+			Capabilities: 3,
+			BlendFuncs:   1,
+			PolygonModes: 1,
+			DepthMasks:   2,
+		},
+		Skipped: StateCounts{
+			Programs:     1,
+			VertexArrays: 1,
+			Capabilities: 2,
+			BlendFuncs:   1,
+			PolygonModes: 1,
+			DepthMasks:   1,
+		},
+	}
+	if c.Stats() != want {
+		t.Fatalf("stats %+v, want %+v", c.Stats(), want)
+	}
+}
+
+func TestStateCacheTextures(t *testing.T) {
+	null := NewNullDevice()
+	c := NewStateCache(null)
+
+	// Without a known unit, every binding is passed on
+	c.BindTexture(gl.TEXTURE_2D, 1)
+	c.BindTexture(gl.TEXTURE_2D, 1)
+
+	// Bindings are remembered per unit
+	c.ActiveTexture(gl.TEXTURE0)
+	c.BindTexture(gl.TEXTURE_2D, 1)
+	c.BindTexture(gl.TEXTURE_2D, 1)
+	c.ActiveTexture(gl.TEXTURE1)
+	c.BindTexture(gl.TEXTURE_2D, 1)
+	c.ActiveTexture(gl.TEXTURE0)
+	c.ActiveTexture(gl.TEXTURE0)
+	c.BindTexture(gl.TEXTURE_2D, 1)
+
+	if got := countCalls(null, "BindTexture"); got != 4 {
+		t.Fatalf("%d BindTexture calls reached the device, want 4", got)
+	}
+	if got := countCalls(null, "ActiveTexture"); got != 3 {
+		t.Fatalf("%d ActiveTexture calls reached the device, want 3", got)
+	}
+
+	// A deleted texture's name can be handed out again,
+	// so binding it afterwards isn't skipped
+	tex := uint32(1)
+	c.DeleteTextures(1, &tex)
+	c.BindTexture(gl.TEXTURE_2D, 1)
+	if got := countCalls(null, "BindTexture"); got != 5 {
+		t.Fatalf("binding a deleted texture was skipped")
+	}
+
+	c.InvalidateTextures()
+	c.ActiveTexture(gl.TEXTURE0)
+	c.BindTexture(gl.TEXTURE_2D, 1)
+	if countCalls(null, "ActiveTexture") != 4 || countCalls(null, "BindTexture") != 6 {
+		t.Fatal("texture calls were skipped after invalidating the textures")
+	}
+}
+
+func TestStateCacheFramebuffers(t *testing.T) {
+	null := NewNullDevice()
+	c := NewStateCache(null)
+
+	c.BindFramebuffer(gl.FRAMEBUFFER, 1)
+	c.BindFramebuffer(gl.FRAMEBUFFER, 1)
+	if got := countCalls(null, "BindFramebuffer"); got != 1 {
+		t.Fatalf("%d BindFramebuffer calls reached the device, want 1", got)
+	}
+
+	// Binding the draw framebuffer forgets FRAMEBUFFER,
+	// and binding FRAMEBUFFER forgets the draw framebuffer
+	c.BindFramebuffer(gl.DRAW_FRAMEBUFFER, 2)
+	c.BindFramebuffer(gl.FRAMEBUFFER, 1)
+	c.BindFramebuffer(gl.DRAW_FRAMEBUFFER, 2)
+	if got := countCalls(null, "BindFramebuffer"); got != 4 {
+		t.Fatalf("%d BindFramebuffer calls reached the device, want 4", got)
+	}
+
+	fb := uint32(2)
+	c.DeleteFramebuffers(1, &fb)
+	c.BindFramebuffer(gl.DRAW_FRAMEBUFFER, 2)
+	if got := countCalls(null, "BindFramebuffer"); got != 5 {
+		t.Fatal("binding a deleted framebuffer was skipped")
+	}
+}
+
+func TestStateCacheInvalidate(t *testing.T) {
+	null := NewNullDevice()
+	c := NewStateCache(null)
+
+	c.UseProgram(1)
+	c.BindVertexArray(1)
+	c.Enable(gl.DEPTH_TEST)
+	c.Invalidate()
+	c.UseProgram(1)
+	c.BindVertexArray(1)
+	c.Enable(gl.DEPTH_TEST)
+
+	for _, name := range []string{"UseProgram", "BindVertexArray", "Enable"} {
+		if got := countCalls(null, name); got != 2 {
+			t.Errorf("%d %s calls reached the device after invalidating, want 2", got, name)
+		}
+	}
+
+	s := c.ResetStats()
+	if s.Made.Programs != 2 || s.Skipped != (StateCounts{}) {
+		t.Fatalf("reset returned %+v, want 2 programs and nothing skipped", s)
+	}
+	if c.Stats() != (StateStats{}) {
+		t.Fatalf("stats %+v after a reset", c.Stats())
+	}
+}
@@ -0,0 +1,63 @@
+package ecs
+
+//  --------------------------------------------------
+//  Query.go runs functions over every entity with a set
+//  of components. Queries walk the smallest of the
+//  storages, and look up the other components of each
+//  entity. Components must not be added or removed
+//  during a query, so Defer such changes instead.
+//  --------------------------------------------------
+
+// Each calls f with every entity which has a component of type A
+func Each[A any](w *World, f func(e Entity, a *A)) {
+	sa := StorageOf[A](w)
+
+	for i := 0; i < len(sa.entities); i++ {
+		f(sa.entities[i], &sa.components[i])
+	}
+}
+
+// Each2 calls f with every entity which has components of types A and B
+func Each2[A, B any](w *World, f func(e Entity, a *A, b *B)) {
+	sa, sb := StorageOf[A](w), StorageOf[B](w)
+
+	if sa.Len() <= sb.Len() {
+		for i := 0; i < len(sa.entities); i++ {
+			e := sa.entities[i]
+			if b := sb.Get(e); b != nil {
+				f(e, &sa.components[i], b)
+			}
+		}
+		return
+	}
+
+	for i := 0; i < len(sb.entities); i++ {
+		e := sb.entities[i]
+		if a := sa.Get(e); a != nil {
+			f(e, a, &sb.components[i])
+		}
+	}
+}
+
+// Each3 calls f with every entity which has components of types A, B and C
+func Each3[A, B, C any](w *World, f func(e Entity, a *A, b *B, c *C)) {
+	sa, sb, sc := StorageOf[A](w), StorageOf[B](w), StorageOf[C](w)
+
+	// The entities of the smallest storage
+	entities := sa.entities
+	if sb.Len() < len(entities) {
+		entities = sb.entities
+	}
+	if sc.Len() < len(entities) {
+		entities = sc.entities
+	}
+
+	for i := 0; i < len(entities); i++ {
+		e := entities[i]
+
+		a, b, c := sa.Get(e), sb.Get(e), sc.Get(e)
+		if a != nil && b != nil && c != nil {
+			f(e, a, b, c)
+		}
+	}
+}
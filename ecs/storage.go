@@ -0,0 +1,165 @@
+package ecs
+
+import (
+	"reflect"
+)
+
+// storage is the part of Storage the world
+// uses without knowing the component type
+type storage interface {
+	remove(e Entity)
+}
+
+// Storage holds the components of one type as a sparse set. The
+// components are packed into a dense array, which systems iterate
+// over directly, and entities find theirs through a sparse array.
+type Storage[T any] struct {
+	// Dense position of each entity index's component, plus one.
+	// Zero means the entity has no component.
+	sparse []int32
+
+	entities   []Entity
+	components []T
+
+	// OnRemove is called before a component is removed,
+	// including when its entity is destroyed
+	OnRemove func(e Entity, c *T)
+
+	world *World
+}
+
+// StorageOf returns the storage of components of type T,
+// creating it the first time it is asked for
+func StorageOf[T any](w *World) *Storage[T] {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	if s, ok := w.storages[t]; ok {
+		return s.(*Storage[T])
+	}
+
+	s := &Storage[T]{world: w}
+	w.storages[t] = s
+	return s
+}
+
+// Add gives e the component c, replacing any it already has,
+// and returns a pointer to the stored component
+func (s *Storage[T]) Add(e Entity, c T) *T {
+	if !s.world.Alive(e) {
+		return nil
+	}
+
+	if i := s.index(e); i >= 0 {
+		s.components[i] = c
+		return &s.components[i]
+	}
+
+	for int(e.Index()) >= len(s.sparse) {
+		s.sparse = append(s.sparse, 0)
+	}
+
+	s.entities = append(s.entities, e)
+	s.components = append(s.components, c)
+	s.sparse[e.Index()] = int32(len(s.entities))
+
+	return &s.components[len(s.components)-1]
+}
+
+// Get returns e's component, or nil if it has none. The pointer
+// is only valid until a component of the type is added or removed.
+func (s *Storage[T]) Get(e Entity) *T {
+	if i := s.index(e); i >= 0 {
+		return &s.components[i]
+	}
+	return nil
+}
+
+// Has reports whether e has a component
+func (s *Storage[T]) Has(e Entity) bool {
+	return s.index(e) >= 0
+}
+
+// Remove removes e's component. The last component
+// is moved into its place, so the array stays dense.
+func (s *Storage[T]) Remove(e Entity) {
+	s.remove(e)
+}
+
+func (s *Storage[T]) remove(e Entity) {
+	i := s.index(e)
+	if i < 0 {
+		return
+	}
+
+	if s.OnRemove != nil {
+		s.OnRemove(e, &s.components[i])
+	}
+
+	last := len(s.entities) - 1
+	moved := s.entities[last]
+
+	s.entities[i] = moved
+	s.components[i] = s.components[last]
+	s.sparse[moved.Index()] = int32(i + 1)
+
+	var zero T
+	s.components[last] = zero
+	s.entities = s.entities[:last]
+	s.components = s.components[:last]
+	s.sparse[e.Index()] = 0
+}
+
+// index returns the dense position of e's component, or -1
+func (s *Storage[T]) index(e Entity) int {
+	i := e.Index()
+	if int(i) >= len(s.sparse) || s.sparse[i] == 0 {
+		return -1
+	}
+
+	dense := int(s.sparse[i] - 1)
+	if s.entities[dense] != e {
+		return -1
+	}
+	return dense
+}
+
+// Len returns the number of components
+func (s *Storage[T]) Len() int {
+	return len(s.entities)
+}
+
+// Entities returns the entities with a component, in the
+// same order as Components. It must not be modified.
+func (s *Storage[T]) Entities() []Entity {
+	return s.entities
+}
+
+// Components returns the dense array of components,
+// which systems can iterate over and modify in place
+func (s *Storage[T]) Components() []T {
+	return s.components
+}
+
+//  --------------------------------------------------
+//  Shorthands
+//  --------------------------------------------------
+
+// Add gives e a component, see Storage.Add
+func Add[T any](w *World, e Entity, c T) *T {
+	return StorageOf[T](w).Add(e, c)
+}
+
+// Get returns e's component of type T, or nil
+func Get[T any](w *World, e Entity) *T {
+	return StorageOf[T](w).Get(e)
+}
+
+// Has reports whether e has a component of type T
+func Has[T any](w *World, e Entity) bool {
+	return StorageOf[T](w).Has(e)
+}
+
+// Remove removes e's component of type T
+func Remove[T any](w *World, e Entity) {
+	StorageOf[T](w).Remove(e)
+}
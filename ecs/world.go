@@ -0,0 +1,177 @@
+package ecs
+
+//  --------------------------------------------------
+//  Package ecs stores gameplay data as components of
+//  entities, which are only IDs. Components of a type
+//  are kept together in a dense array, and systems run
+//  every frame over the entities with the components
+//  they need.
+//  --------------------------------------------------
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Entity identifies an entity. The low 32 bits are its index, and
+// the high 32 bits count how often the index has been reused, so IDs
+// of destroyed entities never refer to new ones. The zero Entity
+// is never alive.
+type Entity uint64
+
+// Index returns the entity's slot, which is reused after it is destroyed
+func (e Entity) Index() uint32 {
+	return uint32(e)
+}
+
+// Generation returns how many entities have used the index before
+func (e Entity) Generation() uint32 {
+	return uint32(e >> 32)
+}
+
+func (e Entity) String() string {
+	return fmt.Sprintf("%d:%d", e.Index(), e.Generation())
+}
+
+func newEntity(index, generation uint32) Entity {
+	return Entity(uint64(generation)<<32 | uint64(index))
+}
+
+// System updates the world every frame
+type System interface {
+	Update(w *World, delta float64)
+}
+
+// SystemFunc adapts a function to System
+type SystemFunc func(w *World, delta float64)
+
+func (f SystemFunc) Update(w *World, delta float64) {
+	f(w, delta)
+}
+
+type namedSystem struct {
+	name   string
+	system System
+}
+
+// World holds entities, their components and the systems run over them
+type World struct {
+	// Generation of each index. Index 0 is never used.
+	generations []uint32
+	free        []uint32
+	alive       int
+
+	storages map[reflect.Type]storage
+
+	systems []namedSystem
+
+	// Changes deferred until the current system has finished
+	deferred []func()
+}
+
+func NewWorld() *World {
+	return &World{
+		generations: []uint32{0},
+		storages:    make(map[reflect.Type]storage),
+	}
+}
+
+//  --------------------------------------------------
+//  Entities
+//  --------------------------------------------------
+
+// NewEntity creates an entity without any components
+func (w *World) NewEntity() Entity {
+	w.alive++
+
+	if n := len(w.free); n > 0 {
+		index := w.free[n-1]
+		w.free = w.free[:n-1]
+		return newEntity(index, w.generations[index])
+	}
+
+	w.generations = append(w.generations, 1)
+	return newEntity(uint32(len(w.generations)-1), 1)
+}
+
+// Alive reports whether e has been created and not destroyed
+func (w *World) Alive(e Entity) bool {
+	i := e.Index()
+	return i != 0 && int(i) < len(w.generations) && w.generations[i] == e.Generation()
+}
+
+// Destroy removes all of an entity's components, and frees its ID
+func (w *World) Destroy(e Entity) {
+	if !w.Alive(e) {
+		return
+	}
+
+	for _, s := range w.storages {
+		s.remove(e)
+	}
+
+	i := e.Index()
+	w.generations[i]++
+	w.free = append(w.free, i)
+	w.alive--
+}
+
+// Len returns the number of entities alive
+func (w *World) Len() int {
+	return w.alive
+}
+
+//  --------------------------------------------------
+//  Systems
+//  --------------------------------------------------
+
+// AddSystem adds a system, which runs after those added before it
+func (w *World) AddSystem(name string, s System) {
+	w.systems = append(w.systems, namedSystem{name, s})
+}
+
+// RemoveSystem removes the system with the given name
+func (w *World) RemoveSystem(name string) {
+	for i, s := range w.systems {
+		if s.name == name {
+			w.systems = append(w.systems[:i], w.systems[i+1:]...)
+			return
+		}
+	}
+}
+
+// Systems returns the names of the systems, in the order they run
+func (w *World) Systems() []string {
+	names := make([]string, len(w.systems))
+	for i, s := range w.systems {
+		names[i] = s.name
+	}
+	return names
+}
+
+// Update runs every system once, in order
+func (w *World) Update(delta float64) {
+	for _, s := range w.systems {
+		s.system.Update(w, delta)
+		w.Flush()
+	}
+}
+
+// Defer delays a change until the current system has finished,
+// such as creating or destroying entities, or adding or removing
+// components, while iterating over them
+func (w *World) Defer(f func()) {
+	w.deferred = append(w.deferred, f)
+}
+
+// Flush runs the deferred changes. Update
+// calls it after every system.
+func (w *World) Flush() {
+	for len(w.deferred) > 0 {
+		deferred := w.deferred
+		w.deferred = nil
+		for _, f := range deferred {
+			f()
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package ecs
+
+import (
+	"testing"
+)
+
+type position struct {
+	X, Y float32
+}
+
+type velocity struct {
+	X, Y float32
+}
+
+//  --------------------------------------------------
+//  Entities
+//  --------------------------------------------------
+
+func TestEntityReuse(t *testing.T) {
+	w := NewWorld()
+
+	a := w.NewEntity()
+	b := w.NewEntity()
+	if a.Index() == 0 || b.Index() == 0 {
+		t.Fatalf("entities %v and %v use index 0", a, b)
+	}
+	if a == b {
+		t.Fatalf("two entities share the ID %v", a)
+	}
+
+	w.Destroy(a)
+	if w.Alive(a) {
+		t.Fatalf("%v is alive after being destroyed", a)
+	}
+	if w.Len() != 1 {
+		t.Fatalf("Len = %d, want 1", w.Len())
+	}
+
+	c := w.NewEntity()
+	if c.Index() != a.Index() {
+		t.Fatalf("new entity %v doesn't reuse the index of %v", c, a)
+	}
+	if c.Generation() != a.Generation()+1 {
+		t.Fatalf("new entity %v has generation %d, want %d", c, c.Generation(), a.Generation()+1)
+	}
+	if w.Alive(a) {
+		t.Fatalf("stale ID %v is alive after its index was reused by %v", a, c)
+	}
+	if !w.Alive(c) || !w.Alive(b) {
+		t.Fatalf("%v or %v isn't alive", c, b)
+	}
+}
+
+func TestStaleEntity(t *testing.T) {
+	w := NewWorld()
+
+	if w.Alive(0) {
+		t.Fatal("the zero Entity is alive")
+	}
+
+	a := w.NewEntity()
+	Add(w, a, position{1, 2})
+	w.Destroy(a)
+
+	c := w.NewEntity()
+	Add(w, c, position{3, 4})
+
+	if Has[position](w, a) || Get[position](w, a) != nil {
+		t.Fatalf("stale ID %v finds the component of %v", a, c)
+	}
+	if Add(w, a, position{5, 6}) != nil {
+		t.Fatalf("a component was added to the stale ID %v", a)
+	}
+
+	// Destroying a stale ID mustn't touch the entity reusing its index
+	w.Destroy(a)
+	if !w.Alive(c) || *Get[position](w, c) != (position{3, 4}) {
+		t.Fatalf("destroying stale ID %v changed %v", a, c)
+	}
+	if w.Len() != 1 {
+		t.Fatalf("Len = %d, want 1", w.Len())
+	}
+}
+
+func TestDestroyRemovesComponents(t *testing.T) {
+	w := NewWorld()
+
+	e := w.NewEntity()
+	Add(w, e, position{1, 2})
+	Add(w, e, velocity{3, 4})
+
+	removed := 0
+	StorageOf[position](w).OnRemove = func(re Entity, p *position) {
+		if re != e || *p != (position{1, 2}) {
+			t.Errorf("OnRemove(%v, %v), want (%v, {1 2})", re, *p, e)
+		}
+		removed++
+	}
+
+	w.Destroy(e)
+
+	if removed != 1 {
+		t.Fatalf("OnRemove called %d times, want 1", removed)
+	}
+	if StorageOf[position](w).Len() != 0 || StorageOf[velocity](w).Len() != 0 {
+		t.Fatal("components remain after their entity was destroyed")
+	}
+}
+
+//  --------------------------------------------------
+//  Storage
+//  --------------------------------------------------
+
+func TestStorageSwapRemove(t *testing.T) {
+	w := NewWorld()
+	s := StorageOf[position](w)
+
+	entities := make([]Entity, 4)
+	for i := range entities {
+		entities[i] = w.NewEntity()
+		s.Add(entities[i], position{float32(i), 0})
+	}
+
+	// Removing the first moves the last into its place
+	s.Remove(entities[0])
+
+	if s.Len() != 3 {
+		t.Fatalf("Len = %d, want 3", s.Len())
+	}
+	if s.Entities()[0] != entities[3] || s.Components()[0].X != 3 {
+		t.Fatalf("dense[0] = %v %v, want the moved %v {3 0}", s.Entities()[0], s.Components()[0], entities[3])
+	}
+	if s.Has(entities[0]) {
+		t.Fatalf("%v still has its component", entities[0])
+	}
+	for i, e := range entities[1:] {
+		p := s.Get(e)
+		if p == nil || p.X != float32(i+1) {
+			t.Fatalf("Get(%v) = %v, want {%d 0}", e, p, i+1)
+		}
+	}
+
+	// Removing the last doesn't move anything
+	s.Remove(entities[2])
+	s.Remove(entities[2])
+
+	if s.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", s.Len())
+	}
+	if got := s.Get(entities[1]); got == nil || got.X != 1 {
+		t.Fatalf("Get(%v) = %v, want {1 0}", entities[1], got)
+	}
+	if got := s.Get(entities[3]); got == nil || got.X != 3 {
+		t.Fatalf("Get(%v) = %v, want {3 0}", entities[3], got)
+	}
+	if len(s.Entities()) != len(s.Components()) {
+		t.Fatalf("%d entities but %d components", len(s.Entities()), len(s.Components()))
+	}
+}
+
+func TestStorageAddReplaces(t *testing.T) {
+	w := NewWorld()
+	e := w.NewEntity()
+
+	Add(w, e, position{1, 2})
+	p := Add(w, e, position{3, 4})
+
+	if StorageOf[position](w).Len() != 1 {
+		t.Fatalf("Len = %d, want 1", StorageOf[position](w).Len())
+	}
+	if *p != (position{3, 4}) || *Get[position](w, e) != (position{3, 4}) {
+		t.Fatalf("component is %v, want {3 4}", *Get[position](w, e))
+	}
+}
+
+//  --------------------------------------------------
+//  Systems
+//  --------------------------------------------------
+
+func TestDeferFlush(t *testing.T) {
+	w := NewWorld()
+	e := w.NewEntity()
+
+	order := []string{}
+	w.Defer(func() {
+		order = append(order, "first")
+
+		// Changes deferred while flushing are run by the same flush
+		w.Defer(func() { order = append(order, "nested") })
+	})
+	w.Defer(func() {
+		order = append(order, "second")
+		w.Destroy(e)
+	})
+
+	if len(order) != 0 || !w.Alive(e) {
+		t.Fatal("deferred changes ran before Flush")
+	}
+
+	w.Flush()
+
+	want := []string{"first", "second", "nested"}
+	if len(order) != len(want) {
+		t.Fatalf("ran %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("ran %v, want %v", order, want)
+		}
+	}
+	if w.Alive(e) {
+		t.Fatalf("%v is alive after its deferred destroy", e)
+	}
+
+	w.Flush()
+	if len(order) != len(want) {
+		t.Fatalf("Flush ran changes twice: %v", order)
+	}
+}
+
+func TestUpdateFlushesAfterEachSystem(t *testing.T) {
+	w := NewWorld()
+
+	var created Entity
+	w.AddSystem("spawn", SystemFunc(func(w *World, delta float64) {
+		w.Defer(func() {
+			created = w.NewEntity()
+			Add(w, created, position{})
+		})
+	}))
+
+	seen := 0
+	w.AddSystem("count", SystemFunc(func(w *World, delta float64) {
+		Each(w, func(e Entity, p *position) { seen++ })
+	}))
+
+	w.Update(1.0 / 60)
+
+	if seen != 1 {
+		t.Fatalf("the second system saw %d entities, want the 1 the first created", seen)
+	}
+	if got := w.Systems(); len(got) != 2 || got[0] != "spawn" || got[1] != "count" {
+		t.Fatalf("Systems() = %v", got)
+	}
+
+	w.RemoveSystem("spawn")
+	if got := w.Systems(); len(got) != 1 || got[0] != "count" {
+		t.Fatalf("Systems() = %v after removing spawn", got)
+	}
+}
+
+//  --------------------------------------------------
+//  Queries
+//  --------------------------------------------------
+
+func TestDestroyDuringIteration(t *testing.T) {
+	w := NewWorld()
+
+	entities := make([]Entity, 10)
+	for i := range entities {
+		entities[i] = w.NewEntity()
+		Add(w, entities[i], position{float32(i), 0})
+		if i%2 == 0 {
+			Add(w, entities[i], velocity{1, 0})
+		}
+	}
+
+	visited := make(map[Entity]int)
+	w.AddSystem("cull", SystemFunc(func(w *World, delta float64) {
+		Each2(w, func(e Entity, p *position, v *velocity) {
+			visited[e]++
+			if int(p.X)%4 == 0 {
+				w.Defer(func() { w.Destroy(e) })
+			}
+		})
+	}))
+
+	w.Update(1.0 / 60)
+
+	if len(visited) != 5 {
+		t.Fatalf("visited %d entities, want 5", len(visited))
+	}
+	for e, n := range visited {
+		if n != 1 {
+			t.Fatalf("visited %v %d times", e, n)
+		}
+	}
+
+	for i, e := range entities {
+		if want := i%4 != 0; w.Alive(e) != want {
+			t.Fatalf("entity %d alive = %v, want %v", i, w.Alive(e), want)
+		}
+	}
+	if StorageOf[position](w).Len() != 7 || StorageOf[velocity](w).Len() != 2 {
+		t.Fatalf("%d positions and %d velocities remain, want 7 and 2",
+			StorageOf[position](w).Len(), StorageOf[velocity](w).Len())
+	}
+
+	// The remaining components are still found through their entities
+	Each(w, func(e Entity, p *position) {
+		if entities[int(p.X)] != e {
+			t.Errorf("%v has the position of %v", e, entities[int(p.X)])
+		}
+	})
+}
+
+func TestEach3(t *testing.T) {
+	w := NewWorld()
+
+	type tag struct{}
+
+	all := w.NewEntity()
+	Add(w, all, position{})
+	Add(w, all, velocity{})
+	Add(w, all, tag{})
+
+	partial := w.NewEntity()
+	Add(w, partial, position{})
+	Add(w, partial, tag{})
+
+	found := []Entity{}
+	Each3(w, func(e Entity, p *position, v *velocity, tg *tag) {
+		found = append(found, e)
+	})
+
+	if len(found) != 1 || found[0] != all {
+		t.Fatalf("Each3 found %v, want [%v]", found, all)
+	}
+}
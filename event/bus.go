@@ -0,0 +1,145 @@
+package event
+
+//  --------------------------------------------------
+//  Bus.go contains the engine's publish/subscribe event
+//  bus. Events can be dispatched immediately, or queued
+//  and dispatched together at the end of the frame.
+//  --------------------------------------------------
+
+import (
+	"sort"
+	"sync"
+)
+
+// Handler is called with each event a subscription receives
+type Handler func(Event)
+
+// Bus dispatches events to the handlers subscribed to their type.
+// Handlers with a higher priority are called first, and handlers
+// with the same priority in the order they subscribed.
+type Bus struct {
+	mu     sync.Mutex
+	subs   map[Type][]*Subscription
+	queue  []Event
+	nextID uint64
+}
+
+// Subscription is the handle returned by Subscribe
+type Subscription struct {
+	bus      *Bus
+	typ      Type
+	id       uint64
+	priority int
+	handler  Handler
+	removed  bool
+}
+
+// NewBus creates an empty event bus
+func NewBus() *Bus {
+	return &Bus{
+		subs: make(map[Type][]*Subscription),
+	}
+}
+
+// Subscribe calls handler with every event of type t,
+// or every event if t is Any
+func (b *Bus) Subscribe(t Type, handler Handler) *Subscription {
+	return b.SubscribePriority(t, 0, handler)
+}
+
+// SubscribePriority is Subscribe with a priority, handlers
+// with higher priorities being called first
+func (b *Bus) SubscribePriority(t Type, priority int, handler Handler) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	s := &Subscription{
+		bus:      b,
+		typ:      t,
+		id:       b.nextID,
+		priority: priority,
+		handler:  handler,
+	}
+
+	subs := append(b.subs[t], s)
+	sort.SliceStable(subs, func(i, j int) bool {
+		return subs[i].priority > subs[j].priority
+	})
+	b.subs[t] = subs
+
+	return s
+}
+
+// Unsubscribe stops the subscription receiving events. It takes
+// effect immediately, even during a dispatch to the subscription.
+func (s *Subscription) Unsubscribe() {
+	b := s.bus
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if s.removed {
+		return
+	}
+	s.removed = true
+
+	subs := b.subs[s.typ]
+	for i, other := range subs {
+		if other == s {
+			b.subs[s.typ] = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+}
+
+// Dispatch immediately calls every handler subscribed to the event
+func (b *Bus) Dispatch(e Event) {
+	b.mu.Lock()
+	subs := make([]*Subscription, 0, len(b.subs[e.Type()])+len(b.subs[Any]))
+	subs = append(subs, b.subs[e.Type()]...)
+	subs = append(subs, b.subs[Any]...)
+	b.mu.Unlock()
+
+	sort.SliceStable(subs, func(i, j int) bool {
+		return subs[i].priority > subs[j].priority
+	})
+
+	for _, s := range subs {
+		b.mu.Lock()
+		removed := s.removed
+		b.mu.Unlock()
+
+		if !removed {
+			s.handler(e)
+		}
+	}
+}
+
+// Publish queues the event to be dispatched by the next Flush
+func (b *Bus) Publish(e Event) {
+	b.mu.Lock()
+	b.queue = append(b.queue, e)
+	b.mu.Unlock()
+}
+
+// Flush dispatches every queued event in the order they were
+// published. Events published by handlers during the flush are
+// left queued for the next one.
+func (b *Bus) Flush() {
+	b.mu.Lock()
+	queue := b.queue
+	b.queue = nil
+	b.mu.Unlock()
+
+	for _, e := range queue {
+		b.Dispatch(e)
+	}
+}
+
+// Pending returns the number of queued events
+func (b *Bus) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.queue)
+}
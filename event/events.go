@@ -0,0 +1,121 @@
+package event
+
+import (
+	"rapidengine/child"
+	"rapidengine/material"
+)
+
+// Type identifies a kind of event
+type Type string
+
+// Event is anything published on a Bus. Handlers
+// type switch on the event to get at its fields.
+type Event interface {
+	Type() Type
+}
+
+// Any subscribes a handler to every event type
+const Any Type = "*"
+
+const (
+	TypeCollisionBegan    Type = "collision_began"
+	TypeCollisionEnded    Type = "collision_ended"
+	TypeKeyPressed        Type = "key_pressed"
+	TypeKeyReleased       Type = "key_released"
+	TypeSceneChanged      Type = "scene_changed"
+	TypeSceneLoaded       Type = "scene_loaded"
+	TypeAnimationFinished Type = "animation_finished"
+	TypeWindowResized     Type = "window_resized"
+	TypeEngineStarted     Type = "engine_started"
+	TypeEngineStopped     Type = "engine_stopped"
+)
+
+//  --------------------------------------------------
+//  Collision
+//  --------------------------------------------------
+
+// CollisionBegan is published when a child with a collision
+// link starts colliding with any child in the link's group.
+// Sides holds the same flags passed to the link's callback.
+type CollisionBegan struct {
+	Child child.Child
+	Group string
+	Sides []bool
+}
+
+// CollisionEnded is published when a child with a collision
+// link stops colliding with every child in the link's group
+type CollisionEnded struct {
+	Child child.Child
+	Group string
+}
+
+func (CollisionBegan) Type() Type { return TypeCollisionBegan }
+func (CollisionEnded) Type() Type { return TypeCollisionEnded }
+
+//  --------------------------------------------------
+//  Input
+//  --------------------------------------------------
+
+// KeyPressed is published the frame a key goes down, using
+// the key names from input.KeyMap
+type KeyPressed struct {
+	Key string
+}
+
+// KeyReleased is published the frame a key comes up
+type KeyReleased struct {
+	Key string
+}
+
+func (KeyPressed) Type() Type  { return TypeKeyPressed }
+func (KeyReleased) Type() Type { return TypeKeyReleased }
+
+//  --------------------------------------------------
+//  Scenes & Animation
+//  --------------------------------------------------
+
+// SceneChanged is published when the current scene is set.
+// Previous is empty if there was no scene before.
+type SceneChanged struct {
+	Previous string
+	Current  string
+}
+
+// SceneLoaded is published when a scene loaded with
+// LoadSceneAsync is ready, or has failed to load
+type SceneLoaded struct {
+	Path string
+	ID   string
+	Err  error
+}
+
+// AnimationFinished is published when an animation
+// played with PlayAnimationOnce reaches its last frame
+type AnimationFinished struct {
+	Material  *material.BasicMaterial
+	Animation string
+}
+
+func (SceneChanged) Type() Type      { return TypeSceneChanged }
+func (SceneLoaded) Type() Type       { return TypeSceneLoaded }
+func (AnimationFinished) Type() Type { return TypeAnimationFinished }
+
+//  --------------------------------------------------
+//  Window & Lifecycle
+//  --------------------------------------------------
+
+// WindowResized is published when the window's size changes
+type WindowResized struct {
+	Width  int
+	Height int
+}
+
+// EngineStarted is published as the render loop starts,
+// and EngineStopped after it exits
+type EngineStarted struct{}
+type EngineStopped struct{}
+
+func (WindowResized) Type() Type { return TypeWindowResized }
+func (EngineStarted) Type() Type { return TypeEngineStarted }
+func (EngineStopped) Type() Type { return TypeEngineStopped }
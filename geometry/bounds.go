@@ -0,0 +1,124 @@
+package geometry
+
+//  --------------------------------------------------
+//  Bounds.go contains the bounding volumes of meshes,
+//  an axis aligned box and a sphere around it, which
+//  the renderer culls children with.
+//  --------------------------------------------------
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Bounds is an axis aligned bounding box, and a sphere
+// containing it. Bounds which aren't Valid contain nothing.
+type Bounds struct {
+	Min mgl32.Vec3
+	Max mgl32.Vec3
+
+	Center mgl32.Vec3
+	Radius float32
+
+	Valid bool
+}
+
+// NewBounds returns the bounds of a list of x, y, z positions
+func NewBounds(vertices []float32) Bounds {
+	if len(vertices) < 3 {
+		return Bounds{}
+	}
+
+	min := mgl32.Vec3{vertices[0], vertices[1], vertices[2]}
+	max := min
+	for i := 3; i+2 < len(vertices); i += 3 {
+		for j := 0; j < 3; j++ {
+			min[j] = float32(math.Min(float64(min[j]), float64(vertices[i+j])))
+			max[j] = float32(math.Max(float64(max[j]), float64(vertices[i+j])))
+		}
+	}
+
+	return NewBoundsFromBox(min, max)
+}
+
+// NewBoundsFromBox returns the bounds of the box from min to max
+func NewBoundsFromBox(min, max mgl32.Vec3) Bounds {
+	return Bounds{
+		Min:    min,
+		Max:    max,
+		Center: min.Add(max).Mul(0.5),
+		Radius: max.Sub(min).Len() / 2,
+		Valid:  true,
+	}
+}
+
+// Union returns bounds containing both b and o
+func (b Bounds) Union(o Bounds) Bounds {
+	if !b.Valid {
+		return o
+	}
+	if !o.Valid {
+		return b
+	}
+
+	min, max := b.Min, b.Max
+	for i := 0; i < 3; i++ {
+		min[i] = float32(math.Min(float64(min[i]), float64(o.Min[i])))
+		max[i] = float32(math.Max(float64(max[i]), float64(o.Max[i])))
+	}
+	return NewBoundsFromBox(min, max)
+}
+
+// Transform returns the bounds of b after it is transformed by m.
+// The box is fitted around the transformed corners, and the sphere
+// is scaled by the largest scale in m, so it stays a tight fit.
+func (b Bounds) Transform(m mgl32.Mat4) Bounds {
+	if !b.Valid {
+		return b
+	}
+
+	var min, max mgl32.Vec3
+	for i := 0; i < 8; i++ {
+		corner := b.Min
+		if i&1 != 0 {
+			corner[0] = b.Max[0]
+		}
+		if i&2 != 0 {
+			corner[1] = b.Max[1]
+		}
+		if i&4 != 0 {
+			corner[2] = b.Max[2]
+		}
+
+		p := mgl32.TransformCoordinate(corner, m)
+		if i == 0 {
+			min, max = p, p
+			continue
+		}
+		for j := 0; j < 3; j++ {
+			min[j] = float32(math.Min(float64(min[j]), float64(p[j])))
+			max[j] = float32(math.Max(float64(max[j]), float64(p[j])))
+		}
+	}
+
+	scale := float32(math.Max(float64(m.Col(0).Vec3().Len()), math.Max(float64(m.Col(1).Vec3().Len()), float64(m.Col(2).Vec3().Len()))))
+
+	return Bounds{
+		Min:    min,
+		Max:    max,
+		Center: mgl32.TransformCoordinate(b.Center, m),
+		Radius: b.Radius * scale,
+		Valid:  true,
+	}
+}
+
+// Distance returns how far p is from the bounding sphere,
+// which is 0 when p is inside it
+func (b Bounds) Distance(p mgl32.Vec3) float32 {
+	d := b.Center.Sub(p).Len() - b.Radius
+	if d < 0 {
+		return 0
+	}
+	return d
+}
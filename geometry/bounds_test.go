@@ -0,0 +1,85 @@
+package geometry
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestNewBounds(t *testing.T) {
+	b := NewBounds([]float32{
+		-1, 0, 2,
+		3, -2, 0,
+		1, 4, 1,
+	})
+
+	if !b.Valid || b.Min != (mgl32.Vec3{-1, -2, 0}) || b.Max != (mgl32.Vec3{3, 4, 2}) {
+		t.Fatalf("bounds %+v, want {-1 -2 0} to {3 4 2}", b)
+	}
+	if b.Center != (mgl32.Vec3{1, 1, 1}) {
+		t.Fatalf("center %v, want {1 1 1}", b.Center)
+	}
+	if want := b.Max.Sub(b.Min).Len() / 2; b.Radius != want {
+		t.Fatalf("radius %v, want %v", b.Radius, want)
+	}
+
+	if NewBounds([]float32{1, 2}).Valid {
+		t.Fatal("bounds of less than a vertex are valid")
+	}
+}
+
+func TestBoundsUnion(t *testing.T) {
+	a := NewBoundsFromBox(mgl32.Vec3{0, 0, 0}, mgl32.Vec3{1, 1, 1})
+	b := NewBoundsFromBox(mgl32.Vec3{-1, 2, 0}, mgl32.Vec3{0, 3, 5})
+
+	u := a.Union(b)
+	if u.Min != (mgl32.Vec3{-1, 0, 0}) || u.Max != (mgl32.Vec3{1, 3, 5}) {
+		t.Fatalf("union %+v, want {-1 0 0} to {1 3 5}", u)
+	}
+
+	// Invalid bounds contain nothing
+	if a.Union(Bounds{}) != a || (Bounds{}).Union(a) != a {
+		t.Fatal("a union with invalid bounds changed them")
+	}
+}
+
+func TestBoundsTransform(t *testing.T) {
+	b := NewBoundsFromBox(mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{1, 1, 1})
+
+	m := mgl32.Translate3D(10, 0, 0).
+		Mul4(mgl32.HomogRotate3DY(mgl32.DegToRad(45))).
+		Mul4(mgl32.Scale3D(2, 1, 1))
+	tb := b.Transform(m)
+
+	if !tb.Center.ApproxEqual(mgl32.Vec3{10, 0, 0}) {
+		t.Fatalf("center %v, want {10 0 0}", tb.Center)
+	}
+
+	// The sphere is scaled by the largest scale, and
+	// still contains every corner of the transformed box
+	if want := b.Radius * 2; !mgl32.FloatEqualThreshold(tb.Radius, want, 1e-4) {
+		t.Fatalf("radius %v, want %v", tb.Radius, want)
+	}
+	for _, corner := range []mgl32.Vec3{{-1, -1, -1}, {1, 1, 1}, {1, -1, 1}, {-1, 1, -1}} {
+		p := mgl32.TransformCoordinate(corner, m)
+		if tb.Distance(p) > 1e-4 {
+			t.Fatalf("corner %v is outside of the sphere %+v", p, tb)
+		}
+		for i := 0; i < 3; i++ {
+			if p[i] < tb.Min[i]-1e-4 || p[i] > tb.Max[i]+1e-4 {
+				t.Fatalf("corner %v is outside of the box %+v", p, tb)
+			}
+		}
+	}
+}
+
+func TestBoundsDistance(t *testing.T) {
+	b := NewBoundsFromBox(mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{1, 0, 0})
+
+	if d := b.Distance(mgl32.Vec3{0.5, 0, 0}); d != 0 {
+		t.Fatalf("distance %v inside the sphere, want 0", d)
+	}
+	if d := b.Distance(mgl32.Vec3{0, 5, 0}); d != 4 {
+		t.Fatalf("distance %v, want 4", d)
+	}
+}
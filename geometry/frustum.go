@@ -0,0 +1,77 @@
+package geometry
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Frustum is the volume a camera sees, as six planes facing
+// inwards. Each plane is a normal followed by its distance.
+type Frustum struct {
+	Planes [6]mgl32.Vec4
+}
+
+// NewFrustum returns the frustum of a projection * view matrix
+func NewFrustum(viewProjection mgl32.Mat4) Frustum {
+	r0, r1, r2, r3 := viewProjection.Row(0), viewProjection.Row(1), viewProjection.Row(2), viewProjection.Row(3)
+
+	f := Frustum{Planes: [6]mgl32.Vec4{
+		r3.Add(r0), // Left
+		r3.Sub(r0), // Right
+		r3.Add(r1), // Bottom
+		r3.Sub(r1), // Top
+		r3.Add(r2), // Near
+		r3.Sub(r2), // Far
+	}}
+
+	for i, p := range f.Planes {
+		if l := p.Vec3().Len(); l > 0 {
+			f.Planes[i] = p.Mul(1 / l)
+		}
+	}
+
+	return f
+}
+
+// ContainsPoint reports whether p is inside the frustum
+func (f Frustum) ContainsPoint(p mgl32.Vec3) bool {
+	return f.IntersectsSphere(p, 0)
+}
+
+// IntersectsSphere reports whether any of the sphere is inside the frustum
+func (f Frustum) IntersectsSphere(center mgl32.Vec3, radius float32) bool {
+	for _, p := range f.Planes {
+		if p.Vec3().Dot(center)+p[3] < -radius {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsBox reports whether any of the box from min to max
+// is inside the frustum. Boxes near the frustum's corners can
+// be reported as inside when they aren't.
+func (f Frustum) IntersectsBox(min, max mgl32.Vec3) bool {
+	for _, p := range f.Planes {
+		// The corner furthest along the plane's normal
+		corner := min
+		for i := 0; i < 3; i++ {
+			if p[i] > 0 {
+				corner[i] = max[i]
+			}
+		}
+
+		if p.Vec3().Dot(corner)+p[3] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsBounds tests the bounding sphere, and then the box.
+// Bounds which aren't Valid are always inside.
+func (f Frustum) IntersectsBounds(b Bounds) bool {
+	if !b.Valid {
+		return true
+	}
+	return f.IntersectsSphere(b.Center, b.Radius) && f.IntersectsBox(b.Min, b.Max)
+}
@@ -0,0 +1,67 @@
+package geometry
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// testFrustum looks down -Z from the origin, seeing from 1 to 100 away
+func testFrustum() Frustum {
+	projection := mgl32.Perspective(mgl32.DegToRad(90), 1, 1, 100)
+	view := mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, 1, 0})
+	return NewFrustum(projection.Mul4(view))
+}
+
+func TestFrustumContainsPoint(t *testing.T) {
+	f := testFrustum()
+
+	tests := []struct {
+		p      mgl32.Vec3
+		inside bool
+	}{
+		{mgl32.Vec3{0, 0, -10}, true},
+		{mgl32.Vec3{9, 9, -10}, true},
+		{mgl32.Vec3{0, 0, 10}, false},
+		{mgl32.Vec3{0, 0, -0.5}, false},
+		{mgl32.Vec3{0, 0, -150}, false},
+		{mgl32.Vec3{11, 0, -10}, false},
+		{mgl32.Vec3{0, -11, -10}, false},
+	}
+
+	for _, test := range tests {
+		if f.ContainsPoint(test.p) != test.inside {
+			t.Errorf("ContainsPoint(%v) = %v, want %v", test.p, !test.inside, test.inside)
+		}
+	}
+}
+
+func TestFrustumIntersects(t *testing.T) {
+	f := testFrustum()
+
+	// Partly inside, centered outside of the left plane
+	if !f.IntersectsSphere(mgl32.Vec3{-11, 0, -10}, 2) {
+		t.Error("a sphere crossing the left plane isn't in the frustum")
+	}
+	if f.IntersectsSphere(mgl32.Vec3{0, 0, 10}, 2) {
+		t.Error("a sphere behind the camera is in the frustum")
+	}
+
+	if !f.IntersectsBox(mgl32.Vec3{-12, -1, -11}, mgl32.Vec3{-9, 1, -9}) {
+		t.Error("a box crossing the left plane isn't in the frustum")
+	}
+	if f.IntersectsBox(mgl32.Vec3{-1, -1, 5}, mgl32.Vec3{1, 1, 8}) {
+		t.Error("a box behind the camera is in the frustum")
+	}
+
+	inside := NewBoundsFromBox(mgl32.Vec3{-1, -1, -11}, mgl32.Vec3{1, 1, -9})
+	behind := NewBoundsFromBox(mgl32.Vec3{-1, -1, 9}, mgl32.Vec3{1, 1, 11})
+	if !f.IntersectsBounds(inside) || f.IntersectsBounds(behind) {
+		t.Error("bounds in front of the camera aren't in the frustum, or those behind it are")
+	}
+
+	// Bounds which aren't known are never culled
+	if !f.IntersectsBounds(Bounds{}) {
+		t.Error("invalid bounds aren't in the frustum")
+	}
+}
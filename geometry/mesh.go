@@ -7,6 +7,7 @@ package geometry
 //  --------------------------------------------------
 
 import (
+	"rapidengine/device"
 	"rapidengine/material"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
@@ -51,35 +52,37 @@ type Mesh struct {
 }
 
 func (p *Mesh) Render(mat material.Material, viewMtx, modelMtx, projMtx *float32, delta, totalTime float64, darkness float32) {
-	gl.BindVertexArray(p.VAO.id)
+	dev := device.Get()
+
+	dev.BindVertexArray(p.VAO.id)
 	mat.GetShader().Bind()
 
-	gl.EnableVertexAttribArray(0)
+	dev.EnableVertexAttribArray(0)
 
 	if p.TexCoordsEnabled {
-		gl.EnableVertexAttribArray(1)
+		dev.EnableVertexAttribArray(1)
 	}
 	if p.NormalsEnabled {
-		gl.EnableVertexAttribArray(2)
+		dev.EnableVertexAttribArray(2)
 	}
 	if p.TangentsEnabled {
-		gl.EnableVertexAttribArray(3)
+		dev.EnableVertexAttribArray(3)
 	}
 	if p.BitangentsEnabled {
-		gl.EnableVertexAttribArray(4)
+		dev.EnableVertexAttribArray(4)
 	}
 
-	gl.UniformMatrix4fv(
+	dev.UniformMatrix4fv(
 		mat.GetShader().GetUniform("viewMtx"),
 		1, false, viewMtx,
 	)
 
-	gl.UniformMatrix4fv(
+	dev.UniformMatrix4fv(
 		mat.GetShader().GetUniform("modelMtx"),
 		1, false, modelMtx,
 	)
 
-	gl.UniformMatrix4fv(
+	dev.UniformMatrix4fv(
 		mat.GetShader().GetUniform("projectionMtx"),
 		1, false, projMtx,
 	)
@@ -89,18 +92,30 @@ func (p *Mesh) Render(mat material.Material, viewMtx, modelMtx, projMtx *float32
 	p.Draw()
 }
 
+// Bounds returns the bounds of the mesh's vertices. Instanced
+// and tesselated meshes are moved by their shaders, so their
+// bounds aren't Valid.
+func (p *Mesh) Bounds() Bounds {
+	if p.VAO == nil || p.InstancingEnabled || p.TesselationEnabled {
+		return Bounds{}
+	}
+	return p.VAO.GetBounds()
+}
+
 func (p *Mesh) Draw() {
+	dev := device.Get()
+
 	if p.InstancingEnabled {
-		gl.DrawElementsInstanced(gl.TRIANGLES, p.NumVertices, gl.UNSIGNED_INT, gl.PtrOffset(0), int32(p.NumInstances))
+		dev.DrawElementsInstanced(gl.TRIANGLES, p.NumVertices, gl.UNSIGNED_INT, gl.PtrOffset(0), int32(p.NumInstances))
 		return
 	}
 
 	if p.TesselationEnabled {
-		gl.DrawElements(gl.PATCHES, p.NumVertices, gl.UNSIGNED_INT, gl.PtrOffset(0))
+		dev.DrawElements(gl.PATCHES, p.NumVertices, gl.UNSIGNED_INT, gl.PtrOffset(0))
 		return
 	}
 
-	gl.DrawElements(gl.TRIANGLES, p.NumVertices, gl.UNSIGNED_INT, gl.PtrOffset(0))
+	dev.DrawElements(gl.TRIANGLES, p.NumVertices, gl.UNSIGNED_INT, gl.PtrOffset(0))
 }
 
 // NormalizeSizes takes in a size in pixels and normalizes to [0, 1]
@@ -11,6 +11,10 @@ import (
 type Model struct {
 	Meshes    []Mesh
 	Materials map[int]material.Material
+
+	// File the model was imported from, which
+	// is empty for models built from meshes
+	Path string
 }
 
 func (m *Model) Render(viewMtx *float32, modelMtx *float32, projMtx *float32, totalTime float64) {
@@ -19,6 +23,20 @@ func (m *Model) Render(viewMtx *float32, modelMtx *float32, projMtx *float32, to
 	}
 }
 
+// Bounds returns the bounds of every mesh in the model,
+// which aren't Valid if any of the meshes' aren't
+func (m *Model) Bounds() Bounds {
+	b := Bounds{}
+	for i := range m.Meshes {
+		mb := m.Meshes[i].Bounds()
+		if !mb.Valid {
+			return Bounds{}
+		}
+		b = b.Union(mb)
+	}
+	return b
+}
+
 func (m *Model) ComputeTangents() {
 	for _, ms := range m.Meshes {
 		ms.ComputeTangents()
@@ -2,17 +2,42 @@ package geometry
 
 import (
 	"bufio"
-	"os"
+	"fmt"
+	"rapidengine/assets"
 	"strconv"
 	"strings"
 
 	"github.com/go-gl/mathgl/mgl32"
 )
 
-func LoadObj(path string, scale float32) Mesh {
-	f, err := os.Open(path)
+// LoadObj loads a triangulated wavefront obj file into a mesh. Malformed
+// lines and out of range face indices return an *assets.DecodeError.
+func LoadObj(path string, scale float32) (Mesh, error) {
+	data, err := DecodeObj(path, scale)
 	if err != nil {
-		panic(err)
+		return Mesh{}, err
+	}
+	return data.Mesh(), nil
+}
+
+// ObjData is an obj file decoded by DecodeObj, which
+// hasn't yet been uploaded to the graphics device
+type ObjData struct {
+	path string
+
+	vertices  []float32
+	normals   []float32
+	texCoords []float32
+	indices   []uint32
+}
+
+// DecodeObj reads and parses an obj file without touching the
+// graphics device, so it can run on any goroutine. The mesh is
+// built from it by Mesh, on the main thread.
+func DecodeObj(path string, scale float32) (ObjData, error) {
+	f, err := assets.Open(path)
+	if err != nil {
+		return ObjData{}, err
 	}
 	defer f.Close()
 
@@ -30,25 +55,30 @@ func LoadObj(path string, scale float32) Mesh {
 	for scanner.Scan() {
 		line = strings.Split(scanner.Text(), " ")
 		if line[0] == "v" {
-			x, _ := strconv.ParseFloat(line[1], 32)
-			y, _ := strconv.ParseFloat(line[2], 32)
-			z, _ := strconv.ParseFloat(line[3], 32)
+			x, y, z, err := parseObjFloats3(line)
+			if err != nil {
+				return ObjData{}, objError(path, err)
+			}
 
-			vertex := mgl32.Vec3{float32(x), float32(y), float32(z)}
+			vertex := mgl32.Vec3{x, y, z}
 			vertex = scaleMatrix.Mul4x1(vertex.Vec4(1.0)).Vec3()
 
 			vertices = append(vertices, vertex)
 		}
 
 		if line[0] == "vn" {
-			x, _ := strconv.ParseFloat(line[1], 32)
-			y, _ := strconv.ParseFloat(line[2], 32)
-			z, _ := strconv.ParseFloat(line[3], 32)
+			x, y, z, err := parseObjFloats3(line)
+			if err != nil {
+				return ObjData{}, objError(path, err)
+			}
 
-			normals = append(normals, mgl32.Vec3{float32(x), float32(y), float32(z)})
+			normals = append(normals, mgl32.Vec3{x, y, z})
 		}
 
 		if line[0] == "vt" {
+			if len(line) < 3 {
+				return ObjData{}, objError(path, fmt.Errorf("texture coordinate needs 2 fields: %q", scanner.Text()))
+			}
 			x, _ := strconv.ParseFloat(line[1], 32)
 			y, _ := strconv.ParseFloat(line[2], 32)
 
@@ -73,77 +103,29 @@ func LoadObj(path string, scale float32) Mesh {
 	// Load faces into arrays
 	for {
 		if line[0] == "f" {
-			v1 := strings.Split(line[1], "/")
-			v2 := strings.Split(line[2], "/")
-			v3 := strings.Split(line[3], "/")
-
-			// Vertex 1
-			vertexIndex, _ := strconv.ParseInt(v1[0], 10, 32)
-			textureIndex, _ := strconv.ParseInt(v1[1], 10, 32)
-			normalIndex, _ := strconv.ParseInt(v1[2], 10, 32)
-
-			vertexIndex--
-			textureIndex--
-			normalIndex--
-
-			indicesArray = append(indicesArray, uint32(vertexIndex))
-
-			currentTexture := textures[textureIndex]
-			currentNormal := normals[normalIndex]
-
-			texturesArray[vertexIndex*3] = currentTexture.X()
-			texturesArray[vertexIndex*3+1] = 1 - currentTexture.Y()
-			texturesArray[vertexIndex*3+2] = 0
-
-			normalsArray[vertexIndex*3] = currentNormal.X()
-			normalsArray[vertexIndex*3+1] = currentNormal.Y()
-			normalsArray[vertexIndex*3+2] = currentNormal.Z()
-
-			// Vertex 2
-			vertexIndex, _ = strconv.ParseInt(v2[0], 10, 32)
-			textureIndex, _ = strconv.ParseInt(v2[1], 10, 32)
-			normalIndex, _ = strconv.ParseInt(v2[2], 10, 32)
-
-			vertexIndex--
-			textureIndex--
-			normalIndex--
-
-			indicesArray = append(indicesArray, uint32(vertexIndex))
-
-			currentTexture = textures[textureIndex]
-			currentNormal = normals[normalIndex]
-
-			texturesArray[vertexIndex*3] = currentTexture.X()
-			texturesArray[vertexIndex*3+1] = 1 - currentTexture.Y()
-			texturesArray[vertexIndex*3+2] = 0
-
-			normalsArray[vertexIndex*3] = currentNormal.X()
-			normalsArray[vertexIndex*3+1] = currentNormal.Y()
-			normalsArray[vertexIndex*3+2] = currentNormal.Z()
-
-			// Vertex 3
-			vertexIndex, _ = strconv.ParseInt(v3[0], 10, 32)
-			textureIndex, _ = strconv.ParseInt(v3[1], 10, 32)
-			normalIndex, _ = strconv.ParseInt(v3[2], 10, 32)
+			if len(line) < 4 {
+				return ObjData{}, objError(path, fmt.Errorf("face needs 3 vertices: %q", scanner.Text()))
+			}
 
-			vertexIndex--
-			textureIndex--
-			normalIndex--
+			for _, field := range line[1:4] {
+				vertexIndex, textureIndex, normalIndex, err := parseObjFaceVertex(field, len(vertices), len(textures), len(normals))
+				if err != nil {
+					return ObjData{}, objError(path, err)
+				}
 
-			indicesArray = append(indicesArray, uint32(vertexIndex))
+				indicesArray = append(indicesArray, uint32(vertexIndex))
 
-			//println(textureIndex)
+				currentTexture := textures[textureIndex]
+				currentNormal := normals[normalIndex]
 
-			currentTexture = textures[textureIndex]
-			currentNormal = normals[normalIndex]
+				texturesArray[vertexIndex*3] = currentTexture.X()
+				texturesArray[vertexIndex*3+1] = 1 - currentTexture.Y()
+				texturesArray[vertexIndex*3+2] = 0
 
-			texturesArray[vertexIndex*3] = currentTexture.X()
-			texturesArray[vertexIndex*3+1] = 1 - currentTexture.Y()
-			texturesArray[vertexIndex*3+2] = 0
-
-			normalsArray[vertexIndex*3] = currentNormal.X()
-			normalsArray[vertexIndex*3+1] = currentNormal.Y()
-			normalsArray[vertexIndex*3+2] = currentNormal.Z()
+				normalsArray[vertexIndex*3] = currentNormal.X()
+				normalsArray[vertexIndex*3+1] = currentNormal.Y()
+				normalsArray[vertexIndex*3+2] = currentNormal.Z()
+			}
 		}
 
 		if line[0] == "usemtl" {
@@ -157,18 +139,34 @@ func LoadObj(path string, scale float32) Mesh {
 		line = strings.Split(scanner.Text(), " ")
 	}
 
+	if err := scanner.Err(); err != nil {
+		return ObjData{}, objError(path, err)
+	}
+
 	for i, v := range vertices {
 		verticesArray[i*3] = v.X()
 		verticesArray[i*3+1] = v.Y()
 		verticesArray[i*3+2] = v.Z()
 	}
 
+	return ObjData{
+		path:      path,
+		vertices:  verticesArray,
+		normals:   normalsArray,
+		texCoords: texturesArray,
+		indices:   indicesArray,
+	}, nil
+}
+
+// Mesh uploads the decoded obj file to the graphics device. Each
+// call builds a new vertex array, so it must be on the main thread.
+func (data ObjData) Mesh() Mesh {
 	m := Mesh{
-		ID:          path,
-		VAO:         NewVertexArray(verticesArray, indicesArray),
-		Normals:     normalsArray,
-		TexCoords:   texturesArray,
-		NumVertices: int32(len(indicesArray)),
+		ID:          data.path,
+		VAO:         NewVertexArray(data.vertices, data.indices),
+		Normals:     data.normals,
+		TexCoords:   data.texCoords,
+		NumVertices: int32(len(data.indices)),
 	}
 
 	m.VAO.AddVertexAttribute(m.TexCoords, 1, 3)
@@ -178,3 +176,49 @@ func LoadObj(path string, scale float32) Mesh {
 
 	return m
 }
+
+func objError(path string, err error) error {
+	return &assets.DecodeError{Path: path, Format: "obj", Err: err}
+}
+
+func parseObjFloats3(line []string) (float32, float32, float32, error) {
+	if len(line) < 4 {
+		return 0, 0, 0, fmt.Errorf("%s needs 3 fields, got %d", line[0], len(line)-1)
+	}
+
+	var v [3]float32
+	for i := range v {
+		f, err := strconv.ParseFloat(line[i+1], 32)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		v[i] = float32(f)
+	}
+
+	return v[0], v[1], v[2], nil
+}
+
+// parseObjFaceVertex parses a v/vt/vn face field into zero based
+// indices, checking each one against the number of elements loaded.
+func parseObjFaceVertex(field string, numVertices, numTextures, numNormals int) (int64, int64, int64, error) {
+	parts := strings.Split(field, "/")
+	if len(parts) != 3 {
+		return 0, 0, 0, fmt.Errorf("face vertex %q is not in v/vt/vn form", field)
+	}
+
+	limits := [3]int{numVertices, numTextures, numNormals}
+	var indices [3]int64
+
+	for i, part := range parts {
+		index, err := strconv.ParseInt(part, 10, 32)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("face vertex %q: %v", field, err)
+		}
+		if index < 1 || int(index) > limits[i] {
+			return 0, 0, 0, fmt.Errorf("face vertex %q: index %d out of range", field, index)
+		}
+		indices[i] = index - 1
+	}
+
+	return indices[0], indices[1], indices[2], nil
+}
@@ -0,0 +1,75 @@
+package geometry
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"rapidengine/assets"
+)
+
+// writeObj writes an obj file to a temporary directory
+func writeObj(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "test.obj")
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testObj = `# a single triangle
+v 0 0 0
+v 1 0 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 0 1
+vn 0 0 1
+f 1/1/1 2/2/1 3/3/1
+`
+
+func TestDecodeObj(t *testing.T) {
+	data, err := DecodeObj(writeObj(t, testObj), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []float32{0, 0, 0, 2, 0, 0, 0, 2, 0}; !reflect.DeepEqual(data.vertices, want) {
+		t.Fatalf("vertices %v, want %v scaled by 2", data.vertices, want)
+	}
+	if want := []uint32{0, 1, 2}; !reflect.DeepEqual(data.indices, want) {
+		t.Fatalf("indices %v, want %v", data.indices, want)
+	}
+
+	// Texture coordinates are flipped vertically
+	if want := []float32{0, 1, 0, 1, 1, 0, 0, 0, 0}; !reflect.DeepEqual(data.texCoords, want) {
+		t.Fatalf("texture coordinates %v, want %v", data.texCoords, want)
+	}
+	if want := []float32{0, 0, 1, 0, 0, 1, 0, 0, 1}; !reflect.DeepEqual(data.normals, want) {
+		t.Fatalf("normals %v, want %v", data.normals, want)
+	}
+}
+
+func TestDecodeObjErrors(t *testing.T) {
+	tests := map[string]string{
+		"short vertex":           "v 0 0\n",
+		"bad vertex":             "v 0 x 0\n",
+		"short face":             "v 0 0 0\nvt 0 0\nvn 0 0 1\nf 1/1/1 1/1/1\n",
+		"face without normals":   "v 0 0 0\nvt 0 0\nvn 0 0 1\nf 1/1 1/1 1/1\n",
+		"face index of 0":        "v 0 0 0\nvt 0 0\nvn 0 0 1\nf 0/1/1 1/1/1 1/1/1\n",
+		"face index past vertex": "v 0 0 0\nvt 0 0\nvn 0 0 1\nf 1/1/1 2/1/1 1/1/1\n",
+	}
+
+	for name, contents := range tests {
+		var decodeErr *assets.DecodeError
+		if _, err := DecodeObj(writeObj(t, contents), 1); !errors.As(err, &decodeErr) {
+			t.Errorf("%s: decoding returned %v, want a DecodeError", name, err)
+		}
+	}
+
+	if _, err := DecodeObj(filepath.Join(t.TempDir(), "missing.obj"), 1); !errors.Is(err, assets.ErrAssetNotFound) {
+		t.Fatalf("decoding a missing file returned %v, want ErrAssetNotFound", err)
+	}
+}
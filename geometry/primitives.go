@@ -1,12 +1,11 @@
 package geometry
 
 import (
+	"github.com/go-gl/mathgl/mgl32"
 	"math"
 	"rapidengine/configuration"
+	"rapidengine/device"
 	"rapidengine/material"
-
-	"github.com/go-gl/gl/v4.1-core/gl"
-	"github.com/go-gl/mathgl/mgl32"
 )
 
 // NewTriangle creates a new triangle Mesh based on 3 points
@@ -157,6 +156,8 @@ func NewCube() Mesh {
 }
 
 func NewPlane(wIDth, height, density int, heightData [][]float32, scale float32) Mesh {
+	dev := device.Get()
+
 	//segWIDth := float32(wIDth) / float32(xCount)
 	//segHeight := float32(height) / float32(yCount)
 	xCount := density
@@ -238,7 +239,7 @@ func NewPlane(wIDth, height, density int, heightData [][]float32, scale float32)
 	m.VAO.AddVertexAttribute(m.TexCoords, 1, 3)
 	m.VAO.AddVertexAttribute(m.Normals, 2, 3)
 
-	gl.BindVertexArray(0)
+	dev.BindVertexArray(0)
 
 	return m
 }
@@ -385,10 +386,10 @@ func NewBillBoard() Mesh {
 	}
 }
 
-func GetHeightMapData(path string, max float32) [][]float32 {
+func GetHeightMapData(path string, max float32) ([][]float32, error) {
 	img, err := material.LoadImageFullDepth(path)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	img.At(0, 0).RGBA()
@@ -413,7 +414,7 @@ func GetHeightMapData(path string, max float32) [][]float32 {
 		}
 	}
 
-	return data
+	return data, nil
 }
 
 func calculateNormal(x, z int, heights [][]float32) mgl32.Vec4 {
@@ -1,5 +1,19 @@
 package geometry
 
+//  --------------------------------------------------
+//  Transform.go contains Transform, a position, rotation
+//  and scale. Rotations are kept as quaternions, so they
+//  can be combined and interpolated without gimbal lock.
+//  --------------------------------------------------
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Transform places an object by scaling it, then rotating it,
+// and then translating it. A zero Rotation is no rotation.
 type Transform struct {
 	X float32
 	Y float32
@@ -8,8 +22,247 @@ type Transform struct {
 	SX float32
 	SY float32
 	SZ float32
+
+	Rotation mgl32.Quat
 }
 
 func NewTransform(x, y, z, sx, sy, sz float32) Transform {
-	return Transform{x, y, z, sx, sy, sz}
+	return Transform{x, y, z, sx, sy, sz, mgl32.QuatIdent()}
+}
+
+//  --------------------------------------------------
+//  Position & Scale
+//  --------------------------------------------------
+
+func (t *Transform) Position() mgl32.Vec3 {
+	return mgl32.Vec3{t.X, t.Y, t.Z}
+}
+
+func (t *Transform) SetPosition(x, y, z float32) {
+	t.X, t.Y, t.Z = x, y, z
+}
+
+// Translate moves the transform by dx, dy, dz
+func (t *Transform) Translate(dx, dy, dz float32) {
+	t.X += dx
+	t.Y += dy
+	t.Z += dz
+}
+
+func (t *Transform) Scale() mgl32.Vec3 {
+	return mgl32.Vec3{t.SX, t.SY, t.SZ}
+}
+
+func (t *Transform) SetScale(sx, sy, sz float32) {
+	t.SX, t.SY, t.SZ = sx, sy, sz
+}
+
+//  --------------------------------------------------
+//  Rotation
+//  --------------------------------------------------
+
+// Orientation returns the rotation as a unit quaternion
+func (t *Transform) Orientation() mgl32.Quat {
+	if t.Rotation == (mgl32.Quat{}) {
+		return mgl32.QuatIdent()
+	}
+	return t.Rotation.Normalize()
+}
+
+// SetOrientation sets the rotation, normalizing it
+func (t *Transform) SetOrientation(q mgl32.Quat) {
+	if q == (mgl32.Quat{}) {
+		q = mgl32.QuatIdent()
+	}
+	t.Rotation = q.Normalize()
+}
+
+// Rotate turns the transform by angle radians about
+// an axis in its parent's space, through its position
+func (t *Transform) Rotate(angle float32, axis mgl32.Vec3) {
+	t.SetOrientation(mgl32.QuatRotate(angle, axis.Normalize()).Mul(t.Orientation()))
+}
+
+// RotateLocal turns the transform by angle radians
+// about an axis in its own space, such as its Up()
+func (t *Transform) RotateLocal(angle float32, axis mgl32.Vec3) {
+	t.SetOrientation(t.Orientation().Mul(mgl32.QuatRotate(angle, axis.Normalize())))
+}
+
+// RotateAround turns the transform by angle radians about an
+// axis through point, moving it around the point as it turns
+func (t *Transform) RotateAround(point mgl32.Vec3, axis mgl32.Vec3, angle float32) {
+	q := mgl32.QuatRotate(angle, axis.Normalize())
+
+	p := point.Add(q.Rotate(t.Position().Sub(point)))
+	t.SetPosition(p[0], p[1], p[2])
+	t.SetOrientation(q.Mul(t.Orientation()))
+}
+
+// LookAt turns the transform so that Forward() points at target,
+// keeping Up() as close to up as it can. Nothing changes if the
+// target is at the transform's position.
+func (t *Transform) LookAt(target mgl32.Vec3, up mgl32.Vec3) {
+	forward := target.Sub(t.Position())
+	if forward.Len() == 0 {
+		return
+	}
+	forward = forward.Normalize()
+
+	// Looking straight along up, so any other axis will do
+	right := forward.Cross(up)
+	if right.Len() < 1e-6 {
+		right = forward.Cross(mgl32.Vec3{0, 0, 1})
+		if right.Len() < 1e-6 {
+			right = forward.Cross(mgl32.Vec3{1, 0, 0})
+		}
+	}
+	right = right.Normalize()
+	up = right.Cross(forward)
+
+	t.SetOrientation(mgl32.Mat4ToQuat(mgl32.Mat3FromCols(right, up, forward.Mul(-1)).Mat4()))
+}
+
+// Euler returns the rotation as angles about X, Y and
+// Z, which rotate a point about Z first and X last
+func (t *Transform) Euler() mgl32.Vec3 {
+	return QuatToEuler(t.Orientation())
+}
+
+// SetEuler sets the rotation from angles about X, Y and Z, see Euler
+func (t *Transform) SetEuler(x, y, z float32) {
+	t.SetOrientation(EulerToQuat(x, y, z))
+}
+
+// Forward returns the direction the transform faces, which is -Z
+// turned by its rotation, as cameras and LookAt use
+func (t *Transform) Forward() mgl32.Vec3 {
+	return t.Orientation().Rotate(mgl32.Vec3{0, 0, -1})
+}
+
+// Right returns +X turned by the transform's rotation
+func (t *Transform) Right() mgl32.Vec3 {
+	return t.Orientation().Rotate(mgl32.Vec3{1, 0, 0})
+}
+
+// Up returns +Y turned by the transform's rotation
+func (t *Transform) Up() mgl32.Vec3 {
+	return t.Orientation().Rotate(mgl32.Vec3{0, 1, 0})
+}
+
+//  --------------------------------------------------
+//  Matrices
+//  --------------------------------------------------
+
+// Matrix returns the transform as translation * rotation * scale
+func (t *Transform) Matrix() mgl32.Mat4 {
+	return mgl32.Translate3D(t.X, t.Y, t.Z).
+		Mul4(t.Orientation().Mat4()).
+		Mul4(mgl32.Scale3D(t.SX, t.SY, t.SZ))
+}
+
+// SetMatrix sets the transform from a matrix, see Decompose
+func (t *Transform) SetMatrix(m mgl32.Mat4) {
+	position, scale, rotation := Decompose(m)
+
+	t.SetPosition(position[0], position[1], position[2])
+	t.SetScale(scale[0], scale[1], scale[2])
+	t.Rotation = rotation
+}
+
+// Decompose splits a matrix made by translating, rotating and then
+// scaling into its parts. Matrices which mirror have a negative X
+// scale, and shear, from scaling a rotated parent unevenly, is lost.
+func Decompose(m mgl32.Mat4) (position, scale mgl32.Vec3, rotation mgl32.Quat) {
+	position = m.Col(3).Vec3()
+
+	basis := m.Mat3()
+	axes := [3]mgl32.Vec3{}
+	for i := 0; i < 3; i++ {
+		axes[i] = basis.Col(i)
+		scale[i] = axes[i].Len()
+	}
+	if basis.Det() < 0 {
+		scale[0] = -scale[0]
+	}
+
+	for i := 0; i < 3; i++ {
+		if scale[i] != 0 {
+			axes[i] = axes[i].Mul(1 / scale[i])
+		}
+	}
+
+	// Axes scaled to nothing are rebuilt from the others
+	for i := 0; i < 3; i++ {
+		if axes[i] == (mgl32.Vec3{}) {
+			a, b := axes[(i+1)%3], axes[(i+2)%3]
+			if a.Cross(b).Len() == 0 {
+				axes = [3]mgl32.Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+				break
+			}
+			axes[i] = a.Cross(b).Normalize()
+		}
+	}
+
+	rotation = mgl32.Mat4ToQuat(mgl32.Mat3FromCols(axes[0], axes[1], axes[2]).Mat4()).Normalize()
+	return position, scale, rotation
+}
+
+//  --------------------------------------------------
+//  Conversions & Interpolation
+//  --------------------------------------------------
+
+// EulerToQuat converts angles about X, Y and Z into a
+// rotation which turns about Z first and X last
+func EulerToQuat(x, y, z float32) mgl32.Quat {
+	return mgl32.QuatRotate(x, mgl32.Vec3{1, 0, 0}).
+		Mul(mgl32.QuatRotate(y, mgl32.Vec3{0, 1, 0})).
+		Mul(mgl32.QuatRotate(z, mgl32.Vec3{0, 0, 1}))
+}
+
+// QuatToEuler converts a rotation into angles about X, Y and Z,
+// see EulerToQuat. When Y is a quarter turn, only X + Z can be
+// recovered, so Z is returned as 0.
+func QuatToEuler(q mgl32.Quat) mgl32.Vec3 {
+	r := q.Normalize().Mat4()
+
+	// r is Rx * Ry * Rz
+	var angles mgl32.Vec3
+	sinY := float64(mgl32.Clamp(r.At(0, 2), -1, 1))
+	angles[1] = float32(math.Asin(sinY))
+	if math.Abs(sinY) < 0.9999 {
+		angles[0] = float32(math.Atan2(float64(-r.At(1, 2)), float64(r.At(2, 2))))
+		angles[2] = float32(math.Atan2(float64(-r.At(0, 1)), float64(r.At(0, 0))))
+	} else {
+		angles[0] = float32(math.Atan2(float64(r.At(2, 1)), float64(r.At(1, 1))))
+	}
+
+	return angles
+}
+
+// SlerpRotation interpolates between two rotations
+// at a constant rate, along the shortest arc
+func SlerpRotation(a, b mgl32.Quat, amount float32) mgl32.Quat {
+	if a.Dot(b) < 0 {
+		b = b.Scale(-1)
+	}
+	return mgl32.QuatSlerp(a, b, amount).Normalize()
+}
+
+// Slerp interpolates between two transforms, moving and
+// scaling in a straight line and rotating with SlerpRotation
+func Slerp(a, b Transform, amount float32) Transform {
+	lerp := func(from, to float32) float32 {
+		return from + (to-from)*amount
+	}
+
+	return Transform{
+		X:        lerp(a.X, b.X),
+		Y:        lerp(a.Y, b.Y),
+		Z:        lerp(a.Z, b.Z),
+		SX:       lerp(a.SX, b.SX),
+		SY:       lerp(a.SY, b.SY),
+		SZ:       lerp(a.SZ, b.SZ),
+		Rotation: SlerpRotation(a.Orientation(), b.Orientation(), amount),
+	}
 }
@@ -2,6 +2,8 @@ package geometry
 
 import (
 	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"rapidengine/device"
 )
 
 type VertexArray struct {
@@ -11,26 +13,33 @@ type VertexArray struct {
 
 	vertices []float32
 	indices  []uint32
+
+	bounds Bounds
 }
 
 func NewVertexArray(vertices []float32, elements []uint32) *VertexArray {
+	dev := device.Get()
+
 	var id uint32
 	vertexArray := VertexArray{
 		id:       id,
 		vertices: vertices,
 		indices:  elements,
+		bounds:   NewBounds(vertices),
 	}
-	gl.GenVertexArrays(1, &vertexArray.id)
-	gl.BindVertexArray(vertexArray.id)
+	dev.GenVertexArrays(1, &vertexArray.id)
+	dev.BindVertexArray(vertexArray.id)
 	vertexArray.vertexBuffer = vertexArray.AddVertexAttribute(vertices, 0, 3)
 	vertexArray.elementBuffer = vertexArray.AddElementAttribute(elements)
 	return &vertexArray
 }
 
 func (vertexArray *VertexArray) AddVertexAttribute(data []float32, index, size int32) uint32 {
-	gl.BindVertexArray(vertexArray.id)
+	dev := device.Get()
+
+	dev.BindVertexArray(vertexArray.id)
 	vbo := NewVertexBuffer(data)
-	gl.VertexAttribPointer(
+	dev.VertexAttribPointer(
 		uint32(index),
 		size,
 		gl.FLOAT,
@@ -42,37 +51,47 @@ func (vertexArray *VertexArray) AddVertexAttribute(data []float32, index, size i
 }
 
 func (vertexArray *VertexArray) AddElementAttribute(data []uint32) uint32 {
-	gl.BindVertexArray(vertexArray.id)
+	dev := device.Get()
+
+	dev.BindVertexArray(vertexArray.id)
 	veo := NewElementBuffer(data)
 	return veo
 }
 
 func NewVertexBuffer(points []float32) uint32 {
+	dev := device.Get()
+
 	var vertexBufferID uint32
-	gl.GenBuffers(1, &vertexBufferID)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vertexBufferID)
-	gl.BufferData(gl.ARRAY_BUFFER, 4*len(points), gl.Ptr(points), gl.STATIC_DRAW)
+	dev.GenBuffers(1, &vertexBufferID)
+	dev.BindBuffer(gl.ARRAY_BUFFER, vertexBufferID)
+	dev.BufferData(gl.ARRAY_BUFFER, 4*len(points), gl.Ptr(points), gl.STATIC_DRAW)
 	return vertexBufferID
 }
 
 func NewElementBuffer(indices []uint32) uint32 {
+	dev := device.Get()
+
 	var elementBufferID uint32
-	gl.GenBuffers(1, &elementBufferID)
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, elementBufferID)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, 4*len(indices), gl.Ptr(indices), gl.STATIC_DRAW)
+	dev.GenBuffers(1, &elementBufferID)
+	dev.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, elementBufferID)
+	dev.BufferData(gl.ELEMENT_ARRAY_BUFFER, 4*len(indices), gl.Ptr(indices), gl.STATIC_DRAW)
 	return elementBufferID
 }
 
 func (vertexArray *VertexArray) RebindVertexArray() {
-	gl.BindVertexArray(vertexArray.id)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vertexArray.vertexBuffer)
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, vertexArray.elementBuffer)
+	dev := device.Get()
+
+	dev.BindVertexArray(vertexArray.id)
+	dev.BindBuffer(gl.ARRAY_BUFFER, vertexArray.vertexBuffer)
+	dev.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, vertexArray.elementBuffer)
 }
 
 func UnbindBuffers() {
-	gl.BindVertexArray(0)
-	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+	dev := device.Get()
+
+	dev.BindVertexArray(0)
+	dev.BindBuffer(gl.ARRAY_BUFFER, 0)
+	dev.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
 }
 
 func (vertexArray *VertexArray) GetID() uint32 {
@@ -82,3 +101,8 @@ func (vertexArray *VertexArray) GetID() uint32 {
 func (vertexArray *VertexArray) GetIndices() []uint32 {
 	return vertexArray.indices
 }
+
+// GetBounds returns the bounds of the vertices
+func (vertexArray *VertexArray) GetBounds() Bounds {
+	return vertexArray.bounds
+}
@@ -1,7 +1,7 @@
 package input
 
 import (
-	"github.com/go-gl/glfw/v3.2/glfw"
+	"rapidengine/device"
 )
 
 var MouseX float64
@@ -34,18 +34,18 @@ type Input struct {
 	Scroll  float64
 }
 
-func MouseCallback(w *glfw.Window, xpos float64, ypos float64) {
+func MouseCallback(xpos float64, ypos float64) {
 	MouseX = xpos
 	MouseY = ypos
 }
 
-func MouseButtonCallback(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
-	LeftMouseButton = (button == 0 && action == glfw.Press)
-	RightMouseButton = (button == 1 && action == glfw.Press)
-	MiddleMouseButton = (button == 2 && action == glfw.Press)
+func MouseButtonCallback(button int, pressed bool) {
+	LeftMouseButton = (button == 0 && pressed)
+	RightMouseButton = (button == 1 && pressed)
+	MiddleMouseButton = (button == 2 && pressed)
 }
 
-func ScrollCallback(w *glfw.Window, xoff float64, yoff float64) {
+func ScrollCallback(xoff float64, yoff float64) {
 	ScrollXOff = xoff
 	ScrollYOff = yoff
 	Scroll += yoff
@@ -56,43 +56,43 @@ func SwapMousePositions() {
 	LastMouseY = MouseY
 }
 
-var KeyMap map[string]glfw.Key = map[string]glfw.Key{
-	"space":  glfw.KeySpace,
-	"shift":  glfw.KeyLeftShift,
-	"escape": glfw.KeyEscape,
-
-	"up":    glfw.KeyUp,
-	"down":  glfw.KeyDown,
-	"left":  glfw.KeyLeft,
-	"right": glfw.KeyRight,
-
-	"a": glfw.KeyA,
-	"b": glfw.KeyB,
-	"c": glfw.KeyC,
-	"d": glfw.KeyD,
-	"e": glfw.KeyE,
-	"f": glfw.KeyF,
-	"g": glfw.KeyG,
-	"h": glfw.KeyH,
-	"i": glfw.KeyI,
-	"j": glfw.KeyJ,
-	"k": glfw.KeyK,
-	"l": glfw.KeyL,
-	"m": glfw.KeyM,
-	"n": glfw.KeyN,
-	"o": glfw.KeyO,
-	"p": glfw.KeyP,
-	"q": glfw.KeyQ,
-	"r": glfw.KeyR,
-	"s": glfw.KeyS,
-	"t": glfw.KeyT,
-	"u": glfw.KeyU,
-	"v": glfw.KeyV,
-	"w": glfw.KeyW,
-	"x": glfw.KeyX,
-	"y": glfw.KeyY,
-	"z": glfw.KeyZ,
-
-	"ctrl_left":  glfw.KeyLeftControl,
-	"ctrl_right": glfw.KeyRightControl,
+var KeyMap map[string]device.Key = map[string]device.Key{
+	"space":  device.KeySpace,
+	"shift":  device.KeyLeftShift,
+	"escape": device.KeyEscape,
+
+	"up":    device.KeyUp,
+	"down":  device.KeyDown,
+	"left":  device.KeyLeft,
+	"right": device.KeyRight,
+
+	"a": device.KeyA,
+	"b": device.KeyB,
+	"c": device.KeyC,
+	"d": device.KeyD,
+	"e": device.KeyE,
+	"f": device.KeyF,
+	"g": device.KeyG,
+	"h": device.KeyH,
+	"i": device.KeyI,
+	"j": device.KeyJ,
+	"k": device.KeyK,
+	"l": device.KeyL,
+	"m": device.KeyM,
+	"n": device.KeyN,
+	"o": device.KeyO,
+	"p": device.KeyP,
+	"q": device.KeyQ,
+	"r": device.KeyR,
+	"s": device.KeyS,
+	"t": device.KeyT,
+	"u": device.KeyU,
+	"v": device.KeyV,
+	"w": device.KeyW,
+	"x": device.KeyX,
+	"y": device.KeyY,
+	"z": device.KeyZ,
+
+	"ctrl_left":  device.KeyLeftControl,
+	"ctrl_right": device.KeyRightControl,
 }
@@ -1,9 +1,8 @@
 package lighting
 
 import (
+	"rapidengine/device"
 	"rapidengine/material"
-
-	"github.com/go-gl/gl/v4.1-core/gl"
 )
 
 type DirectionLight struct {
@@ -28,29 +27,31 @@ func (light *DirectionLight) PreRender() {
 }
 
 func (light *DirectionLight) UpdateShader(cx, cy, cz float32, shader *material.ShaderProgram) {
+	dev := device.Get()
+
 	c := []float32{cx, cy, cz}
 	shader.Bind()
-	gl.Uniform3fv(
+	dev.Uniform3fv(
 		shader.GetUniform("dirLight.direction"),
 		1, &light.Direction[0],
 	)
 
-	gl.Uniform3fv(
+	dev.Uniform3fv(
 		shader.GetUniform("dirLight.ambient"),
 		1, &light.Ambient[0],
 	)
 
-	gl.Uniform3fv(
+	dev.Uniform3fv(
 		shader.GetUniform("dirLight.diffuse"),
 		1, &light.Diffuse[0],
 	)
 
-	gl.Uniform3fv(
+	dev.Uniform3fv(
 		shader.GetUniform("dirLight.specular"),
 		1, &light.Specular[0],
 	)
 
-	gl.Uniform3fv(
+	dev.Uniform3fv(
 		shader.GetUniform("viewPos"),
 		1, &c[0],
 	)
@@ -2,9 +2,8 @@ package lighting
 
 import (
 	"fmt"
+	"rapidengine/device"
 	"rapidengine/material"
-
-	"github.com/go-gl/gl/v4.1-core/gl"
 )
 
 type PointLight struct {
@@ -34,46 +33,48 @@ func NewPointLight(a, d, s []float32, c, l, q float32) *PointLight {
 func (light *PointLight) PreRender() {}
 
 func (light *PointLight) UpdateShader(cx, cy, cz float32, ind int, shader *material.ShaderProgram) {
+	dev := device.Get()
+
 	c := []float32{cx, cy, cz}
 	shader.Bind()
 
-	gl.Uniform3fv(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("pointLights["+fmt.Sprint(ind)+"].ambient"+"\x00")),
+	dev.Uniform3fv(
+		dev.GetUniformLocation(shader.GetID(), "pointLights["+fmt.Sprint(ind)+"].ambient"),
 		1, &light.Ambient[0],
 	)
 
-	gl.Uniform3fv(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("pointLights["+fmt.Sprint(ind)+"].diffuse"+"\x00")),
+	dev.Uniform3fv(
+		dev.GetUniformLocation(shader.GetID(), "pointLights["+fmt.Sprint(ind)+"].diffuse"),
 		1, &light.Diffuse[0],
 	)
 
-	gl.Uniform3fv(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("pointLights["+fmt.Sprint(ind)+"].specular"+"\x00")),
+	dev.Uniform3fv(
+		dev.GetUniformLocation(shader.GetID(), "pointLights["+fmt.Sprint(ind)+"].specular"),
 		1, &light.specular[0],
 	)
 
-	gl.Uniform1f(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("pointLights["+fmt.Sprint(ind)+"].constant"+"\x00")),
+	dev.Uniform1f(
+		dev.GetUniformLocation(shader.GetID(), "pointLights["+fmt.Sprint(ind)+"].constant"),
 		light.constant,
 	)
 
-	gl.Uniform1f(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("pointLights["+fmt.Sprint(ind)+"].linear"+"\x00")),
+	dev.Uniform1f(
+		dev.GetUniformLocation(shader.GetID(), "pointLights["+fmt.Sprint(ind)+"].linear"),
 		light.linear,
 	)
 
-	gl.Uniform1f(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("pointLights["+fmt.Sprint(ind)+"].quadratic"+"\x00")),
+	dev.Uniform1f(
+		dev.GetUniformLocation(shader.GetID(), "pointLights["+fmt.Sprint(ind)+"].quadratic"),
 		light.quadratic,
 	)
 
-	gl.Uniform3fv(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("pointLights["+fmt.Sprint(ind)+"].position"+"\x00")),
+	dev.Uniform3fv(
+		dev.GetUniformLocation(shader.GetID(), "pointLights["+fmt.Sprint(ind)+"].position"),
 		1, &light.Position[0],
 	)
 
-	gl.Uniform3fv(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("viewPos"+"\x00")),
+	dev.Uniform3fv(
+		dev.GetUniformLocation(shader.GetID(), "viewPos"),
 		1, &c[0],
 	)
 }
@@ -82,6 +83,16 @@ func (light *PointLight) SetPosition(pos []float32) {
 	light.Position = pos
 }
 
+func (light *PointLight) GetSpecular() []float32 {
+	return light.specular
+}
+
+// GetAttenuation returns the constant, linear and quadratic
+// terms of the light's falloff with distance
+func (light *PointLight) GetAttenuation() (float32, float32, float32) {
+	return light.constant, light.linear, light.quadratic
+}
+
 func (light *PointLight) SetLevels(linear, quad float32) {
 	light.linear = linear
 	light.quadratic = quad
@@ -1,7 +1,7 @@
 package material
 
 import (
-	"rapidengine/state"
+	"rapidengine/device"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 )
@@ -36,6 +36,14 @@ type BasicMaterial struct {
 	animationPlayingOnce  bool
 	animationOnceCallback func()
 	animationHitCallback  func()
+
+	// Called with the animation's name whenever
+	// a PlayAnimationOnce animation finishes
+	animationFinishedFunc func(anim string)
+
+	// Animation is advanced by the engine's fixed timestep
+	// instead of every time the material is rendered
+	fixedAnimation bool
 }
 
 func NewBasicMaterial(Shader *ShaderProgram) *BasicMaterial {
@@ -56,41 +64,43 @@ func NewBasicMaterial(Shader *ShaderProgram) *BasicMaterial {
 }
 
 func (bm *BasicMaterial) Render(delta float64, darkness float32, totalTime float64) {
-	bm.UpdateAnimation(delta)
+	dev := device.Get()
+
+	if !bm.fixedAnimation {
+		bm.UpdateAnimation(delta)
+	}
 
-	if bm.DiffuseMap != nil && state.BoundTexture0 != *bm.DiffuseMap.Addr {
-		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, *bm.DiffuseMap.Addr)
-		state.BoundTexture0 = *bm.DiffuseMap.Addr
+	if bm.DiffuseMap != nil {
+		dev.ActiveTexture(gl.TEXTURE0)
+		dev.BindTexture(gl.TEXTURE_2D, *bm.DiffuseMap.Addr)
 	}
 
-	if bm.AlphaMap != nil && state.BoundTexture1 != *bm.AlphaMap.Addr {
-		gl.ActiveTexture(gl.TEXTURE1)
-		gl.BindTexture(gl.TEXTURE_2D, *bm.AlphaMap.Addr)
-		state.BoundTexture1 = *bm.AlphaMap.Addr
+	if bm.AlphaMap != nil {
+		dev.ActiveTexture(gl.TEXTURE1)
+		dev.BindTexture(gl.TEXTURE_2D, *bm.AlphaMap.Addr)
 	}
 
-	gl.Uniform1f(bm.Shader.GetUniform("diffuseLevel"), bm.DiffuseLevel)
+	dev.Uniform1f(bm.Shader.GetUniform("diffuseLevel"), bm.DiffuseLevel)
 
-	gl.Uniform4fv(bm.Shader.GetUniform("hue"), 1, &bm.Hue[0])
+	dev.Uniform4fv(bm.Shader.GetUniform("hue"), 1, &bm.Hue[0])
 
-	gl.Uniform1i(bm.Shader.GetUniform("diffuseMap"), 0)
-	gl.Uniform1f(bm.Shader.GetUniform("scale"), bm.DiffuseMapScale)
+	dev.Uniform1i(bm.Shader.GetUniform("diffuseMap"), 0)
+	dev.Uniform1f(bm.Shader.GetUniform("scale"), bm.DiffuseMapScale)
 
-	gl.Uniform1f(bm.Shader.GetUniform("alphaMapLevel"), bm.AlphaMapLevel)
-	gl.Uniform1i(bm.Shader.GetUniform("alphaMap"), 1)
+	dev.Uniform1f(bm.Shader.GetUniform("alphaMapLevel"), bm.AlphaMapLevel)
+	dev.Uniform1i(bm.Shader.GetUniform("alphaMap"), 1)
 
-	gl.Uniform1f(bm.Shader.GetUniform("darkness"), darkness)
+	dev.Uniform1f(bm.Shader.GetUniform("darkness"), darkness)
 
-	gl.Uniform1f(bm.Shader.GetUniform("scatterLevel"), bm.ScatterLevel)
+	dev.Uniform1f(bm.Shader.GetUniform("scatterLevel"), bm.ScatterLevel)
 
-	gl.Uniform1i(bm.Shader.GetUniform("flipped"), int32(bm.Flipped))
+	dev.Uniform1i(bm.Shader.GetUniform("flipped"), int32(bm.Flipped))
 
 	if bm.Blending {
-		gl.Enable(gl.BLEND)
-		gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+		dev.Enable(gl.BLEND)
+		dev.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 	} else {
-		gl.Disable(gl.BLEND)
+		dev.Disable(gl.BLEND)
 	}
 }
 
@@ -106,12 +116,16 @@ func (bm *BasicMaterial) UpdateAnimation(delta float64) {
 			} else {
 
 				if bm.animationPlayingOnce {
+					finished := bm.animationPlaying
 					bm.animationPlaying = ""
 					bm.animationPlayingOnce = false
 					if bm.animationOnceCallback != nil {
 						bm.animationOnceCallback()
 						bm.animationOnceCallback = nil
 					}
+					if bm.animationFinishedFunc != nil {
+						bm.animationFinishedFunc(finished)
+					}
 					return
 				}
 				bm.animationCurrent = 0
@@ -130,6 +144,24 @@ func (bm *BasicMaterial) UpdateAnimation(delta float64) {
 	}
 }
 
+// EnableFixedAnimation stops Render from advancing the animation,
+// leaving it to the caller to call UpdateAnimation at a fixed rate
+func (bm *BasicMaterial) EnableFixedAnimation() {
+	bm.fixedAnimation = true
+}
+
+// IsFixedAnimation returns whether the animation is
+// advanced by the caller rather than by Render
+func (bm *BasicMaterial) IsFixedAnimation() bool {
+	return bm.fixedAnimation
+}
+
+// OnAnimationFinished sets a function called whenever an animation
+// played once finishes, in addition to any per-play callback
+func (bm *BasicMaterial) OnAnimationFinished(f func(anim string)) {
+	bm.animationFinishedFunc = f
+}
+
 func (bm *BasicMaterial) EnableAnimation() {
 	bm.animationEnabled = true
 }
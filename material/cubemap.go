@@ -1,6 +1,10 @@
 package material
 
-import "github.com/go-gl/gl/v4.1-core/gl"
+import (
+	"rapidengine/device"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
 
 type CubemapMaterial struct {
 	shader *ShaderProgram
@@ -15,18 +19,22 @@ func NewCubemapMaterial(shader *ShaderProgram) *CubemapMaterial {
 }
 
 func (cm *CubemapMaterial) Render(delta float64, darkness float32, totalTime float64) {
+	dev := device.Get()
+
 	if cm.CubeDiffuseMap != nil {
-		gl.ActiveTexture(gl.TEXTURE6)
-		gl.BindTexture(gl.TEXTURE_CUBE_MAP, *cm.CubeDiffuseMap)
+		dev.ActiveTexture(gl.TEXTURE6)
+		dev.BindTexture(gl.TEXTURE_CUBE_MAP, *cm.CubeDiffuseMap)
 
-		gl.Uniform1i(cm.GetShader().GetUniform("cubeDiffuseMap"), 6)
+		dev.Uniform1i(cm.GetShader().GetUniform("cubeDiffuseMap"), 6)
 	}
 }
 
 func (cm *CubemapMaterial) UpdateAttribArrays() {
-	gl.EnableVertexAttribArray(0)
-	gl.EnableVertexAttribArray(1)
-	gl.EnableVertexAttribArray(2)
+	dev := device.Get()
+
+	dev.EnableVertexAttribArray(0)
+	dev.EnableVertexAttribArray(1)
+	dev.EnableVertexAttribArray(2)
 }
 
 func (cm *CubemapMaterial) GetShader() *ShaderProgram {
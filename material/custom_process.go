@@ -2,6 +2,8 @@ package material
 
 import (
 	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"rapidengine/device"
 )
 
 type CustomProcessMaterial struct {
@@ -22,16 +24,26 @@ func NewCustomProcessMaterial(shader *ShaderProgram) *CustomProcessMaterial {
 }
 
 func (sm *CustomProcessMaterial) Render(delta float64, darkness float32, totalTime float64) {
-	gl.Uniform1f(sm.shader.GetUniform("fboWidth"), sm.FboWidth)
-	gl.Uniform1f(sm.shader.GetUniform("fboHeight"), sm.FboHeight)
+	dev := device.Get()
+
+	dev.Uniform1f(sm.shader.GetUniform("fboWidth"), sm.FboWidth)
+	dev.Uniform1f(sm.shader.GetUniform("fboHeight"), sm.FboHeight)
 
 	sm.RenderFunc(delta, darkness, totalTime)
 }
 
 func (sm *CustomProcessMaterial) BindCustomInput(index uint32, texture uint32, uniform string) {
-	gl.ActiveTexture(gl.TEXTURE0 + index)
-	gl.BindTexture(gl.TEXTURE_2D, texture)
-	gl.Uniform1i(sm.shader.GetUniform(uniform), int32(index))
+	dev := device.Get()
+
+	dev.ActiveTexture(gl.TEXTURE0 + index)
+	dev.BindTexture(gl.TEXTURE_2D, texture)
+	dev.Uniform1i(sm.shader.GetUniform(uniform), int32(index))
+}
+
+// BindCustomTexture binds a texture, such as a render
+// target's, to a texture unit and sampler uniform
+func (sm *CustomProcessMaterial) BindCustomTexture(index uint32, texture *Texture, uniform string) {
+	sm.BindCustomInput(index, *texture.Addr, uniform)
 }
 
 func (sm *CustomProcessMaterial) GetShader() *ShaderProgram {
@@ -1,6 +1,10 @@
 package material
 
-import "github.com/go-gl/gl/v4.1-core/gl"
+import (
+	"rapidengine/device"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
 
 type FoliageMaterial struct {
 	shader *ShaderProgram
@@ -53,80 +57,81 @@ func NewFoliageMaterial(shader *ShaderProgram) *FoliageMaterial {
 }
 
 func (fm *FoliageMaterial) Render(delta float64, darkness float32, totalTime float64) {
+	dev := device.Get()
 
 	//   --------------------------------------------------
 	//   Standard Material
 	//   --------------------------------------------------
 
 	if fm.DiffuseMap != nil {
-		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, *fm.DiffuseMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE0)
+		dev.BindTexture(gl.TEXTURE_2D, *fm.DiffuseMap.Addr)
 	}
-	gl.Uniform1i(fm.shader.GetUniform("diffuseMap"), 0)
+	dev.Uniform1i(fm.shader.GetUniform("diffuseMap"), 0)
 
 	if fm.NormalMap != nil {
-		gl.ActiveTexture(gl.TEXTURE1)
-		gl.BindTexture(gl.TEXTURE_2D, *fm.NormalMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE1)
+		dev.BindTexture(gl.TEXTURE_2D, *fm.NormalMap.Addr)
 	}
-	gl.Uniform1i(fm.shader.GetUniform("normalMap"), 1)
+	dev.Uniform1i(fm.shader.GetUniform("normalMap"), 1)
 
 	if fm.HeightMap != nil {
-		gl.ActiveTexture(gl.TEXTURE2)
-		gl.BindTexture(gl.TEXTURE_2D, *fm.HeightMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE2)
+		dev.BindTexture(gl.TEXTURE_2D, *fm.HeightMap.Addr)
 	}
-	gl.Uniform1i(fm.shader.GetUniform("heightMap"), 2)
+	dev.Uniform1i(fm.shader.GetUniform("heightMap"), 2)
 
 	if fm.SpecularMap != nil {
-		gl.ActiveTexture(gl.TEXTURE3)
-		gl.BindTexture(gl.TEXTURE_2D, *fm.SpecularMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE3)
+		dev.BindTexture(gl.TEXTURE_2D, *fm.SpecularMap.Addr)
 	}
-	gl.Uniform1i(fm.shader.GetUniform("specularMap"), 3)
+	dev.Uniform1i(fm.shader.GetUniform("specularMap"), 3)
 
-	gl.Uniform1f(fm.shader.GetUniform("diffuseLevel"), fm.DiffuseLevel)
-	gl.Uniform1f(fm.shader.GetUniform("normalLevel"), fm.NormalLevel)
-	gl.Uniform1f(fm.shader.GetUniform("specularLevel"), fm.SpecularLevel)
-	gl.Uniform1f(fm.shader.GetUniform("heightLevel"), fm.HeightLevel)
+	dev.Uniform1f(fm.shader.GetUniform("diffuseLevel"), fm.DiffuseLevel)
+	dev.Uniform1f(fm.shader.GetUniform("normalLevel"), fm.NormalLevel)
+	dev.Uniform1f(fm.shader.GetUniform("specularLevel"), fm.SpecularLevel)
+	dev.Uniform1f(fm.shader.GetUniform("heightLevel"), fm.HeightLevel)
 
-	gl.Uniform4fv(fm.shader.GetUniform("hue"), 1, &fm.Hue[0])
+	dev.Uniform4fv(fm.shader.GetUniform("hue"), 1, &fm.Hue[0])
 
-	gl.Uniform1f(fm.shader.GetUniform("displacement"), fm.Displacement)
-	gl.Uniform1f(fm.shader.GetUniform("scale"), fm.Scale)
+	dev.Uniform1f(fm.shader.GetUniform("displacement"), fm.Displacement)
+	dev.Uniform1f(fm.shader.GetUniform("scale"), fm.Scale)
 
-	gl.Uniform1f(fm.shader.GetUniform("reflectivity"), fm.Reflectivity)
-	gl.Uniform1f(fm.shader.GetUniform("refractivity"), fm.Refractivity)
-	gl.Uniform1f(fm.shader.GetUniform("refractLevel"), fm.RefractLevel)
+	dev.Uniform1f(fm.shader.GetUniform("reflectivity"), fm.Reflectivity)
+	dev.Uniform1f(fm.shader.GetUniform("refractivity"), fm.Refractivity)
+	dev.Uniform1f(fm.shader.GetUniform("refractLevel"), fm.RefractLevel)
 
 	//   --------------------------------------------------
 	//   Foliage Material
 	//   --------------------------------------------------
 
 	if fm.OpacityMap != nil {
-		gl.ActiveTexture(gl.TEXTURE4)
-		gl.BindTexture(gl.TEXTURE_2D, *fm.OpacityMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE4)
+		dev.BindTexture(gl.TEXTURE_2D, *fm.OpacityMap.Addr)
 	}
-	gl.Uniform1i(fm.shader.GetUniform("opacityMap"), 4)
+	dev.Uniform1i(fm.shader.GetUniform("opacityMap"), 4)
 
 	if fm.TerrainHeightMap != nil {
-		gl.ActiveTexture(gl.TEXTURE5)
-		gl.BindTexture(gl.TEXTURE_2D, *fm.TerrainHeightMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE5)
+		dev.BindTexture(gl.TEXTURE_2D, *fm.TerrainHeightMap.Addr)
 	}
-	gl.Uniform1i(fm.shader.GetUniform("terrainHeightMap"), 5)
+	dev.Uniform1i(fm.shader.GetUniform("terrainHeightMap"), 5)
 
 	if fm.TerrainNormalMap != nil {
-		gl.ActiveTexture(gl.TEXTURE6)
-		gl.BindTexture(gl.TEXTURE_2D, *fm.TerrainNormalMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE6)
+		dev.BindTexture(gl.TEXTURE_2D, *fm.TerrainNormalMap.Addr)
 	}
-	gl.Uniform1i(fm.shader.GetUniform("terrainNormalMap"), 6)
+	dev.Uniform1i(fm.shader.GetUniform("terrainNormalMap"), 6)
 
-	gl.Uniform1f(fm.shader.GetUniform("terrainDisplacement"), fm.TerrainDisplacement)
-	gl.Uniform1f(fm.shader.GetUniform("terrainWidth"), fm.TerrainWidth)
-	gl.Uniform1f(fm.shader.GetUniform("terrainLength"), fm.TerrainLength)
+	dev.Uniform1f(fm.shader.GetUniform("terrainDisplacement"), fm.TerrainDisplacement)
+	dev.Uniform1f(fm.shader.GetUniform("terrainWidth"), fm.TerrainWidth)
+	dev.Uniform1f(fm.shader.GetUniform("terrainLength"), fm.TerrainLength)
 
-	gl.Uniform1f(fm.shader.GetUniform("foliageDisplacement"), fm.FoliageDisplacement)
-	gl.Uniform1f(fm.shader.GetUniform("foliageNoiseSeed"), fm.FoliageNoiseSeed)
-	gl.Uniform1f(fm.shader.GetUniform("foliageVariation"), fm.FoliageVariation)
+	dev.Uniform1f(fm.shader.GetUniform("foliageDisplacement"), fm.FoliageDisplacement)
+	dev.Uniform1f(fm.shader.GetUniform("foliageNoiseSeed"), fm.FoliageNoiseSeed)
+	dev.Uniform1f(fm.shader.GetUniform("foliageVariation"), fm.FoliageVariation)
 
-	gl.Uniform1f(fm.shader.GetUniform("totalTime"), float32(totalTime))
+	dev.Uniform1f(fm.shader.GetUniform("totalTime"), float32(totalTime))
 }
 
 func (fm *FoliageMaterial) GetShader() *ShaderProgram {
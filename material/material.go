@@ -5,3 +5,25 @@ type Material interface {
 
 	GetShader() *ShaderProgram
 }
+
+// MainTexture returns the first texture a material
+// binds, for sorting draws, or 0 if it has none
+func MainTexture(m Material) uint32 {
+	var t *Texture
+
+	switch m := m.(type) {
+	case *BasicMaterial:
+		t = m.DiffuseMap
+	case *StandardMaterial:
+		t = m.diffuseMap
+	case *PBRMaterial:
+		t = m.AlbedoMap
+	case *TerrainMaterial:
+		t = m.DiffuseMap
+	}
+
+	if t == nil || t.Addr == nil {
+		return 0
+	}
+	return *t.Addr
+}
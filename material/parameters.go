@@ -0,0 +1,116 @@
+package material
+
+//  --------------------------------------------------
+//  Parameters.go names the settings of each material
+//  type, so that they can be saved to and loaded from
+//  files without knowing the material's type.
+//  --------------------------------------------------
+
+// Parameters points at a material's settings, by name
+type Parameters struct {
+	Type string
+
+	Hue *[4]float32
+
+	Floats   map[string]*float32
+	Bools    map[string]*bool
+	Textures map[string]**Texture
+}
+
+// ParametersOf returns the settings of a material,
+// and false if its type has none which can be saved
+func ParametersOf(m Material) (Parameters, bool) {
+	switch m := m.(type) {
+
+	case *BasicMaterial:
+		return Parameters{
+			Type: "basic",
+			Hue:  &m.Hue,
+			Floats: map[string]*float32{
+				"diffuse_level":     &m.DiffuseLevel,
+				"diffuse_map_scale": &m.DiffuseMapScale,
+				"alpha_map_level":   &m.AlphaMapLevel,
+				"scatter_level":     &m.ScatterLevel,
+			},
+			Bools: map[string]*bool{
+				"blending": &m.Blending,
+			},
+			Textures: map[string]**Texture{
+				"diffuse": &m.DiffuseMap,
+				"alpha":   &m.AlphaMap,
+			},
+		}, true
+
+	case *StandardMaterial:
+		return Parameters{
+			Type: "standard",
+			Hue:  &m.Hue,
+			Floats: map[string]*float32{
+				"diffuse_level":  &m.DiffuseLevel,
+				"normal_level":   &m.NormalLevel,
+				"specular_level": &m.SpecularLevel,
+				"height_level":   &m.HeightLevel,
+				"displacement":   &m.Displacement,
+				"scale":          &m.Scale,
+				"reflectivity":   &m.Reflectivity,
+				"refractivity":   &m.Refractivity,
+				"refract_level":  &m.RefractLevel,
+			},
+			Textures: map[string]**Texture{
+				"diffuse":  &m.diffuseMap,
+				"normal":   &m.normalMap,
+				"height":   &m.heightMap,
+				"specular": &m.specularMap,
+			},
+		}, true
+
+	case *PBRMaterial:
+		return Parameters{
+			Type: "pbr",
+			Floats: map[string]*float32{
+				"diffuse_scalar":        &m.DiffuseScalar,
+				"normal_scalar":         &m.NormalScalar,
+				"metallic_scalar":       &m.MetallicScalar,
+				"roughness_scalar":      &m.RoughnessScalar,
+				"ao_scalar":             &m.AmbientOcclusionScalar,
+				"vertex_displacement":   &m.VertexDisplacement,
+				"parallax_displacement": &m.ParallaxDisplacement,
+				"scale":                 &m.Scale,
+				"reflectivity":          &m.Reflectivity,
+				"refractivity":          &m.Refractivity,
+				"refract_level":         &m.RefractLevel,
+			},
+			Bools: map[string]*bool{
+				"rough_or_smooth": &m.RoughOrSmooth,
+			},
+			Textures: map[string]**Texture{
+				"albedo":    &m.AlbedoMap,
+				"normal":    &m.NormalMap,
+				"height":    &m.HeightMap,
+				"metallic":  &m.MetallicMap,
+				"roughness": &m.RoughnessMap,
+				"ao":        &m.AmbientOcclusionMap,
+			},
+		}, true
+
+	case *TerrainMaterial:
+		return Parameters{
+			Type: "terrain",
+			Floats: map[string]*float32{
+				"terrain_displacement": &m.TerrainDisplacement,
+				"displacement":         &m.Displacement,
+				"scale":                &m.Scale,
+			},
+			Textures: map[string]**Texture{
+				"diffuse":        &m.DiffuseMap,
+				"normal":         &m.NormalMap,
+				"height":         &m.HeightMap,
+				"terrain_height": &m.TerrainHeightMap,
+				"terrain_normal": &m.TerrainNormalMap,
+			},
+		}, true
+
+	}
+
+	return Parameters{}, false
+}
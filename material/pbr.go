@@ -1,6 +1,10 @@
 package material
 
-import "github.com/go-gl/gl/v4.1-core/gl"
+import (
+	"rapidengine/device"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
 
 type PBRMaterial struct {
 	shader *ShaderProgram
@@ -42,61 +46,62 @@ func NewPBRMaterial(shader *ShaderProgram) *PBRMaterial {
 }
 
 func (pm *PBRMaterial) Render(delta float64, darkness float32, totalTime float64) {
+	dev := device.Get()
 
 	if pm.AlbedoMap != nil {
-		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, *pm.AlbedoMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE0)
+		dev.BindTexture(gl.TEXTURE_2D, *pm.AlbedoMap.Addr)
 	}
-	gl.Uniform1i(pm.shader.GetUniform("albedoMap"), 0)
+	dev.Uniform1i(pm.shader.GetUniform("albedoMap"), 0)
 
 	if pm.NormalMap != nil {
-		gl.ActiveTexture(gl.TEXTURE1)
-		gl.BindTexture(gl.TEXTURE_2D, *pm.NormalMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE1)
+		dev.BindTexture(gl.TEXTURE_2D, *pm.NormalMap.Addr)
 	}
-	gl.Uniform1i(pm.shader.GetUniform("normalMap"), 1)
+	dev.Uniform1i(pm.shader.GetUniform("normalMap"), 1)
 
 	if pm.HeightMap != nil {
-		gl.ActiveTexture(gl.TEXTURE2)
-		gl.BindTexture(gl.TEXTURE_2D, *pm.HeightMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE2)
+		dev.BindTexture(gl.TEXTURE_2D, *pm.HeightMap.Addr)
 	}
-	gl.Uniform1i(pm.shader.GetUniform("heightMap"), 2)
+	dev.Uniform1i(pm.shader.GetUniform("heightMap"), 2)
 
 	if pm.MetallicMap != nil {
-		gl.ActiveTexture(gl.TEXTURE3)
-		gl.BindTexture(gl.TEXTURE_2D, *pm.MetallicMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE3)
+		dev.BindTexture(gl.TEXTURE_2D, *pm.MetallicMap.Addr)
 	}
-	gl.Uniform1i(pm.shader.GetUniform("metallicMap"), 3)
+	dev.Uniform1i(pm.shader.GetUniform("metallicMap"), 3)
 
 	if pm.RoughnessMap != nil {
-		gl.ActiveTexture(gl.TEXTURE4)
-		gl.BindTexture(gl.TEXTURE_2D, *pm.RoughnessMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE4)
+		dev.BindTexture(gl.TEXTURE_2D, *pm.RoughnessMap.Addr)
 	}
-	gl.Uniform1i(pm.shader.GetUniform("roughnessMap"), 4)
+	dev.Uniform1i(pm.shader.GetUniform("roughnessMap"), 4)
 
 	if pm.AmbientOcclusionMap != nil {
-		gl.ActiveTexture(gl.TEXTURE5)
-		gl.BindTexture(gl.TEXTURE_2D, *pm.AmbientOcclusionMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE5)
+		dev.BindTexture(gl.TEXTURE_2D, *pm.AmbientOcclusionMap.Addr)
 	}
-	gl.Uniform1i(pm.shader.GetUniform("aoMap"), 5)
+	dev.Uniform1i(pm.shader.GetUniform("aoMap"), 5)
 
-	gl.Uniform1f(pm.shader.GetUniform("normalScalar"), pm.NormalScalar)
-	gl.Uniform1f(pm.shader.GetUniform("metallicScalar"), pm.MetallicScalar)
-	gl.Uniform1f(pm.shader.GetUniform("roughnessScalar"), pm.RoughnessScalar)
-	gl.Uniform1f(pm.shader.GetUniform("aoScalar"), pm.AmbientOcclusionScalar)
+	dev.Uniform1f(pm.shader.GetUniform("normalScalar"), pm.NormalScalar)
+	dev.Uniform1f(pm.shader.GetUniform("metallicScalar"), pm.MetallicScalar)
+	dev.Uniform1f(pm.shader.GetUniform("roughnessScalar"), pm.RoughnessScalar)
+	dev.Uniform1f(pm.shader.GetUniform("aoScalar"), pm.AmbientOcclusionScalar)
 
 	if pm.RoughOrSmooth {
-		gl.Uniform1f(pm.shader.GetUniform("roughORsmooth"), 1)
+		dev.Uniform1f(pm.shader.GetUniform("roughORsmooth"), 1)
 	} else {
-		gl.Uniform1f(pm.shader.GetUniform("roughORsmooth"), -1)
+		dev.Uniform1f(pm.shader.GetUniform("roughORsmooth"), -1)
 	}
 
-	gl.Uniform1f(pm.shader.GetUniform("scale"), pm.Scale)
-	gl.Uniform1f(pm.shader.GetUniform("vertexDisplacement"), pm.VertexDisplacement)
-	gl.Uniform1f(pm.shader.GetUniform("parallaxDisplacement"), pm.ParallaxDisplacement)
+	dev.Uniform1f(pm.shader.GetUniform("scale"), pm.Scale)
+	dev.Uniform1f(pm.shader.GetUniform("vertexDisplacement"), pm.VertexDisplacement)
+	dev.Uniform1f(pm.shader.GetUniform("parallaxDisplacement"), pm.ParallaxDisplacement)
 
-	gl.Uniform1f(pm.shader.GetUniform("reflectivity"), pm.Reflectivity)
-	gl.Uniform1f(pm.shader.GetUniform("refractivity"), pm.Refractivity)
-	gl.Uniform1f(pm.shader.GetUniform("refractLevel"), pm.RefractLevel)
+	dev.Uniform1f(pm.shader.GetUniform("reflectivity"), pm.Reflectivity)
+	dev.Uniform1f(pm.shader.GetUniform("refractivity"), pm.Refractivity)
+	dev.Uniform1f(pm.shader.GetUniform("refractLevel"), pm.RefractLevel)
 }
 
 func (pm *PBRMaterial) GetShader() *ShaderProgram {
@@ -1,7 +1,7 @@
 package material
 
 import (
-	"rapidengine/state"
+	"rapidengine/device"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 )
@@ -23,14 +23,15 @@ func NewPostProcessMaterial(shader *ShaderProgram, screenMap *uint32) *PostProce
 }
 
 func (pm *PostProcessMaterial) Render(delta float64, darkness float32, totalTime float64) {
-	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindTexture(gl.TEXTURE_2D, *pm.ScreenMap)
-	state.BoundTexture0 = *pm.ScreenMap
+	dev := device.Get()
 
-	gl.Uniform1i(pm.shader.GetUniform("screen"), 0)
+	dev.ActiveTexture(gl.TEXTURE0)
+	dev.BindTexture(gl.TEXTURE_2D, *pm.ScreenMap)
 
-	gl.Uniform1f(pm.shader.GetUniform("fboWidth"), pm.FboWidth)
-	gl.Uniform1f(pm.shader.GetUniform("fboHeight"), pm.FboHeight)
+	dev.Uniform1i(pm.shader.GetUniform("screen"), 0)
+
+	dev.Uniform1f(pm.shader.GetUniform("fboWidth"), pm.FboWidth)
+	dev.Uniform1f(pm.shader.GetUniform("fboHeight"), pm.FboHeight)
 }
 
 func (pm *PostProcessMaterial) GetShader() *ShaderProgram {
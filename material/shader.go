@@ -2,8 +2,8 @@ package material
 
 import (
 	"fmt"
-	"io/ioutil"
-	"strings"
+	"rapidengine/assets"
+	"rapidengine/device"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 )
@@ -48,12 +48,12 @@ func NewShaderProgram(
 
 func (shaderProgram *ShaderProgram) Bind() {
 	b := shaderProgram.id
-	gl.UseProgram(b)
+	device.Get().UseProgram(b)
 }
 
 func (shaderProgram *ShaderProgram) RebindAttribLocations() {
 	for attrib, location := range shaderProgram.attributeLocations {
-		gl.BindAttribLocation(shaderProgram.id, location, gl.Str(attrib+"\x00"))
+		device.Get().BindAttribLocation(shaderProgram.id, location, attrib)
 	}
 }
 
@@ -65,122 +65,159 @@ func (shaderProgram *ShaderProgram) GetID() uint32 {
 	return shaderProgram.id
 }
 
-func (shaderProgram *ShaderProgram) Compile() {
-	vert, err := ioutil.ReadFile(shaderProgram.vertexShader)
-	if err != nil {
-		panic(err)
-	}
-	vertexShader, err := CompileShader(string(vert)+"\x00", gl.VERTEX_SHADER)
-	if err != nil {
-		panic(err)
-	}
+// Compile reads, compiles and links every stage of the program.
+// A stage that fails to compile returns a *ShaderCompileError,
+// and a program that fails to link a *ShaderLinkError.
+func (shaderProgram *ShaderProgram) Compile() error {
+	dev := device.Get()
 
-	frag, err := ioutil.ReadFile(shaderProgram.fragmentShader)
+	vertexShader, err := compileStage(shaderProgram.vertexShader, gl.VERTEX_SHADER)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	fragmentShader, err := CompileShader(string(frag)+"\x00", gl.FRAGMENT_SHADER)
+
+	fragmentShader, err := compileStage(shaderProgram.fragmentShader, gl.FRAGMENT_SHADER)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	shaderProgram.id = gl.CreateProgram()
-	gl.AttachShader(shaderProgram.id, vertexShader)
-	gl.AttachShader(shaderProgram.id, fragmentShader)
+	shaderProgram.id = dev.CreateProgram()
+	dev.AttachShader(shaderProgram.id, vertexShader)
+	dev.AttachShader(shaderProgram.id, fragmentShader)
 
 	if shaderProgram.geometryShader != "" {
-		geom, err := ioutil.ReadFile(shaderProgram.geometryShader)
-		if err != nil {
-			panic(err)
-		}
-		geometryShader, err := CompileShader(string(geom)+"\x00", gl.GEOMETRY_SHADER)
+		geometryShader, err := compileStage(shaderProgram.geometryShader, gl.GEOMETRY_SHADER)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		gl.AttachShader(shaderProgram.id, geometryShader)
+		dev.AttachShader(shaderProgram.id, geometryShader)
 	}
 
 	// Tesselation shaders
 	if shaderProgram.controlShader != "" {
-		println("Compiling tesselation shaders")
-		cont, err := ioutil.ReadFile(shaderProgram.controlShader)
-		if err != nil {
-			panic(err)
-		}
-		controlShader, err := CompileShader(string(cont)+"\x00", gl.TESS_CONTROL_SHADER)
+		controlShader, err := compileStage(shaderProgram.controlShader, gl.TESS_CONTROL_SHADER)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		gl.AttachShader(shaderProgram.id, controlShader)
+		dev.AttachShader(shaderProgram.id, controlShader)
 
-		eval, err := ioutil.ReadFile(shaderProgram.evalShader)
-		if err != nil {
-			panic(err)
-		}
-		evalShader, err := CompileShader(string(eval)+"\x00", gl.TESS_EVALUATION_SHADER)
+		evalShader, err := compileStage(shaderProgram.evalShader, gl.TESS_EVALUATION_SHADER)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		gl.AttachShader(shaderProgram.id, evalShader)
+		dev.AttachShader(shaderProgram.id, evalShader)
 	}
 
-	gl.LinkProgram(shaderProgram.id)
+	dev.LinkProgram(shaderProgram.id)
+
+	if ok, log := dev.ProgramLinkStatus(shaderProgram.id); !ok {
+		return &ShaderLinkError{VertexPath: shaderProgram.vertexShader, FragmentPath: shaderProgram.fragmentShader, Log: log}
+	}
 
 	for uni := range shaderProgram.uniformLocations {
-		shaderProgram.uniformLocations[uni] = gl.GetUniformLocation(shaderProgram.id, gl.Str(uni+"\x00"))
+		shaderProgram.uniformLocations[uni] = dev.GetUniformLocation(shaderProgram.id, uni)
 	}
 
 	for attrib, location := range shaderProgram.attributeLocations {
-		gl.BindAttribLocation(shaderProgram.id, location, gl.Str(attrib+"\x00"))
+		dev.BindAttribLocation(shaderProgram.id, location, attrib)
 	}
+
+	return nil
+}
+
+func compileStage(path string, shaderType uint32) (uint32, error) {
+	source, err := assets.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	shader, err := CompileShader(string(source), shaderType)
+	if compileErr, ok := err.(*ShaderCompileError); ok {
+		compileErr.Path = path
+	}
+	return shader, err
 }
 
 func (shaderProgram *ShaderProgram) UniformTexture(index uint32, texture uint32, name string) {
-	gl.ActiveTexture(gl.TEXTURE0 + index)
-	gl.BindTexture(gl.TEXTURE_2D, texture)
-	gl.Uniform1i(shaderProgram.GetUniform(name), int32(index))
+	dev := device.Get()
+
+	dev.ActiveTexture(gl.TEXTURE0 + index)
+	dev.BindTexture(gl.TEXTURE_2D, texture)
+	dev.Uniform1i(shaderProgram.GetUniform(name), int32(index))
 }
 
 func (shaderProgram *ShaderProgram) UniformMatrix4(name string, value *float32) {
-	gl.UniformMatrix4fv(
+	device.Get().UniformMatrix4fv(
 		shaderProgram.GetUniform(name),
 		1, false, value,
 	)
 }
 
 func (shaderProgram *ShaderProgram) UniformVec3(name string, value *float32) {
-	gl.Uniform3fv(shaderProgram.GetUniform(name), 1, value)
+	device.Get().Uniform3fv(shaderProgram.GetUniform(name), 1, value)
 }
 
 func CompileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
-
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-	gl.CompileShader(shader)
+	dev := device.Get()
 
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+	shader := dev.CreateShader(shaderType)
 
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+	dev.ShaderSource(shader, source)
+	dev.CompileShader(shader)
 
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
+	if ok, log := dev.ShaderCompileStatus(shader); !ok {
+		return 0, &ShaderCompileError{ShaderType: shaderType, Log: log}
 	}
 	return shader, nil
 }
 
+// ShaderCompileError is returned when a shader stage fails
+// to compile, and carries the driver's info log.
+type ShaderCompileError struct {
+	Path       string
+	ShaderType uint32
+	Log        string
+}
+
+func (e *ShaderCompileError) Error() string {
+	return fmt.Sprintf("failed to compile %s shader %s: %s", shaderTypeName(e.ShaderType), e.Path, e.Log)
+}
+
+// ShaderLinkError is returned when a program fails
+// to link, and carries the driver's info log.
+type ShaderLinkError struct {
+	VertexPath   string
+	FragmentPath string
+	Log          string
+}
+
+func (e *ShaderLinkError) Error() string {
+	return fmt.Sprintf("failed to link shader program %s, %s: %s", e.VertexPath, e.FragmentPath, e.Log)
+}
+
+func shaderTypeName(shaderType uint32) string {
+	switch shaderType {
+	case gl.VERTEX_SHADER:
+		return "vertex"
+	case gl.FRAGMENT_SHADER:
+		return "fragment"
+	case gl.GEOMETRY_SHADER:
+		return "geometry"
+	case gl.TESS_CONTROL_SHADER:
+		return "tesselation control"
+	case gl.TESS_EVALUATION_SHADER:
+		return "tesselation evaluation"
+	}
+	return "unknown"
+}
+
 //  --------------------------------------------------
 //  Shader Programs
 //  --------------------------------------------------
 
 var BasicProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/basic/basic.vert",
-	fragmentShader: "../rapidengine/material/shaders/basic/basic.frag",
+	vertexShader:   "shaders/basic/basic.vert",
+	fragmentShader: "shaders/basic/basic.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -210,8 +247,8 @@ var BasicProgram = ShaderProgram{
 }
 
 var StandardProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/standard/standard.vert",
-	fragmentShader: "../rapidengine/material/shaders/standard/standard.frag",
+	vertexShader:   "shaders/standard/standard.vert",
+	fragmentShader: "shaders/standard/standard.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -260,8 +297,8 @@ var StandardProgram = ShaderProgram{
 }
 
 var PBRProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/pbr/pbr.vert",
-	fragmentShader: "../rapidengine/material/shaders/pbr/pbr.frag",
+	vertexShader:   "shaders/pbr/pbr.vert",
+	fragmentShader: "shaders/pbr/pbr.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -313,10 +350,10 @@ var PBRProgram = ShaderProgram{
 }
 
 var TerrainProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/terrain/terrain.vert",
-	fragmentShader: "../rapidengine/material/shaders/terrain/terrain.frag",
-	controlShader:  "../rapidengine/material/shaders/terrain/terrain.cont",
-	evalShader:     "../rapidengine/material/shaders/terrain/terrain.eval",
+	vertexShader:   "shaders/terrain/terrain.vert",
+	fragmentShader: "shaders/terrain/terrain.frag",
+	controlShader:  "shaders/terrain/terrain.cont",
+	evalShader:     "shaders/terrain/terrain.eval",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -353,9 +390,9 @@ var TerrainProgram = ShaderProgram{
 }
 
 var FoliageProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/foliage/nfoliage.vert",
-	fragmentShader: "../rapidengine/material/shaders/foliage/nfoliage.frag",
-	//geometryShader: "../rapidengine/material/shaders/foliage/foliage.geom",
+	vertexShader:   "shaders/foliage/nfoliage.vert",
+	fragmentShader: "shaders/foliage/nfoliage.frag",
+	//geometryShader: "shaders/foliage/foliage.geom",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -420,8 +457,8 @@ var FoliageProgram = ShaderProgram{
 }
 
 var WaterProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/water/water.vert",
-	fragmentShader: "../rapidengine/material/shaders/water/water.frag",
+	vertexShader:   "shaders/water/water.vert",
+	fragmentShader: "shaders/water/water.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -459,8 +496,8 @@ var WaterProgram = ShaderProgram{
 }
 
 var SkyBoxProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/skybox/skybox.vert",
-	fragmentShader: "../rapidengine/material/shaders/skybox/skybox.frag",
+	vertexShader:   "shaders/skybox/skybox.vert",
+	fragmentShader: "shaders/skybox/skybox.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -476,8 +513,8 @@ var SkyBoxProgram = ShaderProgram{
 }
 
 var SunProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/sun/basic.vert",
-	fragmentShader: "../rapidengine/material/shaders/sun/basic.frag",
+	vertexShader:   "shaders/sun/basic.vert",
+	fragmentShader: "shaders/sun/basic.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -507,8 +544,8 @@ var SunProgram = ShaderProgram{
 }
 
 var PostFinalProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/final/final.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/final/final.frag",
+	vertexShader:   "shaders/postprocessing/final/final.vert",
+	fragmentShader: "shaders/postprocessing/final/final.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -526,8 +563,8 @@ var PostFinalProgram = ShaderProgram{
 }
 
 var PostHDRProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/hdr/hdr.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/hdr/hdr.frag",
+	vertexShader:   "shaders/postprocessing/hdr/hdr.vert",
+	fragmentShader: "shaders/postprocessing/hdr/hdr.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -545,8 +582,8 @@ var PostHDRProgram = ShaderProgram{
 }
 
 var PostHorizontalProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/blur/horizontal/horizontal.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/blur/horizontal/horizontal.frag",
+	vertexShader:   "shaders/postprocessing/blur/horizontal/horizontal.vert",
+	fragmentShader: "shaders/postprocessing/blur/horizontal/horizontal.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -564,8 +601,8 @@ var PostHorizontalProgram = ShaderProgram{
 }
 
 var PostVerticalProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/blur/vertical/vertical.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/blur/vertical/vertical.frag",
+	vertexShader:   "shaders/postprocessing/blur/vertical/vertical.vert",
+	fragmentShader: "shaders/postprocessing/blur/vertical/vertical.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -583,8 +620,8 @@ var PostVerticalProgram = ShaderProgram{
 }
 
 var PostPreScatteringProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/scattering/prescattering/prescattering.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/scattering/prescattering/prescattering.frag",
+	vertexShader:   "shaders/postprocessing/scattering/prescattering/prescattering.vert",
+	fragmentShader: "shaders/postprocessing/scattering/prescattering/prescattering.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -609,8 +646,8 @@ var PostPreScatteringProgram = ShaderProgram{
 }
 
 var PostPostScatteringProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/scattering/postscattering/postscattering.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/scattering/postscattering/postscattering.frag",
+	vertexShader:   "shaders/postprocessing/scattering/postscattering/postscattering.vert",
+	fragmentShader: "shaders/postprocessing/scattering/postscattering/postscattering.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -630,8 +667,8 @@ var PostPostScatteringProgram = ShaderProgram{
 }
 
 var PostPreBloomProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/bloom/prebloom/prebloom.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/bloom/prebloom/prebloom.frag",
+	vertexShader:   "shaders/postprocessing/bloom/prebloom/prebloom.vert",
+	fragmentShader: "shaders/postprocessing/bloom/prebloom/prebloom.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -651,8 +688,8 @@ var PostPreBloomProgram = ShaderProgram{
 }
 
 var PostPostBloomProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/bloom/postbloom/postbloom.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/bloom/postbloom/postbloom.frag",
+	vertexShader:   "shaders/postprocessing/bloom/postbloom/postbloom.vert",
+	fragmentShader: "shaders/postprocessing/bloom/postbloom/postbloom.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -671,3 +708,42 @@ var PostPostBloomProgram = ShaderProgram{
 		"tex":      0,
 	},
 }
+
+var PostTransitionProgram = ShaderProgram{
+	vertexShader:   "shaders/postprocessing/transition/transition.vert",
+	fragmentShader: "shaders/postprocessing/transition/transition.frag",
+	uniformLocations: map[string]int32{
+		// Vertices
+		"modelMtx":      0,
+		"viewMtx":       0,
+		"projectionMtx": 0,
+
+		"screen":    0,
+		"fboWidth":  0,
+		"fboHeight": 0,
+
+		"previous":  0,
+		"blend":     0,
+		"fade":      0,
+		"fadeColor": 0,
+	},
+	attributeLocations: map[string]uint32{
+		"position": 0,
+		"tex":      0,
+	},
+}
+
+var DebugProgram = ShaderProgram{
+	vertexShader:   "shaders/debug/debug.vert",
+	fragmentShader: "shaders/debug/debug.frag",
+	uniformLocations: map[string]int32{
+		// Vertices
+		"modelMtx":      0,
+		"viewMtx":       0,
+		"projectionMtx": 0,
+	},
+	attributeLocations: map[string]uint32{
+		"position": 0,
+		"color":    1,
+	},
+}
@@ -0,0 +1,16 @@
+package material
+
+import (
+	"embed"
+	"rapidengine/assets"
+)
+
+// shaderFiles holds the engine's shader sources, which are
+// registered as built in assets under "shaders/"
+//
+//go:embed shaders
+var shaderFiles embed.FS
+
+func init() {
+	assets.Default.MountBuiltin("", shaderFiles)
+}
@@ -0,0 +1,56 @@
+package material
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"rapidengine/assets"
+	"rapidengine/device"
+)
+
+// unlinkableDevice is a null device whose programs never link
+type unlinkableDevice struct {
+	*device.NullDevice
+}
+
+func (d unlinkableDevice) ProgramLinkStatus(program uint32) (bool, string) {
+	return false, "missing main"
+}
+
+func compileTestProgram(t *testing.T, dev device.RenderDevice) error {
+	t.Helper()
+
+	prev := device.Get()
+	device.Set(dev)
+	defer device.Set(prev)
+
+	assets.Default.Mount("", "shader_test", fstest.MapFS{
+		"test/shader.vert": {Data: []byte("void main() {}")},
+		"test/shader.frag": {Data: []byte("void main() {}")},
+	})
+	defer assets.Default.Unmount("shader_test")
+
+	program := NewShaderProgram("test/shader.vert", "test/shader.frag", "", "", "",
+		map[string]int32{"model": 0}, map[string]uint32{})
+	return program.Compile()
+}
+
+func TestShaderProgramCompile(t *testing.T) {
+	if err := compileTestProgram(t, device.NewNullDevice()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShaderProgramLinkError(t *testing.T) {
+	err := compileTestProgram(t, unlinkableDevice{device.NewNullDevice()})
+
+	var linkErr *ShaderLinkError
+	if !errors.As(err, &linkErr) {
+		t.Fatalf("Compile returned %v, want a *ShaderLinkError", err)
+	}
+	if linkErr.Log != "missing main" || !strings.Contains(err.Error(), "test/shader.vert") {
+		t.Fatalf("link error %q doesn't carry the log and paths", err)
+	}
+}
@@ -0,0 +1,9 @@
+#version 410
+
+in vec4 Color;
+
+out vec4 FragColor;
+
+void main() {
+    FragColor = Color;
+}
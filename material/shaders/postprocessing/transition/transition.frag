@@ -0,0 +1,19 @@
+#version 410
+
+uniform sampler2D screen;
+uniform sampler2D previous;
+
+in vec3 TexCoord;
+out vec4 FragColor;
+
+uniform float blend;
+uniform float fade;
+uniform vec3 fadeColor;
+
+void main() {
+    vec4 texColor = texture(screen, TexCoord.xy);
+    vec4 previousColor = texture(previous, TexCoord.xy);
+
+    vec4 color = mix(texColor, previousColor, blend);
+    FragColor = vec4(mix(color.rgb, fadeColor, fade), color.a);
+}
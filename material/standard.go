@@ -1,6 +1,10 @@
 package material
 
-import "github.com/go-gl/gl/v4.1-core/gl"
+import (
+	"rapidengine/device"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
 
 type StandardMaterial struct {
 	shader *ShaderProgram
@@ -52,44 +56,45 @@ func (sm *StandardMaterial) AttachSpecularMap(hm *Texture) {
 }
 
 func (sm *StandardMaterial) Render(delta float64, darkness float32, totalTime float64) {
+	dev := device.Get()
 
 	if sm.diffuseMap != nil {
-		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, *sm.diffuseMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE0)
+		dev.BindTexture(gl.TEXTURE_2D, *sm.diffuseMap.Addr)
 	}
-	gl.Uniform1i(sm.shader.GetUniform("diffuseMap"), 0)
+	dev.Uniform1i(sm.shader.GetUniform("diffuseMap"), 0)
 
 	if sm.normalMap != nil {
-		gl.ActiveTexture(gl.TEXTURE1)
-		gl.BindTexture(gl.TEXTURE_2D, *sm.normalMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE1)
+		dev.BindTexture(gl.TEXTURE_2D, *sm.normalMap.Addr)
 	}
-	gl.Uniform1i(sm.shader.GetUniform("normalMap"), 1)
+	dev.Uniform1i(sm.shader.GetUniform("normalMap"), 1)
 
 	if sm.heightMap != nil {
-		gl.ActiveTexture(gl.TEXTURE2)
-		gl.BindTexture(gl.TEXTURE_2D, *sm.heightMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE2)
+		dev.BindTexture(gl.TEXTURE_2D, *sm.heightMap.Addr)
 	}
-	gl.Uniform1i(sm.shader.GetUniform("heightMap"), 2)
+	dev.Uniform1i(sm.shader.GetUniform("heightMap"), 2)
 
 	if sm.specularMap != nil {
-		gl.ActiveTexture(gl.TEXTURE3)
-		gl.BindTexture(gl.TEXTURE_2D, *sm.specularMap.Addr)
+		dev.ActiveTexture(gl.TEXTURE3)
+		dev.BindTexture(gl.TEXTURE_2D, *sm.specularMap.Addr)
 	}
-	gl.Uniform1i(sm.shader.GetUniform("specularMap"), 3)
+	dev.Uniform1i(sm.shader.GetUniform("specularMap"), 3)
 
-	gl.Uniform1f(sm.shader.GetUniform("diffuseLevel"), sm.DiffuseLevel)
-	gl.Uniform1f(sm.shader.GetUniform("normalLevel"), sm.NormalLevel)
-	gl.Uniform1f(sm.shader.GetUniform("specularLevel"), sm.SpecularLevel)
-	gl.Uniform1f(sm.shader.GetUniform("heightLevel"), sm.HeightLevel)
+	dev.Uniform1f(sm.shader.GetUniform("diffuseLevel"), sm.DiffuseLevel)
+	dev.Uniform1f(sm.shader.GetUniform("normalLevel"), sm.NormalLevel)
+	dev.Uniform1f(sm.shader.GetUniform("specularLevel"), sm.SpecularLevel)
+	dev.Uniform1f(sm.shader.GetUniform("heightLevel"), sm.HeightLevel)
 
-	gl.Uniform4fv(sm.shader.GetUniform("hue"), 1, &sm.Hue[0])
+	dev.Uniform4fv(sm.shader.GetUniform("hue"), 1, &sm.Hue[0])
 
-	gl.Uniform1f(sm.shader.GetUniform("displacement"), sm.Displacement)
-	gl.Uniform1f(sm.shader.GetUniform("scale"), sm.Scale)
+	dev.Uniform1f(sm.shader.GetUniform("displacement"), sm.Displacement)
+	dev.Uniform1f(sm.shader.GetUniform("scale"), sm.Scale)
 
-	gl.Uniform1f(sm.shader.GetUniform("reflectivity"), sm.Reflectivity)
-	gl.Uniform1f(sm.shader.GetUniform("refractivity"), sm.Refractivity)
-	gl.Uniform1f(sm.shader.GetUniform("refractLevel"), sm.RefractLevel)
+	dev.Uniform1f(sm.shader.GetUniform("reflectivity"), sm.Reflectivity)
+	dev.Uniform1f(sm.shader.GetUniform("refractivity"), sm.Refractivity)
+	dev.Uniform1f(sm.shader.GetUniform("refractLevel"), sm.RefractLevel)
 }
 
 func (sm *StandardMaterial) GetShader() *ShaderProgram {
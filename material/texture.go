@@ -1,6 +1,7 @@
 package material
 
 import (
+	"bytes"
 	"fmt"
 	"image"
 	"image/draw"
@@ -8,7 +9,7 @@ import (
 	"image/png"
 	"io"
 	"net/http"
-	"os"
+	"rapidengine/assets"
 )
 
 type Texture struct {
@@ -18,21 +19,20 @@ type Texture struct {
 	Addr   *uint32
 }
 
+// LoadImage decodes a jpeg or png image into RGBA. It returns an error
+// wrapping assets.ErrAssetNotFound if the file doesn't exist, and an
+// *assets.DecodeError if it isn't a valid image.
 func LoadImage(path string) (*image.RGBA, error) {
-	imgFile, err := os.Open(path)
+	data, err := assets.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer imgFile.Close()
 
-	ct, err := detectContentType(imgFile)
-	if err != nil {
-		return nil, err
-	}
+	ct := http.DetectContentType(data)
 
-	img, err := convert(imgFile, ct)
+	img, err := convert(bytes.NewReader(data), ct)
 	if err != nil {
-		return nil, err
+		return nil, &assets.DecodeError{Path: path, Format: ct, Err: err}
 	}
 
 	return img, nil
@@ -67,32 +67,17 @@ func (i *notOpaqueRGBA) Opaque() bool {
 	return false
 }
 
-func detectContentType(file io.ReadSeeker) (string, error) {
-	buf := make([]byte, 512)
-	_, err := file.Read(buf)
-	if err != nil {
-		return "", err
-	}
-
-	_, err = file.Seek(0, 0)
-	if err != nil {
-		return "", err
-	}
-
-	return http.DetectContentType(buf), nil
-}
-
 func LoadImageFullDepth(path string) (image.Image, error) {
-	imgFile, err := os.Open(path)
+	imgFile, err := assets.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer imgFile.Close()
 
-	src, _, err := image.Decode(imgFile)
+	src, format, err := image.Decode(imgFile)
 
 	if err != nil {
-		return nil, err
+		return nil, &assets.DecodeError{Path: path, Format: format, Err: err}
 	}
 
 	return src, nil
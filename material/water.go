@@ -1,6 +1,10 @@
 package material
 
-import "github.com/go-gl/gl/v4.1-core/gl"
+import (
+	"rapidengine/device"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
 
 type WaterMaterial struct {
 	shader *ShaderProgram
@@ -37,41 +41,45 @@ func (wm *WaterMaterial) SetDisplacement(d float32) {
 }
 
 func (wm *WaterMaterial) Render(delta float64, darkness float32, totalTime float64) {
+	dev := device.Get()
+
 	wm.UpdateAttribArrays()
 
 	if wm.diffuseMap != nil {
-		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, *wm.diffuseMap)
+		dev.ActiveTexture(gl.TEXTURE0)
+		dev.BindTexture(gl.TEXTURE_2D, *wm.diffuseMap)
 	}
 
-	gl.Uniform1i(wm.shader.GetUniform("diffuseMap"), 0)
+	dev.Uniform1i(wm.shader.GetUniform("diffuseMap"), 0)
 
 	if wm.normalMap != nil {
-		gl.ActiveTexture(gl.TEXTURE1)
-		gl.BindTexture(gl.TEXTURE_2D, *wm.normalMap)
+		dev.ActiveTexture(gl.TEXTURE1)
+		dev.BindTexture(gl.TEXTURE_2D, *wm.normalMap)
 	}
 
-	gl.Uniform1i(wm.shader.GetUniform("normalMap"), 1)
+	dev.Uniform1i(wm.shader.GetUniform("normalMap"), 1)
 
 	if wm.heightMap != nil {
-		gl.ActiveTexture(gl.TEXTURE2)
-		gl.BindTexture(gl.TEXTURE_2D, *wm.heightMap)
+		dev.ActiveTexture(gl.TEXTURE2)
+		dev.BindTexture(gl.TEXTURE_2D, *wm.heightMap)
 	}
 
-	gl.Uniform1i(wm.shader.GetUniform("heightMap"), 2)
+	dev.Uniform1i(wm.shader.GetUniform("heightMap"), 2)
 
-	gl.Uniform1f(wm.shader.GetUniform("displacement"), wm.displacement)
-	gl.Uniform1f(wm.shader.GetUniform("scale"), wm.Scale)
+	dev.Uniform1f(wm.shader.GetUniform("displacement"), wm.displacement)
+	dev.Uniform1f(wm.shader.GetUniform("scale"), wm.Scale)
 
-	gl.Uniform1f(wm.shader.GetUniform("totalTime"), float32(totalTime))
+	dev.Uniform1f(wm.shader.GetUniform("totalTime"), float32(totalTime))
 }
 
 func (wm *WaterMaterial) UpdateAttribArrays() {
-	gl.EnableVertexAttribArray(0)
-	gl.EnableVertexAttribArray(1)
-	gl.EnableVertexAttribArray(2)
-	gl.EnableVertexAttribArray(3)
-	gl.EnableVertexAttribArray(4)
+	dev := device.Get()
+
+	dev.EnableVertexAttribArray(0)
+	dev.EnableVertexAttribArray(1)
+	dev.EnableVertexAttribArray(2)
+	dev.EnableVertexAttribArray(3)
+	dev.EnableVertexAttribArray(4)
 }
 
 func (wm *WaterMaterial) GetShader() *ShaderProgram {
@@ -14,6 +14,10 @@ package physics
 type CollisionLink struct {
 	Group    string
 	Callback func([]bool)
+
+	// Name the callback was registered under, if
+	// any, so that the link can be saved to a file
+	Name string
 }
 
 // Collider contains data about a collision rect.
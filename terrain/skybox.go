@@ -2,6 +2,7 @@ package terrain
 
 import (
 	"rapidengine/camera"
+	"rapidengine/device"
 	"rapidengine/geometry"
 	"rapidengine/material"
 
@@ -30,21 +31,28 @@ func NewSkyBox(mat *material.CubemapMaterial, vao *geometry.VertexArray, projMtx
 	}
 }
 
+// SetProjection replaces the projection matrix,
+// such as after the window is resized
+func (skyBox *SkyBox) SetProjection(projMtx mgl32.Mat4) {
+	skyBox.projectionMatrix = projMtx
+}
+
 var e = float32(0)
 
 func (skyBox *SkyBox) Render(mainCamera camera.Camera) {
+	dev := device.Get()
 
 	for _, shader := range skyBox.shaders {
 		shader.Bind()
 		skyBox.material.Render(0, 1, 0)
-		gl.Uniform1i(shader.GetUniform("cubeDiffuseMap"), 6)
+		dev.Uniform1i(shader.GetUniform("cubeDiffuseMap"), 6)
 	}
 
-	gl.DepthMask(false)
+	dev.DepthMask(false)
 
 	skyBox.material.GetShader().Bind()
 	skyBox.material.Render(0, 1, 0)
-	gl.BindVertexArray(skyBox.vao.GetID())
+	dev.BindVertexArray(skyBox.vao.GetID())
 
 	x, y, z := mainCamera.GetPosition()
 	skyBox.modelMatrix = mgl32.Translate3D(x, y, z)
@@ -52,26 +60,26 @@ func (skyBox *SkyBox) Render(mainCamera camera.Camera) {
 	skyBox.modelMatrix = skyBox.modelMatrix.Mul4(mgl32.HomogRotate3DY(e))
 	e += 0.00001
 
-	gl.UniformMatrix4fv(
+	dev.UniformMatrix4fv(
 		skyBox.material.GetShader().GetUniform("modelMtx"),
 		1, false, &skyBox.modelMatrix[0],
 	)
 
-	gl.UniformMatrix4fv(
+	dev.UniformMatrix4fv(
 		skyBox.material.GetShader().GetUniform("viewMtx"),
 		1, false, mainCamera.GetFirstViewIndex(),
 	)
 
-	gl.UniformMatrix4fv(
+	dev.UniformMatrix4fv(
 		skyBox.material.GetShader().GetUniform("projectionMtx"),
 		1, false, &skyBox.projectionMatrix[0],
 	)
 
-	gl.EnableVertexAttribArray(0)
-	gl.EnableVertexAttribArray(1)
+	dev.EnableVertexAttribArray(0)
+	dev.EnableVertexAttribArray(1)
 
-	gl.DrawElements(gl.TRIANGLES, 108, gl.UNSIGNED_INT, gl.PtrOffset(0))
-	gl.DepthMask(true)
+	dev.DrawElements(gl.TRIANGLES, 108, gl.UNSIGNED_INT, gl.PtrOffset(0))
+	dev.DepthMask(true)
 }
 
 func (skyBox *SkyBox) SetCustomMaterial(m *material.CubemapMaterial) {
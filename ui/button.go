@@ -16,6 +16,10 @@ type Button struct {
 	clickCallback func()
 	justClicked   bool
 
+	// Name of the action the click callback was
+	// registered under, if any, for saving the button
+	Action string
+
 	colliding map[int]bool
 }
 
@@ -66,7 +70,7 @@ func (button *Button) Block() {
 func (button *Button) Update(inputs *input.Input) {
 	if button.colliding[0] {
 		if inputs.LeftMouseButton {
-			if !button.justClicked {
+			if !button.justClicked && button.clickCallback != nil {
 				button.clickCallback()
 				button.justClicked = true
 			}
@@ -77,8 +81,7 @@ func (button *Button) Update(inputs *input.Input) {
 }
 
 func (button *Button) SetPosition(x, y float32) {
-	button.ButtonChild.X = x
-	button.ButtonChild.Y = y
+	button.ButtonChild.SetPosition(x, y)
 
 	if button.TextBx != nil {
 		button.TextBx.X = button.ButtonChild.X + button.GetTransform().SX/2
@@ -87,8 +90,7 @@ func (button *Button) SetPosition(x, y float32) {
 }
 
 func (button *Button) SetDimensions(width, height float32) {
-	button.ButtonChild.ScaleX = width
-	button.ButtonChild.ScaleY = height
+	button.ButtonChild.SetScale(width, height, 1)
 }
 
 func (button *Button) GetTransform() geometry.Transform {
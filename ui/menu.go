@@ -24,8 +24,7 @@ func NewMenu(config *configuration.EngineConfig) Menu {
 func (m *Menu) Initialize() {
 	m.BackChild.SetPosition(m.transform.X, m.transform.Y)
 
-	m.BackChild.ScaleX = m.transform.SX
-	m.BackChild.ScaleY = m.transform.SY
+	m.BackChild.SetScale(m.transform.SX, m.transform.SY, 1)
 
 	m.BackChild.Static = true
 }
@@ -34,6 +33,11 @@ func (m *Menu) AddElement(e Element) {
 	m.elements = append(m.elements, e)
 }
 
+// GetElements returns the elements added to the menu
+func (m *Menu) GetElements() []Element {
+	return m.elements
+}
+
 //  --------------------------------------------------
 //  Interface
 //  --------------------------------------------------
@@ -72,29 +72,27 @@ func (pb *ProgressBar) GetPercentage() float32 {
 //  --------------------------------------------------
 
 func (pb *ProgressBar) Update(inputs *input.Input) {
-	pb.BarChild.ScaleX = (pb.currentPercentage / 100) * (pb.transform.SX * pb.barScaleX)
+	pb.BarChild.SetScale((pb.currentPercentage/100)*(pb.transform.SX*pb.barScaleX), pb.BarChild.SY, 1)
 }
 
 func (pb *ProgressBar) SetPosition(x, y float32) {
 	pb.transform.X = x
 	pb.transform.Y = y
 
-	pb.BackChild.X = x
-	pb.BackChild.Y = y
+	pb.BackChild.SetPosition(x, y)
 
-	pb.BarChild.X = x + (pb.transform.SX-(pb.transform.SX*pb.barScaleX))/2
-	pb.BarChild.Y = y + (pb.transform.SY-(pb.transform.SY*pb.barScaleY))/2
+	pb.BarChild.SetPosition(
+		x+(pb.transform.SX-(pb.transform.SX*pb.barScaleX))/2,
+		y+(pb.transform.SY-(pb.transform.SY*pb.barScaleY))/2,
+	)
 }
 
 func (pb *ProgressBar) SetDimensions(width, height float32) {
 	pb.transform.SX = width
 	pb.transform.SY = height
 
-	pb.BackChild.ScaleX = pb.transform.SX
-	pb.BackChild.ScaleY = pb.transform.SY
-
-	pb.BarChild.ScaleX = pb.transform.SX * pb.barScaleX
-	pb.BarChild.ScaleY = pb.transform.SY * pb.barScaleY
+	pb.BackChild.SetScale(pb.transform.SX, pb.transform.SY, 1)
+	pb.BarChild.SetScale(pb.transform.SX*pb.barScaleX, pb.transform.SY*pb.barScaleY, 1)
 }
 
 func (pb *ProgressBar) GetTransform() geometry.Transform {
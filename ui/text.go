@@ -18,15 +18,23 @@ type TextBox struct {
 	Y float32
 
 	Color [3]float32
+
+	// X is the left edge of the text, rather than its center
+	LeftAligned bool
 }
 
 func (t *TextBox) Update(config *configuration.EngineConfig) {
-	t.textObj.SetString(t.Text)
+	t.textObj.SetString("%s", t.Text)
+	t.textObj.SetScale(t.Scale)
+
+	x := t.X
+	if t.LeftAligned {
+		x += t.textObj.Width() * t.textObj.Scale / 2
+	}
 	t.textObj.SetPosition(mgl32.Vec2{
-		t.X - float32(config.ScreenWidth/2),
+		x - float32(config.ScreenWidth/2),
 		t.Y - float32(config.ScreenHeight/2),
 	})
-	t.textObj.SetScale(t.Scale)
 	t.textObj.SetColor(mgl32.Vec3(t.Color))
 	t.textObj.Draw()
 }